@@ -1,10 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	realip "github.com/ferluci/fast-realip"
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
 	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/report"
+	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
 
+// maxActivityLogExport is the maximum number of activity logs in a single export.
+const maxActivityLogExport = 10000
+
 // handleGetActivityLogs returns activity logs from the database.
 func handleGetActivityLogs(r *fastglue.Request) error {
 	var (
@@ -31,3 +43,91 @@ func handleGetActivityLogs(r *fastglue.Request) error {
 	})
 
 }
+
+// handleExportActivityLogs exports the activity logs matching the filters as CSV or XLSX.
+func handleExportActivityLogs(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		order   = string(r.RequestCtx.QueryArgs().Peek("order"))
+		orderBy = string(r.RequestCtx.QueryArgs().Peek("order_by"))
+		filters = string(r.RequestCtx.QueryArgs().Peek("filters"))
+		format  = string(r.RequestCtx.QueryArgs().Peek("format"))
+	)
+	if format == "" {
+		format = report.FormatCSV
+	}
+	if !report.IsValidFormat(format) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`format`"), nil, envelope.InputError)
+	}
+
+	logs, err := app.activityLog.GetAll(order, orderBy, filters, 1, maxActivityLogExport)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	table := report.Table{
+		Name:    "activity_logs",
+		Headers: []string{"id", "created_at", "activity_type", "activity_description", "actor_id", "target_model_type", "target_model_id", "ip", "changes"},
+	}
+	for _, l := range logs {
+		table.Rows = append(table.Rows, []string{
+			strconv.FormatInt(l.ID, 10),
+			l.CreatedAt.Format(time.RFC3339),
+			l.ActivityType,
+			l.ActivityDescription,
+			strconv.Itoa(l.ActorID),
+			l.TargetModelType,
+			strconv.Itoa(l.TargetModelID),
+			l.IP,
+			string(l.Changes),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := report.Export(&buf, format, table); err != nil {
+		app.lo.Error("error exporting activity logs", "format", format, "error", err)
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.somethingWentWrong"), nil))
+	}
+
+	filename := fmt.Sprintf("activity-logs-%s.%s", time.Now().Format("2006-01-02"), format)
+	r.RequestCtx.Response.Header.Set("Content-Type", report.ContentType(format))
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	r.RequestCtx.SetBody(buf.Bytes())
+	return nil
+}
+
+// logEntityCreated records an admin creating an entity in the activity log.
+func logEntityCreated(r *fastglue.Request, modelType string, id int, name string, after any) {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = realip.FromRequest(r.RequestCtx)
+	)
+	if err := app.activityLog.EntityCreated(auser.ID, auser.Email, ip, modelType, id, name, after); err != nil {
+		app.lo.Error("error creating activity log", "model_type", modelType, "id", id, "error", err)
+	}
+}
+
+// logEntityUpdated records an admin updating an entity in the activity log.
+func logEntityUpdated(r *fastglue.Request, modelType string, id int, name string, before, after any) {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = realip.FromRequest(r.RequestCtx)
+	)
+	if err := app.activityLog.EntityUpdated(auser.ID, auser.Email, ip, modelType, id, name, before, after); err != nil {
+		app.lo.Error("error creating activity log", "model_type", modelType, "id", id, "error", err)
+	}
+}
+
+// logEntityDeleted records an admin deleting an entity in the activity log.
+func logEntityDeleted(r *fastglue.Request, modelType string, id int, name string, before any) {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = realip.FromRequest(r.RequestCtx)
+	)
+	if err := app.activityLog.EntityDeleted(auser.ID, auser.Email, ip, modelType, id, name, before); err != nil {
+		app.lo.Error("error creating activity log", "model_type", modelType, "id", id, "error", err)
+	}
+}
@@ -1,18 +1,51 @@
 package main
 
 import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
+	"github.com/ghotso/libredesk/internal/ai"
+	aimodels "github.com/ghotso/libredesk/internal/ai/models"
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	cmodels "github.com/ghotso/libredesk/internal/conversation/models"
 	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/stringutil"
+	umodels "github.com/ghotso/libredesk/internal/user/models"
 	"github.com/zerodha/fastglue"
 )
 
+// aiThreadMaxMessages is the number of most recent messages fetched as context for conversation prompts.
+const aiThreadMaxMessages = 100
+
 type aiCompletionReq struct {
 	PromptKey string `json:"prompt_key"`
 	Content   string `json:"content"`
 }
 
+// aiProviderAudit is the state of an AI provider recorded in the audit log, the API key in its config is redacted
+// by the audit log.
+type aiProviderAudit struct {
+	IsDefault bool            `json:"is_default"`
+	Config    json.RawMessage `json:"config"`
+}
+
 type providerUpdateReq struct {
+	Provider  string `json:"provider"`
+	APIKey    string `json:"api_key"`
+	Model     string `json:"model"`
+	BaseURL   string `json:"base_url"`
+	IsDefault bool   `json:"is_default"`
+}
+
+type promptProviderReq struct {
 	Provider string `json:"provider"`
-	APIKey   string `json:"api_key"`
+}
+
+type promptUpdateReq struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
 }
 
 // handleAICompletion handles AI completion requests
@@ -33,12 +66,16 @@ func handleAICompletion(r *fastglue.Request) error {
 	return r.SendEnvelope(resp)
 }
 
-// handleGetAIPrompts returns AI prompts
+// handleGetAIPrompts returns AI prompts of the `kind` query param, rewrite prompts by default.
 func handleGetAIPrompts(r *fastglue.Request) error {
 	var (
-		app = r.Context.(*App)
+		app  = r.Context.(*App)
+		kind = string(r.RequestCtx.QueryArgs().Peek("kind"))
 	)
-	resp, err := app.ai.GetPrompts()
+	if kind == "" {
+		kind = ai.PromptKindRewrite
+	}
+	resp, err := app.ai.GetPrompts(kind)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -54,8 +91,244 @@ func handleUpdateAIProvider(r *fastglue.Request) error {
 	if err := r.Decode(&req, "json"); err != nil {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
 	}
-	if err := app.ai.UpdateProvider(req.Provider, req.APIKey); err != nil {
+	id, before, err := getAIProviderAudit(app, req.Provider)
+	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	if err := app.ai.UpdateProvider(req.Provider, ai.ProviderUpdate{
+		APIKey:    req.APIKey,
+		Model:     req.Model,
+		BaseURL:   req.BaseURL,
+		IsDefault: req.IsDefault,
+	}); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, after, err := getAIProviderAudit(app, req.Provider); err == nil {
+		logEntityUpdated(r, almodels.ModelAIProvider, id, req.Provider, before, after)
+	}
 	return r.SendEnvelope("Provider updated successfully")
 }
+
+// getAIProviderAudit returns the ID and the audited state of the AI provider with the given name.
+func getAIProviderAudit(app *App, name string) (int, aiProviderAudit, error) {
+	providers, err := app.ai.GetProviders()
+	if err != nil {
+		return 0, aiProviderAudit{}, err
+	}
+	for _, p := range providers {
+		if p.Name != name {
+			continue
+		}
+		id, _ := strconv.Atoi(p.ID)
+		config := json.RawMessage(p.Config)
+		if !json.Valid(config) {
+			config = json.RawMessage("{}")
+		}
+		return id, aiProviderAudit{IsDefault: p.IsDefault, Config: config}, nil
+	}
+	return 0, aiProviderAudit{}, nil
+}
+
+// handleGetAIProviders returns the AI providers without their API keys.
+func handleGetAIProviders(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	providers, err := app.ai.GetProviders()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(providers)
+}
+
+// handleUpdateAIPromptProvider sets the provider an AI prompt is sent to.
+func handleUpdateAIPromptProvider(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		key = r.RequestCtx.UserValue("key").(string)
+		req promptProviderReq
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
+	}
+	before, err := app.ai.GetPrompt(key)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.ai.SetPromptProvider(key, req.Provider); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if after, err := app.ai.GetPrompt(key); err == nil {
+		logEntityUpdated(r, almodels.ModelAIPrompt, before.ID, before.Key, before, after)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGetAIPrompt returns an AI prompt along with its content.
+func handleGetAIPrompt(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		key = r.RequestCtx.UserValue("key").(string)
+	)
+	prompt, err := app.ai.GetPrompt(key)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(prompt)
+}
+
+// handleUpdateAIPrompt updates the title and content of an AI prompt.
+func handleUpdateAIPrompt(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		key = r.RequestCtx.UserValue("key").(string)
+		req promptUpdateReq
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
+	}
+	before, err := app.ai.GetPrompt(key)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.ai.UpdatePrompt(key, strings.TrimSpace(req.Title), strings.TrimSpace(req.Content)); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if after, err := app.ai.GetPrompt(key); err == nil {
+		logEntityUpdated(r, almodels.ModelAIPrompt, before.ID, before.Key, before, after)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleAIConversationSummary returns a handover summary of a conversation.
+func handleAIConversationSummary(r *fastglue.Request) error {
+	return sendAIConversationCompletion(r, ai.PromptConversationSummary)
+}
+
+// handleAISuggestedReply returns a suggested reply to a conversation in the contact's language,
+// along with the resolved conversations and macros it cites.
+func handleAISuggestedReply(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	thread, err := aiConversationThread(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	access, err := aiConversationAccess(app, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	resp, err := app.ai.SuggestReply(thread, access)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(resp)
+}
+
+// aiConversationAccess returns the conversations an agent can read, the same as enforced by authz.EnforceConversationAccess.
+func aiConversationAccess(app *App, user umodels.User) (aimodels.ConversationAccess, error) {
+	access := aimodels.ConversationAccess{UserID: user.ID, TeamIDs: user.Teams.IDs()}
+	if ok, err := app.authz.Enforce(user, "conversations", "read"); err != nil || !ok {
+		return access, err
+	}
+	for _, p := range []struct {
+		action string
+		dst    *bool
+	}{
+		{"read_all", &access.ReadAll},
+		{"read_assigned", &access.ReadAssigned},
+		{"read_team_all", &access.ReadTeamAll},
+		{"read_team_inbox", &access.ReadTeamInbox},
+		{"read_unassigned", &access.ReadUnassigned},
+	} {
+		ok, err := app.authz.Enforce(user, "conversations", p.action)
+		if err != nil {
+			return access, err
+		}
+		*p.dst = ok
+	}
+	return access, nil
+}
+
+// sendAIConversationCompletion sends the conversation thread with the prompt to the AI provider and returns the response.
+func sendAIConversationCompletion(r *fastglue.Request, promptKey string) error {
+	var app = r.Context.(*App)
+	thread, err := aiConversationThread(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	resp, err := app.ai.ConversationCompletion(promptKey, thread)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(resp)
+}
+
+// aiConversationThread returns the thread of the conversation in the request after checking the user can access it.
+func aiConversationThread(r *fastglue.Request) (ai.ThreadContext, error) {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return ai.ThreadContext{}, err
+	}
+	conversation, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return ai.ThreadContext{}, err
+	}
+	return conversationThread(app, conversation)
+}
+
+// conversationThread returns the contact details and the recent messages, including private notes, of a conversation.
+func conversationThread(app *App, conversation *cmodels.Conversation) (ai.ThreadContext, error) {
+	thread := ai.ThreadContext{
+		ConversationUUID: conversation.UUID,
+		Subject:          conversation.Subject.String,
+		ContactName:      conversation.Contact.FullName(),
+		ContactEmail:     conversation.Contact.Email.String,
+		ContactPhone:     conversation.Contact.PhoneNumber.String,
+	}
+
+	messages, _, err := app.conversation.GetConversationMessages(conversation.UUID, 1, aiThreadMaxMessages, nil, []string{cmodels.MessageIncoming, cmodels.MessageOutgoing})
+	if err != nil {
+		return thread, err
+	}
+
+	// Messages are returned newest first.
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		msg.CensorCSATContent()
+
+		content := msg.TextContent
+		if content == "" {
+			content = stringutil.HTML2Text(msg.Content)
+		}
+		if content == "" {
+			continue
+		}
+
+		role := ai.RoleAgent
+		switch {
+		case msg.Private:
+			role = ai.RoleNote
+		case msg.SenderType == cmodels.SenderTypeContact:
+			role = ai.RoleCustomer
+		}
+		thread.Messages = append(thread.Messages, ai.ThreadMessage{
+			Role:      role,
+			Sender:    strings.TrimSpace(msg.Author.FirstName + " " + msg.Author.LastName),
+			Content:   content,
+			CreatedAt: msg.CreatedAt,
+		})
+	}
+	return thread, nil
+}
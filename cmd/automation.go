@@ -3,6 +3,7 @@ package main
 import (
 	"strconv"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	amodels "github.com/ghotso/libredesk/internal/automation/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/valyala/fasthttp"
@@ -45,10 +46,15 @@ func handleToggleAutomationRule(r *fastglue.Request) error {
 		app   = r.Context.(*App)
 		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
 	)
+	oldRule, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	toggledRule, err := app.automation.ToggleRule(id)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelAutomationRule, id, toggledRule.Name, oldRule, toggledRule)
 	return r.SendEnvelope(toggledRule)
 }
 
@@ -67,10 +73,15 @@ func handleUpdateAutomationRule(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
 	}
 
+	oldRule, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	updatedRule, err := app.automation.UpdateRule(id, rule)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelAutomationRule, id, updatedRule.Name, oldRule, updatedRule)
 	return r.SendEnvelope(updatedRule)
 }
 
@@ -87,6 +98,7 @@ func handleCreateAutomationRule(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityCreated(r, almodels.ModelAutomationRule, createdRule.ID, createdRule.Name, createdRule)
 	return r.SendEnvelope(createdRule)
 }
 
@@ -100,9 +112,14 @@ func handleDeleteAutomationRule(r *fastglue.Request) error {
 	if err != nil || id == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
+	oldRule, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err = app.automation.DeleteRule(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityDeleted(r, almodels.ModelAutomationRule, id, oldRule.Name, oldRule)
 	return r.SendEnvelope(true)
 }
 
@@ -115,10 +132,12 @@ func handleUpdateAutomationRuleWeights(r *fastglue.Request) error {
 	if err := r.Decode(&weights, "json"); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
 	}
-	err := app.automation.UpdateRuleWeights(weights)
+
+	oldWeights, err := app.automation.UpdateRuleWeights(weights)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelAutomationRule, 0, "weights", oldWeights, weights)
 	return r.SendEnvelope(true)
 }
 
@@ -137,9 +156,20 @@ func handleUpdateAutomationRuleExecutionMode(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("automation.invalidRuleExecutionMode"), nil, envelope.InputError)
 	}
 
+	var oldMode string
+	rules, err := app.automation.GetAllRules([]byte(amodels.RuleTypeNewConversation))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(rules) > 0 {
+		oldMode = rules[0].ExecutionMode
+	}
+
 	// Only new conversation rules can be updated as they are the only ones that have execution mode.
 	if err := app.automation.UpdateRuleExecutionMode(amodels.RuleTypeNewConversation, req.Mode); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelAutomationRule, 0, "execution mode",
+		map[string]string{"execution_mode": oldMode}, map[string]string{"execution_mode": req.Mode})
 	return r.SendEnvelope(true)
 }
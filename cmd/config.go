@@ -26,11 +26,13 @@ func handleGetConfig(r *fastglue.Request) error {
 
 	// Filter to only include public fields needed for initial app load
 	publicSettings := map[string]any{
-		"app.lang":          settings["app.lang"],
-		"app.favicon_url":   settings["app.favicon_url"],
-		"app.logo_url":      settings["app.logo_url"],
-		"app.site_name":     settings["app.site_name"],
-		"app.portal_enabled": settings["app.portal_enabled"],
+		"app.lang":                             settings["app.lang"],
+		"app.favicon_url":                      settings["app.favicon_url"],
+		"app.logo_url":                         settings["app.logo_url"],
+		"app.site_name":                        settings["app.site_name"],
+		"app.portal_enabled":                   settings["app.portal_enabled"],
+		"app.portal_magic_link_enabled":        settings["app.portal_magic_link_enabled"],
+		"app.portal_self_registration_enabled": settings["app.portal_self_registration_enabled"],
 	}
 
 	// Get all OIDC providers
@@ -52,6 +54,7 @@ func handleGetConfig(r *fastglue.Request) error {
 				"logo_url":     provider.LogoURL,
 				"enabled":      provider.Enabled,
 				"redirect_uri": provider.RedirectURI,
+				"portal":       provider.Portal,
 			}
 			enabledProviders = append(enabledProviders, providerMap)
 		}
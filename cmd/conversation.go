@@ -10,6 +10,7 @@ import (
 	"github.com/ghotso/libredesk/internal/automation/models"
 	cmodels "github.com/ghotso/libredesk/internal/conversation/models"
 	smodels "github.com/ghotso/libredesk/internal/conversation/status/models"
+	camodels "github.com/ghotso/libredesk/internal/custom_attribute/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	medModels "github.com/ghotso/libredesk/internal/media/models"
 	"github.com/ghotso/libredesk/internal/stringutil"
@@ -37,6 +38,8 @@ type statusUpdateReq struct {
 	Status       string `json:"status"`
 	StatusID     int    `json:"status_id"`
 	SnoozedUntil string `json:"snoozed_until,omitempty"`
+	// ResolveChildren resolves the child conversations along with a resolved parent.
+	ResolveChildren bool `json:"resolve_children"`
 }
 
 type tagsUpdateReq struct {
@@ -581,20 +584,56 @@ func handleUpdateConversationStatus(r *fastglue.Request) error {
 
 	// If status is Resolved (ID 3), send CSAT survey if enabled on inbox.
 	if statusID == smodels.DefaultStatusIDResolved {
-		// Check if CSAT is enabled on the inbox and send CSAT survey message.
-		inbox, err := app.inbox.GetDBRecord(conversation.InboxID)
-		if err != nil {
+		if err := sendCSATIfEnabled(app, user, *conversation); err != nil {
 			return sendErrorEnvelope(r, err)
 		}
-		if inbox.CSATEnabled {
-			if err := app.conversation.SendCSATReply(user.ID, *conversation); err != nil {
-				return sendErrorEnvelope(r, err)
-			}
+		if req.ResolveChildren {
+			resolveChildConversations(app, uuid, user)
 		}
 	}
 	return r.SendEnvelope(true)
 }
 
+// sendCSATIfEnabled sends the CSAT survey of a resolved conversation if CSAT is enabled on its inbox.
+func sendCSATIfEnabled(app *App, user umodels.User, conversation cmodels.Conversation) error {
+	inbox, err := app.inbox.GetDBRecord(conversation.InboxID)
+	if err != nil {
+		return err
+	}
+	if inbox.CSATEnabled {
+		return app.conversation.SendCSATReply(user.ID, conversation)
+	}
+	return nil
+}
+
+// resolveChildConversations resolves the open child conversations of a resolved parent that the agent has access to,
+// children without an assigned user or with custom attributes required before resolving unset are left open as they cannot be resolved.
+func resolveChildConversations(app *App, parentUUID string, user umodels.User) {
+	children, err := app.conversation.GetChildConversations(parentUUID)
+	if err != nil {
+		app.lo.Error("error fetching child conversations", "parent_uuid", parentUUID, "error", err)
+		return
+	}
+	for _, child := range children {
+		if child.Status.String == cmodels.StatusResolved || child.Status.String == cmodels.StatusClosed || child.AssignedUserID.Int == 0 {
+			continue
+		}
+		if _, err := enforceConversationAccess(app, child.UUID, user); err != nil {
+			continue
+		}
+		if missing, err := app.customAttribute.MissingRequiredOnResolve(child.CustomAttributes); err != nil || len(missing) > 0 {
+			continue
+		}
+		if err := app.conversation.UpdateConversationStatus(child.UUID, smodels.DefaultStatusIDResolved, "", "", user); err != nil {
+			app.lo.Error("error resolving child conversation", "uuid", child.UUID, "parent_uuid", parentUUID, "error", err)
+			continue
+		}
+		if err := sendCSATIfEnabled(app, user, child); err != nil {
+			app.lo.Error("error sending child conversation CSAT", "uuid", child.UUID, "error", err)
+		}
+	}
+}
+
 type shareWithOrganizationReq struct {
 	SharedWithOrganization bool `json:"shared_with_organization"`
 }
@@ -719,6 +758,9 @@ func handleUpdateContactCustomAttributes(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	if err := app.customAttribute.ValidateValues(camodels.AppliesToContact, attributes); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err := app.user.UpdateCustomAttributes(conversation.ContactID, attributes); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -877,7 +919,7 @@ func handleCreateConversation(r *fastglue.Request) error {
 	switch req.Initiator {
 	case umodels.UserTypeAgent:
 		// Queue reply.
-		if _, err := app.conversation.QueueReply(media, req.InboxID, auser.ID /**sender_id**/, conversationUUID, req.Content, to, nil /**cc**/, nil /**bcc**/, map[string]any{} /**meta**/); err != nil {
+		if _, err := app.conversation.QueueReply(media, req.InboxID, auser.ID /**sender_id**/, conversationUUID, req.Content, to, nil /**cc**/, nil /**bcc**/, map[string]any{} /**meta**/, time.Time{} /**send_at**/); err != nil {
 			// Delete the conversation if msg queue fails.
 			if err := app.conversation.DeleteConversation(conversationUUID); err != nil {
 				app.lo.Error("error deleting conversation", "error", err)
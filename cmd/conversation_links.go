@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strconv"
+
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	cmodels "github.com/ghotso/libredesk/internal/conversation/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	mmodels "github.com/ghotso/libredesk/internal/media/models"
+	umodels "github.com/ghotso/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+type conversationLinkReq struct {
+	// The linked conversation by UUID or reference number.
+	ConversationUUID string `json:"conversation_uuid"`
+	ReferenceNumber  string `json:"reference_number"`
+	// Relation is what the linked conversation is to the conversation, one of parent, child or related.
+	Relation string `json:"relation"`
+}
+
+// handleGetConversationLinks returns the links of a conversation.
+func handleGetConversationLinks(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	links, err := app.conversation.GetConversationLinks(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(links)
+}
+
+// handleLinkConversation links another conversation to a conversation as its parent, child or related conversation.
+func handleLinkConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = conversationLinkReq{}
+	)
+
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conversation, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Resolve the linked conversation by reference number, the agent needs access to it as well.
+	linkedUUID := req.ConversationUUID
+	if linkedUUID == "" {
+		if req.ReferenceNumber == "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`conversation_uuid`"), nil, envelope.InputError)
+		}
+		c, err := app.conversation.GetConversation(0, "", req.ReferenceNumber)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		linkedUUID = c.UUID
+	}
+	linked, err := enforceConversationAccess(app, linkedUUID, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.conversation.LinkConversations(*conversation, *linked, req.Relation, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	links, err := app.conversation.GetConversationLinks(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(links)
+}
+
+// handleUnlinkConversation removes a link of a conversation.
+func handleUnlinkConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conversation, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.conversation.UnlinkConversations(*conversation, id, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// broadcastReplyToChildren queues a copy of a parent conversation reply in each child conversation the agent has access to,
+// addressed to the child's contact. Each copy gets its own copy of the reply's attachments.
+func broadcastReplyToChildren(app *App, reply cmodels.Message, media []mmodels.Media, user umodels.User) {
+	children, err := app.conversation.GetChildConversations(reply.ConversationUUID)
+	if err != nil {
+		return
+	}
+	for _, child := range children {
+		if child.Contact.Email.String == "" {
+			continue
+		}
+		if allowed, err := app.authz.EnforceConversationAccess(user, child); err != nil || !allowed {
+			continue
+		}
+
+		var childMedia = make([]mmodels.Media, 0, len(media))
+		for _, m := range media {
+			cp, err := app.media.Copy(m)
+			if err != nil {
+				app.lo.Error("error copying broadcast reply attachment", "uuid", child.UUID, "media_uuid", m.UUID, "error", err)
+				continue
+			}
+			childMedia = append(childMedia, cp)
+		}
+		if _, err := app.conversation.QueueBroadcastReply(reply, childMedia, child.InboxID, child.UUID, []string{child.Contact.Email.String}); err != nil {
+			app.lo.Error("error queueing broadcast reply", "uuid", child.UUID, "parent_uuid", reply.ConversationUUID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"strconv"
+
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	cmodels "github.com/ghotso/libredesk/internal/conversation/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	umodels "github.com/ghotso/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+type conversationTaskReq struct {
+	Title string `json:"title"`
+	// AssignedUserID defaults to the agent creating the task.
+	AssignedUserID int       `json:"assigned_user_id"`
+	DueAt          null.Time `json:"due_at"`
+	Completed      bool      `json:"completed"`
+}
+
+type updateConversationTaskReq struct {
+	Title string `json:"title"`
+	// AssignedUserID keeps the current assignee when absent.
+	AssignedUserID null.Int  `json:"assigned_user_id"`
+	DueAt          null.Time `json:"due_at"`
+	Completed      bool      `json:"completed"`
+}
+
+// handleGetConversationTasks returns the tasks of a conversation.
+func handleGetConversationTasks(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	tasks, err := app.conversation.GetConversationTasks(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(tasks)
+}
+
+// handleCreateConversationTask adds a task to a conversation.
+func handleCreateConversationTask(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = conversationTaskReq{}
+	)
+
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if req.AssignedUserID == 0 {
+		req.AssignedUserID = user.ID
+	}
+	task, err := app.conversation.CreateConversationTask(uuid, req.Title, req.AssignedUserID, req.DueAt, user.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(task)
+}
+
+// handleUpdateConversationTask updates a task of a conversation, including completing and reopening it.
+func handleUpdateConversationTask(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = updateConversationTaskReq{}
+	)
+
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	task, err := app.conversation.GetConversationTask(uuid, id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := enforceTaskOwnership(app, user, task); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	assignedUserID := task.AssignedUserID
+	if req.AssignedUserID.Valid {
+		assignedUserID = req.AssignedUserID.Int
+	}
+	task, err = app.conversation.UpdateConversationTask(uuid, id, req.Title, assignedUserID, req.DueAt, req.Completed)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(task)
+}
+
+// handleDeleteConversationTask deletes a task of a conversation.
+func handleDeleteConversationTask(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	task, err := app.conversation.GetConversationTask(uuid, id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := enforceTaskOwnership(app, user, task); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.conversation.DeleteConversationTask(uuid, id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// enforceTaskOwnership allows only the creator and the assignee of a task, and admins, to change it.
+func enforceTaskOwnership(app *App, user umodels.User, task cmodels.ConversationTask) error {
+	if task.AssignedUserID == user.ID || (task.CreatedByID.Valid && task.CreatedByID.Int == user.ID) || user.HasAdminRole() {
+		return nil
+	}
+	return envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil)
+}
+
+// handleGetCurrentAgentTasks returns the tasks assigned to the current agent, open tasks only unless `completed` is set.
+func handleGetCurrentAgentTasks(r *fastglue.Request) error {
+	var (
+		app              = r.Context.(*App)
+		auser            = r.RequestCtx.UserValue("user").(amodels.User)
+		includeCompleted = r.RequestCtx.QueryArgs().GetBool("completed")
+	)
+
+	tasks, err := app.conversation.GetUserTasks(auser.ID, includeCompleted)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(tasks)
+}
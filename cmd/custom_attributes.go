@@ -4,9 +4,11 @@ import (
 	"slices"
 	"strconv"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	cmodels "github.com/ghotso/libredesk/internal/custom_attribute/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/fastglue"
 )
 
@@ -67,13 +69,14 @@ func handleCreateCustomAttribute(r *fastglue.Request) error {
 	if err := r.Decode(&attribute, "json"); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
 	}
-	if err := validateCustomAttribute(app, attribute); err != nil {
+	if err := validateCustomAttribute(app, &attribute); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 	createdAttr, err := app.customAttribute.Create(attribute)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityCreated(r, almodels.ModelCustomAttribute, createdAttr.ID, createdAttr.Name, createdAttr)
 	return r.SendEnvelope(createdAttr)
 }
 
@@ -90,13 +93,18 @@ func handleUpdateCustomAttribute(r *fastglue.Request) error {
 	if err := r.Decode(&attribute, "json"); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
 	}
-	if err := validateCustomAttribute(app, attribute); err != nil {
+	if err := validateCustomAttribute(app, &attribute); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	oldAttr, err := app.customAttribute.Get(id)
+	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 	updatedAttr, err := app.customAttribute.Update(id, attribute)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelCustomAttribute, id, updatedAttr.Name, oldAttr, updatedAttr)
 	return r.SendEnvelope(updatedAttr)
 }
 
@@ -109,14 +117,19 @@ func handleDeleteCustomAttribute(r *fastglue.Request) error {
 	if err != nil || id <= 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
+	oldAttr, err := app.customAttribute.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err = app.customAttribute.Delete(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityDeleted(r, almodels.ModelCustomAttribute, id, oldAttr.Name, oldAttr)
 	return r.SendEnvelope(true)
 }
 
-// validateCustomAttribute validates a custom attribute.
-func validateCustomAttribute(app *App, attribute cmodels.CustomAttribute) error {
+// validateCustomAttribute validates a custom attribute and clears the options that don't apply to its data type.
+func validateCustomAttribute(app *App, attribute *cmodels.CustomAttribute) error {
 	if attribute.Name == "" {
 		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil)
 	}
@@ -126,6 +139,9 @@ func validateCustomAttribute(app *App, attribute cmodels.CustomAttribute) error
 	if attribute.DataType == "" {
 		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`type`"), nil)
 	}
+	if !slices.Contains(cmodels.DataTypes, attribute.DataType) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`type`"), nil)
+	}
 	if attribute.Description == "" {
 		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`description`"), nil)
 	}
@@ -135,5 +151,26 @@ func validateCustomAttribute(app *App, attribute cmodels.CustomAttribute) error
 	if slices.Contains(disallowedKeys, attribute.Key) {
 		return envelope.NewError(envelope.InputError, app.i18n.T("admin.customAttributes.keyNotAllowed"), nil)
 	}
+
+	if attribute.DataType != cmodels.DataTypeNumber {
+		attribute.MinValue, attribute.MaxValue = null.Float64{}, null.Float64{}
+	}
+	if attribute.MinValue.Valid && attribute.MaxValue.Valid && attribute.MinValue.Float64 > attribute.MaxValue.Float64 {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`min_value`"), nil)
+	}
+	if attribute.DataType == cmodels.DataTypeLookup {
+		if !slices.Contains(cmodels.LookupTypes, attribute.LookupType) {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`lookup_type`"), nil)
+		}
+	} else {
+		attribute.LookupType = ""
+	}
+	if (attribute.DataType == cmodels.DataTypeList || attribute.DataType == cmodels.DataTypeMultiList) && len(attribute.Values) == 0 {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`values`"), nil)
+	}
+	// Only conversations are resolved.
+	if attribute.AppliesTo != cmodels.AppliesToConversation {
+		attribute.RequiredOnResolve = false
+	}
 	return nil
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	realip "github.com/ferluci/fast-realip"
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	"github.com/ghotso/libredesk/internal/colorlog"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/setting"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/stuffbin"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// handleExportContactData exports all the data held about a contact as a ZIP file.
+func handleExportContactData(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	var buf bytes.Buffer
+	if err := app.gdpr.Export(id, &buf); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.activityLog.ContactDataExported(auser.ID, auser.Email, realip.FromRequest(r.RequestCtx), id); err != nil {
+		app.lo.Error("error creating activity log", "contact_id", id, "error", err)
+	}
+
+	r.RequestCtx.Response.Header.Set("Content-Type", "application/zip")
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, contactExportFilename(id)))
+	r.RequestCtx.SetBody(buf.Bytes())
+	return nil
+}
+
+// handleEraseContactData anonymizes a contact and redacts their conversations.
+func handleEraseContactData(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	if err := app.gdpr.Erase(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.activityLog.ContactDataErased(auser.ID, auser.Email, realip.FromRequest(r.RequestCtx), id); err != nil {
+		app.lo.Error("error creating activity log", "contact_id", id, "error", err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// runContactDataCommand runs the `--export-contact` and `--erase-contact` commandline operations.
+// Both are recorded in the activity log as done by the system user.
+func runContactDataCommand(db *sqlx.DB, fs stuffbin.FileSystem, settings *setting.Manager, prompt bool) {
+	var (
+		i18n        = initI18n(fs)
+		contactData = initGDPR(db, i18n, initMedia(db, i18n, settings))
+		activityLog = initActivityLog(db, i18n)
+	)
+	systemUser, err := initUser(i18n, db).GetSystemUser()
+	if err != nil {
+		log.Fatalf("error fetching system user: %v", err)
+	}
+
+	if id := ko.Int("export-contact"); id > 0 {
+		out := cmp.Or(ko.String("output"), contactExportFilename(id))
+		f, err := os.Create(out)
+		if err != nil {
+			log.Fatalf("error creating export file: %v", err)
+		}
+		if err := contactData.Export(id, f); err != nil {
+			f.Close()
+			os.Remove(out)
+			log.Fatalf("error exporting contact data: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("error writing export file: %v", err)
+		}
+		if err := activityLog.ContactDataExported(systemUser.ID, systemUser.Email.String, "", id); err != nil {
+			log.Printf("error creating activity log: %v", err)
+		}
+		log.Printf("exported data of contact %d to %s", id, out)
+		return
+	}
+
+	id := ko.Int("erase-contact")
+	colorlog.Red(fmt.Sprintf("WARNING: This will permanently erase the data of contact %d", id))
+	if prompt {
+		log.Print("Continue (y/n)? ")
+		var ok string
+		fmt.Scanf("%s", &ok)
+		if !strings.EqualFold(ok, "y") {
+			log.Fatalf("erasure cancelled")
+		}
+	}
+	if err := contactData.Erase(id); err != nil {
+		log.Fatalf("error erasing contact data: %v", err)
+	}
+	if err := activityLog.ContactDataErased(systemUser.ID, systemUser.Email.String, "", id); err != nil {
+		log.Printf("error creating activity log: %v", err)
+	}
+	log.Printf("erased data of contact %d", id)
+}
+
+// contactExportFilename returns the filename of a contact data export.
+func contactExportFilename(id int) string {
+	return fmt.Sprintf("contact-%d-%s.zip", id, time.Now().Format("2006-01-02"))
+}
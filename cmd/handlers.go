@@ -27,16 +27,35 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.POST("/api/v1/portal/auth/forgot-password", handlePortalForgotPassword)
 	g.GET("/api/v1/portal/auth/me", portalAuth(handlePortalMe))
 	g.POST("/api/v1/portal/auth/set-password", handlePortalSetPassword)
+	g.POST("/api/v1/portal/auth/magic-link", handlePortalRequestMagicLink)
+	g.POST("/api/v1/portal/auth/magic-link/verify", handlePortalVerifyMagicLink)
+	g.POST("/api/v1/portal/auth/register", handlePortalRegister)
+	g.GET("/api/v1/portal/oidc/{id}/login", handlePortalOIDCLogin)
+	g.GET("/api/v1/portal/oidc/{id}/finish", handlePortalOIDCCallback)
 	g.GET("/api/v1/portal/conversations", portalAuth(handlePortalListConversations))
 	g.GET("/api/v1/portal/conversations/{uuid}", portalAuth(handlePortalGetConversation))
 	g.POST("/api/v1/portal/conversations", portalAuth(handlePortalCreateConversation))
+	g.GET("/api/v1/portal/ticket-forms", portalAuth(handlePortalGetTicketForms))
+	g.GET("/api/v1/portal/organization/members", portalAuth(handlePortalGetOrganizationMembers))
+	g.POST("/api/v1/portal/organization/members", portalAuth(handlePortalAddOrganizationMember))
+	g.PUT("/api/v1/portal/organization/members/{contact_id}", portalAuth(handlePortalUpdateOrganizationMember))
+	g.DELETE("/api/v1/portal/organization/members/{contact_id}", portalAuth(handlePortalRemoveOrganizationMember))
 	g.POST("/api/v1/portal/conversations/{uuid}/messages", portalAuth(handlePortalSendMessage))
 	g.POST("/api/v1/portal/conversations/{uuid}/close", portalAuth(handlePortalCloseConversation))
 	g.POST("/api/v1/portal/media", portalAuth(handleMediaUpload))
+	g.GET("/api/v1/portal/kb/categories", portalPublic(handlePortalGetKBCategories))
+	g.GET("/api/v1/portal/kb/categories/{slug}/articles", portalPublic(handlePortalGetKBCategoryArticles))
+	g.GET("/api/v1/portal/kb/articles/{slug}", portalPublic(handlePortalGetKBArticle))
+	g.GET("/api/v1/portal/kb/search", portalPublic(handlePortalSearchKBArticles))
 
 	// i18n.
 	g.GET("/api/v1/lang/{lang}", handleGetI18nLang)
 
+	// Prometheus metrics.
+	if ko.Bool("app.metrics.enabled") {
+		g.GET("/metrics", handleMetrics)
+	}
+
 	// Public config for app initialization.
 	g.GET("/api/v1/config", handleGetConfig)
 
@@ -47,6 +66,7 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	// Settings.
 	g.GET("/api/v1/settings/general", auth(handleGetGeneralSettings))
 	g.PUT("/api/v1/settings/general", perm(handleUpdateGeneralSettings, "general_settings:manage"))
+	g.PUT("/api/v1/settings/portal", perm(handleUpdatePortalSettings, "general_settings:manage"))
 	g.GET("/api/v1/settings/notifications/email", perm(handleGetEmailNotificationSettings, "notification_settings:manage"))
 	g.PUT("/api/v1/settings/notifications/email", perm(handleUpdateEmailNotificationSettings, "notification_settings:manage"))
 
@@ -80,6 +100,32 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/conversations/{uuid}/messages", perm(handleGetMessages, "messages:read"))
 	g.POST("/api/v1/conversations/{cuuid}/messages", perm(handleSendMessage, "messages:write"))
 	g.PUT("/api/v1/conversations/{cuuid}/messages/{uuid}/retry", perm(handleRetryMessage, "messages:write"))
+	g.PUT("/api/v1/conversations/{cuuid}/messages/{uuid}/schedule", perm(handleUpdateScheduledMessage, "messages:write"))
+	g.DELETE("/api/v1/conversations/{cuuid}/messages/{uuid}/schedule", perm(handleCancelScheduledMessage, "messages:write"))
+	g.POST("/api/v1/conversations/{cuuid}/messages/{uuid}/recall", perm(handleRecallMessage, "messages:write"))
+
+	// Conversation links.
+	g.GET("/api/v1/conversations/{uuid}/links", perm(handleGetConversationLinks, "conversations:read"))
+	g.POST("/api/v1/conversations/{uuid}/links", perm(handleLinkConversation, "conversations:update_links"))
+	g.DELETE("/api/v1/conversations/{uuid}/links/{id}", perm(handleUnlinkConversation, "conversations:update_links"))
+
+	// Conversation time entries.
+	g.GET("/api/v1/conversations/{uuid}/time-entries", perm(handleGetTimeEntries, "conversations:read"))
+	g.POST("/api/v1/conversations/{uuid}/time-entries", perm(handleCreateTimeEntry, "conversations:track_time"))
+	g.PUT("/api/v1/conversations/{uuid}/time-entries/{id}", perm(handleUpdateTimeEntry, "conversations:track_time"))
+	g.DELETE("/api/v1/conversations/{uuid}/time-entries/{id}", perm(handleDeleteTimeEntry, "conversations:track_time"))
+
+	// Conversation tasks.
+	g.GET("/api/v1/conversations/{uuid}/tasks", perm(handleGetConversationTasks, "conversations:read"))
+	g.POST("/api/v1/conversations/{uuid}/tasks", perm(handleCreateConversationTask, "conversations:manage_tasks"))
+	g.PUT("/api/v1/conversations/{uuid}/tasks/{id}", perm(handleUpdateConversationTask, "conversations:manage_tasks"))
+	g.DELETE("/api/v1/conversations/{uuid}/tasks/{id}", perm(handleDeleteConversationTask, "conversations:manage_tasks"))
+
+	// Side conversations.
+	g.GET("/api/v1/conversations/{cuuid}/side-conversations", perm(handleGetSideConversations, "messages:read"))
+	g.POST("/api/v1/conversations/{cuuid}/side-conversations", perm(handleCreateSideConversation, "messages:write"))
+	g.GET("/api/v1/conversations/{cuuid}/side-conversations/{uuid}", perm(handleGetSideConversation, "messages:read"))
+	g.POST("/api/v1/conversations/{cuuid}/side-conversations/{uuid}/messages", perm(handleSendSideConversationMessage, "messages:write"))
 	g.POST("/api/v1/conversations", perm(handleCreateConversation, "conversations:write"))
 	g.PUT("/api/v1/conversations/{uuid}/custom-attributes", auth(handleUpdateConversationCustomAttributes))
 	g.PUT("/api/v1/conversations/{uuid}/contacts/custom-attributes", auth(handleUpdateContactCustomAttributes))
@@ -133,6 +179,8 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/agents/me", auth(handleUpdateCurrentAgent))
 	g.GET("/api/v1/agents/me/teams", auth(handleGetCurrentAgentTeams))
 	g.PUT("/api/v1/agents/me/availability", auth(handleUpdateAgentAvailability))
+	g.PUT("/api/v1/agents/me/undo-send", auth(handleUpdateAgentUndoSend))
+	g.GET("/api/v1/agents/me/tasks", auth(handleGetCurrentAgentTasks))
 	g.DELETE("/api/v1/agents/me/avatar", auth(handleDeleteCurrentAgentAvatar))
 
 	g.GET("/api/v1/agents/compact", auth(handleGetAgentsCompact))
@@ -156,6 +204,8 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/contacts/{id}", perm(handleUpdateContact, "contacts:write"))
 	g.POST("/api/v1/contacts/{id}/send-set-password-email", perm(handleSendContactSetPasswordEmail, "contacts:write"))
 	g.PUT("/api/v1/contacts/{id}/block", perm(handleBlockContact, "contacts:block"))
+	g.GET("/api/v1/contacts/{id}/export", perm(handleExportContactData, "contacts:export"))
+	g.POST("/api/v1/contacts/{id}/erase", perm(handleEraseContactData, "contacts:erase"))
 
 	// Contact notes.
 	g.GET("/api/v1/contacts/{id}/notes", perm(handleGetContactNotes, "contact_notes:read"))
@@ -229,6 +279,16 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/reports/overview/csat", perm(handleOverviewCSAT, "reports:manage"))
 	g.GET("/api/v1/reports/overview/messages", perm(handleOverviewMessageVolume, "reports:manage"))
 	g.GET("/api/v1/reports/overview/tags", perm(handleOverviewTagDistribution, "reports:manage"))
+	g.GET("/api/v1/reports/agents", perm(handleAgentPerformance, "reports:manage"))
+	g.GET("/api/v1/reports/agents/{id}/conversations", perm(handleAgentPerformanceConversations, "reports:manage"))
+	g.GET("/api/v1/reports/teams", perm(handleTeamPerformance, "reports:manage"))
+	g.GET("/api/v1/reports/teams/{id}/conversations", perm(handleTeamPerformanceConversations, "reports:manage"))
+	g.GET("/api/v1/reports/time-tracking", perm(handleTimeTrackingReport, "reports:manage"))
+	g.GET("/api/v1/reports/schedules", perm(handleGetReportSchedules, "reports:manage"))
+	g.POST("/api/v1/reports/schedules", perm(handleCreateReportSchedule, "reports:manage"))
+	g.GET("/api/v1/reports/schedules/{id}", perm(handleGetReportSchedule, "reports:manage"))
+	g.PUT("/api/v1/reports/schedules/{id}", perm(handleUpdateReportSchedule, "reports:manage"))
+	g.DELETE("/api/v1/reports/schedules/{id}", perm(handleDeleteReportSchedule, "reports:manage"))
 
 	// Templates.
 	g.GET("/api/v1/templates", perm(handleGetTemplates, "templates:manage"))
@@ -255,6 +315,29 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/ai/prompts", auth(handleGetAIPrompts))
 	g.POST("/api/v1/ai/completion", auth(handleAICompletion))
 	g.PUT("/api/v1/ai/provider", perm(handleUpdateAIProvider, "ai:manage"))
+	g.GET("/api/v1/ai/providers", perm(handleGetAIProviders, "ai:manage"))
+	g.PUT("/api/v1/ai/prompts/{key}/provider", perm(handleUpdateAIPromptProvider, "ai:manage"))
+	g.GET("/api/v1/ai/prompts/{key}", perm(handleGetAIPrompt, "ai:manage"))
+	g.PUT("/api/v1/ai/prompts/{key}", perm(handleUpdateAIPrompt, "ai:manage"))
+	g.POST("/api/v1/conversations/{uuid}/ai/summary", perm(handleAIConversationSummary, "conversations:read"))
+	g.POST("/api/v1/conversations/{uuid}/ai/suggested-reply", perm(handleAISuggestedReply, "conversations:read"))
+
+	// Knowledge base.
+	g.GET("/api/v1/knowledge-base/search", auth(handleSearchKBArticles))
+	g.GET("/api/v1/knowledge-base/categories", perm(handleGetKBCategories, "knowledge_base:manage"))
+	g.POST("/api/v1/knowledge-base/categories", perm(handleCreateKBCategory, "knowledge_base:manage"))
+	g.GET("/api/v1/knowledge-base/categories/{id}", perm(handleGetKBCategory, "knowledge_base:manage"))
+	g.PUT("/api/v1/knowledge-base/categories/{id}", perm(handleUpdateKBCategory, "knowledge_base:manage"))
+	g.DELETE("/api/v1/knowledge-base/categories/{id}", perm(handleDeleteKBCategory, "knowledge_base:manage"))
+	g.GET("/api/v1/knowledge-base/articles", perm(handleGetKBArticles, "knowledge_base:manage"))
+	g.POST("/api/v1/knowledge-base/articles", perm(handleCreateKBArticle, "knowledge_base:manage"))
+	g.GET("/api/v1/knowledge-base/articles/{id}", perm(handleGetKBArticle, "knowledge_base:manage"))
+	g.PUT("/api/v1/knowledge-base/articles/{id}", perm(handleUpdateKBArticle, "knowledge_base:manage"))
+	g.DELETE("/api/v1/knowledge-base/articles/{id}", perm(handleDeleteKBArticle, "knowledge_base:manage"))
+	g.PUT("/api/v1/knowledge-base/articles/{id}/translations/{locale}", perm(handleSaveKBTranslation, "knowledge_base:manage"))
+	g.DELETE("/api/v1/knowledge-base/articles/{id}/translations/{locale}", perm(handleDeleteKBTranslation, "knowledge_base:manage"))
+	g.PUT("/api/v1/knowledge-base/articles/{id}/translations/{locale}/status", perm(handleUpdateKBTranslationStatus, "knowledge_base:manage"))
+	g.GET("/api/v1/knowledge-base/articles/{id}/translations/{locale}/versions", perm(handleGetKBTranslationVersions, "knowledge_base:manage"))
 
 	// Custom attributes.
 	g.GET("/api/v1/custom-attributes", auth(handleGetCustomAttributes))
@@ -263,8 +346,16 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/custom-attributes/{id}", perm(handleUpdateCustomAttribute, "custom_attributes:manage"))
 	g.DELETE("/api/v1/custom-attributes/{id}", perm(handleDeleteCustomAttribute, "custom_attributes:manage"))
 
+	// Ticket forms.
+	g.GET("/api/v1/ticket-forms", perm(handleGetTicketForms, "general_settings:manage"))
+	g.POST("/api/v1/ticket-forms", perm(handleCreateTicketForm, "general_settings:manage"))
+	g.GET("/api/v1/ticket-forms/{id}", perm(handleGetTicketForm, "general_settings:manage"))
+	g.PUT("/api/v1/ticket-forms/{id}", perm(handleUpdateTicketForm, "general_settings:manage"))
+	g.DELETE("/api/v1/ticket-forms/{id}", perm(handleDeleteTicketForm, "general_settings:manage"))
+
 	// Actvity logs.
 	g.GET("/api/v1/activity-logs", perm(handleGetActivityLogs, "activity_logs:manage"))
+	g.GET("/api/v1/activity-logs/export", perm(handleExportActivityLogs, "activity_logs:manage"))
 
 	// User notifications.
 	g.GET("/api/v1/notifications", auth(handleGetUserNotifications))
@@ -273,6 +364,16 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/notifications/read-all", auth(handleMarkAllNotificationsAsRead))
 	g.DELETE("/api/v1/notifications/{id}", auth(handleDeleteNotification))
 	g.DELETE("/api/v1/notifications", auth(handleDeleteAllNotifications))
+	g.GET("/api/v1/notifications/chat-identities", auth(handleGetChatIdentities))
+	g.PUT("/api/v1/notifications/chat-identities/{provider}", auth(handleUpsertChatIdentity))
+	g.DELETE("/api/v1/notifications/chat-identities/{provider}", auth(handleDeleteChatIdentity))
+	g.POST("/api/v1/notifications/chat-identities/{provider}/test", auth(handleTestChatIdentity))
+	g.GET("/api/v1/notifications/preferences", auth(handleGetNotificationPreferences))
+	g.PUT("/api/v1/notifications/preferences", auth(handleUpdateNotificationPreferences))
+	g.GET("/api/v1/notifications/push/config", auth(handleGetPushConfig))
+	g.GET("/api/v1/notifications/push/subscriptions", auth(handleGetPushSubscriptions))
+	g.POST("/api/v1/notifications/push/subscriptions", auth(handleCreatePushSubscription))
+	g.DELETE("/api/v1/notifications/push/subscriptions", auth(handleDeletePushSubscription))
 
 	// WebSocket.
 	g.GET("/ws", auth(func(r *fastglue.Request) error {
@@ -293,6 +394,7 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	// FIXME: Don't need three separate routes for the same thing.
 	g.GET("/assets/{all:*}", serveFrontendStaticFiles)
 	g.GET("/images/{all:*}", serveFrontendStaticFiles)
+	g.GET("/push-sw.js", serveFrontendStaticFiles)
 	g.GET("/static/public/{all:*}", serveStaticFiles)
 
 	// Public pages.
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/ghotso/libredesk/internal/inbox"
 	"github.com/ghotso/libredesk/internal/inbox/channel/email/oauth"
@@ -75,6 +76,8 @@ func handleCreateInbox(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.couldNotReload", "name", "{globals.terms.inbox}"), nil, envelope.GeneralError)
 	}
 
+	logEntityCreated(r, almodels.ModelInbox, createdInbox.ID, createdInbox.Name, createdInbox)
+
 	// Clear passwords before returning.
 	if err := createdInbox.ClearPasswords(); err != nil {
 		app.lo.Error("error clearing inbox passwords from response", "error", err)
@@ -109,10 +112,16 @@ func handleUpdateInbox(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
+	oldInbox, err := app.inbox.GetDBRecord(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	updatedInbox, err := app.inbox.Update(id, inbox)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelInbox, id, updatedInbox.Name, oldInbox, updatedInbox)
 
 	if err := reloadInboxes(app); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.couldNotReload", "name", "{globals.terms.inbox}"), nil, envelope.GeneralError)
@@ -138,10 +147,16 @@ func handleToggleInbox(r *fastglue.Request) error {
 			app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
 
+	oldInbox, err := app.inbox.GetDBRecord(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	toggledInbox, err := app.inbox.Toggle(id)
 	if err != nil {
 		return err
 	}
+	logEntityUpdated(r, almodels.ModelInbox, id, toggledInbox.Name, oldInbox, toggledInbox)
 
 	if err := reloadInboxes(app); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.couldNotReload", "name", "{globals.terms.inbox}"), nil, envelope.GeneralError)
@@ -162,10 +177,14 @@ func handleDeleteInbox(r *fastglue.Request) error {
 		app   = r.Context.(*App)
 		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
 	)
-	err := app.inbox.SoftDelete(id)
+	oldInbox, err := app.inbox.GetDBRecord(id)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	if err := app.inbox.SoftDelete(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityDeleted(r, almodels.ModelInbox, id, oldInbox.Name, oldInbox)
 	if err := reloadInboxes(app); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.couldNotReload", "name", "{globals.terms.inbox}"), nil, envelope.GeneralError)
 	}
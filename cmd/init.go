@@ -26,8 +26,10 @@ import (
 	"github.com/ghotso/libredesk/internal/conversation/status"
 	"github.com/ghotso/libredesk/internal/csat"
 	customAttribute "github.com/ghotso/libredesk/internal/custom_attribute"
+	"github.com/ghotso/libredesk/internal/gdpr"
 	"github.com/ghotso/libredesk/internal/importer"
 	"github.com/ghotso/libredesk/internal/inbox"
+	"github.com/ghotso/libredesk/internal/knowledgebase"
 	"github.com/ghotso/libredesk/internal/inbox/channel/email"
 	imodels "github.com/ghotso/libredesk/internal/inbox/models"
 	"github.com/ghotso/libredesk/internal/macro"
@@ -36,6 +38,10 @@ import (
 	"github.com/ghotso/libredesk/internal/media/stores/s3"
 	notifier "github.com/ghotso/libredesk/internal/notification"
 	emailnotifier "github.com/ghotso/libredesk/internal/notification/providers/email"
+	slacknotifier "github.com/ghotso/libredesk/internal/notification/providers/slack"
+	teamsnotifier "github.com/ghotso/libredesk/internal/notification/providers/teams"
+	webhooknotifier "github.com/ghotso/libredesk/internal/notification/providers/webhook"
+	webpushnotifier "github.com/ghotso/libredesk/internal/notification/providers/webpush"
 	"github.com/ghotso/libredesk/internal/organization"
 	"github.com/ghotso/libredesk/internal/oidc"
 	"github.com/ghotso/libredesk/internal/report"
@@ -44,8 +50,10 @@ import (
 	"github.com/ghotso/libredesk/internal/setting"
 	"github.com/ghotso/libredesk/internal/sla"
 	"github.com/ghotso/libredesk/internal/tag"
+	"github.com/ghotso/libredesk/internal/timetracking"
 	"github.com/ghotso/libredesk/internal/team"
 	tmpl "github.com/ghotso/libredesk/internal/template"
+	ticketform "github.com/ghotso/libredesk/internal/ticket_form"
 	"github.com/ghotso/libredesk/internal/user"
 	"github.com/ghotso/libredesk/internal/view"
 	"github.com/ghotso/libredesk/internal/webhook"
@@ -134,6 +142,10 @@ func initFlags() {
 	f.Bool("yes", false, "skip confirmation prompt")
 	f.Bool("upgrade", false, "upgrade the database schema")
 	f.Bool("set-system-user-password", false, "set password for the system user")
+	f.Int("export-contact", 0, "export all data held about the contact with the given ID as a ZIP file")
+	f.String("output", "", "file path of the --export-contact ZIP file")
+	f.Int("erase-contact", 0, "erase the data of the contact with the given ID")
+	f.Bool("generate-vapid-keys", false, "generate a VAPID key pair for Web Push notifications")
 
 	if err := f.Parse(os.Args[1:]); err != nil {
 		log.Fatalf("loading flags: %v", err)
@@ -253,6 +265,8 @@ func initConversations(
 	webhook *webhook.Manager,
 	dispatcher *notifier.Dispatcher,
 	organizationStore *organization.Manager,
+	aiManager *ai.Manager,
+	customAttributeStore *customAttribute.Manager,
 ) *conversation.Manager {
 	opts := conversation.Opts{
 		DB:                       db,
@@ -263,6 +277,12 @@ func initConversations(
 	if organizationStore != nil {
 		opts.OrganizationStore = organizationStore
 	}
+	if aiManager != nil {
+		opts.Classifier = aiManager
+	}
+	if customAttributeStore != nil {
+		opts.CustomAttributeStore = customAttributeStore
+	}
 	c, err := conversation.New(hub, i18n, sla, status, priority, inboxStore, userStore, teamStore, mediaStore, settings, csat, automationEngine, template, webhook, dispatcher, opts)
 	if err != nil {
 		log.Fatalf("error initializing conversation manager: %v", err)
@@ -284,6 +304,20 @@ func initTag(db *sqlx.DB, i18n *i18n.I18n) *tag.Manager {
 	return mgr
 }
 
+// initTimeTracking inits time tracking manager.
+func initTimeTracking(db *sqlx.DB, i18n *i18n.I18n) *timetracking.Manager {
+	var lo = initLogger("time_tracking_manager")
+	mgr, err := timetracking.New(timetracking.Opts{
+		DB:   db,
+		Lo:   lo,
+		I18n: i18n,
+	})
+	if err != nil {
+		log.Fatalf("error initializing time tracking: %v", err)
+	}
+	return mgr
+}
+
 // initViews inits view manager.
 func initView(db *sqlx.DB, i18n *i18n.I18n) *view.Manager {
 	var lo = initLogger("view_manager")
@@ -578,7 +612,7 @@ func initAutoAssigner(teamManager *team.Manager, userManager *user.Manager, conv
 }
 
 // initNotifier initializes the notifier service with available providers.
-func initNotifier() *notifier.Service {
+func initNotifier(userNotification *notifier.UserNotificationManager) *notifier.Service {
 	smtpCfg := imodels.SMTPConfig{}
 	if err := ko.UnmarshalWithConf("notification.email", &smtpCfg, koanf.UnmarshalConf{Tag: "json"}); err != nil {
 		log.Fatalf("error unmarshalling email notification provider config: %v", err)
@@ -592,8 +626,37 @@ func initNotifier() *notifier.Service {
 		log.Fatalf("error initializing email notifier: %v", err)
 	}
 
+	// Chat providers post to the webhook URLs linked by agents and teams.
+	var (
+		chatTimeout      = cmp.Or(ko.Duration("notification.chat.timeout"), 10*time.Second)
+		chatAllowPrivate = ko.Bool("notification.chat.allow_private_networks")
+	)
+	slackNotifier := slacknotifier.New(slacknotifier.Opts{Lo: initLogger("slack-notifier"), Timeout: chatTimeout, AllowPrivateNetworks: chatAllowPrivate})
+	teamsNotifier := teamsnotifier.New(teamsnotifier.Opts{Lo: initLogger("teams-notifier"), Timeout: chatTimeout, AllowPrivateNetworks: chatAllowPrivate})
+	webhookNotifier := webhooknotifier.New(webhooknotifier.Opts{Lo: initLogger("webhook-notifier"), Timeout: chatTimeout, AllowPrivateNetworks: chatAllowPrivate})
+
 	notifierProviders := map[string]notifier.Notifier{
-		emailNotifier.Name(): emailNotifier,
+		emailNotifier.Name():   emailNotifier,
+		slackNotifier.Name():   slackNotifier,
+		teamsNotifier.Name():   teamsNotifier,
+		webhookNotifier.Name(): webhookNotifier,
+	}
+
+	// Web Push is enabled only when VAPID keys are configured, expired subscriptions are removed as they are reported.
+	if ko.Bool("notification.webpush.enabled") {
+		pushNotifier, err := webpushnotifier.New(webpushnotifier.Opts{
+			Lo:              initLogger("webpush-notifier"),
+			Timeout:         cmp.Or(ko.Duration("notification.webpush.timeout"), 10*time.Second),
+			VAPIDPublicKey:  ko.MustString("notification.webpush.vapid_public_key"),
+			VAPIDPrivateKey: ko.MustString("notification.webpush.vapid_private_key"),
+			Subject:         ko.MustString("notification.webpush.subject"),
+			TTL:             cmp.Or(ko.Duration("notification.webpush.ttl"), 24*time.Hour),
+			OnExpired:       userNotification.DeleteExpiredPushSubscription,
+		})
+		if err != nil {
+			log.Fatalf("error initializing web push notifier: %v", err)
+		}
+		notifierProviders[pushNotifier.Name()] = pushNotifier
 	}
 
 	return notifier.NewService(notifierProviders, ko.MustInt("notification.concurrency"), ko.MustInt("notification.queue_size"), initLogger("notifier"))
@@ -753,6 +816,9 @@ func buildProviders(o *oidc.Manager) ([]auth_.Provider, error) {
 			RedirectURL:  config.RedirectURI,
 			ClientID:     config.ClientID,
 			ClientSecret: config.ClientSecret,
+
+			Portal:            config.Portal,
+			PortalRedirectURL: config.PortalRedirectURI,
 		})
 	}
 	return providers, nil
@@ -877,10 +943,19 @@ func initPriority(db *sqlx.DB, i18n *i18n.I18n) *priority.Manager {
 func initAI(db *sqlx.DB, i18n *i18n.I18n) *ai.Manager {
 	lo := initLogger("ai")
 	m, err := ai.New(ai.Opts{
-		DB:            db,
-		Lo:            lo,
-		I18n:          i18n,
-		EncryptionKey: ko.MustString("app.encryption_key"),
+		DB:                db,
+		Lo:                lo,
+		I18n:              i18n,
+		EncryptionKey:     ko.MustString("app.encryption_key"),
+		ThreadTokenBudget: ko.Int("ai.thread_token_budget"),
+		Embeddings: ai.EmbeddingOpts{
+			Enabled:       ko.Bool("ai.embeddings.enabled"),
+			Provider:      ko.String("ai.embeddings.provider"),
+			Model:         ko.String("ai.embeddings.model"),
+			TopK:          ko.Int("ai.embeddings.top_k"),
+			MinSimilarity: ko.Float64("ai.embeddings.min_similarity"),
+			BatchSize:     ko.Int("ai.embeddings.batch_size"),
+		},
 	})
 	if err != nil {
 		log.Fatalf("error initializing AI manager: %v", err)
@@ -888,6 +963,20 @@ func initAI(db *sqlx.DB, i18n *i18n.I18n) *ai.Manager {
 	return m
 }
 
+// initKnowledgeBase inits knowledge base manager.
+func initKnowledgeBase(db *sqlx.DB, i18n *i18n.I18n) *knowledgebase.Manager {
+	lo := initLogger("knowledge-base")
+	m, err := knowledgebase.New(knowledgebase.Opts{
+		DB:   db,
+		Lo:   lo,
+		I18n: i18n,
+	})
+	if err != nil {
+		log.Fatalf("error initializing knowledge base manager: %v", err)
+	}
+	return m
+}
+
 // initSearch inits search manager.
 func initSearch(db *sqlx.DB, i18n *i18n.I18n) *search.Manager {
 	lo := initLogger("search")
@@ -916,6 +1005,21 @@ func initCustomAttribute(db *sqlx.DB, i18n *i18n.I18n) *customAttribute.Manager
 	return m
 }
 
+// initTicketForm inits ticket form manager.
+func initTicketForm(db *sqlx.DB, i18n *i18n.I18n, customAttributeStore *customAttribute.Manager) *ticketform.Manager {
+	lo := initLogger("ticket-form")
+	m, err := ticketform.New(ticketform.Opts{
+		DB:                   db,
+		Lo:                   lo,
+		I18n:                 i18n,
+		CustomAttributeStore: customAttributeStore,
+	})
+	if err != nil {
+		log.Fatalf("error initializing ticket form manager: %v", err)
+	}
+	return m
+}
+
 // initActivityLog inits activity log manager.
 func initActivityLog(db *sqlx.DB, i18n *i18n.I18n) *activitylog.Manager {
 	lo := initLogger("activity-log")
@@ -930,13 +1034,30 @@ func initActivityLog(db *sqlx.DB, i18n *i18n.I18n) *activitylog.Manager {
 	return m
 }
 
-// initReport inits report manager.
-func initReport(db *sqlx.DB, i18n *i18n.I18n) *report.Manager {
-	lo := initLogger("report")
-	m, err := report.New(report.Opts{
+// initGDPR inits the contact data export and erasure manager.
+func initGDPR(db *sqlx.DB, i18n *i18n.I18n, media *media.Manager) *gdpr.Manager {
+	lo := initLogger("gdpr")
+	m, err := gdpr.New(gdpr.Opts{
 		DB:   db,
 		Lo:   lo,
 		I18n: i18n,
+	}, media)
+	if err != nil {
+		log.Fatalf("error initializing gdpr manager: %v", err)
+	}
+	return m
+}
+
+// initReport inits report manager.
+func initReport(db *sqlx.DB, i18n *i18n.I18n, template *tmpl.Manager, userManager *user.Manager, notifier *notifier.Service) *report.Manager {
+	lo := initLogger("report")
+	m, err := report.New(report.Opts{
+		DB:        db,
+		Lo:        lo,
+		I18n:      i18n,
+		Template:  template,
+		UserStore: userManager,
+		Notifier:  notifier,
 	})
 	if err != nil {
 		log.Fatalf("error initializing report manager: %v", err)
@@ -985,11 +1106,12 @@ func initImporter(i18n *i18n.I18n) *importer.Importer {
 }
 
 // initNotifDispatcher initializes the notification dispatcher.
-func initNotifDispatcher(userNotification *notifier.UserNotificationManager, outbound *notifier.Service, wsHub *ws.Hub) *notifier.Dispatcher {
+func initNotifDispatcher(userNotification *notifier.UserNotificationManager, outbound *notifier.Service, wsHub *ws.Hub, template *tmpl.Manager) *notifier.Dispatcher {
 	return notifier.NewDispatcher(notifier.DispatcherOpts{
 		InApp:    userNotification,
 		Outbound: outbound,
 		WSHub:    wsHub,
+		Template: template,
 		Lo:       initLogger("notification-dispatcher"),
 	})
 }
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/knowledgebase"
+	kbmodels "github.com/ghotso/libredesk/internal/knowledgebase/models"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+// kbSuggestionLimit is the number of articles suggested on the portal while a subject is typed.
+const kbSuggestionLimit = 5
+
+type kbArticleReq struct {
+	CategoryID null.Int `json:"category_id"`
+	Slug       string   `json:"slug"`
+	Position   int      `json:"position"`
+	// Locale, title and body of the first translation when creating an article.
+	Locale string `json:"locale"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+type kbTranslationReq struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type kbTranslationStatusReq struct {
+	Status string `json:"status"`
+}
+
+// handleGetKBCategories returns all knowledge base categories.
+func handleGetKBCategories(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	categories, err := app.knowledgeBase.GetCategories()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(categories)
+}
+
+// handleGetKBCategory returns a knowledge base category.
+func handleGetKBCategory(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	category, err := app.knowledgeBase.GetCategory(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(category)
+}
+
+// handleCreateKBCategory creates a knowledge base category.
+func handleCreateKBCategory(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		category = kbmodels.Category{}
+	)
+	if err := r.Decode(&category, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	created, err := app.knowledgeBase.CreateCategory(category)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityCreated(r, almodels.ModelKBCategory, created.ID, created.Name, created)
+	return r.SendEnvelope(created)
+}
+
+// handleUpdateKBCategory updates a knowledge base category.
+func handleUpdateKBCategory(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		category = kbmodels.Category{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&category, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	oldCategory, err := app.knowledgeBase.GetCategory(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	updated, err := app.knowledgeBase.UpdateCategory(id, category)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityUpdated(r, almodels.ModelKBCategory, id, updated.Name, oldCategory, updated)
+	return r.SendEnvelope(updated)
+}
+
+// handleDeleteKBCategory deletes a knowledge base category, its articles are left uncategorized.
+func handleDeleteKBCategory(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	oldCategory, err := app.knowledgeBase.GetCategory(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.knowledgeBase.DeleteCategory(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityDeleted(r, almodels.ModelKBCategory, id, oldCategory.Name, oldCategory)
+	return r.SendEnvelope(true)
+}
+
+// handleGetKBArticles returns knowledge base articles with the title and status of their translation
+// in the `locale` query param, optionally of the `category_id` query param.
+func handleGetKBArticles(r *fastglue.Request) error {
+	var (
+		app           = r.Context.(*App)
+		categoryID, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("category_id")))
+	)
+	articles, err := app.knowledgeBase.GetArticles(kbLocale(r), categoryID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(articles)
+}
+
+// handleGetKBArticle returns a knowledge base article with all its translations.
+func handleGetKBArticle(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	article, err := app.knowledgeBase.GetArticle(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(article)
+}
+
+// handleCreateKBArticle creates a knowledge base article along with its first translation as a draft.
+func handleCreateKBArticle(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = kbArticleReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	locale := req.Locale
+	if locale == "" {
+		locale = ko.String("app.lang")
+	}
+	article, err := app.knowledgeBase.CreateArticle(req.CategoryID, req.Slug, req.Position, locale, req.Title, req.Body, auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityCreated(r, almodels.ModelKBArticle, article.ID, article.Slug, article)
+	return r.SendEnvelope(article)
+}
+
+// handleUpdateKBArticle updates the category, slug and position of a knowledge base article.
+func handleUpdateKBArticle(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = kbArticleReq{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	oldArticle, err := app.knowledgeBase.GetArticle(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	article, err := app.knowledgeBase.UpdateArticle(id, req.CategoryID, req.Slug, req.Position)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityUpdated(r, almodels.ModelKBArticle, id, article.Slug, oldArticle, article)
+	return r.SendEnvelope(article)
+}
+
+// handleDeleteKBArticle deletes a knowledge base article with all its translations.
+func handleDeleteKBArticle(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	oldArticle, err := app.knowledgeBase.GetArticle(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.knowledgeBase.DeleteArticle(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityDeleted(r, almodels.ModelKBArticle, id, oldArticle.Slug, oldArticle)
+	return r.SendEnvelope(true)
+}
+
+// handleSaveKBTranslation creates or updates the translation of an article in a locale, saving a new version.
+func handleSaveKBTranslation(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		auser  = r.RequestCtx.UserValue("user").(amodels.User)
+		locale = r.RequestCtx.UserValue("locale").(string)
+		req    = kbTranslationReq{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	article, oldTranslation, exists, err := getKBTranslation(app, id, locale)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	translation, err := app.knowledgeBase.SaveTranslation(id, locale, req.Title, req.Body, auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if exists {
+		logEntityUpdated(r, almodels.ModelKBTranslation, translation.ID, kbTranslationName(article, locale), oldTranslation, translation)
+	} else {
+		logEntityCreated(r, almodels.ModelKBTranslation, translation.ID, kbTranslationName(article, locale), translation)
+	}
+	return r.SendEnvelope(translation)
+}
+
+// handleUpdateKBTranslationStatus publishes or unpublishes the translation of an article.
+func handleUpdateKBTranslationStatus(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		locale = r.RequestCtx.UserValue("locale").(string)
+		req    = kbTranslationStatusReq{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	article, oldTranslation, _, err := getKBTranslation(app, id, locale)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	translation, err := app.knowledgeBase.SetTranslationStatus(id, locale, req.Status)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityUpdated(r, almodels.ModelKBTranslation, translation.ID, kbTranslationName(article, locale), oldTranslation, translation)
+	return r.SendEnvelope(translation)
+}
+
+// handleDeleteKBTranslation deletes the translation of an article in a locale.
+func handleDeleteKBTranslation(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		locale = r.RequestCtx.UserValue("locale").(string)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	article, oldTranslation, _, err := getKBTranslation(app, id, locale)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.knowledgeBase.DeleteTranslation(id, locale); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityDeleted(r, almodels.ModelKBTranslation, oldTranslation.ID, kbTranslationName(article, locale), oldTranslation)
+	return r.SendEnvelope(true)
+}
+
+// handleGetKBTranslationVersions returns the saved versions of the translation of an article, latest first.
+func handleGetKBTranslationVersions(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		locale = r.RequestCtx.UserValue("locale").(string)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	versions, err := app.knowledgeBase.GetVersions(id, locale)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(versions)
+}
+
+// handleSearchKBArticles searches published articles for agents to insert links to them into replies.
+func handleSearchKBArticles(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		query = strings.TrimSpace(string(r.RequestCtx.QueryArgs().Peek("query")))
+	)
+	articles, err := app.knowledgeBase.Search(query, kbLocale(r), ko.String("app.lang"), knowledgebase.MaxSearchResults, false)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(withArticleURLs(articles))
+}
+
+// handlePortalGetKBCategories returns the categories with articles published in the `locale` query param or the default language.
+func handlePortalGetKBCategories(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	categories, err := app.knowledgeBase.GetPublicCategories(kbLocale(r), ko.String("app.lang"))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(categories)
+}
+
+// handlePortalGetKBCategoryArticles returns the published articles of a category.
+func handlePortalGetKBCategoryArticles(r *fastglue.Request) error {
+	var (
+		app  = r.Context.(*App)
+		slug = r.RequestCtx.UserValue("slug").(string)
+	)
+	articles, err := app.knowledgeBase.GetPublicArticles(slug, kbLocale(r), ko.String("app.lang"))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(withArticleURLs(articles))
+}
+
+// handlePortalGetKBArticle returns a published article.
+func handlePortalGetKBArticle(r *fastglue.Request) error {
+	var (
+		app  = r.Context.(*App)
+		slug = r.RequestCtx.UserValue("slug").(string)
+	)
+	article, err := app.knowledgeBase.GetPublicArticle(slug, kbLocale(r), ko.String("app.lang"))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	article.URL = kbArticleURL(article.Slug)
+	return r.SendEnvelope(article)
+}
+
+// handlePortalSearchKBArticles searches published articles. With the `suggest` query param set, articles matching
+// any of the words are returned, which is used to suggest articles while a contact types a new conversation subject.
+func handlePortalSearchKBArticles(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		query   = strings.TrimSpace(string(r.RequestCtx.QueryArgs().Peek("query")))
+		suggest = r.RequestCtx.QueryArgs().GetBool("suggest")
+		limit   = knowledgebase.MaxSearchResults
+	)
+	if suggest {
+		limit = kbSuggestionLimit
+	}
+	articles, err := app.knowledgeBase.Search(query, kbLocale(r), ko.String("app.lang"), limit, suggest)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(withArticleURLs(articles))
+}
+
+// getKBTranslation returns an article along with its translation in the locale, exists is false if the article
+// has no translation in the locale yet.
+func getKBTranslation(app *App, articleID int, locale string) (kbmodels.Article, kbmodels.Translation, bool, error) {
+	article, err := app.knowledgeBase.GetArticle(articleID)
+	if err != nil {
+		return article, kbmodels.Translation{}, false, err
+	}
+	for _, t := range article.Translations {
+		if t.Locale == locale {
+			return article, t, true, nil
+		}
+	}
+	return article, kbmodels.Translation{}, false, nil
+}
+
+// kbTranslationName returns the name of an article translation in the audit log, e.g. `reset-password (en)`.
+func kbTranslationName(article kbmodels.Article, locale string) string {
+	return article.Slug + " (" + locale + ")"
+}
+
+// kbLocale returns the `locale` query param, or the default language when it is missing or invalid.
+func kbLocale(r *fastglue.Request) string {
+	if locale := string(r.RequestCtx.QueryArgs().Peek("locale")); knowledgebase.ValidLocale(locale) {
+		return locale
+	}
+	return ko.String("app.lang")
+}
+
+// withArticleURLs sets the portal URL of each article.
+func withArticleURLs(articles []kbmodels.PublicArticle) []kbmodels.PublicArticle {
+	for i := range articles {
+		articles[i].URL = kbArticleURL(articles[i].Slug)
+	}
+	return articles
+}
+
+// kbArticleURL returns the portal URL of an article.
+func kbArticleURL(slug string) string {
+	return strings.TrimRight(ko.String("app.root_url"), "/") + "/portal/kb/" + slug
+}
@@ -34,8 +34,10 @@ import (
 	"github.com/ghotso/libredesk/internal/conversation"
 	"github.com/ghotso/libredesk/internal/conversation/priority"
 	"github.com/ghotso/libredesk/internal/conversation/status"
+	"github.com/ghotso/libredesk/internal/gdpr"
 	"github.com/ghotso/libredesk/internal/importer"
 	"github.com/ghotso/libredesk/internal/inbox"
+	"github.com/ghotso/libredesk/internal/knowledgebase"
 	"github.com/ghotso/libredesk/internal/media"
 	"github.com/ghotso/libredesk/internal/oidc"
 	"github.com/ghotso/libredesk/internal/organization"
@@ -44,6 +46,8 @@ import (
 	"github.com/ghotso/libredesk/internal/tag"
 	"github.com/ghotso/libredesk/internal/team"
 	"github.com/ghotso/libredesk/internal/template"
+	ticketform "github.com/ghotso/libredesk/internal/ticket_form"
+	"github.com/ghotso/libredesk/internal/timetracking"
 	"github.com/ghotso/libredesk/internal/user"
 	"github.com/ghotso/libredesk/internal/webhook"
 	"github.com/knadh/go-i18n"
@@ -88,6 +92,7 @@ type App struct {
 	status           *status.Manager
 	priority         *priority.Manager
 	tag              *tag.Manager
+	timeTracking     *timetracking.Manager
 	inbox            *inbox.Manager
 	tmpl             *template.Manager
 	macro            *macro.Manager
@@ -98,11 +103,14 @@ type App struct {
 	csat             *csat.Manager
 	view             *view.Manager
 	ai               *ai.Manager
+	knowledgeBase    *knowledgebase.Manager
 	search           *search.Manager
 	activityLog      *activitylog.Manager
+	gdpr             *gdpr.Manager
 	notifier         *notifier.Service
 	userNotification *notifier.UserNotificationManager
 	customAttribute  *customAttribute.Manager
+	ticketForm       *ticketform.Manager
 	report           *report.Manager
 	webhook          *webhook.Manager
 	importer         *importer.Importer
@@ -128,6 +136,12 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Generate VAPID keys for Web Push notifications.
+	if ko.Bool("generate-vapid-keys") {
+		generateVAPIDKeys()
+		os.Exit(0)
+	}
+
 	// Build string injected at build time.
 	colorlog.Green("Build: %s", buildString)
 
@@ -178,6 +192,12 @@ func main() {
 	// Validate config.
 	validateConfig(ko)
 
+	// Contact data export and erasure.
+	if ko.Int("export-contact") > 0 || ko.Int("erase-contact") > 0 {
+		runContactDataCommand(db, fs, settings, !ko.Bool("yes"))
+		os.Exit(0)
+	}
+
 	// Fallback for config typo. Logs a warning but continues to work with the incorrect key.
 	// Uses 'message.message_outgoing_scan_interval' (correct key) as default key, falls back to the common typo.
 	msgOutgoingScanIntervalKey := "message.message_outgoing_scan_interval"
@@ -197,6 +217,11 @@ func main() {
 		messageIncomingQWorkers     = ko.MustDuration("message.incoming_queue_workers")
 		messageOutgoingScanInterval = ko.MustDuration(msgOutgoingScanIntervalKey)
 		slaEvaluationInterval       = ko.MustDuration("sla.evaluation_interval")
+		reportScheduleInterval      = cmp.Or(ko.Duration("report.schedule_interval"), 5*time.Minute)
+		notificationDigestInterval  = cmp.Or(ko.Duration("notification.digest_interval"), 5*time.Minute)
+		aiReindexInterval           = cmp.Or(ko.Duration("ai.embeddings.reindex_interval"), time.Hour)
+		presenceExpiryInterval      = cmp.Or(ko.Duration("conversation.presence_expiry_interval"), 5*time.Second)
+		taskReminderInterval        = cmp.Or(ko.Duration("conversation.task_reminder_interval"), time.Minute)
 		lo                          = initLogger(appName)
 		rdb                         = initRedis()
 		constants                   = initConstants()
@@ -215,21 +240,27 @@ func main() {
 		webhook                     = initWebhook(db, i18n)
 		user                        = initUser(i18n, db)
 		wsHub                       = initWS(user)
-		notifier                    = initNotifier()
 		userNotification            = initUserNotification(db, i18n)
-		notifDispatcher             = initNotifDispatcher(userNotification, notifier, wsHub)
+		notifier                    = initNotifier(userNotification)
+		notifDispatcher             = initNotifDispatcher(userNotification, notifier, wsHub, template)
 		automation                  = initAutomationEngine(db, i18n)
+		ai                          = initAI(db, i18n)
+		customAttribute             = initCustomAttribute(db, i18n)
 		sla                         = initSLA(db, team, settings, businessHours, template, user, i18n, notifDispatcher)
-		conversation                = initConversations(i18n, sla, status, priority, wsHub, db, inbox, user, team, media, settings, csat, automation, template, webhook, notifDispatcher, organization)
+		conversation                = initConversations(i18n, sla, status, priority, wsHub, db, inbox, user, team, media, settings, csat, automation, template, webhook, notifDispatcher, organization, ai, customAttribute)
 		autoassigner                = initAutoAssigner(team, user, conversation)
+		report                      = initReport(db, i18n, template, user, notifier)
+		timeTracking                = initTimeTracking(db, i18n)
 	)
 	automation.SetConversationStore(conversation)
+	wsHub.SetTimeRecorder(timeTracking)
 
 	startInboxes(ctx, inbox, conversation, user)
 	go automation.Run(ctx, automationWorkers)
 	go autoassigner.Run(ctx, autoAssignInterval)
 	go conversation.Run(ctx, messageIncomingQWorkers, messageOutgoingQWorkers, messageOutgoingScanInterval)
 	go conversation.RunUnsnoozer(ctx, unsnoozeInterval)
+	go conversation.RunTaskReminder(ctx, taskReminderInterval)
 	go webhook.Run(ctx)
 	go notifier.Run(ctx)
 	go sla.Run(ctx, slaEvaluationInterval)
@@ -238,6 +269,10 @@ func main() {
 	go user.MonitorAgentAvailability(ctx)
 	go conversation.RunDraftCleaner(ctx, draftRetentionDuration)
 	go userNotification.RunNotificationCleaner(ctx)
+	go report.RunScheduler(ctx, reportScheduleInterval)
+	go notifDispatcher.RunDigests(ctx, notificationDigestInterval)
+	go ai.RunIndexer(ctx, aiReindexInterval)
+	go wsHub.RunPresenceExpiry(ctx, presenceExpiryInterval)
 
 	var app = &App{
 		lo:               lo,
@@ -264,22 +299,31 @@ func main() {
 		businessHours:    businessHours,
 		importer:         initImporter(i18n),
 		activityLog:      initActivityLog(db, i18n),
-		customAttribute:  initCustomAttribute(db, i18n),
+		gdpr:             initGDPR(db, i18n, media),
+		customAttribute:  customAttribute,
+		ticketForm:       initTicketForm(db, i18n, customAttribute),
 		authz:            initAuthz(i18n),
 		view:             initView(db, i18n),
-		report:           initReport(db, i18n),
+		report:           report,
 		csat:             initCSAT(db, i18n),
 		search:           initSearch(db, i18n),
 		role:             initRole(db, i18n),
 		tag:              initTag(db, i18n),
+		timeTracking:     timeTracking,
 		macro:            initMacro(db, i18n),
-		ai:               initAI(db, i18n),
+		ai:               ai,
+		knowledgeBase:    initKnowledgeBase(db, i18n),
 		webhook:          webhook,
 	}
 	app.consts.Store(constants)
+	wsHub.SetConversationAccess(presenceAccess{app: app})
 
 	g := fastglue.NewGlue()
 	g.SetContext(app)
+	if ko.Bool("app.metrics.enabled") {
+		initMetrics(db, wsHub, conversation)
+		g.After(recordHTTPMetrics(g))
+	}
 	initHandlers(g, wsHub)
 
 	s := &fasthttp.Server{
@@ -310,6 +354,8 @@ func main() {
 	<-ctx.Done()
 	colorlog.Red("Shutting down HTTP server...")
 	s.Shutdown()
+	colorlog.Red("Shutting down websocket hub...")
+	wsHub.Close()
 	colorlog.Red("Shutting down inboxes...")
 	inbox.Close()
 	colorlog.Red("Shutting down automation...")
@@ -179,11 +179,11 @@ func handleServeMedia(r *fastglue.Request) error {
 		if err != nil {
 			return sendErrorEnvelope(r, err)
 		}
-		orgID, err := portalContactOrgID(app, auser.ID)
+		canAccess, err := contactCanAccessConversation(app, conv, auser.ID)
 		if err != nil {
 			return sendErrorEnvelope(r, err)
 		}
-		if !contactCanAccessConversation(conv, auser.ID, orgID) {
+		if !canAccess {
 			return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.PermissionError)
 		}
 		return serveMediaFile(r, app, uuid, &media)
@@ -216,6 +216,18 @@ func handleServeMedia(r *fastglue.Request) error {
 		}
 	}
 
+	// Side conversation attachments are visible to the agents that can access the conversation.
+	if media.Model.String == mmodels.ModelSideConversationMessages {
+		conversation, err := app.conversation.GetConversationBySideConversationMessageID(media.ModelID.Int)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		allowed, err = app.authz.EnforceConversationAccess(user, conversation)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
 	if !allowed {
 		return r.SendErrorEnvelope(http.StatusUnauthorized, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.UnauthorizedError)
 	}
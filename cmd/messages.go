@@ -1,7 +1,10 @@
 package main
 
 import (
+	"cmp"
+	"slices"
 	"strings"
+	"time"
 
 	amodels "github.com/ghotso/libredesk/internal/auth/models"
 	authzModels "github.com/ghotso/libredesk/internal/authz/models"
@@ -10,6 +13,7 @@ import (
 	medModels "github.com/ghotso/libredesk/internal/media/models"
 	umodels "github.com/ghotso/libredesk/internal/user/models"
 	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/fastglue"
 )
 
@@ -22,6 +26,20 @@ type messageReq struct {
 	BCC         []string               `json:"bcc"`
 	SenderType  string                 `json:"sender_type"`
 	Mentions    []cmodels.MentionInput `json:"mentions"`
+	// SendAt schedules the reply to be sent later.
+	SendAt null.Time `json:"send_at"`
+	// CancelOnReply cancels the scheduled reply if the contact replies first.
+	CancelOnReply bool `json:"cancel_on_reply"`
+	// CheckCollision rejects the reply if another agent has a draft in progress in the conversation.
+	CheckCollision bool `json:"check_collision"`
+	// BroadcastToChildren sends a copy of the reply to the contacts of the child conversations.
+	BroadcastToChildren bool `json:"broadcast_to_children"`
+}
+
+type scheduledMessageReq struct {
+	Message       string    `json:"message"`
+	SendAt        time.Time `json:"send_at"`
+	CancelOnReply bool      `json:"cancel_on_reply"`
 }
 
 // handleGetMessages returns messages for a conversation.
@@ -62,6 +80,8 @@ func handleGetMessages(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	// Replies held in the undo send period can still be recalled, only their sender sees them until the period ends.
+	messages = slices.DeleteFunc(messages, func(m cmodels.Message) bool { return m.HeldForUndo() && m.SenderID != user.ID })
 
 	for i := range messages {
 		total = messages[i].Total
@@ -106,6 +126,9 @@ func handleGetMessage(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	if message.HeldForUndo() && message.SenderID != user.ID {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
 
 	// Redact CSAT survey link
 	message.CensorCSATContent()
@@ -227,10 +250,146 @@ func handleSendMessage(r *fastglue.Request) error {
 		return r.SendEnvelope(message)
 	}
 
-	// Queue reply.
-	message, err := app.conversation.QueueReply(media, conv.InboxID, user.ID, cuuid, req.Message, req.To, req.CC, req.BCC, map[string]any{} /**meta**/)
+	// Warn the agent if another agent is drafting a reply, the agent can resend without the check to reply anyway.
+	if req.CheckCollision {
+		window := cmp.Or(ko.Duration("conversation.draft_collision_window"), 10*time.Minute)
+		agents, err := app.conversation.GetOtherAgentDrafts(cuuid, user.ID, window)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		if len(agents) > 0 {
+			return sendErrorEnvelope(r, envelope.NewError(envelope.ConflictError, app.i18n.T("conversation.otherAgentDrafting"), agents))
+		}
+	}
+
+	// Queue reply, scheduled replies are sent once send at is due.
+	var (
+		meta   = map[string]any{}
+		sendAt = req.SendAt.Time
+	)
+	if req.SendAt.Valid && req.CancelOnReply {
+		meta["cancel_on_reply"] = true
+	}
+	// Hold the reply for the undo send period of the agent, during which it can be recalled. Held replies are scheduled,
+	// so the new message events are only sent once the period ends and only the agent sees the reply until then.
+	if !req.SendAt.Valid && user.UndoSendSeconds > 0 {
+		meta["undo_send"] = true
+		sendAt = time.Now().Add(time.Duration(user.UndoSendSeconds) * time.Second)
+	}
+	message, err := app.conversation.QueueReply(media, conv.InboxID, user.ID, cuuid, req.Message, req.To, req.CC, req.BCC, meta, sendAt)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if req.BroadcastToChildren {
+		broadcastReplyToChildren(app, message, media, user)
+	}
+	return r.SendEnvelope(message)
+}
+
+// handleUpdateScheduledMessage updates the content and send time of a scheduled reply.
+func handleUpdateScheduledMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		req   = scheduledMessageReq{}
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`message`"), nil, envelope.InputError)
+	}
+
+	// Make sure the message belongs to the conversation.
+	msg, err := app.conversation.GetMessage(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	// Replies held for undo send can only be changed by their sender.
+	if msg.ConversationUUID != cuuid || (msg.HeldForUndo() && msg.SenderID != user.ID) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
+
+	message, err := app.conversation.UpdateScheduledMessage(uuid, req.Message, req.SendAt, req.CancelOnReply)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 	return r.SendEnvelope(message)
 }
+
+// handleRecallMessage recalls a reply held in the undo send period back to the agent's draft.
+func handleRecallMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Make sure the message belongs to the conversation.
+	msg, err := app.conversation.GetMessage(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if msg.ConversationUUID != cuuid {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
+
+	draft, err := app.conversation.RecallMessage(uuid, user.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(draft)
+}
+
+// handleCancelScheduledMessage cancels a scheduled reply before it is sent.
+func handleCancelScheduledMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Make sure the message belongs to the conversation.
+	msg, err := app.conversation.GetMessage(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	// Replies held for undo send can only be cancelled by their sender.
+	if msg.ConversationUUID != cuuid || (msg.HeldForUndo() && msg.SenderID != user.ID) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
+
+	if err := app.conversation.CancelScheduledMessage(uuid); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
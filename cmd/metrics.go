@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ghotso/libredesk/internal/conversation"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/ws"
+	"github.com/jmoiron/sqlx"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// initMetrics registers the gauges that are read from app components at scrape time.
+func initMetrics(db *sqlx.DB, hub *ws.Hub, conversation *conversation.Manager) {
+	metrics.NewGauge(`libredesk_message_queue_depth{queue="incoming"}`, func() float64 {
+		return float64(conversation.IncomingQueueLen())
+	})
+	metrics.NewGauge(`libredesk_message_queue_depth{queue="outgoing"}`, func() float64 {
+		return float64(conversation.OutgoingQueueLen())
+	})
+	metrics.NewGauge(`libredesk_websocket_connections`, func() float64 {
+		return float64(hub.ClientCount())
+	})
+
+	// DB connection pool stats.
+	metrics.NewGauge(`libredesk_db_connections{state="open"}`, func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	metrics.NewGauge(`libredesk_db_connections{state="in_use"}`, func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	metrics.NewGauge(`libredesk_db_connections{state="idle"}`, func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	metrics.NewGauge(`libredesk_db_connections_max_open`, func() float64 {
+		return float64(db.Stats().MaxOpenConnections)
+	})
+	metrics.NewGauge(`libredesk_db_wait_count_total`, func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+	metrics.NewGauge(`libredesk_db_wait_duration_seconds_total`, func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+}
+
+// recordHTTPMetrics is a fastglue "after" middleware that records the count and latency of
+// HTTP requests per matched route.
+func recordHTTPMetrics(g *fastglue.Fastglue) fastglue.FastMiddleware {
+	return func(r *fastglue.Request) *fastglue.Request {
+		route, _ := r.RequestCtx.UserValue(g.MatchedRoutePathParam).(string)
+		if route == "" {
+			return r
+		}
+		var (
+			method = string(r.RequestCtx.Method())
+			status = strconv.Itoa(r.RequestCtx.Response.StatusCode())
+		)
+		metrics.GetOrCreateCounter(fmt.Sprintf(`libredesk_http_requests_total{method="%s",route="%s",status="%s"}`, method, route, status)).Inc()
+		metrics.GetOrCreateSummary(fmt.Sprintf(`libredesk_http_request_duration_seconds{method="%s",route="%s"}`, method, route)).UpdateDuration(r.RequestCtx.Time())
+		return r
+	}
+}
+
+// handleMetrics serves the metrics in the Prometheus text format.
+// If credentials are configured, the request must present either the bearer token or the basic auth credentials.
+func handleMetrics(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		token    = ko.String("app.metrics.token")
+		username = ko.String("app.metrics.username")
+		password = ko.String("app.metrics.password")
+	)
+	if token != "" || username != "" {
+		if !isMetricsAuthorized(r.RequestCtx, token, username, password) {
+			r.RequestCtx.Response.Header.Set("WWW-Authenticate", `Basic realm="metrics"`)
+			return r.SendErrorEnvelope(http.StatusUnauthorized, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.UnauthorizedError)
+		}
+	}
+
+	r.RequestCtx.SetContentType("text/plain; version=0.0.4")
+	metrics.WritePrometheus(r.RequestCtx, true)
+	return nil
+}
+
+// isMetricsAuthorized checks the request's Authorization header against the configured bearer token and basic auth credentials.
+func isMetricsAuthorized(ctx *fasthttp.RequestCtx, token, username, password string) bool {
+	header := string(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization))
+	if token != "" && subtle.ConstantTimeCompare([]byte(header), []byte("Bearer "+token)) == 1 {
+		return true
+	}
+	if username != "" {
+		u, p, ok := parseBasicAuth(header)
+		if ok && subtle.ConstantTimeCompare([]byte(u), []byte(username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBasicAuth parses an HTTP basic auth Authorization header value.
+func parseBasicAuth(header string) (string, string, bool) {
+	req := http.Request{Header: http.Header{"Authorization": {header}}}
+	return req.BasicAuth()
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"strconv"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/ghotso/libredesk/internal/oidc/models"
 	"github.com/valyala/fasthttp"
@@ -57,6 +58,7 @@ func handleCreateOIDC(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityCreated(r, almodels.ModelOIDC, createdOIDC.ID, createdOIDC.Name, createdOIDC)
 
 	// Reload the auth manager to update the OIDC providers.
 	if err := reloadAuth(app); err != nil {
@@ -89,10 +91,16 @@ func handleUpdateOIDC(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
+	oldOIDC, err := app.oidc.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	updatedOIDC, err := app.oidc.Update(id, req)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelOIDC, id, updatedOIDC.Name, oldOIDC, updatedOIDC)
 
 	// Reload the auth manager to update the OIDC providers.
 	if err := reloadAuth(app); err != nil {
@@ -112,8 +120,13 @@ func handleDeleteOIDC(r *fastglue.Request) error {
 	if err != nil || id == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
+	oldOIDC, err := app.oidc.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err = app.oidc.Delete(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityDeleted(r, almodels.ModelOIDC, id, oldOIDC.Name, oldOIDC)
 	return r.SendEnvelope(true)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/ghotso/libredesk/internal/envelope"
 	umodels "github.com/ghotso/libredesk/internal/user/models"
 	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/fastglue"
 )
 
@@ -25,7 +26,8 @@ type addOrganizationMemberRequest struct {
 }
 
 type updateOrganizationMemberRequest struct {
-	ShareTicketsByDefault bool `json:"share_tickets_by_default"`
+	ShareTicketsByDefault null.Bool   `json:"share_tickets_by_default"`
+	Role                  null.String `json:"role"`
 }
 
 func handleGetOrganizations(r *fastglue.Request) error {
@@ -157,7 +159,7 @@ func handleUpdateOrganizationMember(r *fastglue.Request) error {
 	if err := r.Decode(&req, "json"); err != nil {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
 	}
-	member, err := app.organization.UpdateMemberShareTicketsByDefault(orgID, contactID, req.ShareTicketsByDefault)
+	member, err := app.organization.UpdateMember(orgID, contactID, req.ShareTicketsByDefault, req.Role)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
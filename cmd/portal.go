@@ -5,12 +5,14 @@ import (
 	"errors"
 	"net/http"
 	"net/textproto"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	amodels "github.com/ghotso/libredesk/internal/auth/models"
 	cmodels "github.com/ghotso/libredesk/internal/conversation/models"
+	camodels "github.com/ghotso/libredesk/internal/custom_attribute/models"
 	smodels "github.com/ghotso/libredesk/internal/conversation/status/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	inboxemail "github.com/ghotso/libredesk/internal/inbox/channel/email"
@@ -32,18 +34,11 @@ func portalAuth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler
 		app := r.Context.(*App)
 
 		// Check if portal is enabled.
-		settingsJSON, err := app.setting.GetByPrefix("app")
+		enabled, err := portalEnabled(app)
 		if err != nil {
-			app.lo.Error("error fetching app settings for portal", "error", err)
-			return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil, envelope.GeneralError)
-		}
-		var settings map[string]interface{}
-		if err := json.Unmarshal(settingsJSON, &settings); err != nil {
-			app.lo.Error("error unmarshalling app settings", "error", err)
-			return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil, envelope.GeneralError)
+			return sendErrorEnvelope(r, err)
 		}
-		portalEnabled, _ := settings["app.portal_enabled"].(bool)
-		if !portalEnabled {
+		if !enabled {
 			return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("auth.invalidOrExpiredSession"), nil, envelope.PermissionError)
 		}
 
@@ -83,6 +78,37 @@ func portalAuth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler
 	}
 }
 
+// portalPublic ensures portal is enabled for public portal endpoints that need no contact session.
+func portalPublic(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
+	return func(r *fastglue.Request) error {
+		app := r.Context.(*App)
+		enabled, err := portalEnabled(app)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		if !enabled {
+			return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.disabled"), nil, envelope.PermissionError)
+		}
+		return handler(r)
+	}
+}
+
+// portalEnabled returns whether the portal is enabled in the app settings.
+func portalEnabled(app *App) (bool, error) {
+	settingsJSON, err := app.setting.GetByPrefix("app")
+	if err != nil {
+		app.lo.Error("error fetching app settings for portal", "error", err)
+		return false, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil)
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(settingsJSON, &settings); err != nil {
+		app.lo.Error("error unmarshalling app settings", "error", err)
+		return false, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil)
+	}
+	enabled, _ := settings["app.portal_enabled"].(bool)
+	return enabled, nil
+}
+
 // handlePortalLogin handles POST /api/v1/portal/auth/login (email + password).
 func handlePortalLogin(r *fastglue.Request) error {
 	app := r.Context.(*App)
@@ -118,18 +144,8 @@ func handlePortalLogin(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
-	if err := app.auth.SavePortalSession(amodels.User{
-		ID:        user.ID,
-		Email:     user.Email.String,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		UserType:  models.UserTypeContact,
-	}, r); err != nil {
-		app.lo.Error("error saving portal session", "error", err)
-		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil))
-	}
-	if err := app.auth.SetCSRFCookie(r); err != nil {
-		app.lo.Error("error setting csrf cookie", "error", err)
+	if err := startPortalSession(app, r, user); err != nil {
+		return sendErrorEnvelope(r, err)
 	}
 
 	// Return contact info (no sensitive fields).
@@ -162,10 +178,28 @@ func handlePortalMe(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(http.StatusUnauthorized, app.i18n.T("auth.invalidOrExpiredSession"), nil, envelope.GeneralError)
 	}
 	out := map[string]interface{}{
-		"id":         contact.ID,
-		"email":      contact.Email.String,
-		"first_name": contact.FirstName,
-		"last_name":  contact.LastName,
+		"id":           contact.ID,
+		"email":        contact.Email.String,
+		"first_name":   contact.FirstName,
+		"last_name":    contact.LastName,
+		"organization": nil,
+	}
+
+	// The organization and role decide what the portal shows, e.g. the members page of organization admins.
+	mem, ok, err := app.organization.GetMembershipForContact(int64(contact.ID))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if ok {
+		org, err := app.organization.Get(mem.OrganizationID)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		out["organization"] = map[string]interface{}{
+			"id":   org.ID,
+			"name": org.Name,
+			"role": mem.Role,
+		}
 	}
 	return r.SendEnvelope(out)
 }
@@ -328,27 +362,33 @@ func handlePortalForgotPassword(r *fastglue.Request) error {
 	return r.SendEnvelope(map[string]string{"ok": "true"})
 }
 
-// portalContactOrgID returns the contact's organization ID when the contact has membership; otherwise 0.
-func portalContactOrgID(app *App, contactID int) (int, error) {
+// contactCanAccessConversation returns true if the contact can access the conversation (owner or org-shared).
+// Organization admins can also access the conversations of every member of their organization.
+func contactCanAccessConversation(app *App, conv cmodels.Conversation, contactID int) (bool, error) {
+	if conv.ContactID == contactID {
+		return true, nil
+	}
 	mem, ok, err := app.organization.GetMembershipForContact(int64(contactID))
-	if err != nil {
-		return 0, err
+	if err != nil || !ok {
+		return false, err
 	}
-	if !ok {
-		return 0, nil
+	if conv.OrganizationID.Valid && int(conv.OrganizationID.Int) == mem.OrganizationID {
+		return true, nil
+	}
+	if !mem.IsAdmin() {
+		return false, nil
 	}
-	return mem.OrganizationID, nil
+	return portalContactInOrganization(app, mem.OrganizationID, conv.ContactID)
 }
 
-// contactCanAccessConversation returns true if the contact can access the conversation (owner or org-shared).
-func contactCanAccessConversation(conv cmodels.Conversation, contactID int, orgID int) bool {
-	if conv.ContactID == contactID {
-		return true
-	}
-	if orgID > 0 && conv.OrganizationID.Valid && int(conv.OrganizationID.Int) == orgID {
-		return true
+// portalContactInOrganization returns true if the contact is a member of the organization.
+func portalContactInOrganization(app *App, orgID, contactID int) (bool, error) {
+	ok, err := app.organization.ContactInOrganization(orgID, int64(contactID))
+	if err != nil {
+		app.lo.Error("error checking organization membership", "organization_id", orgID, "contact_id", contactID, "error", err)
+		return false, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.organization}"), nil)
 	}
-	return false
+	return ok, nil
 }
 
 // handlePortalListConversations returns conversations visible to the contact (portalAuth).
@@ -356,7 +396,7 @@ func handlePortalListConversations(r *fastglue.Request) error {
 	app := r.Context.(*App)
 	contact, _ := r.RequestCtx.UserValue("contact").(models.User)
 
-	orgID, err := portalContactOrgID(app, contact.ID)
+	mem, _, err := app.organization.GetMembershipForContact(int64(contact.ID))
 	if err != nil {
 		app.lo.Error("error getting contact org for portal list", "error", err)
 		return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil, envelope.GeneralError)
@@ -371,7 +411,7 @@ func handlePortalListConversations(r *fastglue.Request) error {
 		page = 1
 	}
 
-	list, total, err := app.conversation.GetConversationsForContact(contact.ID, orgID, page, pageSize)
+	list, total, err := app.conversation.GetConversationsForContact(contact.ID, mem.OrganizationID, mem.IsAdmin(), page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -396,13 +436,13 @@ func handlePortalGetConversation(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
-	orgID, err := portalContactOrgID(app, contact.ID)
+	canAccess, err := contactCanAccessConversation(app, conv, contact.ID)
 	if err != nil {
 		app.lo.Error("error getting contact org for portal detail", "error", err)
 		return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil, envelope.GeneralError)
 	}
 
-	if !contactCanAccessConversation(conv, contact.ID, orgID) {
+	if !canAccess {
 		return r.SendErrorEnvelope(http.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil, envelope.NotFoundError)
 	}
 
@@ -412,6 +452,8 @@ func handlePortalGetConversation(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	// Scheduled replies are not shown until they are sent.
+	messages = slices.DeleteFunc(messages, func(m cmodels.Message) bool { return m.Status == cmodels.MessageStatusScheduled })
 
 	out := map[string]interface{}{
 		"conversation": conv,
@@ -425,25 +467,16 @@ func handlePortalCreateConversation(r *fastglue.Request) error {
 	app := r.Context.(*App)
 	contact, _ := r.RequestCtx.UserValue("contact").(models.User)
 
-	settingsJSON, err := app.setting.GetByPrefix("app")
-	if err != nil {
-		return sendErrorEnvelope(r, err)
-	}
-	var settings map[string]interface{}
-	if err := json.Unmarshal(settingsJSON, &settings); err != nil {
-		return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil, envelope.GeneralError)
-	}
-	defaultInboxID, _ := settings["app.portal_default_inbox_id"].(float64) // JSON number
-	if defaultInboxID == 0 {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.required", "name", "portal default inbox"), nil, envelope.InputError)
-	}
-	inboxID := int(defaultInboxID)
-
 	var req struct {
 		Subject               string `json:"subject"`
 		Content                string `json:"content"`
 		Attachments            []int  `json:"attachments"`
 		ShareWithOrganization  *bool  `json:"share_with_organization"`
+		// FormID is the ticket form filled in, its inbox is used instead of the portal default inbox.
+		FormID           int            `json:"form_id"`
+		CustomAttributes map[string]any `json:"custom_attributes"`
+		// ContactID is the colleague an organization admin opens the conversation for.
+		ContactID int `json:"contact_id"`
 	}
 	if err := r.Decode(&req, "json"); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
@@ -452,20 +485,62 @@ func handlePortalCreateConversation(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.required", "name", "content"), nil, envelope.InputError)
 	}
 
-	contactChannelID, err := app.user.EnsureContactChannel(contact.ID, inboxID, contact.Email.String)
+	// Organization admins can open conversations on behalf of the members of their organization, the admin stays the
+	// author of the first message.
+	requester := contact
+	onBehalf := req.ContactID > 0 && req.ContactID != contact.ID
+	if onBehalf {
+		var err error
+		if _, requester, err = portalOrganizationColleague(app, contact, req.ContactID); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
+	var (
+		inboxID          int
+		customAttributes map[string]any
+	)
+	if req.FormID > 0 {
+		form, values, err := app.ticketForm.GetSubmission(req.FormID, req.CustomAttributes)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		if err := app.customAttribute.ValidateValues(camodels.AppliesToConversation, values); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		inboxID, customAttributes = form.InboxID, values
+	} else {
+		settingsJSON, err := app.setting.GetByPrefix("app")
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		var settings map[string]interface{}
+		if err := json.Unmarshal(settingsJSON, &settings); err != nil {
+			return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil, envelope.GeneralError)
+		}
+		defaultInboxID, _ := settings["app.portal_default_inbox_id"].(float64) // JSON number
+		if defaultInboxID == 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.required", "name", "portal default inbox"), nil, envelope.InputError)
+		}
+		inboxID = int(defaultInboxID)
+	}
+
+	contactChannelID, err := app.user.EnsureContactChannel(requester.ID, inboxID, requester.Email.String)
 	if err != nil {
 		app.lo.Error("error ensuring contact channel for portal", "error", err)
 		return sendErrorEnvelope(r, err)
 	}
 
+	// The conversation belongs to the requester, so whether it's shared follows the requester's own membership
+	// setting when an admin opens it on their behalf.
 	orgID := 0
-	mem, hasOrg, _ := app.organization.GetMembershipForContact(int64(contact.ID))
+	mem, hasOrg, _ := app.organization.GetMembershipForContact(int64(requester.ID))
 	if hasOrg {
 		orgID = mem.OrganizationID
 	}
 	shareWithOrg := false
 	if hasOrg {
-		if req.ShareWithOrganization != nil {
+		if req.ShareWithOrganization != nil && !onBehalf {
 			shareWithOrg = *req.ShareWithOrganization
 		} else {
 			shareWithOrg = mem.ShareTicketsByDefault
@@ -477,7 +552,7 @@ func handlePortalCreateConversation(r *fastglue.Request) error {
 	}
 
 	conversationID, conversationUUID, err := app.conversation.CreateConversation(
-		contact.ID, contactChannelID, inboxID,
+		requester.ID, contactChannelID, inboxID,
 		"", time.Now(), req.Subject,
 		true, setOrgID,
 	)
@@ -491,10 +566,31 @@ func handlePortalCreateConversation(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
-	conv, _ := app.conversation.GetConversation(conversationID, "", "")
+	// Store the ticket form values before the new conversation rules run so that automations can act on them.
+	if len(customAttributes) > 0 {
+		if err := app.conversation.UpdateConversationCustomAttributes(conversationUUID, customAttributes); err != nil {
+			app.lo.Error("error setting ticket form custom attributes", "conversation_uuid", conversationUUID, "error", err)
+		}
+	}
+
+	conv, err := app.conversation.GetConversation(conversationID, "", "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	app.automation.EvaluateNewConversationRules(conv)
 	return r.SendEnvelope(conv)
 }
 
+// handlePortalGetTicketForms returns the enabled ticket forms contacts can create conversations with (portalAuth).
+func handlePortalGetTicketForms(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	forms, err := app.ticketForm.GetPortalForms()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(forms)
+}
+
 // resolveMediaFromIDs loads media by ids (for portal message/create). Returns empty slice on any error.
 func resolveMediaFromIDs(app *App, ids []int) []medModels.Media {
 	var out []medModels.Media
@@ -518,11 +614,11 @@ func handlePortalSendMessage(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	orgID, err := portalContactOrgID(app, contact.ID)
+	canAccess, err := contactCanAccessConversation(app, conv, contact.ID)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	if !contactCanAccessConversation(conv, contact.ID, orgID) {
+	if !canAccess {
 		return r.SendErrorEnvelope(http.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil, envelope.NotFoundError)
 	}
 
@@ -555,11 +651,11 @@ func handlePortalCloseConversation(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	orgID, err := portalContactOrgID(app, contact.ID)
+	canAccess, err := contactCanAccessConversation(app, conv, contact.ID)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	if !contactCanAccessConversation(conv, contact.ID, orgID) {
+	if !canAccess {
 		return r.SendErrorEnvelope(http.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil, envelope.NotFoundError)
 	}
 
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	realip "github.com/ferluci/fast-realip"
+	auth_ "github.com/ghotso/libredesk/internal/auth"
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	smodels "github.com/ghotso/libredesk/internal/setting/models"
+	"github.com/ghotso/libredesk/internal/stringutil"
+	tmpl "github.com/ghotso/libredesk/internal/template"
+	"github.com/ghotso/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	portalMagicLinkTTL = 15 * time.Minute
+
+	// Sign in emails sent to an address and requests from an IP allowed per window.
+	portalAuthRateWindow    = time.Hour
+	portalAuthEmailAttempts = 5
+	portalAuthIPAttempts    = 20
+
+	maxPortalNameLength = 140
+
+	portalOIDCStateSessKey = "portal_oidc_state"
+)
+
+// handlePortalRequestMagicLink handles POST /api/v1/portal/auth/magic-link (email). Sends a sign in link to the contact if found.
+func handlePortalRequestMagicLink(r *fastglue.Request) error {
+	app := r.Context.(*App)
+
+	settings, err := portalSettings(app)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !settings.PortalEnabled || !settings.PortalMagicLinkEnabled {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("auth.invalidOrExpiredSession"), nil, envelope.PermissionError)
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	email := strings.TrimSpace(strings.ToLower(req.Email))
+	if !stringutil.ValidEmail(email) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`email`"), nil, envelope.InputError)
+	}
+	if err := allowPortalAuthAttempt(app, r, "magic_link", email); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Unknown and disabled contacts get the same response as the others, so that the emails of contacts can't be probed.
+	contact, err := app.user.Get(0, email, models.UserTypeContact)
+	if err != nil {
+		if isNotFoundError(err) {
+			return r.SendEnvelope(map[string]string{"ok": "true"})
+		}
+		return sendErrorEnvelope(r, err)
+	}
+	if !contact.Enabled {
+		return r.SendEnvelope(map[string]string{"ok": "true"})
+	}
+
+	if err := sendPortalMagicLink(app, amodels.PortalMagicLink{Email: email}); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(map[string]string{"ok": "true"})
+}
+
+// handlePortalRegister handles POST /api/v1/portal/auth/register (email + name). Emails a link that creates the contact
+// and signs them in, contacts that already exist are sent a sign in link instead.
+func handlePortalRegister(r *fastglue.Request) error {
+	app := r.Context.(*App)
+
+	settings, err := portalSettings(app)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !settings.PortalEnabled || !settings.PortalSelfRegistrationEnabled {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.registrationNotAllowed"), nil, envelope.PermissionError)
+	}
+
+	var req struct {
+		Email     string `json:"email"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	link := amodels.PortalMagicLink{
+		Email:     strings.TrimSpace(strings.ToLower(req.Email)),
+		FirstName: strings.TrimSpace(req.FirstName),
+		LastName:  strings.TrimSpace(req.LastName),
+	}
+	if !stringutil.ValidEmail(link.Email) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`email`"), nil, envelope.InputError)
+	}
+	if link.FirstName == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`first_name`"), nil, envelope.InputError)
+	}
+	if len([]rune(link.FirstName)) > maxPortalNameLength || len([]rune(link.LastName)) > maxPortalNameLength {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.tooLong", "name", "{globals.terms.name}", "max", strconv.Itoa(maxPortalNameLength)), nil, envelope.InputError)
+	}
+
+	allowed, err := portalRegistrationAllowed(app, settings, link.Email)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !allowed {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.registrationNotAllowed"), nil, envelope.PermissionError)
+	}
+	if err := allowPortalAuthAttempt(app, r, "register", link.Email); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Respond the same whether or not the contact exists so that sign ups can't be used to look up contacts.
+	contact, err := app.user.Get(0, link.Email, models.UserTypeContact)
+	switch {
+	case err == nil:
+		if !contact.Enabled {
+			return r.SendEnvelope(map[string]string{"ok": "true"})
+		}
+		link = amodels.PortalMagicLink{Email: link.Email}
+	case !isNotFoundError(err):
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := sendPortalMagicLink(app, link); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(map[string]string{"ok": "true"})
+}
+
+// handlePortalVerifyMagicLink handles POST /api/v1/portal/auth/magic-link/verify (token). Signs the contact of a magic
+// link in, creating the contact first if the link is a sign up.
+func handlePortalVerifyMagicLink(r *fastglue.Request) error {
+	app := r.Context.(*App)
+
+	settings, err := portalSettings(app)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !settings.PortalEnabled {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("auth.invalidOrExpiredSession"), nil, envelope.PermissionError)
+	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	link, err := app.auth.ConsumePortalMagicLink(strings.TrimSpace(req.Token))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	contact, err := app.user.Get(0, link.Email, models.UserTypeContact)
+	if err != nil {
+		if !isNotFoundError(err) {
+			return sendErrorEnvelope(r, err)
+		}
+		// Only sign up links create contacts, and only while sign ups stay open to the email.
+		if link.FirstName == "" || !settings.PortalSelfRegistrationEnabled {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("portal.invalidOrExpiredLink"), nil, envelope.InputError)
+		}
+		allowed, err := portalRegistrationAllowed(app, settings, link.Email)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		if !allowed {
+			return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.registrationNotAllowed"), nil, envelope.PermissionError)
+		}
+		if contact, err = registerPortalContact(app, link); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+	if !contact.Enabled {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("portal.invalidOrExpiredLink"), nil, envelope.InputError)
+	}
+
+	if err := startPortalSession(app, r, contact); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	out := map[string]interface{}{
+		"id":         contact.ID,
+		"email":      contact.Email.String,
+		"first_name": contact.FirstName,
+		"last_name":  contact.LastName,
+	}
+	return r.SendEnvelope(out)
+}
+
+// handlePortalOIDCLogin handles GET /api/v1/portal/oidc/{id}/login. Redirects the contact to an OIDC provider enabled
+// for the portal.
+func handlePortalOIDCLogin(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	providerID, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || providerID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	settings, err := portalSettings(app)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !settings.PortalEnabled {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.disabled"), nil, envelope.PermissionError)
+	}
+
+	// Save a state in the portal session to prevent CSRF attacks.
+	state, err := stringutil.RandomAlphanumeric(32)
+	if err != nil {
+		app.lo.Error("error generating state", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.errorGenerating", "name", "state"), nil, envelope.GeneralError)
+	}
+	if err := app.auth.SetPortalSessionValues(r, map[string]any{portalOIDCStateSessKey: state}); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil, envelope.GeneralError)
+	}
+
+	authURL, err := app.auth.PortalLoginURL(providerID, state)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.Redirect(authURL, fasthttp.StatusFound, nil, "")
+}
+
+// handlePortalOIDCCallback handles GET /api/v1/portal/oidc/{id}/finish, the redirect back from the OIDC provider.
+// Signs in the contact of the email claim, creating the contact first if there's none.
+func handlePortalOIDCCallback(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		code  = string(r.RequestCtx.QueryArgs().Peek("code"))
+		state = string(r.RequestCtx.QueryArgs().Peek("state"))
+	)
+	providerID, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || providerID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	settings, err := portalSettings(app)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !settings.PortalEnabled {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.disabled"), nil, envelope.PermissionError)
+	}
+
+	// Compare the state from the portal session with the state from the query.
+	sessionState, err := app.auth.GetPortalSessionValue(r, portalOIDCStateSessKey)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.session}"), nil, envelope.GeneralError)
+	}
+	if state == "" || state != sessionState {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.Ts("globals.messages.mismatch", "name", "{globals.terms.state}"), nil, envelope.GeneralError)
+	}
+
+	_, claims, err := app.auth.ExchangePortalOIDCToken(r.RequestCtx, providerID, code)
+	if err != nil {
+		app.lo.Error("error exchanging portal oidc token", "provider_id", providerID, "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.T("globals.messages.errorExchangingToken"), nil, envelope.GeneralError)
+	}
+	email := strings.TrimSpace(strings.ToLower(claims.Email))
+	if !stringutil.ValidEmail(email) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`email`"), nil, envelope.InputError)
+	}
+	// Contacts are looked up by email, so an unverified email could sign in as somebody else.
+	if !claims.EmailVerified {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.emailNotVerified"), nil, envelope.PermissionError)
+	}
+
+	contact, err := app.user.Get(0, email, models.UserTypeContact)
+	if err != nil {
+		if !isNotFoundError(err) {
+			return sendErrorEnvelope(r, err)
+		}
+		// Contacts are created just in time under the same rules as portal sign ups.
+		if !settings.PortalSelfRegistrationEnabled {
+			return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.registrationNotAllowed"), nil, envelope.PermissionError)
+		}
+		allowed, err := portalRegistrationAllowed(app, settings, email)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		if !allowed {
+			return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("portal.registrationNotAllowed"), nil, envelope.PermissionError)
+		}
+		firstName, lastName := portalOIDCContactName(claims, email)
+		contact, err = registerPortalContact(app, amodels.PortalMagicLink{Email: email, FirstName: firstName, LastName: lastName})
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+	if !contact.Enabled {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("user.accountDisabled"), nil, envelope.PermissionError)
+	}
+
+	if err := startPortalSession(app, r, contact); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.RedirectURI("/portal/tickets", fasthttp.StatusFound, nil, "")
+}
+
+// portalOIDCContactName returns the first and last name of a contact signing up with an OIDC provider, from the name
+// claims or else the email.
+func portalOIDCContactName(claims auth_.OIDCclaim, email string) (string, string) {
+	firstName, lastName := strings.TrimSpace(claims.GivenName), strings.TrimSpace(claims.FamilyName)
+	if firstName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(email, "@")
+	}
+	return truncateRunes(firstName, maxPortalNameLength), truncateRunes(strings.TrimSpace(lastName), maxPortalNameLength)
+}
+
+// truncateRunes returns s cut to at most n runes.
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// startPortalSession saves the portal session of a contact and sets the CSRF cookie.
+func startPortalSession(app *App, r *fastglue.Request, contact models.User) error {
+	if err := app.auth.SavePortalSession(amodels.User{
+		ID:        contact.ID,
+		Email:     contact.Email.String,
+		FirstName: contact.FirstName,
+		LastName:  contact.LastName,
+		UserType:  models.UserTypeContact,
+	}, r); err != nil {
+		app.lo.Error("error saving portal session", "error", err)
+		return envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil)
+	}
+	if err := app.auth.SetCSRFCookie(r); err != nil {
+		app.lo.Error("error setting csrf cookie", "error", err)
+	}
+	return nil
+}
+
+// registerPortalContact creates the contact of a sign up link or OIDC sign in and adds it to the organizations of its
+// email domain.
+func registerPortalContact(app *App, link amodels.PortalMagicLink) (models.User, error) {
+	inboxID, err := defaultInboxIDForContact(app)
+	if err != nil || inboxID <= 0 {
+		return models.User{}, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.inbox}"), nil)
+	}
+	contact := models.User{
+		Email:           null.StringFrom(link.Email),
+		FirstName:       link.FirstName,
+		LastName:        link.LastName,
+		InboxID:         inboxID,
+		SourceChannelID: null.StringFrom(link.Email),
+	}
+	if err := app.user.CreateContact(&contact); err != nil {
+		return models.User{}, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.contact}"), nil)
+	}
+	app.organization.AddContactToOrganizationsByEmailDomain(int64(contact.ID), link.Email)
+	return app.user.Get(contact.ID, "", models.UserTypeContact)
+}
+
+// sendPortalMagicLink creates a magic link and emails it to the address it signs in.
+func sendPortalMagicLink(app *App, link amodels.PortalMagicLink) error {
+	token, err := app.auth.CreatePortalMagicLink(link, portalMagicLinkTTL)
+	if err != nil {
+		return err
+	}
+	register := link.FirstName != ""
+	content, err := app.tmpl.RenderInMemoryTemplate(tmpl.TmplPortalMagicLink, map[string]any{
+		"Token":            token,
+		"Register":         register,
+		"ExpiresInMinutes": int(portalMagicLinkTTL.Minutes()),
+	})
+	if err != nil {
+		app.lo.Error("error rendering portal magic link template", "error", err)
+		return envelope.NewError(envelope.GeneralError, app.i18n.T("portal.errorSendingMagicLink"), nil)
+	}
+	subject := app.i18n.T("portal.magicLinkEmailSubject")
+	if register {
+		subject = app.i18n.T("portal.registerEmailSubject")
+	}
+	if err := sendPortalEmail(app, notifier.Message{
+		RecipientEmails: []string{link.Email},
+		Subject:         subject,
+		Content:         content,
+		Provider:        notifier.ProviderEmail,
+	}); err != nil {
+		app.lo.Error("error sending portal magic link email", "error", err)
+		return envelope.NewError(envelope.GeneralError, app.i18n.T("portal.errorSendingMagicLink"), nil)
+	}
+	return nil
+}
+
+// allowPortalAuthAttempt rate limits the portal sign in emails sent to an address and the requests of an IP.
+func allowPortalAuthAttempt(app *App, r *fastglue.Request, action, email string) error {
+	limits := []struct {
+		key   string
+		limit int
+	}{
+		{"portal_auth:" + action + ":" + realip.FromRequest(r.RequestCtx), portalAuthIPAttempts},
+		{"portal_auth:email:" + email, portalAuthEmailAttempts},
+	}
+	for _, l := range limits {
+		ok, err := app.auth.AllowAttempt(l.key, l.limit, portalAuthRateWindow)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return envelope.NewErrorWithCode(envelope.InputError, fasthttp.StatusTooManyRequests, app.i18n.T("portal.tooManyAttempts"), nil)
+		}
+	}
+	return nil
+}
+
+// portalRegistrationAllowed returns true if contacts can sign up with an email, its domain must be one of the allowed
+// domains or, if enabled, the email domain of an organization.
+func portalRegistrationAllowed(app *App, settings smodels.General, email string) (bool, error) {
+	domain := stringutil.EmailDomain(email)
+	if domain == "" {
+		return false, nil
+	}
+	if slices.Contains(settings.PortalRegistrationDomains, domain) {
+		return true, nil
+	}
+	if !settings.PortalRegistrationOrganizations {
+		return false, nil
+	}
+	orgIDs, err := app.organization.OrganizationIDsByEmailDomain(domain)
+	if err != nil {
+		return false, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.organization}"), nil)
+	}
+	return len(orgIDs) > 0, nil
+}
+
+// portalSettings returns the general settings the portal sign in flows depend on.
+func portalSettings(app *App) (smodels.General, error) {
+	var settings smodels.General
+	settingsJSON, err := app.setting.GetByPrefix("app")
+	if err != nil {
+		return settings, err
+	}
+	if err := json.Unmarshal(settingsJSON, &settings); err != nil {
+		app.lo.Error("error unmarshalling app settings", "error", err)
+		return settings, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil)
+	}
+	return settings, nil
+}
+
+// isNotFoundError returns true if err is a not found envelope error.
+func isNotFoundError(err error) bool {
+	var envErr envelope.Error
+	return errors.As(err, &envErr) && envErr.ErrorType == envelope.NotFoundError
+}
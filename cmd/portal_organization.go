@@ -0,0 +1,196 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/organization"
+	omodels "github.com/ghotso/libredesk/internal/organization/models"
+	"github.com/ghotso/libredesk/internal/stringutil"
+	"github.com/ghotso/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+// handlePortalGetOrganizationMembers returns the members of the organization of an organization admin (portalAuth).
+func handlePortalGetOrganizationMembers(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	contact, _ := r.RequestCtx.UserValue("contact").(models.User)
+
+	mem, err := portalOrganizationAdmin(app, contact)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	members, err := app.organization.GetMembers(mem.OrganizationID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(members)
+}
+
+// handlePortalAddOrganizationMember adds a colleague to the organization of an organization admin (portalAuth).
+// The email must be of one of the organization domains, contacts that don't exist yet are created. Existing contacts
+// that already belong to another organization can't be pulled over, only agents can move them.
+func handlePortalAddOrganizationMember(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	contact, _ := r.RequestCtx.UserValue("contact").(models.User)
+
+	mem, err := portalOrganizationAdmin(app, contact)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	var req struct {
+		Email     string `json:"email"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	link := amodels.PortalMagicLink{
+		Email:     strings.TrimSpace(strings.ToLower(req.Email)),
+		FirstName: strings.TrimSpace(req.FirstName),
+		LastName:  strings.TrimSpace(req.LastName),
+	}
+	if !stringutil.ValidEmail(link.Email) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`email`"), nil, envelope.InputError)
+	}
+	if len([]rune(link.FirstName)) > maxPortalNameLength || len([]rune(link.LastName)) > maxPortalNameLength {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.tooLong", "name", "{globals.terms.name}", "max", strconv.Itoa(maxPortalNameLength)), nil, envelope.InputError)
+	}
+
+	// Admins can only add the people of their own email domains, so that they can't read the conversations of
+	// contacts from outside the organization.
+	domains, err := app.organization.GetDomains(mem.OrganizationID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	emailDomain := stringutil.EmailDomain(link.Email)
+	if !slices.ContainsFunc(domains, func(d omodels.OrganizationDomain) bool { return d.Domain == emailDomain }) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("portal.memberEmailDomainNotAllowed"), nil, envelope.InputError)
+	}
+
+	colleague, err := app.user.Get(0, link.Email, models.UserTypeContact)
+	if err != nil {
+		if !isNotFoundError(err) {
+			return sendErrorEnvelope(r, err)
+		}
+		if link.FirstName == "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`first_name`"), nil, envelope.InputError)
+		}
+		if colleague, err = registerPortalContact(app, link); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
+	memberships, err := app.organization.GetMembershipsForContact(int64(colleague.ID))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if slices.ContainsFunc(memberships, func(m omodels.ContactOrganizationMembership) bool { return m.OrganizationID != mem.OrganizationID }) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("portal.memberInOtherOrganization"), nil, envelope.InputError)
+	}
+
+	isMember, err := portalContactInOrganization(app, mem.OrganizationID, colleague.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !isMember {
+		if _, err := app.organization.AddMember(mem.OrganizationID, int64(colleague.ID), false); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+	members, err := app.organization.GetMembers(mem.OrganizationID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(members)
+}
+
+// handlePortalUpdateOrganizationMember changes the role of a member of the organization of an organization admin (portalAuth).
+func handlePortalUpdateOrganizationMember(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	contact, _ := r.RequestCtx.UserValue("contact").(models.User)
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if !organization.ValidMemberRole(req.Role) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`role`"), nil, envelope.InputError)
+	}
+
+	mem, colleague, err := portalOrganizationMemberFromRequest(app, r, contact)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	member, err := app.organization.UpdateMember(mem.OrganizationID, int64(colleague.ID), null.Bool{}, null.StringFrom(req.Role))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(member)
+}
+
+// handlePortalRemoveOrganizationMember removes a member from the organization of an organization admin (portalAuth).
+func handlePortalRemoveOrganizationMember(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	contact, _ := r.RequestCtx.UserValue("contact").(models.User)
+
+	mem, colleague, err := portalOrganizationMemberFromRequest(app, r, contact)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.organization.RemoveMember(mem.OrganizationID, int64(colleague.ID)); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// portalOrganizationMemberFromRequest returns the membership of an organization admin and the colleague of the
+// contact_id in the request path. Admins can't change their own membership, so an organization is never left without one.
+func portalOrganizationMemberFromRequest(app *App, r *fastglue.Request, contact models.User) (omodels.Membership, models.User, error) {
+	contactID, _ := strconv.Atoi(r.RequestCtx.UserValue("contact_id").(string))
+	if contactID <= 0 {
+		return omodels.Membership{}, models.User{}, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`contact_id`"), nil)
+	}
+	if contactID == contact.ID {
+		return omodels.Membership{}, models.User{}, envelope.NewError(envelope.InputError, app.i18n.T("portal.cannotChangeOwnMembership"), nil)
+	}
+	return portalOrganizationColleague(app, contact, contactID)
+}
+
+// portalOrganizationColleague returns the membership of an organization admin and a member of its organization.
+func portalOrganizationColleague(app *App, contact models.User, contactID int) (omodels.Membership, models.User, error) {
+	mem, err := portalOrganizationAdmin(app, contact)
+	if err != nil {
+		return mem, models.User{}, err
+	}
+	isMember, err := portalContactInOrganization(app, mem.OrganizationID, contactID)
+	if err != nil {
+		return mem, models.User{}, err
+	}
+	if !isMember {
+		return mem, models.User{}, envelope.NewError(envelope.NotFoundError, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.contact}"), nil)
+	}
+	colleague, err := app.user.Get(contactID, "", models.UserTypeContact)
+	return mem, colleague, err
+}
+
+// portalOrganizationAdmin returns the organization membership of a contact that is an organization admin.
+func portalOrganizationAdmin(app *App, contact models.User) (omodels.Membership, error) {
+	mem, ok, err := app.organization.GetMembershipForContact(int64(contact.ID))
+	if err != nil {
+		return mem, err
+	}
+	if !ok || !mem.IsAdmin() {
+		return mem, envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil)
+	}
+	return mem, nil
+}
@@ -1,8 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
 	"strconv"
+	"time"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/report"
+	rmodels "github.com/ghotso/libredesk/internal/report/models"
+	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
 
@@ -15,7 +25,7 @@ func handleOverviewCounts(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	return r.SendEnvelope(counts)
+	return sendReport(r, report.ReportOverviewCounts, counts)
 }
 
 // handleOverviewCharts retrieves general dashboard chart data.
@@ -28,7 +38,7 @@ func handleOverviewCharts(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	return r.SendEnvelope(charts)
+	return sendReport(r, report.ReportOverviewCharts, charts)
 }
 
 // handleOverviewSLA retrieves SLA data for the dashboard.
@@ -41,7 +51,7 @@ func handleOverviewSLA(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	return r.SendEnvelope(sla)
+	return sendReport(r, report.ReportOverviewSLA, sla)
 }
 
 // handleOverviewCSAT retrieves CSAT metrics for the dashboard.
@@ -54,7 +64,7 @@ func handleOverviewCSAT(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	return r.SendEnvelope(csat)
+	return sendReport(r, report.ReportOverviewCSAT, csat)
 }
 
 // handleOverviewMessageVolume retrieves message volume metrics for the dashboard.
@@ -67,7 +77,7 @@ func handleOverviewMessageVolume(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	return r.SendEnvelope(volume)
+	return sendReport(r, report.ReportOverviewMessages, volume)
 }
 
 // handleOverviewTagDistribution retrieves tag distribution metrics for the dashboard.
@@ -80,5 +90,271 @@ func handleOverviewTagDistribution(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	return r.SendEnvelope(tags)
+	return sendReport(r, report.ReportOverviewTags, tags)
+}
+
+// handleAgentPerformance retrieves per-agent performance metrics.
+func handleAgentPerformance(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		days, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("days")))
+	)
+	rows, err := app.report.GetAgentPerformance(days)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return sendReport(r, report.ReportAgents, rows)
+}
+
+// handleTeamPerformance retrieves per-team performance metrics.
+func handleTeamPerformance(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		days, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("days")))
+	)
+	rows, err := app.report.GetTeamPerformance(days)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return sendReport(r, report.ReportTeams, rows)
+}
+
+// handleTimeTrackingReport retrieves the time tracked on conversations grouped by agent, conversation, contact or organization.
+func handleTimeTrackingReport(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		days, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("days")))
+		groupBy = string(r.RequestCtx.QueryArgs().Peek("group_by"))
+	)
+	if groupBy == "" {
+		groupBy = "agent"
+	}
+	rows, err := app.report.GetTimeTracking(groupBy, days)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return sendReport(r, "time-tracking-"+groupBy, rows)
+}
+
+// handleAgentPerformanceConversations retrieves the conversations behind an agent's performance row.
+func handleAgentPerformanceConversations(r *fastglue.Request) error {
+	return handlePerformanceConversations(r, report.PerformanceAgent)
+}
+
+// handleTeamPerformanceConversations retrieves the conversations behind a team's performance row.
+func handleTeamPerformanceConversations(r *fastglue.Request) error {
+	return handlePerformanceConversations(r, report.PerformanceTeam)
+}
+
+// handlePerformanceConversations retrieves the paginated conversations behind an agent or team performance row.
+// Exports stream all the conversations instead of a page.
+func handlePerformanceConversations(r *fastglue.Request, entityType string) error {
+	var (
+		app     = r.Context.(*App)
+		id, _   = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		days, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("days")))
+		total   = 0
+	)
+	if id < 1 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if isReportExport(r) {
+		return streamPerformanceConversations(r, entityType, id, days)
+	}
+
+	page, pageSize := getPagination(r)
+	conversations, err := app.report.GetPerformanceConversations(entityType, id, days, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(conversations) > 0 {
+		total = conversations[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    conversations,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+		Page:       page,
+	})
+}
+
+// streamPerformanceConversations streams the CSV or XLSX export of all the conversations behind a performance row.
+// Errors once the download has started can only be logged.
+func streamPerformanceConversations(r *fastglue.Request, entityType string, id, days int) error {
+	var (
+		app    = r.Context.(*App)
+		format = string(r.RequestCtx.QueryArgs().Peek("format"))
+		name   = entityType + "-" + strconv.Itoa(id) + "-conversations"
+	)
+	if !report.IsValidFormat(format) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`format`"), nil, envelope.InputError)
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), format)
+	r.RequestCtx.Response.Header.Set("Content-Type", report.ContentType(format))
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	r.RequestCtx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := app.report.ExportPerformanceConversations(w, format, entityType, id, days); err != nil {
+			app.lo.Error("error exporting performance conversations", "type", entityType, "id", id, "format", format, "error", err)
+		}
+	})
+	return nil
+}
+
+// handleGetReportSchedules returns all report schedules.
+func handleGetReportSchedules(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	schedules, err := app.report.GetSchedules()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(schedules)
+}
+
+// handleGetReportSchedule returns a report schedule by ID.
+func handleGetReportSchedule(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	schedule, err := app.report.GetSchedule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(schedule)
+}
+
+// handleCreateReportSchedule creates a new report schedule.
+func handleCreateReportSchedule(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		schedule = rmodels.Schedule{}
+	)
+	if err := r.Decode(&schedule, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
+	}
+	if err := validateReportSchedule(app, schedule); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	schedule, err := app.report.CreateSchedule(schedule)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityCreated(r, almodels.ModelReportSchedule, schedule.ID, schedule.Name, schedule)
+	return r.SendEnvelope(schedule)
+}
+
+// handleUpdateReportSchedule updates a report schedule by ID.
+func handleUpdateReportSchedule(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		schedule = rmodels.Schedule{}
+		id, _    = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&schedule, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
+	}
+	if err := validateReportSchedule(app, schedule); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	oldSchedule, err := app.report.GetSchedule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	schedule, err = app.report.UpdateSchedule(id, schedule)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityUpdated(r, almodels.ModelReportSchedule, id, schedule.Name, oldSchedule, schedule)
+	return r.SendEnvelope(schedule)
+}
+
+// handleDeleteReportSchedule deletes a report schedule by ID.
+func handleDeleteReportSchedule(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	oldSchedule, err := app.report.GetSchedule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.report.DeleteSchedule(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityDeleted(r, almodels.ModelReportSchedule, id, oldSchedule.Name, oldSchedule)
+	return r.SendEnvelope(true)
+}
+
+// validateReportSchedule validates a report schedule.
+func validateReportSchedule(app *App, schedule rmodels.Schedule) error {
+	if schedule.Name == "" {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil)
+	}
+	if !report.IsValidFrequency(schedule.Frequency) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`frequency`"), nil)
+	}
+	if !report.IsValidFormat(schedule.Format) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`format`"), nil)
+	}
+	if len(schedule.Reports) == 0 {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`reports`"), nil)
+	}
+	if slices.ContainsFunc(schedule.Reports, func(key string) bool { return !report.IsValidReport(key) }) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`reports`"), nil)
+	}
+	if len(schedule.RecipientIDs) == 0 {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`recipient_ids`"), nil)
+	}
+	return nil
+}
+
+// isReportExport returns true if the request asks for a file export instead of JSON.
+func isReportExport(r *fastglue.Request) bool {
+	format := string(r.RequestCtx.QueryArgs().Peek("format"))
+	return format != "" && format != "json"
+}
+
+// sendReport sends report data as JSON, or as a CSV or XLSX download when
+// the `format` query param is set.
+func sendReport(r *fastglue.Request, name string, data any) error {
+	var (
+		app    = r.Context.(*App)
+		format = string(r.RequestCtx.QueryArgs().Peek("format"))
+	)
+	if !isReportExport(r) {
+		return r.SendEnvelope(data)
+	}
+	if !report.IsValidFormat(format) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`format`"), nil, envelope.InputError)
+	}
+
+	table, err := report.NewTable(name, data)
+	if err != nil {
+		app.lo.Error("error building report export", "report", name, "error", err)
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.somethingWentWrong"), nil))
+	}
+	var buf bytes.Buffer
+	if err := report.Export(&buf, format, table); err != nil {
+		app.lo.Error("error exporting report", "report", name, "format", format, "error", err)
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.somethingWentWrong"), nil))
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), format)
+	r.RequestCtx.Response.Header.Set("Content-Type", report.ContentType(format))
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	r.RequestCtx.SetBody(buf.Bytes())
+	return nil
 }
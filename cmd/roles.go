@@ -3,6 +3,7 @@ package main
 import (
 	"strconv"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	amodels "github.com/ghotso/libredesk/internal/auth/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/ghotso/libredesk/internal/role"
@@ -43,9 +44,14 @@ func handleDeleteRole(r *fastglue.Request) error {
 		app   = r.Context.(*App)
 		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
 	)
+	oldRole, err := app.role.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err := app.role.Delete(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityDeleted(r, almodels.ModelRole, id, oldRole.Name, oldRole)
 
 	// Invalidate all caches when role is deleted.
 	app.user.InvalidateAllAgentCache()
@@ -67,6 +73,7 @@ func handleCreateRole(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityCreated(r, almodels.ModelRole, createdRole.ID, createdRole.Name, createdRole)
 	return r.SendEnvelope(createdRole)
 }
 
@@ -93,6 +100,7 @@ func handleUpdateRole(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelRole, id, updatedRole.Name, oldRole, updatedRole)
 
 	// Log permission changes and invalidate caches.
 	added, removed := role.ComparePermissions(oldRole.Permissions, updatedRole.Permissions)
@@ -3,8 +3,10 @@ package main
 import (
 	"encoding/json"
 	"net/mail"
+	"slices"
 	"strings"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/ghotso/libredesk/internal/setting/models"
 	"github.com/ghotso/libredesk/internal/stringutil"
@@ -60,6 +62,22 @@ func handleUpdateGeneralSettings(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("admin.general.portalDefaultInboxRequired"), nil, envelope.InputError)
 	}
 
+	// Self-registration is only open to allowed email domains or to the domains of organizations.
+	req.PortalRegistrationDomains = normalizeRegistrationDomains(req.PortalRegistrationDomains)
+	if req.PortalSelfRegistrationEnabled && len(req.PortalRegistrationDomains) == 0 && !req.PortalRegistrationOrganizations {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("admin.general.portalRegistrationRestrictionRequired"), nil, envelope.InputError)
+	}
+
+	// Get current settings before update for the audit log.
+	var cur models.General
+	out, err := app.setting.GetByPrefix("app")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := json.Unmarshal(out, &cur); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorUpdating", "name", app.i18n.T("globals.terms.setting")), nil))
+	}
+
 	// Get current language before update.
 	app.Lock()
 	oldLang := ko.String("app.lang")
@@ -68,6 +86,7 @@ func handleUpdateGeneralSettings(r *fastglue.Request) error {
 	if err := app.setting.Update(req); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelSetting, 0, "general", cur, req)
 	// Reload the settings and templates.
 	if err := reloadSettings(app); err != nil {
 		return envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.couldNotReload", "name", app.i18n.T("globals.terms.setting")), nil)
@@ -89,6 +108,60 @@ func handleUpdateGeneralSettings(r *fastglue.Request) error {
 	return r.SendEnvelope(true)
 }
 
+// handleUpdatePortalSettings updates the portal settings, leaving the rest of the general settings untouched.
+func handleUpdatePortalSettings(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = models.Portal{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.badRequest"), nil, envelope.InputError)
+	}
+
+	// When portal is enabled, default inbox is required (used for portal tickets and sending portal emails).
+	if req.PortalEnabled && req.PortalDefaultInboxID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("admin.general.portalDefaultInboxRequired"), nil, envelope.InputError)
+	}
+
+	// Self-registration is only open to allowed email domains or to the domains of organizations.
+	req.PortalRegistrationDomains = normalizeRegistrationDomains(req.PortalRegistrationDomains)
+	if req.PortalSelfRegistrationEnabled && len(req.PortalRegistrationDomains) == 0 && !req.PortalRegistrationOrganizations {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("admin.general.portalRegistrationRestrictionRequired"), nil, envelope.InputError)
+	}
+
+	// Get current settings before update for the audit log.
+	var cur models.Portal
+	out, err := app.setting.GetByPrefix("app")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := json.Unmarshal(out, &cur); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorUpdating", "name", app.i18n.T("globals.terms.setting")), nil))
+	}
+
+	if err := app.setting.Update(req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityUpdated(r, almodels.ModelSetting, 0, "portal", cur, req)
+	if err := reloadSettings(app); err != nil {
+		return envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.couldNotReload", "name", app.i18n.T("globals.terms.setting")), nil)
+	}
+	return r.SendEnvelope(true)
+}
+
+// normalizeRegistrationDomains lowercases the portal self-registration email domains, strips a leading `@`
+// and drops empty and duplicate domains.
+func normalizeRegistrationDomains(domains []string) []string {
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "@")
+		if d != "" && !slices.Contains(out, d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
 // handleGetEmailNotificationSettings fetches email notification settings.
 func handleGetEmailNotificationSettings(r *fastglue.Request) error {
 	var (
@@ -153,6 +226,7 @@ func handleUpdateEmailNotificationSettings(r *fastglue.Request) error {
 	if err := app.setting.Update(req); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelSetting, 0, "email notification", cur, req)
 
 	// Email notification settings require app restart to take effect.
 	app.Lock()
@@ -0,0 +1,135 @@
+package main
+
+import (
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	cmodels "github.com/ghotso/libredesk/internal/conversation/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+type sideConversationReq struct {
+	Subject string   `json:"subject"`
+	To      []string `json:"to"`
+	CC      []string `json:"cc"`
+	Message string   `json:"message"`
+}
+
+type sideConversationResp struct {
+	cmodels.SideConversation
+	Messages []cmodels.SideConversationMessage `json:"messages"`
+}
+
+// handleGetSideConversations returns the side conversations of a conversation.
+func handleGetSideConversations(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	sides, err := app.conversation.GetSideConversations(cuuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(sides)
+}
+
+// handleGetSideConversation returns a side conversation with its messages.
+func handleGetSideConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	side, err := app.conversation.GetSideConversation(cuuid, uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	messages, err := app.conversation.GetSideConversationMessages(side.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(sideConversationResp{SideConversation: side, Messages: messages})
+}
+
+// handleCreateSideConversation starts a side conversation from a conversation and sends its first message.
+func handleCreateSideConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		req   = sideConversationReq{}
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := r.Decode(&req, "json"); err != nil {
+		app.lo.Error("error unmarshalling side conversation request", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	side, message, err := app.conversation.CreateSideConversation(cuuid, user.ID, req.Subject, req.To, req.CC, req.Message)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(sideConversationResp{SideConversation: side, Messages: []cmodels.SideConversationMessage{message}})
+}
+
+// handleSendSideConversationMessage sends a message to the recipients of a side conversation.
+func handleSendSideConversationMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		req   = sideConversationReq{}
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := r.Decode(&req, "json"); err != nil {
+		app.lo.Error("error unmarshalling side conversation message request", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	side, err := app.conversation.GetSideConversation(cuuid, uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	message, err := app.conversation.SendSideConversationMessage(side, user.ID, req.Message)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(message)
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/sla"
 	smodels "github.com/ghotso/libredesk/internal/sla/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -58,6 +61,7 @@ func handleCreateSLA(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityCreated(r, almodels.ModelSLAPolicy, createdSLA.ID, createdSLA.Name, createdSLA)
 
 	return r.SendEnvelope(createdSLA)
 }
@@ -82,10 +86,16 @@ func handleUpdateSLA(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
+	oldSLA, err := app.sla.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	updatedSLA, err := app.sla.Update(id, sla.Name, sla.Description, sla.FirstResponseTime, sla.ResolutionTime, sla.NextResponseTime, sla.Notifications)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelSLAPolicy, id, updatedSLA.Name, oldSLA, updatedSLA)
 
 	return r.SendEnvelope(updatedSLA)
 }
@@ -100,9 +110,15 @@ func handleDeleteSLA(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
 
+	oldSLA, err := app.sla.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	if err = app.sla.Delete(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityDeleted(r, almodels.ModelSLAPolicy, id, oldSLA.Name, oldSLA)
 
 	return r.SendEnvelope(true)
 }
@@ -143,6 +159,9 @@ func validateSLA(app *App, sla *smodels.SLAPolicy) error {
 		if len(n.Recipients) == 0 {
 			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`recipients`"), nil)
 		}
+		if err := validateSLATeamRecipients(app, n.Recipients); err != nil {
+			return err
+		}
 	}
 
 	// Validate first response time duration string if not empty.
@@ -187,3 +206,21 @@ func validateSLA(app *App, sla *smodels.SLAPolicy) error {
 
 	return nil
 }
+
+// validateSLATeamRecipients validates that the `team:<id>` notification recipients point to existing teams.
+func validateSLATeamRecipients(app *App, recipients []string) error {
+	for _, recipient := range recipients {
+		idS, ok := strings.CutPrefix(recipient, sla.RecipientTeamPrefix)
+		if !ok {
+			continue
+		}
+		teamID, err := strconv.Atoi(idS)
+		if err != nil || teamID <= 0 {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`recipients`"), nil)
+		}
+		if _, err := app.team.Get(teamID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
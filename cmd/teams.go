@@ -3,9 +3,11 @@ package main
 import (
 	"strconv"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/ghotso/libredesk/internal/team/models"
 	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/fastglue"
 )
 
@@ -60,10 +62,15 @@ func handleCreateTeam(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
 	}
 
-	createdTeam, err := app.team.Create(req.Name, req.Timezone, req.ConversationAssignmentType, req.BusinessHoursID, req.SLAPolicyID, req.Emoji.String, req.MaxAutoAssignedConversations)
+	if err := validateTeamChatChannel(app, &req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	createdTeam, err := app.team.Create(req.Name, req.Timezone, req.ConversationAssignmentType, req.BusinessHoursID, req.SLAPolicyID, req.Emoji.String, req.MaxAutoAssignedConversations, req.ChatProvider, req.ChatWebhookURL)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityCreated(r, almodels.ModelTeam, createdTeam.ID, createdTeam.Name, createdTeam)
 	return r.SendEnvelope(createdTeam)
 }
 
@@ -83,10 +90,20 @@ func handleUpdateTeam(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
 	}
 
-	updatedTeam, err := app.team.Update(id, req.Name, req.Timezone, req.ConversationAssignmentType, req.BusinessHoursID, req.SLAPolicyID, req.Emoji.String, req.MaxAutoAssignedConversations)
+	if err := validateTeamChatChannel(app, &req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	oldTeam, err := app.team.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	updatedTeam, err := app.team.Update(id, req.Name, req.Timezone, req.ConversationAssignmentType, req.BusinessHoursID, req.SLAPolicyID, req.Emoji.String, req.MaxAutoAssignedConversations, req.ChatProvider, req.ChatWebhookURL)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelTeam, id, updatedTeam.Name, oldTeam, updatedTeam)
 	return r.SendEnvelope(updatedTeam)
 }
 
@@ -99,9 +116,25 @@ func handleDeleteTeam(r *fastglue.Request) error {
 	if err != nil || id == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
+	oldTeam, err := app.team.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	err = app.team.Delete(id)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityDeleted(r, almodels.ModelTeam, id, oldTeam.Name, oldTeam)
 	return r.SendEnvelope(true)
 }
+
+// validateTeamChatChannel validates the chat channel SLA alerts of the team are routed to.
+// An empty provider clears the channel.
+func validateTeamChatChannel(app *App, req *models.Team) error {
+	if req.ChatProvider.String == "" {
+		req.ChatProvider = null.String{}
+		req.ChatWebhookURL = null.String{}
+		return nil
+	}
+	return validateChatChannel(app, req.ChatProvider.String, req.ChatWebhookURL.String)
+}
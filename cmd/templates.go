@@ -3,6 +3,7 @@ package main
 import (
 	"strconv"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/ghotso/libredesk/internal/template/models"
 	"github.com/valyala/fasthttp"
@@ -57,6 +58,7 @@ func handleCreateTemplate(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityCreated(r, almodels.ModelTemplate, template.ID, template.Name, template)
 	return r.SendEnvelope(template)
 }
 
@@ -77,10 +79,15 @@ func handleUpdateTemplate(r *fastglue.Request) error {
 	if req.Name == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil, envelope.InputError)
 	}
+	oldTemplate, err := app.tmpl.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	updatedTemplate, err := app.tmpl.Update(id, req)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelTemplate, id, updatedTemplate.Name, oldTemplate, updatedTemplate)
 	return r.SendEnvelope(updatedTemplate)
 }
 
@@ -98,8 +105,13 @@ func handleDeleteTemplate(r *fastglue.Request) error {
 	if err := r.Decode(&req, "json"); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
 	}
+	oldTemplate, err := app.tmpl.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err = app.tmpl.Delete(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityDeleted(r, almodels.ModelTemplate, id, oldTemplate.Name, oldTemplate)
 	return r.SendEnvelope(true)
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strconv"
+
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/ticket_form/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// handleGetTicketForms returns all ticket forms.
+func handleGetTicketForms(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	forms, err := app.ticketForm.GetAll(false)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(forms)
+}
+
+// handleGetTicketForm returns a ticket form by ID.
+func handleGetTicketForm(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	form, err := app.ticketForm.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(form)
+}
+
+// handleCreateTicketForm creates a new ticket form.
+func handleCreateTicketForm(r *fastglue.Request) error {
+	var (
+		app  = r.Context.(*App)
+		form = models.TicketForm{}
+	)
+	if err := r.Decode(&form, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if err := validateTicketFormInbox(app, form); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	created, err := app.ticketForm.Create(form)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityCreated(r, almodels.ModelTicketForm, created.ID, created.Name, created)
+	return r.SendEnvelope(created)
+}
+
+// handleUpdateTicketForm updates a ticket form.
+func handleUpdateTicketForm(r *fastglue.Request) error {
+	var (
+		app  = r.Context.(*App)
+		form = models.TicketForm{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&form, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if err := validateTicketFormInbox(app, form); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	oldForm, err := app.ticketForm.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	updated, err := app.ticketForm.Update(id, form)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityUpdated(r, almodels.ModelTicketForm, id, updated.Name, oldForm, updated)
+	return r.SendEnvelope(updated)
+}
+
+// handleDeleteTicketForm deletes a ticket form.
+func handleDeleteTicketForm(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	oldForm, err := app.ticketForm.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.ticketForm.Delete(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	logEntityDeleted(r, almodels.ModelTicketForm, id, oldForm.Name, oldForm)
+	return r.SendEnvelope(true)
+}
+
+// validateTicketFormInbox validates that the inbox of a ticket form exists.
+func validateTicketFormInbox(app *App, form models.TicketForm) error {
+	if form.InboxID <= 0 {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`inbox_id`"), nil)
+	}
+	if _, err := app.inbox.GetDBRecord(form.InboxID); err != nil {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`inbox_id`"), nil)
+	}
+	return nil
+}
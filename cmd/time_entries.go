@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+type timeEntryReq struct {
+	DurationSeconds int    `json:"duration_seconds"`
+	Note            string `json:"note"`
+	Billable        bool   `json:"billable"`
+	// StartedAt is when the work started, defaults to now.
+	StartedAt time.Time `json:"started_at"`
+}
+
+// handleGetTimeEntries returns the time entries of a conversation with their totals.
+func handleGetTimeEntries(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	conversationTime, err := app.timeTracking.GetConversationTime(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(conversationTime)
+}
+
+// handleCreateTimeEntry adds a time entry of the agent to a conversation.
+func handleCreateTimeEntry(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = timeEntryReq{}
+	)
+
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	entry, err := app.timeTracking.CreateEntry(uuid, user.ID, req.DurationSeconds, req.Note, req.Billable, req.StartedAt)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(entry)
+}
+
+// handleUpdateTimeEntry updates a time entry of the agent.
+func handleUpdateTimeEntry(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = timeEntryReq{}
+	)
+
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	if err := enforceTimeEntryOwner(app, uuid, id, auser.ID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	entry, err := app.timeTracking.UpdateEntry(uuid, id, req.DurationSeconds, req.Note, req.Billable)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(entry)
+}
+
+// handleDeleteTimeEntry deletes a time entry of the agent.
+func handleDeleteTimeEntry(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	if err := enforceTimeEntryOwner(app, uuid, id, auser.ID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.timeTracking.DeleteEntry(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// enforceTimeEntryOwner returns an error unless the agent has access to the conversation and the time entry is theirs,
+// agents only change their own time.
+func enforceTimeEntryOwner(app *App, conversationUUID string, id, userID int) error {
+	user, err := app.user.GetAgent(userID, "")
+	if err != nil {
+		return err
+	}
+	if _, err := enforceConversationAccess(app, conversationUUID, user); err != nil {
+		return err
+	}
+	entry, err := app.timeTracking.GetEntry(conversationUUID, id)
+	if err != nil {
+		return err
+	}
+	if entry.UserID != user.ID {
+		return envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil)
+	}
+	return nil
+}
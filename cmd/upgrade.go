@@ -43,6 +43,7 @@ var migList = []migFunc{
 	{"v1.1.0", migrations.V1_1_0},
 	{"v1.2.0", migrations.V1_2_0},
 	{"v1.3.0", migrations.V1_3_0},
+	{"v1.4.0", migrations.V1_4_0},
 }
 
 // upgrade upgrades the database to the current version by running SQL migration files
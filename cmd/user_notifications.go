@@ -1,10 +1,16 @@
 package main
 
 import (
+	"fmt"
+	"log"
+	"net/url"
 	"strconv"
 
 	amodels "github.com/ghotso/libredesk/internal/auth/models"
 	"github.com/ghotso/libredesk/internal/envelope"
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	nmodels "github.com/ghotso/libredesk/internal/notification/models"
+	webpushnotifier "github.com/ghotso/libredesk/internal/notification/providers/webpush"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
@@ -97,3 +103,221 @@ func handleDeleteAllNotifications(r *fastglue.Request) error {
 	}
 	return r.SendEnvelope(true)
 }
+
+type chatIdentityReq struct {
+	WebhookURL string `json:"webhook_url"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// handleGetChatIdentities returns the chat channels linked by the current agent.
+func handleGetChatIdentities(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	identities, err := app.userNotification.GetChatIdentities(auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(identities)
+}
+
+// handleUpsertChatIdentity links a chat channel of the given provider for the current agent.
+func handleUpsertChatIdentity(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		auser    = r.RequestCtx.UserValue("user").(amodels.User)
+		provider = r.RequestCtx.UserValue("provider").(string)
+		req      = chatIdentityReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if err := validateChatChannel(app, provider, req.WebhookURL); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	identity, err := app.userNotification.UpsertChatIdentity(auser.ID, provider, req.WebhookURL, req.Enabled)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(identity)
+}
+
+// handleDeleteChatIdentity unlinks the chat channel of the given provider for the current agent.
+func handleDeleteChatIdentity(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		auser    = r.RequestCtx.UserValue("user").(amodels.User)
+		provider = r.RequestCtx.UserValue("provider").(string)
+	)
+	if err := app.userNotification.DeleteChatIdentity(auser.ID, provider); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleTestChatIdentity posts a test message to the current agent's chat channel of the given provider.
+// Webhooks to private addresses are refused when dialing and only the response status is reported back, so that
+// the endpoint can't be used to probe other hosts.
+func handleTestChatIdentity(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		auser    = r.RequestCtx.UserValue("user").(amodels.User)
+		provider = r.RequestCtx.UserValue("provider").(string)
+	)
+	identities, err := app.userNotification.GetChatIdentities(auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	for _, identity := range identities {
+		if identity.Provider != provider {
+			continue
+		}
+		if err := app.notifier.SendNow(notifier.Message{
+			Subject:     app.i18n.T("user.chatTestTitle"),
+			Content:     app.i18n.T("user.chatTestBody"),
+			Provider:    provider,
+			WebhookURLs: []string{identity.WebhookURL},
+			Type:        "test",
+		}); err != nil {
+			app.lo.Error("error sending test chat notification", "provider", provider, "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusBadGateway, app.i18n.Ts("globals.messages.errorSending", "name", "{globals.terms.notification}"), nil, envelope.GeneralError)
+		}
+		return r.SendEnvelope(true)
+	}
+	return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.chatIdentity}"), nil, envelope.NotFoundError)
+}
+
+// validateChatChannel validates the provider and incoming webhook URL of a chat channel.
+func validateChatChannel(app *App, provider, webhookURL string) error {
+	if !notifier.IsChatProvider(provider) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`provider`"), nil)
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`webhook_url`"), nil)
+	}
+	return nil
+}
+
+type pushSubscriptionReq struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// handleGetPushConfig returns whether Web Push is enabled and the VAPID public key browsers subscribe with.
+func handleGetPushConfig(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		enabled = app.notifier.HasProvider(notifier.ProviderWebPush)
+	)
+	resp := map[string]any{
+		"enabled":          enabled,
+		"vapid_public_key": "",
+	}
+	if enabled {
+		resp["vapid_public_key"] = ko.String("notification.webpush.vapid_public_key")
+	}
+	return r.SendEnvelope(resp)
+}
+
+// handleGetPushSubscriptions returns the browsers the current agent subscribed to push notifications from.
+func handleGetPushSubscriptions(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	subscriptions, err := app.userNotification.GetPushSubscriptions(auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(subscriptions)
+}
+
+// handleCreatePushSubscription saves the push subscription of the current agent's browser.
+func handleCreatePushSubscription(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = pushSubscriptionReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if !webpushnotifier.IsPushServiceEndpoint(req.Endpoint) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`endpoint`"), nil, envelope.InputError)
+	}
+	if req.Keys.P256dh == "" || req.Keys.Auth == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`keys`"), nil, envelope.InputError)
+	}
+	userAgent := string(r.RequestCtx.UserAgent())
+	if len(userAgent) > 1000 {
+		userAgent = userAgent[:1000]
+	}
+	subscription, err := app.userNotification.UpsertPushSubscription(auser.ID, req.Endpoint, req.Keys.P256dh, req.Keys.Auth, userAgent)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(subscription)
+}
+
+// handleDeletePushSubscription deletes the push subscription of the current agent's browser.
+func handleDeletePushSubscription(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = pushSubscriptionReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if req.Endpoint == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`endpoint`"), nil, envelope.InputError)
+	}
+	if err := app.userNotification.DeletePushSubscription(auser.ID, req.Endpoint); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGetNotificationPreferences returns the notification preferences of the current agent.
+func handleGetNotificationPreferences(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	prefs, err := app.userNotification.GetPreferences(auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(prefs)
+}
+
+// handleUpdateNotificationPreferences updates the channels, quiet hours and digest settings of the current agent.
+func handleUpdateNotificationPreferences(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = nmodels.NotificationPreferences{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	prefs, err := app.userNotification.UpdatePreferences(auser.ID, req)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(prefs)
+}
+
+// generateVAPIDKeys prints a new VAPID key pair for the `notification.webpush` config.
+func generateVAPIDKeys() {
+	publicKey, privateKey, err := webpushnotifier.GenerateVAPIDKeys()
+	if err != nil {
+		log.Fatalf("error generating VAPID keys: %v", err)
+	}
+	fmt.Printf("vapid_public_key = %q\nvapid_private_key = %q\n", publicKey, privateKey)
+}
@@ -39,6 +39,10 @@ type availabilityRequest struct {
 	Status string `json:"status"`
 }
 
+type undoSendRequest struct {
+	Seconds int `json:"seconds"`
+}
+
 type agentReq struct {
 	FirstName          string   `json:"first_name"`
 	LastName           string   `json:"last_name"`
@@ -131,6 +135,26 @@ func handleUpdateAgentAvailability(r *fastglue.Request) error {
 	return r.SendEnvelope(agent)
 }
 
+// handleUpdateAgentUndoSend updates the undo send grace period of the current agent, 0 disables it.
+func handleUpdateAgentUndoSend(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   undoSendRequest
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if err := app.user.UpdateUndoSendSeconds(auser.ID, req.Seconds); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	agent, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(agent)
+}
+
 // handleGetCurrentAgentTeams returns the teams of current agent.
 func handleGetCurrentAgentTeams(r *fastglue.Request) error {
 	var (
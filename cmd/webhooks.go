@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"strings"
 
+	almodels "github.com/ghotso/libredesk/internal/activity_log/models"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/ghotso/libredesk/internal/stringutil"
 	"github.com/ghotso/libredesk/internal/webhook/models"
@@ -71,6 +72,7 @@ func handleCreateWebhook(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityCreated(r, almodels.ModelWebhook, webhook.ID, webhook.Name, webhook)
 
 	// Clear secret before returning
 	webhook.Secret = strings.Repeat(stringutil.PasswordDummy, 10)
@@ -99,10 +101,16 @@ func handleUpdateWebhook(r *fastglue.Request) error {
 		return r.SendEnvelope(err)
 	}
 
+	oldWebhook, err := app.webhook.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	updatedWebhook, err := app.webhook.Update(id, webhook)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelWebhook, id, updatedWebhook.Name, oldWebhook, updatedWebhook)
 
 	// Clear secret before returning
 	updatedWebhook.Secret = strings.Repeat(stringutil.PasswordDummy, 10)
@@ -120,9 +128,15 @@ func handleDeleteWebhook(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
 
+	oldWebhook, err := app.webhook.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	if err := app.webhook.Delete(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityDeleted(r, almodels.ModelWebhook, id, oldWebhook.Name, oldWebhook)
 
 	return r.SendEnvelope(true)
 }
@@ -138,10 +152,16 @@ func handleToggleWebhook(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
 
+	oldWebhook, err := app.webhook.Get(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	toggledWebhook, err := app.webhook.Toggle(id)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	logEntityUpdated(r, almodels.ModelWebhook, id, toggledWebhook.Name, oldWebhook, toggledWebhook)
 
 	// Clear secret before returning
 	toggledWebhook.Secret = strings.Repeat(stringutil.PasswordDummy, 10)
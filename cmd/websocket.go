@@ -48,3 +48,32 @@ func handleWS(r *fastglue.Request, hub *ws.Hub) error {
 	}
 	return nil
 }
+
+// presenceAccess applies the conversation access checks of the HTTP handlers to websocket presence updates.
+type presenceAccess struct {
+	app *App
+}
+
+// CanAccessConversation returns true if the agent can access the conversation.
+func (p presenceAccess) CanAccessConversation(userID int, conversationUUID string) bool {
+	user, err := p.app.user.GetAgent(userID, "")
+	if err != nil {
+		return false
+	}
+	_, err = enforceConversationAccess(p.app, conversationUUID, user)
+	return err == nil
+}
+
+// CanTrackTime returns true if the agent can track time on conversations.
+func (p presenceAccess) CanTrackTime(userID int) bool {
+	user, err := p.app.user.GetAgent(userID, "")
+	if err != nil {
+		return false
+	}
+	ok, err := p.app.authz.Enforce(user, "conversations", "track_time")
+	if err != nil {
+		p.app.lo.Error("error checking time tracking permission", "user_id", userID, "error", err)
+		return false
+	}
+	return ok
+}
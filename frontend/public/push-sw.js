@@ -0,0 +1,30 @@
+// Service worker showing the Web Push notifications sent to agents.
+self.addEventListener('push', (event) => {
+  if (!event.data) return
+  const data = event.data.json()
+  event.waitUntil(
+    self.registration.showNotification(data.title, {
+      body: data.body,
+      tag: data.conversation_uuid || data.type,
+      data
+    })
+  )
+})
+
+// Focus an open tab or open a new one with the conversation of the clicked notification.
+self.addEventListener('notificationclick', (event) => {
+  event.notification.close()
+  const { conversation_uuid: uuid } = event.notification.data || {}
+  const url = uuid ? `/inboxes/all/conversation/${uuid}` : '/inboxes/assigned'
+  event.waitUntil(
+    self.clients.matchAll({ type: 'window', includeUncontrolled: true }).then((clients) => {
+      for (const client of clients) {
+        if ('focus' in client) {
+          client.navigate(url)
+          return client.focus()
+        }
+      }
+      return self.clients.openWindow(url)
+    })
+  )
+})
@@ -207,6 +207,9 @@ const blockContact = (id, data) => http.put(`/api/v1/contacts/${id}/block`, data
     'Content-Type': 'application/json'
   }
 })
+const exportContactData = (id) =>
+  http.get(`/api/v1/contacts/${id}/export`, { responseType: 'blob' })
+const eraseContactData = (id) => http.post(`/api/v1/contacts/${id}/erase`)
 const getTeam = (id) => http.get(`/api/v1/teams/${id}`)
 const getTeams = () => http.get('/api/v1/teams')
 const updateTeam = (id, data) => http.put(`/api/v1/teams/${id}`, data, {
@@ -377,6 +380,30 @@ const getOverviewSLA = (params) => http.get('/api/v1/reports/overview/sla', { pa
 const getOverviewCSAT = (params) => http.get('/api/v1/reports/overview/csat', { params })
 const getOverviewMessageVolume = (params) => http.get('/api/v1/reports/overview/messages', { params })
 const getOverviewTagDistribution = (params) => http.get('/api/v1/reports/overview/tags', { params })
+const getAgentPerformance = (params) => http.get('/api/v1/reports/agents', { params })
+const getAgentPerformanceConversations = (id, params) =>
+  http.get(`/api/v1/reports/agents/${id}/conversations`, { params })
+const getTeamPerformance = (params) => http.get('/api/v1/reports/teams', { params })
+const getTeamPerformanceConversations = (id, params) =>
+  http.get(`/api/v1/reports/teams/${id}/conversations`, { params })
+const getTimeTrackingReport = (params) => http.get('/api/v1/reports/time-tracking', { params })
+const exportReport = (path, params) =>
+  http.get(`/api/v1/reports/${path}`, { params, responseType: 'blob' })
+const getReportSchedules = () => http.get('/api/v1/reports/schedules')
+const getReportSchedule = (id) => http.get(`/api/v1/reports/schedules/${id}`)
+const createReportSchedule = (data) =>
+  http.post('/api/v1/reports/schedules', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const updateReportSchedule = (id, data) =>
+  http.put(`/api/v1/reports/schedules/${id}`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const deleteReportSchedule = (id) => http.delete(`/api/v1/reports/schedules/${id}`)
 const getLanguage = (lang) => http.get(`/api/v1/lang/${lang}`)
 const createInbox = (data) =>
   http.post('/api/v1/inboxes', data, {
@@ -426,13 +453,25 @@ const deleteDraft = (uuid) => http.delete(`/api/v1/conversations/${uuid}/draft`)
 const portalLogin = (data) => http.post('/api/v1/portal/auth/login', data, { headers: { 'Content-Type': 'application/json' } })
 const portalForgotPassword = (data) => http.post('/api/v1/portal/auth/forgot-password', data, { headers: { 'Content-Type': 'application/json' } })
 const portalSetPassword = (data) => http.post('/api/v1/portal/auth/set-password', data, { headers: { 'Content-Type': 'application/json' } })
+const portalRequestMagicLink = (data) => http.post('/api/v1/portal/auth/magic-link', data, { headers: { 'Content-Type': 'application/json' } })
+const portalVerifyMagicLink = (data) => http.post('/api/v1/portal/auth/magic-link/verify', data, { headers: { 'Content-Type': 'application/json' } })
+const portalRegister = (data) => http.post('/api/v1/portal/auth/register', data, { headers: { 'Content-Type': 'application/json' } })
 const portalLogout = () => http.post('/api/v1/portal/auth/logout')
 const portalMe = () => http.get('/api/v1/portal/auth/me')
 const portalGetConversations = (params) => http.get('/api/v1/portal/conversations', { params })
 const portalGetConversation = (uuid) => http.get(`/api/v1/portal/conversations/${uuid}`)
 const portalCreateConversation = (data) => http.post('/api/v1/portal/conversations', data, { headers: { 'Content-Type': 'application/json' } })
+const portalGetTicketForms = () => http.get('/api/v1/portal/ticket-forms')
+const portalGetOrganizationMembers = () => http.get('/api/v1/portal/organization/members')
+const portalAddOrganizationMember = (data) => http.post('/api/v1/portal/organization/members', data, { headers: { 'Content-Type': 'application/json' } })
+const portalUpdateOrganizationMember = (contactId, data) => http.put(`/api/v1/portal/organization/members/${contactId}`, data, { headers: { 'Content-Type': 'application/json' } })
+const portalRemoveOrganizationMember = (contactId) => http.delete(`/api/v1/portal/organization/members/${contactId}`)
 const portalSendMessage = (uuid, data) => http.post(`/api/v1/portal/conversations/${uuid}/messages`, data, { headers: { 'Content-Type': 'application/json' } })
 const portalCloseConversation = (uuid, data) => http.post(`/api/v1/portal/conversations/${uuid}/close`, data, { headers: { 'Content-Type': 'application/json' } })
+const portalGetKBCategories = (params) => http.get('/api/v1/portal/kb/categories', { params })
+const portalGetKBCategoryArticles = (slug, params) => http.get(`/api/v1/portal/kb/categories/${slug}/articles`, { params })
+const portalGetKBArticle = (slug, params) => http.get(`/api/v1/portal/kb/articles/${slug}`, { params })
+const portalSearchKBArticles = (params) => http.get('/api/v1/portal/kb/search', { params })
 const portalUploadMedia = (data) => http.post('/api/v1/portal/media', data, { headers: { 'Content-Type': 'multipart/form-data' } })
 const getCurrentUserViews = () => http.get('/api/v1/views/me')
 const createView = (data) =>
@@ -466,7 +505,47 @@ const updateSharedView = (id, data) =>
   })
 const deleteSharedView = (id) => http.delete(`/api/v1/shared-views/${id}`)
 
-const getAiPrompts = () => http.get('/api/v1/ai/prompts')
+const getAiPrompts = (params) => http.get('/api/v1/ai/prompts', { params })
+const getAiPrompt = (key) => http.get(`/api/v1/ai/prompts/${key}`)
+const updateAiPrompt = (key, data) => http.put(`/api/v1/ai/prompts/${key}`, data)
+const getConversationSummary = (uuid) => http.post(`/api/v1/conversations/${uuid}/ai/summary`)
+const updateScheduledMessage = (cuuid, uuid, data) => http.put(`/api/v1/conversations/${cuuid}/messages/${uuid}/schedule`, data)
+const recallMessage = (cuuid, uuid) => http.post(`/api/v1/conversations/${cuuid}/messages/${uuid}/recall`)
+const updateUndoSend = (data) => http.put('/api/v1/agents/me/undo-send', data)
+const getConversationLinks = (uuid) => http.get(`/api/v1/conversations/${uuid}/links`)
+const linkConversation = (uuid, data) => http.post(`/api/v1/conversations/${uuid}/links`, data)
+const unlinkConversation = (uuid, id) => http.delete(`/api/v1/conversations/${uuid}/links/${id}`)
+const getTimeEntries = (uuid) => http.get(`/api/v1/conversations/${uuid}/time-entries`)
+const createTimeEntry = (uuid, data) => http.post(`/api/v1/conversations/${uuid}/time-entries`, data)
+const updateTimeEntry = (uuid, id, data) => http.put(`/api/v1/conversations/${uuid}/time-entries/${id}`, data)
+const deleteTimeEntry = (uuid, id) => http.delete(`/api/v1/conversations/${uuid}/time-entries/${id}`)
+const getConversationTasks = (uuid) => http.get(`/api/v1/conversations/${uuid}/tasks`)
+const createConversationTask = (uuid, data) => http.post(`/api/v1/conversations/${uuid}/tasks`, data)
+const updateConversationTask = (uuid, id, data) => http.put(`/api/v1/conversations/${uuid}/tasks/${id}`, data)
+const deleteConversationTask = (uuid, id) => http.delete(`/api/v1/conversations/${uuid}/tasks/${id}`)
+const getMyTasks = (params) => http.get('/api/v1/agents/me/tasks', { params })
+const getSideConversations = (cuuid) => http.get(`/api/v1/conversations/${cuuid}/side-conversations`)
+const getSideConversation = (cuuid, uuid) => http.get(`/api/v1/conversations/${cuuid}/side-conversations/${uuid}`)
+const createSideConversation = (cuuid, data) => http.post(`/api/v1/conversations/${cuuid}/side-conversations`, data)
+const sendSideConversationMessage = (cuuid, uuid, data) =>
+  http.post(`/api/v1/conversations/${cuuid}/side-conversations/${uuid}/messages`, data)
+const cancelScheduledMessage = (cuuid, uuid) => http.delete(`/api/v1/conversations/${cuuid}/messages/${uuid}/schedule`)
+const getSuggestedReply = (uuid) => http.post(`/api/v1/conversations/${uuid}/ai/suggested-reply`)
+const searchKBArticles = (params) => http.get('/api/v1/knowledge-base/search', { params })
+const getKBCategories = () => http.get('/api/v1/knowledge-base/categories')
+const getKBCategory = (id) => http.get(`/api/v1/knowledge-base/categories/${id}`)
+const createKBCategory = (data) => http.post('/api/v1/knowledge-base/categories', data)
+const updateKBCategory = (id, data) => http.put(`/api/v1/knowledge-base/categories/${id}`, data)
+const deleteKBCategory = (id) => http.delete(`/api/v1/knowledge-base/categories/${id}`)
+const getKBArticles = (params) => http.get('/api/v1/knowledge-base/articles', { params })
+const getKBArticle = (id) => http.get(`/api/v1/knowledge-base/articles/${id}`)
+const createKBArticle = (data) => http.post('/api/v1/knowledge-base/articles', data)
+const updateKBArticle = (id, data) => http.put(`/api/v1/knowledge-base/articles/${id}`, data)
+const deleteKBArticle = (id) => http.delete(`/api/v1/knowledge-base/articles/${id}`)
+const saveKBTranslation = (id, locale, data) => http.put(`/api/v1/knowledge-base/articles/${id}/translations/${locale}`, data)
+const deleteKBTranslation = (id, locale) => http.delete(`/api/v1/knowledge-base/articles/${id}/translations/${locale}`)
+const updateKBTranslationStatus = (id, locale, data) => http.put(`/api/v1/knowledge-base/articles/${id}/translations/${locale}/status`, data)
+const getKBTranslationVersions = (id, locale) => http.get(`/api/v1/knowledge-base/articles/${id}/translations/${locale}/versions`)
 const aiCompletion = (data) => http.post('/api/v1/ai/completion', data, {
   headers: {
     'Content-Type': 'application/json'
@@ -477,6 +556,8 @@ const updateAIProvider = (data) => http.put('/api/v1/ai/provider', data, {
     'Content-Type': 'application/json'
   }
 })
+const getAIProviders = () => http.get('/api/v1/ai/providers')
+const updateAIPromptProvider = (key, data) => http.put(`/api/v1/ai/prompts/${key}/provider`, data)
 const getContactNotes = (id) => http.get(`/api/v1/contacts/${id}/notes`)
 const getContactOrganizations = (id) => http.get(`/api/v1/contacts/${id}/organizations`)
 const createContactNote = (id, data) => http.post(`/api/v1/contacts/${id}/notes`, data, {
@@ -486,6 +567,8 @@ const createContactNote = (id, data) => http.post(`/api/v1/contacts/${id}/notes`
 })
 const deleteContactNote = (id, noteId) => http.delete(`/api/v1/contacts/${id}/notes/${noteId}`)
 const getActivityLogs = (params) => http.get('/api/v1/activity-logs', { params })
+const exportActivityLogs = (params) =>
+  http.get('/api/v1/activity-logs/export', { params, responseType: 'blob' })
 const getWebhooks = () => http.get('/api/v1/webhooks')
 const getWebhook = (id) => http.get(`/api/v1/webhooks/${id}`)
 const createWebhook = (data) =>
@@ -503,6 +586,21 @@ const updateWebhook = (id, data) =>
 const deleteWebhook = (id) => http.delete(`/api/v1/webhooks/${id}`)
 const toggleWebhook = (id) => http.put(`/api/v1/webhooks/${id}/toggle`)
 const testWebhook = (id) => http.post(`/api/v1/webhooks/${id}/test`)
+const getTicketForms = () => http.get('/api/v1/ticket-forms')
+const getTicketForm = (id) => http.get(`/api/v1/ticket-forms/${id}`)
+const createTicketForm = (data) =>
+  http.post('/api/v1/ticket-forms', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const updateTicketForm = (id, data) =>
+  http.put(`/api/v1/ticket-forms/${id}`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const deleteTicketForm = (id) => http.delete(`/api/v1/ticket-forms/${id}`)
 
 const generateAPIKey = (id) => 
   http.post(`/api/v1/agents/${id}/api-key`, {}, {
@@ -527,6 +625,20 @@ const markNotificationAsRead = (id) => http.put(`/api/v1/notifications/${id}/rea
 const markAllNotificationsAsRead = () => http.put('/api/v1/notifications/read-all')
 const deleteNotification = (id) => http.delete(`/api/v1/notifications/${id}`)
 const deleteAllNotifications = () => http.delete('/api/v1/notifications')
+const getChatIdentities = () => http.get('/api/v1/notifications/chat-identities')
+const updateChatIdentity = (provider, data) =>
+  http.put(`/api/v1/notifications/chat-identities/${provider}`, data)
+const deleteChatIdentity = (provider) =>
+  http.delete(`/api/v1/notifications/chat-identities/${provider}`)
+const testChatIdentity = (provider) =>
+  http.post(`/api/v1/notifications/chat-identities/${provider}/test`)
+const getNotificationPreferences = () => http.get('/api/v1/notifications/preferences')
+const updateNotificationPreferences = (data) => http.put('/api/v1/notifications/preferences', data)
+const getPushConfig = () => http.get('/api/v1/notifications/push/config')
+const getPushSubscriptions = () => http.get('/api/v1/notifications/push/subscriptions')
+const createPushSubscription = (data) => http.post('/api/v1/notifications/push/subscriptions', data)
+const deletePushSubscription = (data) =>
+  http.delete('/api/v1/notifications/push/subscriptions', { data })
 
 export default {
   login,
@@ -574,6 +686,17 @@ export default {
   getOverviewCSAT,
   getOverviewMessageVolume,
   getOverviewTagDistribution,
+  getAgentPerformance,
+  getAgentPerformanceConversations,
+  getTeamPerformance,
+  getTeamPerformanceConversations,
+  getTimeTrackingReport,
+  exportReport,
+  getReportSchedules,
+  getReportSchedule,
+  createReportSchedule,
+  updateReportSchedule,
+  deleteReportSchedule,
   getConversationParticipants,
   getConversationMessage,
   getConversationMessages,
@@ -602,6 +725,8 @@ export default {
   updateAutomationRuleWeights,
   updateAutomationRulesExecutionMode,
   updateAIProvider,
+  getAIProviders,
+  updateAIPromptProvider,
   createAutomationRule,
   toggleAutomationRule,
   deleteAutomationRule,
@@ -667,6 +792,45 @@ export default {
   updateSharedView,
   deleteSharedView,
   getAiPrompts,
+  getAiPrompt,
+  updateAiPrompt,
+  getConversationSummary,
+  getSuggestedReply,
+  updateScheduledMessage,
+  cancelScheduledMessage,
+  recallMessage,
+  updateUndoSend,
+  getConversationLinks,
+  linkConversation,
+  unlinkConversation,
+  getTimeEntries,
+  createTimeEntry,
+  updateTimeEntry,
+  deleteTimeEntry,
+  getConversationTasks,
+  createConversationTask,
+  updateConversationTask,
+  deleteConversationTask,
+  getMyTasks,
+  getSideConversations,
+  getSideConversation,
+  createSideConversation,
+  sendSideConversationMessage,
+  searchKBArticles,
+  getKBCategories,
+  getKBCategory,
+  createKBCategory,
+  updateKBCategory,
+  deleteKBCategory,
+  getKBArticles,
+  getKBArticle,
+  createKBArticle,
+  updateKBArticle,
+  deleteKBArticle,
+  saveKBTranslation,
+  deleteKBTranslation,
+  updateKBTranslationStatus,
+  getKBTranslationVersions,
   aiCompletion,
   searchConversations,
   searchMessages,
@@ -678,6 +842,8 @@ export default {
   updateContact,
   sendContactSetPasswordEmail,
   blockContact,
+  exportContactData,
+  eraseContactData,
   getCustomAttributes,
   createCustomAttribute,
   updateCustomAttribute,
@@ -688,6 +854,7 @@ export default {
   createContactNote,
   deleteContactNote,
   getActivityLogs,
+  exportActivityLogs,
   getWebhooks,
   getWebhook,
   createWebhook,
@@ -695,6 +862,11 @@ export default {
   deleteWebhook,
   toggleWebhook,
   testWebhook,
+  getTicketForms,
+  getTicketForm,
+  createTicketForm,
+  updateTicketForm,
+  deleteTicketForm,
   generateAPIKey,
   revokeAPIKey,
   initiateOAuthFlow,
@@ -704,15 +876,37 @@ export default {
   markAllNotificationsAsRead,
   deleteNotification,
   deleteAllNotifications,
+  getChatIdentities,
+  updateChatIdentity,
+  deleteChatIdentity,
+  testChatIdentity,
+  getNotificationPreferences,
+  updateNotificationPreferences,
+  getPushConfig,
+  getPushSubscriptions,
+  createPushSubscription,
+  deletePushSubscription,
   portalLogin,
   portalForgotPassword,
   portalSetPassword,
+  portalRequestMagicLink,
+  portalVerifyMagicLink,
+  portalRegister,
   portalLogout,
   portalMe,
   portalGetConversations,
   portalGetConversation,
   portalCreateConversation,
+  portalGetTicketForms,
+  portalGetOrganizationMembers,
+  portalAddOrganizationMember,
+  portalUpdateOrganizationMember,
+  portalRemoveOrganizationMember,
   portalSendMessage,
   portalCloseConversation,
+  portalGetKBCategories,
+  portalGetKBCategoryArticles,
+  portalGetKBArticle,
+  portalSearchKBArticles,
   portalUploadMedia
 }
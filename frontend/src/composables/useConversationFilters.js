@@ -24,8 +24,11 @@ export function useConversationFilters () {
         'number': FIELD_TYPE.NUMBER,
         'checkbox': FIELD_TYPE.BOOLEAN,
         'date': FIELD_TYPE.DATE,
+        'datetime': FIELD_TYPE.DATE,
         'link': FIELD_TYPE.TEXT,
         'list': FIELD_TYPE.SELECT,
+        'multi_list': FIELD_TYPE.TEXT,
+        'lookup': FIELD_TYPE.NUMBER,
     }
 
     const customAttributeDataTypeToFieldOperators = {
@@ -33,8 +36,11 @@ export function useConversationFilters () {
         'number': FIELD_OPERATORS.NUMBER,
         'checkbox': FIELD_OPERATORS.BOOLEAN,
         'date': FIELD_OPERATORS.DATE,
+        'datetime': FIELD_OPERATORS.DATE,
         'link': FIELD_OPERATORS.TEXT,
         'list': FIELD_OPERATORS.SELECT,
+        'multi_list': FIELD_OPERATORS.TEXT,
+        'lookup': FIELD_OPERATORS.NUMBER,
     }
 
     const conversationsListFilters = computed(() => ({
@@ -252,6 +258,9 @@ export function useConversationFilters () {
                 name: t('globals.terms.csat').toLowerCase()
             }),
         },
+        ai_classify: {
+            label: t('admin.automation.action.aiClassify')
+        },
         set_sla: {
             label: t('globals.messages.set', {
                 name: t('globals.terms.sla').toLowerCase()
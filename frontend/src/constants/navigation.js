@@ -20,6 +20,17 @@ export const adminNavItems = [
         href: '/admin/portal',
         permission: 'general_settings:manage'
       },
+      {
+        titleKey: 'globals.terms.ticketForm',
+        href: '/admin/ticket-forms',
+        permission: 'general_settings:manage',
+        isTitleKeyPlural: true
+      },
+      {
+        titleKey: 'globals.terms.knowledgeBase',
+        href: '/admin/knowledge-base',
+        permission: 'knowledge_base:manage'
+      },
       {
         titleKey: 'globals.terms.businessHour',
         href: '/admin/business-hours',
@@ -11,6 +11,9 @@ export const permissions = {
   CONVERSATIONS_UPDATE_PRIORITY: 'conversations:update_priority',
   CONVERSATIONS_UPDATE_STATUS: 'conversations:update_status',
   CONVERSATIONS_UPDATE_TAGS: 'conversations:update_tags',
+  CONVERSATIONS_UPDATE_LINKS: 'conversations:update_links',
+  CONVERSATIONS_TRACK_TIME: 'conversations:track_time',
+  CONVERSATIONS_MANAGE_TASKS: 'conversations:manage_tasks',
   MESSAGES_READ: 'messages:read',
   MESSAGES_WRITE: 'messages:write',
   MESSAGES_WRITE_AS_CONTACT: 'messages:write_as_contact',
@@ -32,11 +35,14 @@ export const permissions = {
   BUSINESS_HOURS_MANAGE: 'business_hours:manage',
   SLA_MANAGE: 'sla:manage',
   AI_MANAGE: 'ai:manage',
+  KNOWLEDGE_BASE_MANAGE: 'knowledge_base:manage',
   CUSTOM_ATTRIBUTES_MANAGE: 'custom_attributes:manage',
   CONTACTS_READ_ALL: 'contacts:read_all',
   CONTACTS_READ: 'contacts:read',
   CONTACTS_WRITE: 'contacts:write',
   CONTACTS_BLOCK: 'contacts:block',
+  CONTACTS_EXPORT: 'contacts:export',
+  CONTACTS_ERASE: 'contacts:erase',
   CONTACT_NOTES_READ: 'contact_notes:read',
   CONTACT_NOTES_WRITE: 'contact_notes:write',
   CONTACT_NOTES_DELETE: 'contact_notes:delete',
@@ -1,6 +1,15 @@
 export const WS_EVENT = {
     NEW_MESSAGE: 'new_message',
     MESSAGE_PROP_UPDATE: 'message_prop_update',
+    MESSAGE_RECALLED: 'message_recalled',
     CONVERSATION_PROP_UPDATE: 'conversation_prop_update',
     NEW_NOTIFICATION: 'new_notification',
+    CONVERSATION_PRESENCE: 'conversation_presence',
+    NEW_SIDE_CONVERSATION_MESSAGE: 'new_side_conversation_message',
+}
+
+export const PRESENCE_STATUS = {
+    VIEWING: 'viewing',
+    TYPING: 'typing',
+    STOPPED: 'stopped',
 }
\ No newline at end of file
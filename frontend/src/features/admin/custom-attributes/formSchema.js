@@ -1,4 +1,15 @@
 import * as z from 'zod'
+
+// optionalNumber is a number input that may be left empty.
+const optionalNumber = (t) => z.preprocess(
+    (val) => (val === '' || val === undefined || val === null ? null : Number(val)),
+    z.number({
+        invalid_type_error: t('globals.messages.invalid', {
+            name: t('globals.terms.value').toLowerCase(),
+        }),
+    }).nullable()
+)
+
 export const createFormSchema = (t) => z.object({
     id: z.number().optional(),
     applies_to: z.enum(['contact', 'conversation'], {
@@ -57,17 +68,37 @@ export const createFormSchema = (t) => z.object({
                 max: 300,
             })
         }),
-    data_type: z.enum(['text', 'number', 'checkbox', 'date', 'link', 'list'], {
+    data_type: z.enum(['text', 'number', 'checkbox', 'date', 'datetime', 'link', 'list', 'multi_list', 'lookup'], {
         required_error: t('globals.messages.required'),
     }),
     regex: z.string().optional(),
     regex_hint: z.string().optional(),
     values: z.array(z.string())
-        .default([])
+        .default([]),
+    min_value: optionalNumber(t),
+    max_value: optionalNumber(t),
+    lookup_type: z.enum(['', 'contact', 'organization', 'agent']).default(''),
+    required_on_resolve: z.boolean().default(false)
 })
     .superRefine((data, ctx) => {
-        if (data.data_type === 'list') {
-            // If data_type is 'list', values should be defined and have at least one item.
+        if (data.data_type === 'lookup' && !data.lookup_type) {
+            ctx.addIssue({
+                code: z.ZodIssueCode.custom,
+                message: t('globals.messages.required'),
+                path: ['lookup_type'],
+            });
+        }
+        if (data.data_type === 'number' && data.min_value != null && data.max_value != null && data.min_value > data.max_value) {
+            ctx.addIssue({
+                code: z.ZodIssueCode.custom,
+                message: t('globals.messages.invalid', {
+                    name: t('globals.terms.max'),
+                }),
+                path: ['max_value'],
+            });
+        }
+        if (data.data_type === 'list' || data.data_type === 'multi_list') {
+            // If data_type is a list, values should be defined and have at least one item.
             if (!data.values || data.values.length === 0) {
                 ctx.addIssue({
                     code: z.ZodIssueCode.too_small,
@@ -0,0 +1,74 @@
+import { h } from 'vue'
+import { RouterLink } from 'vue-router'
+import dropdown from './dataTableDropdown.vue'
+import { format } from 'date-fns'
+import { Badge } from '@/components/ui/badge'
+
+export const createColumns = (t, categoryName) => [
+  {
+    accessorKey: 'title',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('knowledgeBase.title'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center' },
+        h(RouterLink,
+          {
+            to: { name: 'edit-kb-article', params: { id: row.original.id } },
+            class: 'text-primary hover:underline'
+          },
+          () => row.original.title || row.original.slug
+        )
+      )
+    }
+  },
+  {
+    accessorKey: 'category_id',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.category'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center' }, categoryName(row.getValue('category_id')))
+    }
+  },
+  {
+    accessorKey: 'status',
+    header: () => h('div', { class: 'text-center' }, t('globals.terms.status')),
+    cell: ({ row }) => {
+      const status = row.getValue('status')
+      return h('div', { class: 'text-center' }, [
+        h(
+          Badge,
+          { variant: status === 'published' ? 'default' : 'secondary', class: 'text-xs' },
+          () => status === 'published' ? t('knowledgeBase.published') : t('knowledgeBase.draft')
+        )
+      ])
+    }
+  },
+  {
+    accessorKey: 'locales',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.translation', 2))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center text-sm' }, (row.getValue('locales') || []).join(', '))
+    }
+  },
+  {
+    accessorKey: 'updated_at',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.updatedAt'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center text-sm' }, format(row.getValue('updated_at'), 'PPpp'))
+    }
+  },
+  {
+    id: 'actions',
+    enableHiding: false,
+    enableSorting: false,
+    cell: ({ row }) => {
+      return h('div', { class: 'relative' }, h(dropdown, { article: row.original }))
+    }
+  }
+]
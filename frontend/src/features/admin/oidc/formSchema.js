@@ -21,5 +21,7 @@ export const createFormSchema = (t) => z.object({
     required_error: t('globals.messages.required'),
   }),
   redirect_uri: z.string().readonly().optional(),
+  portal_redirect_uri: z.string().readonly().optional(),
+  portal: z.boolean().default(false).optional(),
   enabled: z.boolean().default(true).optional(),
 })
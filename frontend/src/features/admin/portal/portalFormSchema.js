@@ -4,9 +4,23 @@ export const createPortalFormSchema = (t) =>
   z
     .object({
       portal_enabled: z.boolean().optional().default(false),
-      portal_default_inbox_id: z.coerce.number().optional().default(0)
+      portal_default_inbox_id: z.coerce.number().optional().default(0),
+      portal_magic_link_enabled: z.boolean().optional().default(false),
+      portal_self_registration_enabled: z.boolean().optional().default(false),
+      portal_registration_domains: z.array(z.string()).nullable().optional().default([]),
+      portal_registration_organizations: z.boolean().optional().default(false)
     })
     .refine((data) => !data.portal_enabled || data.portal_default_inbox_id > 0, {
       message: t('admin.general.portalDefaultInboxRequired'),
       path: ['portal_default_inbox_id']
     })
+    .refine(
+      (data) =>
+        !data.portal_self_registration_enabled ||
+        data.portal_registration_domains?.length > 0 ||
+        data.portal_registration_organizations,
+      {
+        message: t('admin.general.portalRegistrationRestrictionRequired'),
+        path: ['portal_registration_domains']
+      }
+    )
@@ -0,0 +1,96 @@
+import { h } from 'vue'
+import { RouterLink } from 'vue-router'
+import dropdown from './dataTableDropdown.vue'
+import { format } from 'date-fns'
+import { Badge } from '@/components/ui/badge'
+
+export const createColumns = (t, inboxName) => [
+  {
+    accessorKey: 'name',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.name'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center' },
+        h(RouterLink,
+          {
+            to: { name: 'edit-ticket-form', params: { id: row.original.id } },
+            class: 'text-primary hover:underline'
+          },
+          () => row.getValue('name')
+        )
+      )
+    }
+  },
+  {
+    accessorKey: 'inbox_id',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.inbox'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center' }, inboxName(row.getValue('inbox_id')))
+    }
+  },
+  {
+    accessorKey: 'fields',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.field', 2))
+    },
+    cell: function ({ row }) {
+      const fields = row.getValue('fields') || []
+      return h('div', { class: 'text-center' }, [
+        h(
+          Badge,
+          { variant: 'secondary', class: 'text-xs' },
+          () => `${fields.length} ${t('globals.terms.field', 2).toLowerCase()}`
+        )
+      ])
+    },
+    sortingFn: (rowA, rowB) => {
+      const a = rowA.original.fields?.length || 0
+      const b = rowB.original.fields?.length || 0
+      return a - b
+    }
+  },
+  {
+    accessorKey: 'enabled',
+    header: () => h('div', { class: 'text-center' }, t('globals.terms.status')),
+    cell: ({ row }) => {
+      const enabled = row.getValue('enabled')
+      return h('div', { class: 'text-center' }, [
+        h(
+          Badge,
+          {
+            variant: enabled ? 'default' : 'secondary',
+            class: 'text-xs'
+          },
+          () => enabled ? t('globals.terms.enabled') : t('globals.terms.disabled')
+        )
+      ])
+    }
+  },
+  {
+    accessorKey: 'updated_at',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.updatedAt'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center text-sm' }, format(row.getValue('updated_at'), 'PPpp'))
+    }
+  },
+  {
+    id: 'actions',
+    enableHiding: false,
+    enableSorting: false,
+    cell: ({ row }) => {
+      const ticketForm = row.original
+      return h(
+        'div',
+        { class: 'relative' },
+        h(dropdown, {
+          ticketForm
+        })
+      )
+    }
+  }
+]
@@ -0,0 +1,50 @@
+import * as z from 'zod'
+
+export const createFormSchema = (t) =>
+  z.object({
+    name: z
+      .string({
+        required_error: t('globals.messages.required')
+      })
+      .min(1, {
+        message: t('globals.messages.required')
+      })
+      .max(140, {
+        message: t('globals.messages.tooLong', { name: t('globals.terms.name'), max: 140 })
+      }),
+    description: z
+      .string()
+      .max(1000, {
+        message: t('globals.messages.tooLong', { name: t('globals.terms.description'), max: 1000 })
+      })
+      .optional(),
+    inbox_id: z
+      .string({
+        required_error: t('globals.messages.required')
+      })
+      .min(1, {
+        message: t('globals.messages.required')
+      }),
+    enabled: z.boolean().default(true).optional(),
+    fields: z
+      .array(
+        z.object({
+          custom_attribute_id: z.number({
+            required_error: t('globals.messages.required'),
+            invalid_type_error: t('globals.messages.required')
+          }),
+          help_text: z.string().optional(),
+          required: z.boolean().default(false),
+          condition: z
+            .object({
+              custom_attribute_id: z.number(),
+              values: z.array(z.string()).min(1, {
+                message: t('globals.messages.required')
+              })
+            })
+            .nullable()
+            .optional()
+        })
+      )
+      .default([])
+  })
@@ -235,6 +235,58 @@ const routes = [
             component: () => import('@/views/admin/portal/Portal.vue'),
             meta: { title: 'Portal' }
           },
+          {
+            path: 'ticket-forms',
+            component: () => import('@/views/admin/ticket-forms/TicketForms.vue'),
+            name: 'ticket-forms',
+            meta: { title: 'Ticket forms' },
+            children: [
+              {
+                path: '',
+                name: 'ticket-form-list',
+                component: () => import('@/views/admin/ticket-forms/TicketFormList.vue')
+              },
+              {
+                path: ':id/edit',
+                props: true,
+                name: 'edit-ticket-form',
+                component: () => import('@/views/admin/ticket-forms/CreateEditTicketForm.vue'),
+                meta: { title: 'Edit Ticket form' }
+              },
+              {
+                path: 'new',
+                name: 'new-ticket-form',
+                component: () => import('@/views/admin/ticket-forms/CreateEditTicketForm.vue'),
+                meta: { title: 'New Ticket form' }
+              }
+            ]
+          },
+          {
+            path: 'knowledge-base',
+            component: () => import('@/views/admin/knowledge-base/KnowledgeBase.vue'),
+            name: 'knowledge-base',
+            meta: { title: 'Knowledge base' },
+            children: [
+              {
+                path: '',
+                name: 'kb-article-list',
+                component: () => import('@/views/admin/knowledge-base/KBArticleList.vue')
+              },
+              {
+                path: 'articles/:id/edit',
+                props: true,
+                name: 'edit-kb-article',
+                component: () => import('@/views/admin/knowledge-base/CreateEditKBArticle.vue'),
+                meta: { title: 'Edit Article' }
+              },
+              {
+                path: 'articles/new',
+                name: 'new-kb-article',
+                component: () => import('@/views/admin/knowledge-base/CreateEditKBArticle.vue'),
+                meta: { title: 'New Article' }
+              }
+            ]
+          },
           {
             path: 'business-hours',
             component: () => import('@/views/admin/business-hours/BusinessHours.vue'),
@@ -597,6 +649,31 @@ const routes = [
         component: () => import('@/views/portal/PortalSetPasswordView.vue'),
         meta: { title: 'Set Password' }
       },
+      {
+        path: 'register',
+        name: 'portal-register',
+        component: () => import('@/views/portal/PortalRegisterView.vue'),
+        meta: { title: 'Sign Up' }
+      },
+      {
+        path: 'magic-link',
+        name: 'portal-magic-link',
+        component: () => import('@/views/portal/PortalMagicLinkView.vue'),
+        meta: { title: 'Sign In' }
+      },
+      {
+        path: 'kb',
+        name: 'portal-kb',
+        component: () => import('@/views/portal/PortalKnowledgeBaseView.vue'),
+        meta: { title: 'Help Center' }
+      },
+      {
+        path: 'kb/:slug',
+        name: 'portal-kb-article',
+        component: () => import('@/views/portal/PortalKnowledgeBaseArticleView.vue'),
+        props: true,
+        meta: { title: 'Help Center' }
+      },
       {
         path: 'tickets',
         component: PortalLayout,
@@ -622,6 +699,19 @@ const routes = [
             meta: { title: 'Ticket' }
           }
         ]
+      },
+      {
+        path: 'organization',
+        component: PortalLayout,
+        meta: { title: 'Organization', requiresPortalAuth: true },
+        children: [
+          {
+            path: '',
+            name: 'portal-organization-members',
+            component: () => import('@/views/portal/PortalOrganizationMembersView.vue'),
+            meta: { title: 'Organization' }
+          }
+        ]
       }
     ]
   },
@@ -18,6 +18,8 @@ export const useConversationStore = defineStore('conversation', () => {
   const currentCC = ref([])
   const macros = ref({})
   const drafts = ref(new Map())
+  // Conversation UUID to the other agents viewing or typing in it.
+  const presence = ref(new Map())
 
   // Options for select fields
   const priorityOptions = computed(() => {
@@ -635,7 +637,12 @@ export const useConversationStore = defineStore('conversation', () => {
   function updateMessageProp (message) {
     const exists = messages.data.hasMessage(message.conversation_uuid, message.uuid)
     if (exists) {
-      messages.data.updateMessageField(message.conversation_uuid, message.uuid, message.prop, message.value)
+      // Cancelled scheduled messages are deleted.
+      if (message.prop === 'deleted') {
+        messages.data.removeMessage(message.conversation_uuid, message.uuid)
+      } else {
+        messages.data.updateMessageField(message.conversation_uuid, message.uuid, message.prop, message.value)
+      }
       incrementMessageVersion()
     }
   }
@@ -756,6 +763,23 @@ export const useConversationStore = defineStore('conversation', () => {
     return drafts.value.has(uuid)
   }
 
+  // Set the other agents present in a conversation, the current agent is left out.
+  function setConversationPresence (data, currentUserID) {
+    const agents = (data.agents || []).filter(agent => agent.user_id !== currentUserID)
+    if (agents.length === 0) {
+      presence.value.delete(data.conversation_uuid)
+    } else {
+      presence.value.set(data.conversation_uuid, agents)
+    }
+    // Trigger reactivity
+    presence.value = new Map(presence.value)
+  }
+
+  // Get the other agents present in a conversation
+  function getConversationPresence (uuid) {
+    return presence.value.get(uuid) || []
+  }
+
   return {
     macros,
     conversations,
@@ -814,6 +838,9 @@ export const useConversationStore = defineStore('conversation', () => {
     getDraft,
     setDraft,
     removeDraft,
-    hasDraft
+    hasDraft,
+    presence,
+    setConversationPresence,
+    getConversationPresence
   }
 })
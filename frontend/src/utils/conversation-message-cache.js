@@ -109,6 +109,17 @@ export default class MessageCache {
         })
     }
 
+    /**
+     * Removes a message from a conversation
+     */
+    removeMessage (convId, msgId) {
+        const conv = this.cache.get(convId)
+        if (!conv) return
+        conv.pages.forEach((msgs, page) => {
+            conv.pages.set(page, msgs.filter(m => m.uuid !== msgId))
+        })
+    }
+
     /**
      * Updates a single field in a message
      */
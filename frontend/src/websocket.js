@@ -1,6 +1,9 @@
 import { useConversationStore } from './stores/conversation'
 import { useNotificationStore } from './stores/notification'
+import { useUserStore } from './stores/user'
+import { useEmitter } from './composables/useEmitter'
 import { WS_EVENT } from './constants/websocket'
+import { EMITTER_EVENTS } from './constants/emitterEvents'
 
 export class WebSocketClient {
   constructor() {
@@ -15,6 +18,8 @@ export class WebSocketClient {
     this.lastPong = Date.now()
     this.convStore = useConversationStore()
     this.notificationStore = useNotificationStore()
+    this.userStore = useUserStore()
+    this.emitter = useEmitter()
   }
 
   init () {
@@ -63,8 +68,12 @@ export class WebSocketClient {
           this.convStore.updateConversationMessage(data.data)
         },
         [WS_EVENT.MESSAGE_PROP_UPDATE]: () => this.convStore.updateMessageProp(data.data),
+        // A reply recalled in the undo send period is restored to the draft.
+        [WS_EVENT.MESSAGE_RECALLED]: () => this.convStore.setDraft(data.data.conversation_uuid, data.data.draft),
         [WS_EVENT.CONVERSATION_PROP_UPDATE]: () => this.convStore.updateConversationProp(data.data),
-        [WS_EVENT.NEW_NOTIFICATION]: () => this.notificationStore.addNotification(data.data)
+        [WS_EVENT.NEW_NOTIFICATION]: () => this.notificationStore.addNotification(data.data),
+        [WS_EVENT.CONVERSATION_PRESENCE]: () => this.convStore.setConversationPresence(data.data, this.userStore.userID),
+        [WS_EVENT.NEW_SIDE_CONVERSATION_MESSAGE]: () => this.emitter.emit(EMITTER_EVENTS.NEW_SIDE_CONVERSATION_MESSAGE, data.data)
       }
 
       const handler = handlers[data.type]
@@ -171,4 +180,10 @@ export function initWS () {
 }
 
 export const sendMessage = message => wsClient?.send(message)
+// Status is one of PRESENCE_STATUS, viewing has to be resent within a minute and typing within ten seconds to not expire.
+// With trackTime set the agent's time in the conversation is recorded as an automatic time entry once they leave it.
+export const sendConversationPresence = (conversationUUID, status, trackTime = false) => sendMessage({
+  type: WS_EVENT.CONVERSATION_PRESENCE,
+  data: { conversation_uuid: conversationUUID, status, track_time: trackTime }
+})
 export const closeWebSocket = () => wsClient?.close()
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -220,9 +221,83 @@ func (al *Manager) RolePermissionsChanged(actorID int, actorEmail, ip string, ro
 	)
 }
 
+// ContactDataExported records an export of all the data held about a contact.
+func (al *Manager) ContactDataExported(actorID int, actorEmail, ip string, contactID int) error {
+	return al.create(
+		models.ContactDataExported,
+		fmt.Sprintf("%s (#%d) exported the data of contact (#%d)", actorEmail, actorID, contactID),
+		actorID,
+		umodels.UserModel,
+		contactID,
+		ip,
+	)
+}
+
+// ContactDataErased records the erasure of a contact's data.
+func (al *Manager) ContactDataErased(actorID int, actorEmail, ip string, contactID int) error {
+	return al.create(
+		models.ContactDataErased,
+		fmt.Sprintf("%s (#%d) erased the data of contact (#%d)", actorEmail, actorID, contactID),
+		actorID,
+		umodels.UserModel,
+		contactID,
+		ip,
+	)
+}
+
+// EntityCreated records the creation of an entity by an admin along with its fields.
+func (al *Manager) EntityCreated(actorID int, actorEmail, ip, modelType string, modelID int, name string, after any) error {
+	return al.audit(models.EntityCreated, "created", actorID, actorEmail, ip, modelType, modelID, name, nil, after)
+}
+
+// EntityUpdated records an update of an entity by an admin along with the changed fields.
+// Nothing is recorded if no field changed.
+func (al *Manager) EntityUpdated(actorID int, actorEmail, ip, modelType string, modelID int, name string, before, after any) error {
+	return al.audit(models.EntityUpdated, "updated", actorID, actorEmail, ip, modelType, modelID, name, before, after)
+}
+
+// EntityDeleted records the deletion of an entity by an admin along with its last known fields.
+func (al *Manager) EntityDeleted(actorID int, actorEmail, ip, modelType string, modelID int, name string, before any) error {
+	return al.audit(models.EntityDeleted, "deleted", actorID, actorEmail, ip, modelType, modelID, name, before, nil)
+}
+
+// audit records an admin change with the redacted diff of the entity.
+func (al *Manager) audit(activityType, verb string, actorID int, actorEmail, ip, modelType string, modelID int, name string, before, after any) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		al.lo.Error("error computing activity log changes", "model_type", modelType, "model_id", modelID, "error", err)
+		return envelope.NewError(envelope.GeneralError, al.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.activityLog}"), nil)
+	}
+	if activityType == models.EntityUpdated && len(changes) == 0 {
+		return nil
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		al.lo.Error("error marshalling activity log changes", "model_type", modelType, "model_id", modelID, "error", err)
+		return envelope.NewError(envelope.GeneralError, al.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.activityLog}"), nil)
+	}
+
+	entity := strings.ReplaceAll(modelType, "_", " ")
+	if name != "" {
+		entity += " " + name
+	}
+	var description string
+	if modelID > 0 {
+		description = fmt.Sprintf("%s (#%d) %s %s (#%d)", actorEmail, actorID, verb, entity, modelID)
+	} else {
+		description = fmt.Sprintf("%s (#%d) %s %s", actorEmail, actorID, verb, entity)
+	}
+	return al.createWithChanges(activityType, description, actorID, modelType, modelID, ip, changesJSON)
+}
+
 // create creates a new activity log in DB.
 func (m *Manager) create(activityType, activityDescription string, actorID int, targetModelType string, targetModelID int, ip string) error {
-	if _, err := m.q.InsertActivity.Exec(activityType, activityDescription, actorID, targetModelType, targetModelID, ip); err != nil {
+	return m.createWithChanges(activityType, activityDescription, actorID, targetModelType, targetModelID, ip, nil)
+}
+
+// createWithChanges creates a new activity log in DB with the JSON diff of the changed fields.
+func (m *Manager) createWithChanges(activityType, activityDescription string, actorID int, targetModelType string, targetModelID int, ip string, changes []byte) error {
+	if _, err := m.q.InsertActivity.Exec(activityType, activityDescription, actorID, targetModelType, targetModelID, ip, changes); err != nil {
 		m.lo.Error("error inserting activity log", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.activityLog}"), nil)
 	}
@@ -241,6 +316,6 @@ func (m *Manager) makeQuery(page, pageSize int, order, orderBy, filtersJSON stri
 		Page:     page,
 		PageSize: pageSize,
 	}, filtersJSON, dbutil.AllowedFields{
-		"activity_logs": {"activity_type", "actor_id", "ip", "created_at"},
+		"activity_logs": {"activity_type", "activity_description", "actor_id", "target_model_type", "target_model_id", "ip", "created_at"},
 	})
 }
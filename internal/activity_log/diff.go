@@ -0,0 +1,162 @@
+package activitylog
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// redacted replaces the values of secret fields in audit log diffs.
+const redacted = "[REDACTED]"
+
+// secretFields are field names, or suffixes of field names, whose values are never stored in audit log diffs.
+var secretFields = []string{
+	"password",
+	"secret",
+	"token",
+	"api_key",
+	"private_key",
+	"encryption_key",
+	"webhook_url",
+}
+
+// ignoredFields are bookkeeping fields left out of audit log diffs.
+var ignoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// Change is the old and new value of a changed field.
+type Change struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Diff compares two values by their JSON representation and returns the changed fields keyed by their dotted path,
+// e.g. `config.imap.0.host`. Either value may be nil for created and deleted entities.
+// Values of secret fields are redacted, only the fact that they changed is kept.
+func Diff(before, after any) (map[string]Change, error) {
+	var (
+		oldFields = map[string]any{}
+		newFields = map[string]any{}
+	)
+	if err := flattenJSON(before, oldFields); err != nil {
+		return nil, err
+	}
+	if err := flattenJSON(after, newFields); err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for key, oldVal := range oldFields {
+		newVal, ok := newFields[key]
+		if ok && reflect.DeepEqual(oldVal, newVal) {
+			continue
+		}
+		changes[key] = Change{Old: oldVal, New: newVal}
+	}
+	for key, newVal := range newFields {
+		if _, ok := oldFields[key]; !ok {
+			changes[key] = Change{Old: nil, New: newVal}
+		}
+	}
+
+	for key, c := range changes {
+		if isSecretField(key) {
+			changes[key] = Change{Old: redactValue(c.Old), New: redactValue(c.New)}
+		}
+	}
+	return changes, nil
+}
+
+// flattenJSON marshals v to JSON and flattens it into out keyed by dotted paths.
+func flattenJSON(v any, out map[string]any) error {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	flattenValue("", decoded, out)
+	return nil
+}
+
+// flattenValue recursively flattens objects and arrays, scalars are stored as is.
+func flattenValue(prefix string, v any, out map[string]any) {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if prefix == "" && ignoredFields[k] {
+				continue
+			}
+			// Some entities store nested config as a JSON encoded string.
+			if s, ok := child.(string); ok && isJSONObject(s) {
+				var nested any
+				if err := json.Unmarshal([]byte(s), &nested); err == nil {
+					child = nested
+				}
+			}
+			flattenValue(joinPath(prefix, k), child, out)
+		}
+	case []any:
+		// Arrays of scalars are compared as a whole, arrays of objects per item.
+		if !hasObjects(val) {
+			out[prefix] = val
+			return
+		}
+		for i, child := range val {
+			flattenValue(joinPath(prefix, strconv.Itoa(i)), child, out)
+		}
+	default:
+		if prefix != "" {
+			out[prefix] = val
+		}
+	}
+}
+
+// isSecretField returns true if the last segment of the dotted path is a secret field.
+func isSecretField(path string) bool {
+	name := strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+	for _, f := range secretFields {
+		if name == f || strings.HasSuffix(name, "_"+f) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue redacts non-empty values.
+func redactValue(v any) any {
+	if v == nil || v == "" {
+		return v
+	}
+	return redacted
+}
+
+func isJSONObject(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")
+}
+
+func hasObjects(arr []any) bool {
+	for _, v := range arr {
+		switch v.(type) {
+		case map[string]any, []any:
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,98 @@
+package activitylog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type inbox struct {
+		Name      string         `json:"name"`
+		Enabled   bool           `json:"enabled"`
+		Config    string         `json:"config"`
+		Tags      []string       `json:"tags"`
+		Secret    string         `json:"secret"`
+		UpdatedAt string         `json:"updated_at"`
+		Extra     map[string]any `json:"extra,omitempty"`
+	}
+
+	tests := []struct {
+		name     string
+		before   any
+		after    any
+		expected map[string]Change
+	}{
+		{
+			name:     "no changes",
+			before:   inbox{Name: "Support", Tags: []string{"a"}},
+			after:    inbox{Name: "Support", Tags: []string{"a"}},
+			expected: map[string]Change{},
+		},
+		{
+			name:   "scalar and array changes",
+			before: inbox{Name: "Support", Tags: []string{"a"}, UpdatedAt: "x"},
+			after:  inbox{Name: "Helpdesk", Enabled: true, Tags: []string{"a", "b"}, UpdatedAt: "y"},
+			expected: map[string]Change{
+				"name":    {Old: "Support", New: "Helpdesk"},
+				"enabled": {Old: false, New: true},
+				"tags":    {Old: []any{"a"}, New: []any{"a", "b"}},
+			},
+		},
+		{
+			name:   "secrets are redacted",
+			before: inbox{Secret: "old"},
+			after:  inbox{Secret: "new"},
+			expected: map[string]Change{
+				"secret": {Old: redacted, New: redacted},
+			},
+		},
+		{
+			name:   "nested JSON config is diffed per field",
+			before: inbox{Config: `{"imap": [{"host": "a", "password": "p1"}], "from": "x"}`},
+			after:  inbox{Config: `{"imap": [{"host": "b", "password": "p2"}], "from": "x"}`},
+			expected: map[string]Change{
+				"config.imap.0.host":     {Old: "a", New: "b"},
+				"config.imap.0.password": {Old: redacted, New: redacted},
+			},
+		},
+		{
+			name:   "created entity",
+			before: nil,
+			after:  map[string]any{"name": "Sales", "client_secret": "s"},
+			expected: map[string]Change{
+				"name":          {Old: nil, New: "Sales"},
+				"client_secret": {Old: nil, New: redacted},
+			},
+		},
+		{
+			name:   "chat webhook urls are redacted",
+			before: map[string]any{"chat_provider": nil, "chat_webhook_url": nil},
+			after:  map[string]any{"chat_provider": "slack", "chat_webhook_url": "https://hooks.slack.com/services/x"},
+			expected: map[string]Change{
+				"chat_provider":    {Old: nil, New: "slack"},
+				"chat_webhook_url": {Old: nil, New: redacted},
+			},
+		},
+		{
+			name:   "deleted entity",
+			before: map[string]any{"name": "Sales", "api_key": ""},
+			after:  nil,
+			expected: map[string]Change{
+				"name":    {Old: "Sales", New: nil},
+				"api_key": {Old: "", New: nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Diff(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
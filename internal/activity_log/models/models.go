@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -12,18 +13,49 @@ const (
 	AgentOnline                 = "agent_online"
 	AgentPasswordSet            = "agent_password_set"
 	AgentRolePermissionsChanged = "agent_role_permissions_changed"
+
+	// Audit trail of admin changes.
+	EntityCreated = "entity_created"
+	EntityUpdated = "entity_updated"
+	EntityDeleted = "entity_deleted"
+
+	// Data subject requests.
+	ContactDataExported = "contact_data_exported"
+	ContactDataErased   = "contact_data_erased"
+)
+
+// Target model types of admin changes.
+const (
+	ModelInbox           = "inbox"
+	ModelAutomationRule  = "automation_rule"
+	ModelSLAPolicy       = "sla_policy"
+	ModelRole            = "role"
+	ModelTeam            = "team"
+	ModelWebhook         = "webhook"
+	ModelSetting         = "setting"
+	ModelOIDC            = "oidc"
+	ModelCustomAttribute = "custom_attribute"
+	ModelTemplate        = "template"
+	ModelTicketForm      = "ticket_form"
+	ModelAIProvider      = "ai_provider"
+	ModelAIPrompt        = "ai_prompt"
+	ModelKBCategory      = "kb_category"
+	ModelKBArticle       = "kb_article"
+	ModelKBTranslation   = "kb_article_translation"
+	ModelReportSchedule  = "report_schedule"
 )
 
 type ActivityLog struct {
-	ID                  int64     `db:"id" json:"id"`
-	CreatedAt           time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt           time.Time `db:"updated_at" json:"updated_at"`
-	ActivityType        string    `db:"activity_type" json:"activity_type"`
-	ActivityDescription string    `db:"activity_description" json:"activity_description"`
-	ActorID             int       `db:"actor_id" json:"actor_id"`
-	TargetModelType     string    `db:"target_model_type" json:"target_model_type"`
-	TargetModelID       int       `db:"target_model_id" json:"target_model_id"`
-	IP                  string    `db:"ip" json:"ip"`
+	ID                  int64           `db:"id" json:"id"`
+	CreatedAt           time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time       `db:"updated_at" json:"updated_at"`
+	ActivityType        string          `db:"activity_type" json:"activity_type"`
+	ActivityDescription string          `db:"activity_description" json:"activity_description"`
+	ActorID             int             `db:"actor_id" json:"actor_id"`
+	TargetModelType     string          `db:"target_model_type" json:"target_model_type"`
+	TargetModelID       int             `db:"target_model_id" json:"target_model_id"`
+	IP                  string          `db:"ip" json:"ip"`
+	Changes             json.RawMessage `db:"changes" json:"changes"`
 
 	Total int `db:"total" json:"-"`
 }
@@ -9,7 +9,8 @@ SELECT
     actor_id, 
     target_model_type, 
     target_model_id, 
-    ip
+    ip,
+    changes
 FROM 
     activity_logs WHERE 1=1 
 
@@ -20,7 +21,8 @@ INSERT INTO activity_logs (
     actor_id, 
     target_model_type, 
     target_model_id, 
-    ip
+    ip,
+    changes
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, NULLIF($6, '')::inet, $7
 );
\ No newline at end of file
@@ -6,6 +6,8 @@ import (
 	"embed"
 	"encoding/json"
 	"errors"
+	"net/url"
+	"slices"
 
 	"github.com/ghotso/libredesk/internal/ai/models"
 	"github.com/ghotso/libredesk/internal/crypto"
@@ -26,9 +28,14 @@ var (
 
 type Manager struct {
 	q             queries
+	db            *sqlx.DB
 	lo            *logf.Logger
 	i18n          *i18n.I18n
 	encryptionKey string
+	tokenBudget   int
+	embeddings    EmbeddingOpts
+	// pgvector is true if the pgvector extension is installed, embeddings are then ranked by the database.
+	pgvector bool
 }
 
 // Opts contains options for initializing the Manager.
@@ -37,14 +44,43 @@ type Opts struct {
 	I18n          *i18n.I18n
 	Lo            *logf.Logger
 	EncryptionKey string
+	// ThreadTokenBudget is the approximate number of tokens of a conversation thread sent to a provider.
+	ThreadTokenBudget int
+	Embeddings        EmbeddingOpts
 }
 
 // queries contains prepared SQL queries.
 type queries struct {
-	GetDefaultProvider *sqlx.Stmt `query:"get-default-provider"`
-	GetPrompt          *sqlx.Stmt `query:"get-prompt"`
-	GetPrompts         *sqlx.Stmt `query:"get-prompts"`
-	SetOpenAIKey       *sqlx.Stmt `query:"set-openai-key"`
+	GetDefaultProvider   *sqlx.Stmt `query:"get-default-provider"`
+	GetProvider          *sqlx.Stmt `query:"get-provider"`
+	GetProviders         *sqlx.Stmt `query:"get-providers"`
+	GetPrompt            *sqlx.Stmt `query:"get-prompt"`
+	GetPrompts           *sqlx.Stmt `query:"get-prompts"`
+	UpdateProviderConfig *sqlx.Stmt `query:"update-provider-config"`
+	UnsetDefaultProvider *sqlx.Stmt `query:"unset-default-provider"`
+	SetDefaultProvider   *sqlx.Stmt `query:"set-default-provider"`
+	SetPromptProvider    *sqlx.Stmt `query:"set-prompt-provider"`
+	UpdatePrompt         *sqlx.Stmt `query:"update-prompt"`
+
+	GetProviderByName       *sqlx.Stmt `query:"get-provider-by-name"`
+	GetConversationsToEmbed *sqlx.Stmt `query:"get-conversations-to-embed"`
+	GetMacrosToEmbed        *sqlx.Stmt `query:"get-macros-to-embed"`
+	UpsertEmbedding         *sqlx.Stmt `query:"upsert-embedding"`
+	DeleteStaleEmbeddings   *sqlx.Stmt `query:"delete-stale-embeddings"`
+	GetEmbeddings           *sqlx.Stmt `query:"get-embeddings"`
+	GetEmbeddingsPGVector   string     `query:"get-embeddings-pgvector"`
+	PGVectorInstalled       *sqlx.Stmt `query:"pgvector-installed"`
+
+	GetMessageClassification    *sqlx.Stmt `query:"get-message-classification"`
+	InsertMessageClassification *sqlx.Stmt `query:"insert-message-classification"`
+}
+
+// ProviderUpdate holds the changes to a provider, empty fields are left unchanged.
+type ProviderUpdate struct {
+	APIKey    string
+	Model     string
+	BaseURL   string
+	IsDefault bool
 }
 
 // New creates and returns a new instance of the Manager.
@@ -53,127 +89,322 @@ func New(opts Opts) (*Manager, error) {
 	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
 		return nil, err
 	}
+	if opts.ThreadTokenBudget <= 0 {
+		opts.ThreadTokenBudget = DefaultThreadTokenBudget
+	}
+	if opts.Embeddings.Provider == "" {
+		opts.Embeddings.Provider = defaultEmbeddingProvider
+	}
+	if opts.Embeddings.Model == "" {
+		opts.Embeddings.Model = defaultEmbeddingModel
+	}
+	if opts.Embeddings.TopK <= 0 {
+		opts.Embeddings.TopK = defaultEmbeddingTopK
+	}
+	if opts.Embeddings.BatchSize <= 0 {
+		opts.Embeddings.BatchSize = defaultEmbeddingBatchSize
+	}
+	var pgvector bool
+	if err := q.PGVectorInstalled.Get(&pgvector); err != nil {
+		return nil, err
+	}
 	return &Manager{
 		q:             q,
+		db:            opts.DB,
+		pgvector:      pgvector,
 		lo:            opts.Lo,
 		i18n:          opts.I18n,
 		encryptionKey: opts.EncryptionKey,
+		tokenBudget:   opts.ThreadTokenBudget,
+		embeddings:    opts.Embeddings,
 	}, nil
 }
 
-// Completion sends a prompt to the default provider and returns the response.
+// Completion sends a prompt to the provider picked for it, or the default provider, and returns the response.
 func (m *Manager) Completion(k string, prompt string) (string, error) {
-	systemPrompt, err := m.getPrompt(k)
+	p, err := m.getPrompt(k)
 	if err != nil {
 		return "", err
 	}
+	return m.complete(p, prompt)
+}
 
-	client, err := m.getDefaultProviderClient()
+// ConversationCompletion sends a conversation prompt along with the conversation thread, truncated to the token budget.
+func (m *Manager) ConversationCompletion(k string, thread ThreadContext) (string, error) {
+	p, err := m.getPrompt(k)
 	if err != nil {
-		m.lo.Error("error getting provider client", "error", err)
-		return "", envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		return "", err
+	}
+	if p.Kind != PromptKindConversation {
+		return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.template")), nil)
+	}
+	return m.complete(p, buildThreadPrompt(thread, m.tokenBudget))
+}
+
+// complete sends the prompt to its provider and returns the response.
+func (m *Manager) complete(p models.Prompt, prompt string) (string, error) {
+	provider, err := m.getPromptProvider(p)
+	if err != nil {
+		return "", err
+	}
+	client, err := m.newProviderClient(provider)
+	if err != nil {
+		return "", err
 	}
 
 	payload := PromptPayload{
-		SystemPrompt: systemPrompt,
+		SystemPrompt: p.Content,
 		UserPrompt:   prompt,
 	}
 
 	response, err := client.SendPrompt(payload)
 	if err != nil {
 		if errors.Is(err, ErrInvalidAPIKey) {
-			m.lo.Error("error invalid API key", "error", err)
-			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "OpenAI API Key"), nil)
+			m.lo.Error("error invalid API key", "provider", provider.Name, "error", err)
+			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", providerLabel(provider)+" API Key"), nil)
 		}
 		if errors.Is(err, ErrApiKeyNotSet) {
-			m.lo.Error("error API key not set", "error", err)
-			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("ai.apiKeyNotSet", "provider", "OpenAI"), nil)
+			m.lo.Error("error API key not set", "provider", provider.Name, "error", err)
+			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("ai.apiKeyNotSet", "provider", providerLabel(provider)), nil)
 		}
-		m.lo.Error("error sending prompt to provider", "error", err)
+		m.lo.Error("error sending prompt to provider", "provider", provider.Name, "error", err)
 		return "", envelope.NewError(envelope.GeneralError, err.Error(), nil)
 	}
 
 	return response, nil
 }
 
-// GetPrompts returns a list of prompts from the database.
-func (m *Manager) GetPrompts() ([]models.Prompt, error) {
+// GetPrompts returns the prompts of a kind from the database.
+func (m *Manager) GetPrompts(kind string) ([]models.Prompt, error) {
 	var prompts = make([]models.Prompt, 0)
-	if err := m.q.GetPrompts.Select(&prompts); err != nil {
+	if err := m.q.GetPrompts.Select(&prompts, kind); err != nil {
 		m.lo.Error("error fetching prompts", "error", err)
 		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.template")), nil)
 	}
 	return prompts, nil
 }
 
-// UpdateProvider updates a provider.
-func (m *Manager) UpdateProvider(provider, apiKey string) error {
-	switch ProviderType(provider) {
-	case ProviderOpenAI:
-		return m.setOpenAIAPIKey(apiKey)
-	default:
-		m.lo.Error("unsupported provider type", "provider", provider)
-		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.provider")), nil)
+// GetPrompt returns a prompt along with its content.
+func (m *Manager) GetPrompt(key string) (models.Prompt, error) {
+	return m.getPrompt(key)
+}
+
+// UpdatePrompt updates the title and content of a prompt.
+func (m *Manager) UpdatePrompt(key, title, content string) error {
+	if title == "" || len(title) > 140 {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`title`"), nil)
+	}
+	if content == "" {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`content`"), nil)
+	}
+	var id int
+	if err := m.q.UpdatePrompt.Get(&id, key, title, content); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", m.i18n.Ts("globals.terms.template")), nil)
+		}
+		m.lo.Error("error updating prompt", "key", key, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.template")), nil)
+	}
+	return nil
+}
+
+// GetProviders returns the providers with the non-secret parts of their config.
+func (m *Manager) GetProviders() ([]models.Provider, error) {
+	var providers = make([]models.Provider, 0)
+	if err := m.q.GetProviders.Select(&providers); err != nil {
+		m.lo.Error("error fetching providers", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	for i := range providers {
+		var config models.ProviderConfig
+		if err := json.Unmarshal([]byte(providers[i].Config), &config); err != nil {
+			m.lo.Error("error parsing provider config", "provider", providers[i].Name, "error", err)
+			continue
+		}
+		providers[i].Model = config.Model
+		providers[i].BaseURL = config.BaseURL
+		providers[i].HasAPIKey = config.APIKey != ""
 	}
+	return providers, nil
 }
 
-// setOpenAIAPIKey sets the OpenAI API key in the database.
-func (m *Manager) setOpenAIAPIKey(apiKey string) error {
-	// Encrypt API key before storing.
-	encryptedKey, err := crypto.Encrypt(apiKey, m.encryptionKey)
+// UpdateProvider updates the config of a provider and optionally makes it the default provider.
+func (m *Manager) UpdateProvider(name string, update ProviderUpdate) error {
+	var provider ProviderType
+	providers, err := m.GetProviders()
 	if err != nil {
-		m.lo.Error("error encrypting API key", "error", err)
-		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "OpenAI API Key"), nil)
+		return err
+	}
+	for _, p := range providers {
+		if p.Name == name {
+			provider = ProviderType(p.Provider)
+		}
+	}
+
+	config := map[string]string{}
+	switch provider {
+	case ProviderOpenAI, ProviderClaude:
+		if update.Model != "" {
+			config["model"] = update.Model
+		}
+	case ProviderOpenAICompatible:
+		if update.BaseURL != "" {
+			if u, err := url.Parse(update.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`base_url`"), nil)
+			}
+			config["base_url"] = update.BaseURL
+		}
+		if update.Model != "" {
+			config["model"] = update.Model
+		}
+	default:
+		m.lo.Error("unsupported provider type", "provider", name)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+
+	if update.APIKey != "" {
+		// Encrypt API key before storing.
+		encryptedKey, err := crypto.Encrypt(update.APIKey, m.encryptionKey)
+		if err != nil {
+			m.lo.Error("error encrypting API key", "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "API Key"), nil)
+		}
+		config["api_key"] = encryptedKey
+	}
+
+	if len(config) > 0 {
+		b, _ := json.Marshal(config)
+		if _, err := m.q.UpdateProviderConfig.Exec(name, string(b)); err != nil {
+			m.lo.Error("error updating provider config", "provider", name, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
+	}
+
+	if update.IsDefault {
+		// Swap the default provider in one transaction so there is never zero or two defaults.
+		tx, err := m.db.Beginx()
+		if err != nil {
+			m.lo.Error("error beginning default provider transaction", "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Stmtx(m.q.UnsetDefaultProvider).Exec(name); err != nil {
+			m.lo.Error("error unsetting default provider", "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
+		if _, err := tx.Stmtx(m.q.SetDefaultProvider).Exec(name); err != nil {
+			m.lo.Error("error setting default provider", "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
+		if err := tx.Commit(); err != nil {
+			m.lo.Error("error committing default provider", "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
 	}
+	return nil
+}
 
-	if _, err := m.q.SetOpenAIKey.Exec(encryptedKey); err != nil {
-		m.lo.Error("error setting OpenAI API key", "error", err)
-		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "OpenAI API Key"), nil)
+// SetPromptProvider sets the provider a prompt is sent to, an empty provider uses the default provider.
+func (m *Manager) SetPromptProvider(key, provider string) error {
+	if provider != "" {
+		providers, err := m.GetProviders()
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(providers, func(p models.Provider) bool { return p.Name == provider }) {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
+	}
+	var id int
+	if err := m.q.SetPromptProvider.Get(&id, key, provider); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", m.i18n.Ts("globals.terms.template")), nil)
+		}
+		m.lo.Error("error setting prompt provider", "key", key, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.template")), nil)
 	}
 	return nil
 }
 
 // getPrompt returns a prompt from the database.
-func (m *Manager) getPrompt(k string) (string, error) {
+func (m *Manager) getPrompt(k string) (models.Prompt, error) {
 	var p models.Prompt
 	if err := m.q.GetPrompt.Get(&p, k); err != nil {
 		if err == sql.ErrNoRows {
 			m.lo.Error("error prompt not found", "key", k)
-			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.notFound", "name", m.i18n.Ts("globals.terms.template")), nil)
+			return p, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.notFound", "name", m.i18n.Ts("globals.terms.template")), nil)
 		}
 		m.lo.Error("error fetching prompt", "error", err)
-		return "", envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.template")), nil)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.template")), nil)
 	}
-	return p.Content, nil
+	return p, nil
 }
 
-// getDefaultProviderClient returns a ProviderClient for the default provider.
-func (m *Manager) getDefaultProviderClient() (ProviderClient, error) {
-	var p models.Provider
-
-	if err := m.q.GetDefaultProvider.Get(&p); err != nil {
+// getPromptProvider returns the provider picked for the prompt, falling back to the default provider.
+func (m *Manager) getPromptProvider(prompt models.Prompt) (models.Provider, error) {
+	var (
+		p   models.Provider
+		err error
+	)
+	if prompt.ProviderID.Valid {
+		err = m.q.GetProvider.Get(&p, prompt.ProviderID.Int)
+	} else {
+		err = m.q.GetDefaultProvider.Get(&p)
+	}
+	if err != nil {
 		m.lo.Error("error fetching provider details", "error", err)
-		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
 	}
+	return p, nil
+}
 
-	switch ProviderType(p.Provider) {
-	case ProviderOpenAI:
-		config := struct {
-			APIKey string `json:"api_key"`
-		}{}
-		if err := json.Unmarshal([]byte(p.Config), &config); err != nil {
-			m.lo.Error("error parsing provider config", "error", err)
-			return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorParsing", "name", m.i18n.Ts("globals.terms.provider")), nil)
-		}
-		// Decrypt API key.
+// newProviderClient returns a ProviderClient for the provider.
+func (m *Manager) newProviderClient(p models.Provider) (ProviderClient, error) {
+	var config models.ProviderConfig
+	if err := json.Unmarshal([]byte(p.Config), &config); err != nil {
+		m.lo.Error("error parsing provider config", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorParsing", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+
+	// Decrypt API key.
+	var apiKey string
+	if config.APIKey != "" {
 		decryptedKey, err := crypto.Decrypt(config.APIKey, m.encryptionKey)
 		if err != nil {
 			m.lo.Error("error decrypting API key", "error", err)
 			return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
 		}
-		return NewOpenAIClient(decryptedKey, m.lo), nil
+		apiKey = decryptedKey
+	}
+
+	switch ProviderType(p.Provider) {
+	case ProviderOpenAI:
+		client := NewOpenAIClient(apiKey, m.lo)
+		if config.Model != "" {
+			client.model = config.Model
+		}
+		return client, nil
+	case ProviderClaude:
+		return NewClaudeClient(apiKey, "", config.Model, m.lo), nil
+	case ProviderOpenAICompatible:
+		if config.BaseURL == "" || config.Model == "" {
+			return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("ai.providerNotConfigured", "provider", providerLabel(p)), nil)
+		}
+		return NewOpenAICompatibleClient(config.BaseURL, config.Model, apiKey, m.lo), nil
 	default:
 		m.lo.Error("unsupported provider type", "provider", p.Provider)
 		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.provider")), nil)
 	}
 }
+
+// providerLabel returns the display name of a provider used in error messages.
+func providerLabel(p models.Provider) string {
+	switch ProviderType(p.Provider) {
+	case ProviderOpenAI:
+		return "OpenAI"
+	case ProviderClaude:
+		return "Claude"
+	}
+	return p.Name
+}
@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ghotso/libredesk/internal/ai/models"
+)
+
+const (
+	// PromptClassifyMessage is the key of the prompt used by the AI classification automation action.
+	PromptClassifyMessage = "classify_message"
+
+	// Conversation custom attributes the classification is written to.
+	AttributeIntent    = "ai_intent"
+	AttributeSentiment = "ai_sentiment"
+	AttributeLanguage  = "ai_language"
+	AttributeUrgency   = "ai_urgency"
+)
+
+var (
+	sentiments = []string{"positive", "neutral", "negative"}
+	urgencies  = []string{"low", "medium", "high", "urgent"}
+)
+
+// ClassifyMessage returns the intent, sentiment, language and urgency of a message as conversation custom attributes.
+// Results are cached per message so evaluating rules again does not call the provider again.
+func (m *Manager) ClassifyMessage(messageID int, content string) (map[string]string, error) {
+	var c models.Classification
+	err := m.q.GetMessageClassification.Get(&c, messageID)
+	if err == nil {
+		return classificationAttributes(c), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("fetching message classification: %w", err)
+	}
+
+	p, err := m.getPrompt(PromptClassifyMessage)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.complete(p, truncate(strings.TrimSpace(content), m.tokenBudget*charsPerToken))
+	if err != nil {
+		return nil, err
+	}
+	if c, err = parseClassification(resp); err != nil {
+		m.lo.Error("error parsing message classification", "message_id", messageID, "response", resp, "error", err)
+		return nil, err
+	}
+
+	if _, err := m.q.InsertMessageClassification.Exec(messageID, c.Intent, c.Sentiment, c.Language, c.Urgency); err != nil {
+		m.lo.Error("error caching message classification", "message_id", messageID, "error", err)
+	}
+	return classificationAttributes(c), nil
+}
+
+// classificationAttributes returns the classification keyed by the conversation custom attributes it is written to.
+func classificationAttributes(c models.Classification) map[string]string {
+	return map[string]string{
+		AttributeIntent:    c.Intent,
+		AttributeSentiment: c.Sentiment,
+		AttributeLanguage:  c.Language,
+		AttributeUrgency:   c.Urgency,
+	}
+}
+
+// parseClassification parses the JSON classification returned by the provider, values outside
+// the known sentiments and urgencies are left empty.
+func parseClassification(resp string) (models.Classification, error) {
+	var c models.Classification
+
+	// Models tend to wrap JSON in markdown code fences.
+	resp = strings.TrimSpace(resp)
+	if start, end := strings.Index(resp, "{"), strings.LastIndex(resp, "}"); start >= 0 && end > start {
+		resp = resp[start : end+1]
+	}
+	if err := json.Unmarshal([]byte(resp), &c); err != nil {
+		return c, fmt.Errorf("decoding classification: %w", err)
+	}
+
+	c.Intent = strings.ToLower(strings.TrimSpace(c.Intent))
+	c.Sentiment = strings.ToLower(strings.TrimSpace(c.Sentiment))
+	c.Language = strings.ToLower(strings.TrimSpace(c.Language))
+	c.Urgency = strings.ToLower(strings.TrimSpace(c.Urgency))
+	if !slices.Contains(sentiments, c.Sentiment) {
+		c.Sentiment = ""
+	}
+	if !slices.Contains(urgencies, c.Urgency) {
+		c.Urgency = ""
+	}
+	return c, nil
+}
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/ghotso/libredesk/internal/ai/models"
+)
+
+func TestParseClassification(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    string
+		want    models.Classification
+		wantErr bool
+	}{
+		{
+			name: "plain json",
+			resp: `{"intent":"refund","sentiment":"negative","language":"de","urgency":"high"}`,
+			want: models.Classification{Intent: "refund", Sentiment: "negative", Language: "de", Urgency: "high"},
+		},
+		{
+			name: "code fence and casing",
+			resp: "```json\n{\"intent\":\" Bug_Report \",\"sentiment\":\"Neutral\",\"language\":\"EN\",\"urgency\":\"Urgent\"}\n```",
+			want: models.Classification{Intent: "bug_report", Sentiment: "neutral", Language: "en", Urgency: "urgent"},
+		},
+		{
+			name: "unknown sentiment and urgency dropped",
+			resp: `{"intent":"billing","sentiment":"furious","language":"fr","urgency":"asap"}`,
+			want: models.Classification{Intent: "billing", Language: "fr"},
+		},
+		{
+			name:    "not json",
+			resp:    "The customer seems upset.",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseClassification(tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
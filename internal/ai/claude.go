@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zerodha/logf"
+)
+
+const (
+	claudeBaseURL    = "https://api.anthropic.com"
+	claudeModel      = "claude-sonnet-4-5"
+	anthropicVersion = "2023-06-01"
+)
+
+// ClaudeClient talks to the Anthropic Messages API.
+type ClaudeClient struct {
+	apikey  string
+	baseURL string
+	model   string
+	lo      *logf.Logger
+	client  *http.Client
+}
+
+// NewClaudeClient returns a client for the Anthropic Messages API.
+// Empty baseURL and model fall back to the public API and the default model.
+func NewClaudeClient(apiKey, baseURL, model string, lo *logf.Logger) *ClaudeClient {
+	if baseURL == "" {
+		baseURL = claudeBaseURL
+	}
+	if model == "" {
+		model = claudeModel
+	}
+	return &ClaudeClient{
+		apikey:  apiKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		lo:      lo,
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// SendPrompt sends a prompt to the Messages API and returns the text of the response.
+func (c *ClaudeClient) SendPrompt(payload PromptPayload) (string, error) {
+	if c.apikey == "" {
+		return "", ErrApiKeyNotSet
+	}
+
+	requestBody := map[string]any{
+		"model":      c.model,
+		"max_tokens": 1024,
+		"system":     payload.SystemPrompt,
+		"messages": []map[string]string{
+			{"role": "user", "content": payload.UserPrompt},
+		},
+		"temperature": 0.7,
+	}
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("marshalling request body: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/v1/messages", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("x-api-key", c.apikey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.lo.Error("error making HTTP request", "error", err)
+		return "", fmt.Errorf("making HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", ErrInvalidAPIKey
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		c.lo.Error("non-ok response received from claude API", "status", resp.Status, "code", resp.StatusCode, "response_text", body)
+		return "", fmt.Errorf("API error: %s, body: %s", resp.Status, body)
+	}
+
+	var responseBody struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
+		return "", fmt.Errorf("decoding response body: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range responseBody.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response found")
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zerodha/logf"
+)
+
+func TestClaudeSendPrompt(t *testing.T) {
+	var got struct {
+		Model    string `json:"model"`
+		System   string `json:"system"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("got path %q, want /v1/messages", r.URL.Path)
+		}
+		if k := r.Header.Get("x-api-key"); k != "sk-test" {
+			t.Errorf("got api key %q, want sk-test", k)
+		}
+		if v := r.Header.Get("anthropic-version"); v != anthropicVersion {
+			t.Errorf("got version %q, want %q", v, anthropicVersion)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("error decoding request: %v", err)
+		}
+		w.Write([]byte(`{"content":[{"type":"text","text":"Hello, "},{"type":"text","text":"how can I help?"}]}`))
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	c := NewClaudeClient("sk-test", srv.URL, "", &lo)
+	resp, err := c.SendPrompt(PromptPayload{SystemPrompt: "Make it friendly.", UserPrompt: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp != "Hello, how can I help?" {
+		t.Errorf("got response %q", resp)
+	}
+	if got.Model != claudeModel || got.System != "Make it friendly." {
+		t.Errorf("got model %q and system %q", got.Model, got.System)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Role != "user" || got.Messages[0].Content != "hi" {
+		t.Errorf("got messages %+v", got.Messages)
+	}
+}
+
+func TestClaudeSendPromptErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	if _, err := NewClaudeClient("", srv.URL, "", &lo).SendPrompt(PromptPayload{}); !errors.Is(err, ErrApiKeyNotSet) {
+		t.Errorf("got error %v, want ErrApiKeyNotSet", err)
+	}
+	if _, err := NewClaudeClient("sk-bad", srv.URL, "", &lo).SendPrompt(PromptPayload{}); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("got error %v, want ErrInvalidAPIKey", err)
+	}
+}
@@ -0,0 +1,330 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/ai/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/stringutil"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// Sources of the indexed texts.
+	EmbeddingSourceConversation = "conversation"
+	EmbeddingSourceMacro        = "macro"
+
+	defaultEmbeddingProvider  = "openai"
+	defaultEmbeddingModel     = "text-embedding-3-small"
+	defaultEmbeddingTopK      = 3
+	defaultEmbeddingBatchSize = 50
+
+	// maxEmbeddingChars caps the text of a single source sent to the embeddings API.
+	maxEmbeddingChars = 8000
+
+	// maxEmbeddingCandidates caps the embeddings compared in the app when pgvector is not installed.
+	maxEmbeddingCandidates = 5000
+
+	// maxReferenceChars caps each reference included in the suggested reply prompt.
+	maxReferenceChars = 1500
+)
+
+// EmbeddingOpts configures the embeddings index of resolved conversations and macros cited in suggested replies.
+type EmbeddingOpts struct {
+	Enabled bool
+	// Provider is the name of the AI provider texts are embedded with, it has to support embeddings.
+	Provider      string
+	Model         string
+	TopK          int
+	MinSimilarity float64
+	BatchSize     int
+}
+
+// RunIndexer indexes newly resolved conversations and updated macros every interval until the context is cancelled.
+func (m *Manager) RunIndexer(ctx context.Context, interval time.Duration) {
+	if !m.embeddings.Enabled {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Reindex(ctx); err != nil {
+			m.lo.Error("error indexing embeddings", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reindex removes embeddings of deleted sources and embeds the resolved conversations and macros
+// that are not indexed with the configured model yet or have changed since.
+func (m *Manager) Reindex(ctx context.Context) error {
+	client, err := m.embeddingClient()
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.q.DeleteStaleEmbeddings.Exec(); err != nil {
+		return fmt.Errorf("deleting stale embeddings: %w", err)
+	}
+
+	for _, s := range []struct {
+		sourceType string
+		stmt       *sqlx.Stmt
+	}{
+		{EmbeddingSourceConversation, m.q.GetConversationsToEmbed},
+		{EmbeddingSourceMacro, m.q.GetMacrosToEmbed},
+	} {
+		for ctx.Err() == nil {
+			var sources []models.EmbeddingSource
+			if err := s.stmt.Select(&sources, m.embeddings.BatchSize, m.embeddings.Model); err != nil {
+				return fmt.Errorf("fetching %s sources: %w", s.sourceType, err)
+			}
+			if len(sources) == 0 {
+				break
+			}
+			if err := m.indexSources(client, s.sourceType, sources); err != nil {
+				return err
+			}
+			m.lo.Info("indexed embeddings", "source_type", s.sourceType, "count", len(sources))
+			if len(sources) < m.embeddings.BatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// indexSources embeds a batch of sources and stores the embeddings.
+func (m *Manager) indexSources(client EmbeddingClient, sourceType string, sources []models.EmbeddingSource) error {
+	texts := make([]string, len(sources))
+	for i, s := range sources {
+		texts[i] = embeddingText(sourceType, s)
+	}
+
+	vectors, err := client.Embed(m.embeddings.Model, texts)
+	if err != nil {
+		return fmt.Errorf("embedding %s sources: %w", sourceType, err)
+	}
+
+	for i, s := range sources {
+		if _, err := m.q.UpsertEmbedding.Exec(sourceType, s.SourceID, s.Title, texts[i], pq.Float64Array(vectors[i]), m.embeddings.Model, s.SourceUpdatedAt); err != nil {
+			return fmt.Errorf("storing embedding of %s %d: %w", sourceType, s.SourceID, err)
+		}
+	}
+	return nil
+}
+
+// SuggestReply sends the conversation thread with the suggested reply prompt, along with the closest
+// resolved conversations the agent can read and macros from the embeddings index which the reply is asked to cite.
+func (m *Manager) SuggestReply(thread ThreadContext, access models.ConversationAccess) (models.SuggestedReply, error) {
+	var out = models.SuggestedReply{Sources: []models.EmbeddingMatch{}}
+
+	p, err := m.getPrompt(PromptSuggestedReply)
+	if err != nil {
+		return out, err
+	}
+
+	prompt := buildThreadPrompt(thread, m.tokenBudget)
+	if m.embeddings.Enabled {
+		// Retrieval is best effort, a reply is still suggested without references.
+		matches, err := m.findMatches(thread, access)
+		if err != nil {
+			m.lo.Error("error finding similar conversations and macros", "error", err)
+		}
+		if len(matches) > 0 {
+			prompt += buildReferencesPrompt(matches)
+			for _, match := range matches {
+				out.Sources = append(out.Sources, match.EmbeddingMatch)
+			}
+		}
+	}
+
+	if out.Reply, err = m.complete(p, prompt); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// scoredEmbedding is an indexed source along with its similarity to the conversation.
+type scoredEmbedding struct {
+	models.EmbeddingMatch
+	Content string
+}
+
+// findMatches returns the indexed sources the agent can read most similar to what the contact wrote in the thread.
+func (m *Manager) findMatches(thread ThreadContext, access models.ConversationAccess) ([]scoredEmbedding, error) {
+	query := retrievalQuery(thread)
+	if query == "" {
+		return nil, nil
+	}
+
+	client, err := m.embeddingClient()
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := client.Embed(m.embeddings.Model, []string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	// The conversation itself is skipped in case it was indexed before it was reopened.
+	args := []any{
+		m.embeddings.Model, thread.ConversationUUID,
+		access.ReadAll, access.ReadAssigned, access.ReadTeamAll, access.ReadTeamInbox, access.ReadUnassigned,
+		access.UserID, pq.Array(access.TeamIDs),
+	}
+	var embeddings []models.Embedding
+
+	// With pgvector the database ranks the embeddings, else similarity is computed here over plain float arrays
+	// of the most recently indexed sources.
+	if m.pgvector {
+		args = append(args, m.embeddings.TopK, pq.Float64Array(vectors[0]))
+		if err := m.db.Select(&embeddings, m.q.GetEmbeddingsPGVector, args...); err != nil {
+			return nil, fmt.Errorf("fetching embeddings: %w", err)
+		}
+		return rankedMatches(embeddings, m.embeddings.MinSimilarity), nil
+	}
+
+	args = append(args, maxEmbeddingCandidates)
+	if err := m.q.GetEmbeddings.Select(&embeddings, args...); err != nil {
+		return nil, fmt.Errorf("fetching embeddings: %w", err)
+	}
+	return topMatches(vectors[0], embeddings, m.embeddings.TopK, m.embeddings.MinSimilarity), nil
+}
+
+// embeddingClient returns the client of the provider configured for embeddings.
+func (m *Manager) embeddingClient() (EmbeddingClient, error) {
+	var p models.Provider
+	if err := m.q.GetProviderByName.Get(&p, m.embeddings.Provider); err != nil {
+		m.lo.Error("error fetching embeddings provider", "provider", m.embeddings.Provider, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	client, err := m.newProviderClient(p)
+	if err != nil {
+		return nil, err
+	}
+	ec, ok := client.(EmbeddingClient)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support embeddings", p.Name)
+	}
+	return ec, nil
+}
+
+// embeddingText returns the text of a source that is embedded and later cited.
+func embeddingText(sourceType string, s models.EmbeddingSource) string {
+	var text string
+	switch sourceType {
+	case EmbeddingSourceMacro:
+		text = s.Title + "\n" + stringutil.HTML2Text(s.Answer)
+	default:
+		text = "Question: " + strings.TrimSpace(s.Question) + "\nAnswer: " + strings.TrimSpace(s.Answer)
+	}
+	return truncate(text, maxEmbeddingChars)
+}
+
+// retrievalQuery returns the subject and the latest messages from the contact, which are matched against the index.
+func retrievalQuery(thread ThreadContext) string {
+	var parts []string
+	size := len(thread.Subject)
+	for i := len(thread.Messages) - 1; i >= 0 && size < maxEmbeddingChars; i-- {
+		if msg := thread.Messages[i]; msg.Role == RoleCustomer {
+			parts = append([]string{strings.TrimSpace(msg.Content)}, parts...)
+			size += len(msg.Content)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	if thread.Subject != "" {
+		parts = append([]string{thread.Subject}, parts...)
+	}
+	return truncate(strings.Join(parts, "\n\n"), maxEmbeddingChars)
+}
+
+// buildReferencesPrompt renders the matches as numbered references the reply is asked to cite.
+func buildReferencesPrompt(matches []scoredEmbedding) string {
+	var b strings.Builder
+	b.WriteString("\nReferences from previously resolved conversations and saved replies. Use them where relevant and cite each one you use as [n]:\n\n")
+	for i, match := range matches {
+		fmt.Fprintf(&b, "[%d] %s\n%s\n\n", i+1, match.Title, strings.TrimSpace(truncate(match.Content, maxReferenceChars)))
+	}
+	return b.String()
+}
+
+// rankedMatches returns the embeddings ranked by the database that are at least min similar.
+func rankedMatches(embeddings []models.Embedding, min float64) []scoredEmbedding {
+	var out []scoredEmbedding
+	for _, e := range embeddings {
+		if e.Similarity < min {
+			continue
+		}
+		out = append(out, scoredEmbedding{
+			EmbeddingMatch: models.EmbeddingMatch{
+				SourceType:       e.SourceType,
+				SourceID:         e.SourceID,
+				Title:            e.Title,
+				ConversationUUID: e.ConversationUUID,
+				ReferenceNumber:  e.ReferenceNumber,
+				Similarity:       e.Similarity,
+			},
+			Content: e.Content,
+		})
+	}
+	return out
+}
+
+// topMatches returns up to k embeddings most similar to the query, at least min similar, most similar first.
+func topMatches(query []float64, embeddings []models.Embedding, k int, min float64) []scoredEmbedding {
+	var out []scoredEmbedding
+	for _, e := range embeddings {
+		sim := cosineSimilarity(query, e.Embedding)
+		if sim < min {
+			continue
+		}
+		out = append(out, scoredEmbedding{
+			EmbeddingMatch: models.EmbeddingMatch{
+				SourceType:       e.SourceType,
+				SourceID:         e.SourceID,
+				Title:            e.Title,
+				ConversationUUID: e.ConversationUUID,
+				ReferenceNumber:  e.ReferenceNumber,
+				Similarity:       sim,
+			},
+			Content: e.Content,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, 0 if they differ in length or either is zero.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
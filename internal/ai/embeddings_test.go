@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ghotso/libredesk/internal/ai/models"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{"identical", []float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+		{"scaled", []float64{1, 2, 3}, []float64{2, 4, 6}, 1},
+		{"orthogonal", []float64{1, 0}, []float64{0, 1}, 0},
+		{"opposite", []float64{1, 0}, []float64{-1, 0}, -1},
+		{"length mismatch", []float64{1, 0}, []float64{1, 0, 0}, 0},
+		{"zero vector", []float64{0, 0}, []float64{1, 0}, 0},
+		{"empty", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopMatches(t *testing.T) {
+	embeddings := []models.Embedding{
+		{SourceType: EmbeddingSourceMacro, SourceID: 1, Title: "far", Embedding: []float64{0, 1}},
+		{SourceType: EmbeddingSourceConversation, SourceID: 2, Title: "close", Embedding: []float64{1, 0.1}},
+		{SourceType: EmbeddingSourceMacro, SourceID: 3, Title: "closest", Embedding: []float64{1, 0}},
+		{SourceType: EmbeddingSourceConversation, SourceID: 4, Title: "near", Embedding: []float64{1, 0.5}},
+	}
+
+	got := topMatches([]float64{1, 0}, embeddings, 2, 0.3)
+	if len(got) != 2 || got[0].Title != "closest" || got[1].Title != "close" {
+		t.Errorf("got %+v", got)
+	}
+
+	// The minimum similarity drops the orthogonal match even when k allows more.
+	got = topMatches([]float64{1, 0}, embeddings, 10, 0.3)
+	if len(got) != 3 {
+		t.Errorf("got %d matches, want 3", len(got))
+	}
+}
+
+func TestRankedMatches(t *testing.T) {
+	embeddings := []models.Embedding{
+		{SourceType: EmbeddingSourceMacro, SourceID: 1, Title: "closest", Similarity: 0.9},
+		{SourceType: EmbeddingSourceConversation, SourceID: 2, Title: "close", Similarity: 0.5},
+		{SourceType: EmbeddingSourceMacro, SourceID: 3, Title: "far", Similarity: 0.1},
+	}
+	got := rankedMatches(embeddings, 0.3)
+	if len(got) != 2 || got[0].Title != "closest" || got[1].Title != "close" || got[1].Similarity != 0.5 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestRetrievalQuery(t *testing.T) {
+	thread := ThreadContext{
+		Subject: "Login issue",
+		Messages: []ThreadMessage{
+			{Role: RoleCustomer, Content: "I can't log in."},
+			{Role: RoleAgent, Content: "Have you tried resetting your password?"},
+			{Role: RoleNote, Content: "Account is locked."},
+			{Role: RoleCustomer, Content: "Yes, the reset email never arrives."},
+		},
+	}
+	got := retrievalQuery(thread)
+	want := "Login issue\n\nI can't log in.\n\nYes, the reset email never arrives."
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if strings.Contains(got, "locked") {
+		t.Error("query contains private note")
+	}
+
+	if got := retrievalQuery(ThreadContext{Subject: "Hi", Messages: []ThreadMessage{{Role: RoleAgent, Content: "Hello"}}}); got != "" {
+		t.Errorf("got %q, want empty query without contact messages", got)
+	}
+}
@@ -1,22 +1,99 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
 
 type Provider struct {
-	ID        string    `db:"id"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
-	Name      string    `db:"name"`
-	Provider  string    `db:"provider"`
-	Config    string    `db:"config"`
-	IsDefault bool      `db:"is_default"`
+	ID        string    `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	Name      string    `db:"name" json:"name"`
+	Provider  string    `db:"provider" json:"provider"`
+	Config    string    `db:"config" json:"-"`
+	IsDefault bool      `db:"is_default" json:"is_default"`
+
+	// Non-secret parts of the config returned to admins.
+	Model     string `db:"-" json:"model"`
+	BaseURL   string `db:"-" json:"base_url"`
+	HasAPIKey bool   `db:"-" json:"has_api_key"`
+}
+
+// ProviderConfig is the config of a provider, the API key is stored encrypted.
+type ProviderConfig struct {
+	APIKey  string `json:"api_key"`
+	Model   string `json:"model,omitempty"`
+	BaseURL string `json:"base_url,omitempty"`
 }
 
 type Prompt struct {
-	ID        int       `db:"id" json:"id"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
-	Title     string    `db:"title" json:"title"`
-	Key       string    `db:"key" json:"key"`
-	Content   string    `db:"content" json:"content,omitempty"`
+	ID         int       `db:"id" json:"id"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	Title      string    `db:"title" json:"title"`
+	Key        string    `db:"key" json:"key"`
+	Kind       string    `db:"kind" json:"kind"`
+	Content    string    `db:"content" json:"content,omitempty"`
+	ProviderID null.Int  `db:"provider_id" json:"provider_id"`
+}
+
+// EmbeddingSource is a resolved conversation or macro to be indexed.
+type EmbeddingSource struct {
+	SourceID        int       `db:"source_id"`
+	SourceUpdatedAt time.Time `db:"source_updated_at"`
+	Title           string    `db:"title"`
+	Question        string    `db:"question"`
+	Answer          string    `db:"answer"`
+}
+
+// Embedding is an indexed resolved conversation or macro.
+type Embedding struct {
+	ID               int             `db:"id"`
+	SourceType       string          `db:"source_type"`
+	SourceID         int             `db:"source_id"`
+	Title            string          `db:"title"`
+	Content          string          `db:"content"`
+	Embedding        pq.Float64Array `db:"embedding"`
+	ConversationUUID string          `db:"conversation_uuid"`
+	ReferenceNumber  string          `db:"reference_number"`
+	// Similarity is only set when ranked by the database.
+	Similarity float64 `db:"similarity"`
+}
+
+// ConversationAccess is the conversations an agent can read, resolved conversations are only cited to agents who can read them.
+type ConversationAccess struct {
+	UserID         int
+	TeamIDs        []int
+	ReadAll        bool
+	ReadAssigned   bool
+	ReadTeamAll    bool
+	ReadTeamInbox  bool
+	ReadUnassigned bool
+}
+
+// EmbeddingMatch is an indexed conversation or macro cited in a suggested reply.
+type EmbeddingMatch struct {
+	SourceType       string  `json:"source_type"`
+	SourceID         int     `json:"source_id"`
+	Title            string  `json:"title"`
+	ConversationUUID string  `json:"conversation_uuid,omitempty"`
+	ReferenceNumber  string  `json:"reference_number,omitempty"`
+	Similarity       float64 `json:"similarity"`
+}
+
+// SuggestedReply is a suggested reply along with the indexed conversations and macros it cites.
+type SuggestedReply struct {
+	Reply   string           `json:"reply"`
+	Sources []EmbeddingMatch `json:"sources"`
+}
+
+// Classification is the intent, sentiment, language and urgency of a message.
+type Classification struct {
+	Intent    string `db:"intent" json:"intent"`
+	Sentiment string `db:"sentiment" json:"sentiment"`
+	Language  string `db:"language" json:"language"`
+	Urgency   string `db:"urgency" json:"urgency"`
 }
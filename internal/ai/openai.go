@@ -6,35 +6,56 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/logf"
 )
 
+const (
+	openAIBaseURL = "https://api.openai.com/v1"
+	openAIModel   = "gpt-4o-mini"
+)
+
+// OpenAIClient talks to the OpenAI chat completions API or any server that implements it, e.g. Ollama or vLLM.
 type OpenAIClient struct {
-	apikey string
-	lo     *logf.Logger
-	client *http.Client
+	apikey     string
+	baseURL    string
+	model      string
+	requireKey bool
+	lo         *logf.Logger
+	client     *http.Client
 }
 
+// NewOpenAIClient returns a client for the OpenAI API.
 func NewOpenAIClient(apiKey string, lo *logf.Logger) *OpenAIClient {
+	c := NewOpenAICompatibleClient(openAIBaseURL, openAIModel, apiKey, lo)
+	c.requireKey = true
+	return c
+}
+
+// NewOpenAICompatibleClient returns a client for a self-hosted OpenAI compatible server.
+// baseURL is the URL the `/chat/completions` path is appended to, e.g. `http://localhost:11434/v1`.
+// The API key is optional as local servers usually do not require one.
+func NewOpenAICompatibleClient(baseURL, model, apiKey string, lo *logf.Logger) *OpenAIClient {
 	return &OpenAIClient{
-		apikey: apiKey,
-		lo:     lo,
-		client: &http.Client{Timeout: 10 * time.Second},
+		apikey:  apiKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		lo:      lo,
+		client:  &http.Client{Timeout: requestTimeout},
 	}
 }
 
-// SendPrompt sends a prompt to the OpenAI API and returns the response text.
+// SendPrompt sends a prompt to the chat completions API and returns the response text.
 func (o *OpenAIClient) SendPrompt(payload PromptPayload) (string, error) {
-	if o.apikey == "" {
+	if o.requireKey && o.apikey == "" {
 		return "", ErrApiKeyNotSet
 	}
 
-	apiURL := "https://api.openai.com/v1/chat/completions"
+	apiURL := o.baseURL + "/chat/completions"
 	requestBody := map[string]interface{}{
-		"model": "gpt-4o-mini",
+		"model": o.model,
 		"messages": []map[string]string{
 			{"role": "system", "content": payload.SystemPrompt},
 			{"role": "user", "content": payload.UserPrompt},
@@ -55,7 +76,9 @@ func (o *OpenAIClient) SendPrompt(payload PromptPayload) (string, error) {
 		return "", fmt.Errorf("error creating request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+o.apikey)
+	if o.apikey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apikey)
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := o.client.Do(req)
@@ -91,3 +114,64 @@ func (o *OpenAIClient) SendPrompt(payload PromptPayload) (string, error) {
 	}
 	return "", fmt.Errorf("no response found")
 }
+
+// Embed returns the embedding vectors of the texts from the embeddings API, in the order of the texts.
+func (o *OpenAIClient) Embed(model string, texts []string) ([][]float64, error) {
+	if o.requireKey && o.apikey == "" {
+		return nil, ErrApiKeyNotSet
+	}
+
+	bodyBytes, err := json.Marshal(map[string]any{
+		"model": model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling request body: %w", err)
+	}
+
+	req, err := http.NewRequest(fasthttp.MethodPost, o.baseURL+"/embeddings", bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	if o.apikey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apikey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("making HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrInvalidAPIKey
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		o.lo.Error("non-ok response received from embeddings API", "status", resp.Status, "code", resp.StatusCode, "response_text", body)
+		return nil, fmt.Errorf("API error: %s, body: %s", resp.Status, body)
+	}
+
+	var responseBody struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
+		return nil, fmt.Errorf("decoding response body: %w", err)
+	}
+	if len(responseBody.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(responseBody.Data), len(texts))
+	}
+
+	out := make([][]float64, len(texts))
+	for _, d := range responseBody.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("invalid embedding index %d", d.Index)
+		}
+		out[d.Index] = d.Embedding
+	}
+	return out, nil
+}
@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zerodha/logf"
+)
+
+func TestOpenAICompatibleSendPrompt(t *testing.T) {
+	var (
+		got struct {
+			Model    string              `json:"model"`
+			Messages []map[string]string `json:"messages"`
+		}
+		auth string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("got path %q, want /v1/chat/completions", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("error decoding request: %v", err)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"Sure thing!"}}]}`))
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+
+	// Local servers such as Ollama do not need an API key.
+	c := NewOpenAICompatibleClient(srv.URL+"/v1/", "llama3.1", "", &lo)
+	resp, err := c.SendPrompt(PromptPayload{SystemPrompt: "Be concise.", UserPrompt: "hello"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp != "Sure thing!" {
+		t.Errorf("got response %q", resp)
+	}
+	if got.Model != "llama3.1" {
+		t.Errorf("got model %q, want llama3.1", got.Model)
+	}
+	if len(got.Messages) != 2 || got.Messages[0]["role"] != "system" || got.Messages[1]["content"] != "hello" {
+		t.Errorf("got messages %+v", got.Messages)
+	}
+	if auth != "" {
+		t.Errorf("got authorization %q, want none", auth)
+	}
+
+	c = NewOpenAICompatibleClient(srv.URL+"/v1", "llama3.1", "secret", &lo)
+	if _, err := c.SendPrompt(PromptPayload{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("got authorization %q, want Bearer secret", auth)
+	}
+}
+
+func TestOpenAIRequiresAPIKey(t *testing.T) {
+	lo := logf.New(logf.Opts{})
+	if _, err := NewOpenAIClient("", &lo).SendPrompt(PromptPayload{}); !errors.Is(err, ErrApiKeyNotSet) {
+		t.Errorf("got error %v, want ErrApiKeyNotSet", err)
+	}
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	var got struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("got path %q, want /v1/embeddings", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("error decoding request: %v", err)
+		}
+		// Out of order on purpose, the index decides the position.
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	c := NewOpenAICompatibleClient(srv.URL+"/v1", "llama3.1", "", &lo)
+	vecs, err := c.Embed("nomic-embed-text", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Model != "nomic-embed-text" || len(got.Input) != 2 {
+		t.Errorf("got request %+v", got)
+	}
+	if len(vecs) != 2 || vecs[0][0] != 1 || vecs[1][1] != 1 {
+		t.Errorf("got embeddings %v", vecs)
+	}
+
+	if _, err := c.Embed("nomic-embed-text", []string{"a", "b", "c"}); err == nil {
+		t.Error("expected error for mismatched embedding count")
+	}
+}
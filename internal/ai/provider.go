@@ -1,18 +1,29 @@
 package ai
 
+import "time"
+
 // ProviderClient is the interface all providers should implement.
 type ProviderClient interface {
 	SendPrompt(payload PromptPayload) (string, error)
 }
 
+// EmbeddingClient is implemented by providers that can turn text into embedding vectors.
+type EmbeddingClient interface {
+	Embed(model string, texts []string) ([][]float64, error)
+}
+
 // ProviderType is an enum-like type for different providers.
 type ProviderType string
 
 const (
-	ProviderOpenAI ProviderType = "openai"
-	ProviderClaude ProviderType = "claude"
+	ProviderOpenAI           ProviderType = "openai"
+	ProviderClaude           ProviderType = "claude"
+	ProviderOpenAICompatible ProviderType = "openai_compatible"
 )
 
+// requestTimeout is the timeout of a single completion request to a provider.
+const requestTimeout = 60 * time.Second
+
 // PromptPayload represents the structured input for an LLM provider.
 type PromptPayload struct {
 	SystemPrompt string `json:"system_prompt"`
@@ -1,17 +1,140 @@
 -- name: get-default-provider
-SELECT id, name, provider, config, is_default FROM ai_providers where is_default is true;
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers where is_default is true;
+
+-- name: get-provider
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers where id = $1;
+
+-- name: get-providers
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers order by id;
 
 -- name: get-prompt
-SELECT id, created_at, updated_at, key, title, content FROM ai_prompts where key = $1;
+SELECT id, created_at, updated_at, key, title, kind, content, provider_id FROM ai_prompts where key = $1;
 
 -- name: get-prompts
-SELECT id, created_at, updated_at, key, title FROM ai_prompts order by title;
-
--- name: set-openai-key
-UPDATE ai_providers 
-SET config = jsonb_set(
-    COALESCE(config, '{}'::jsonb),
-    '{api_key}', 
-    to_jsonb($1::text)
-) 
-WHERE provider = 'openai';
+SELECT id, created_at, updated_at, key, title, kind, provider_id FROM ai_prompts where kind = $1 order by title;
+
+-- name: update-prompt
+UPDATE ai_prompts
+SET title = $2,
+    content = $3,
+    updated_at = now()
+WHERE key = $1
+RETURNING id;
+
+-- name: update-provider-config
+UPDATE ai_providers
+SET config = COALESCE(config, '{}'::jsonb) || $2::jsonb,
+    updated_at = now()
+WHERE name = $1;
+
+-- name: unset-default-provider
+UPDATE ai_providers SET is_default = false, updated_at = now() WHERE is_default = true AND name <> $1;
+
+-- name: set-default-provider
+UPDATE ai_providers SET is_default = true, updated_at = now() WHERE name = $1;
+
+-- name: set-prompt-provider
+UPDATE ai_prompts
+SET provider_id = (SELECT id FROM ai_providers WHERE name = $2),
+    updated_at = now()
+WHERE key = $1
+RETURNING id;
+
+-- name: get-provider-by-name
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers where name = $1;
+
+-- name: get-conversations-to-embed
+-- Resolved conversations that are not indexed with the model yet or were resolved again, as question and answer pairs.
+SELECT * FROM (
+    SELECT c.id AS source_id, c.resolved_at AS source_updated_at, COALESCE(c.subject, '') AS title,
+        COALESCE((SELECT m.text_content FROM conversation_messages m
+            WHERE m.conversation_id = c.id AND m.type = 'incoming' AND m.private = false
+            ORDER BY m.created_at LIMIT 1), '') AS question,
+        COALESCE((SELECT m.text_content FROM conversation_messages m
+            WHERE m.conversation_id = c.id AND m.type = 'outgoing' AND m.private = false AND m.sender_type = 'agent'
+            ORDER BY m.created_at DESC LIMIT 1), '') AS answer
+    FROM conversations c
+    JOIN conversation_statuses s ON s.id = c.status_id
+    LEFT JOIN ai_embeddings e ON e.source_type = 'conversation' AND e.source_id = c.id
+    WHERE s.name = 'Resolved' AND c.resolved_at IS NOT NULL
+    AND (e.id IS NULL OR e.model <> $2 OR e.source_updated_at < c.resolved_at)
+    ORDER BY c.resolved_at
+) pairs
+WHERE question <> '' AND answer <> ''
+LIMIT $1;
+
+-- name: get-macros-to-embed
+-- Macros visible to everyone that are not indexed with the model yet or were updated since.
+SELECT m.id AS source_id, m.updated_at AS source_updated_at, m.name AS title, '' AS question, m.message_content AS answer
+FROM macros m
+LEFT JOIN ai_embeddings e ON e.source_type = 'macro' AND e.source_id = m.id
+WHERE m.visibility = 'all' AND m.message_content <> ''
+AND (e.id IS NULL OR e.model <> $2 OR e.source_updated_at < m.updated_at)
+ORDER BY m.updated_at
+LIMIT $1;
+
+-- name: upsert-embedding
+INSERT INTO ai_embeddings (source_type, source_id, title, content, embedding, model, source_updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (source_type, source_id) DO UPDATE
+SET title = EXCLUDED.title,
+    content = EXCLUDED.content,
+    embedding = EXCLUDED.embedding,
+    model = EXCLUDED.model,
+    source_updated_at = EXCLUDED.source_updated_at,
+    updated_at = now();
+
+-- name: delete-stale-embeddings
+DELETE FROM ai_embeddings e
+WHERE (e.source_type = 'macro' AND NOT EXISTS (SELECT 1 FROM macros m WHERE m.id = e.source_id AND m.visibility = 'all'))
+OR (e.source_type = 'conversation' AND NOT EXISTS (SELECT 1 FROM conversations c WHERE c.id = e.source_id));
+
+-- name: get-embeddings
+-- Embeddings of the model, macros and the conversations the agent can read other than the given one, most recently indexed first.
+-- $3 to $7 are the read_all, read_assigned, read_team_all, read_team_inbox and read_unassigned permissions of the agent.
+SELECT e.id, e.source_type, e.source_id, e.title, e.content, e.embedding, COALESCE(c.uuid::TEXT, '') AS conversation_uuid, COALESCE(c.reference_number, '') AS reference_number
+FROM ai_embeddings e
+LEFT JOIN conversations c ON e.source_type = 'conversation' AND c.id = e.source_id
+WHERE e.model = $1
+AND (c.id IS NULL OR c.uuid::TEXT <> $2)
+AND (
+    e.source_type = 'macro'
+    OR $3::BOOLEAN
+    OR ($4::BOOLEAN AND c.assigned_user_id = $8)
+    OR ($5::BOOLEAN AND c.assigned_team_id = ANY($9::INT[]))
+    OR ($6::BOOLEAN AND c.assigned_team_id = ANY($9::INT[]) AND c.assigned_user_id IS NULL)
+    OR ($7::BOOLEAN AND c.assigned_team_id IS NULL AND c.assigned_user_id IS NULL)
+)
+ORDER BY e.updated_at DESC
+LIMIT $10;
+
+-- name: get-embeddings-pgvector
+-- Same as get-embeddings but ranked by the database with the pgvector extension, $11 is the query vector.
+-- Kept as text and not prepared as the vector type only exists once the extension is installed.
+SELECT e.id, e.source_type, e.source_id, e.title, e.content, COALESCE(c.uuid::TEXT, '') AS conversation_uuid, COALESCE(c.reference_number, '') AS reference_number,
+    1 - (e.embedding::vector <=> $11::FLOAT8[]::vector) AS similarity
+FROM ai_embeddings e
+LEFT JOIN conversations c ON e.source_type = 'conversation' AND c.id = e.source_id
+WHERE e.model = $1
+AND (c.id IS NULL OR c.uuid::TEXT <> $2)
+AND (
+    e.source_type = 'macro'
+    OR $3::BOOLEAN
+    OR ($4::BOOLEAN AND c.assigned_user_id = $8)
+    OR ($5::BOOLEAN AND c.assigned_team_id = ANY($9::INT[]))
+    OR ($6::BOOLEAN AND c.assigned_team_id = ANY($9::INT[]) AND c.assigned_user_id IS NULL)
+    OR ($7::BOOLEAN AND c.assigned_team_id IS NULL AND c.assigned_user_id IS NULL)
+)
+ORDER BY e.embedding::vector <=> $11::FLOAT8[]::vector
+LIMIT $10;
+
+-- name: pgvector-installed
+SELECT EXISTS(SELECT 1 FROM pg_extension WHERE extname = 'vector');
+
+-- name: get-message-classification
+SELECT intent, sentiment, language, urgency FROM ai_message_classifications WHERE message_id = $1;
+
+-- name: insert-message-classification
+INSERT INTO ai_message_classifications (message_id, intent, sentiment, language, urgency)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (message_id) DO NOTHING;
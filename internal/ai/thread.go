@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Keys of the prompts that are sent with a conversation thread.
+	PromptConversationSummary = "conversation_summary"
+	PromptSuggestedReply      = "suggested_reply"
+
+	// Prompt kinds, rewrite prompts are applied to text typed by the agent.
+	PromptKindRewrite        = "rewrite"
+	PromptKindConversation   = "conversation"
+	PromptKindClassification = "classification"
+
+	// Thread message roles.
+	RoleCustomer = "customer"
+	RoleAgent    = "agent"
+	RoleNote     = "private note"
+
+	// charsPerToken is a rough estimate used to keep the thread within the token budget.
+	charsPerToken = 4
+
+	// DefaultThreadTokenBudget is the default token budget of a conversation thread.
+	DefaultThreadTokenBudget = 6000
+)
+
+// ThreadContext is a conversation thread sent as context to a provider.
+type ThreadContext struct {
+	ConversationUUID string
+	Subject          string
+	ContactName      string
+	ContactEmail     string
+	ContactPhone     string
+	// Messages ordered from oldest to newest.
+	Messages []ThreadMessage
+}
+
+// ThreadMessage is a single message of a conversation thread.
+type ThreadMessage struct {
+	Role      string
+	Sender    string
+	Content   string
+	CreatedAt time.Time
+}
+
+// buildThreadPrompt renders the thread as the user prompt, dropping the oldest messages
+// that don't fit in the token budget. The latest message is truncated if it alone exceeds the budget.
+func buildThreadPrompt(t ThreadContext, tokenBudget int) string {
+	var header strings.Builder
+	header.WriteString("Contact:\n")
+	if t.ContactName != "" {
+		fmt.Fprintf(&header, "Name: %s\n", t.ContactName)
+	}
+	if t.ContactEmail != "" {
+		fmt.Fprintf(&header, "Email: %s\n", t.ContactEmail)
+	}
+	if t.ContactPhone != "" {
+		fmt.Fprintf(&header, "Phone: %s\n", t.ContactPhone)
+	}
+	if t.Subject != "" {
+		fmt.Fprintf(&header, "\nSubject: %s\n", t.Subject)
+	}
+	header.WriteString("\nConversation:\n")
+
+	budget := tokenBudget*charsPerToken - header.Len()
+
+	// Walk from the newest message and keep as many as fit.
+	var (
+		kept  []string
+		first = len(t.Messages)
+	)
+	for i := len(t.Messages) - 1; i >= 0; i-- {
+		msg := formatThreadMessage(t.Messages[i])
+		if len(msg) > budget {
+			if len(kept) == 0 && budget > 0 {
+				kept = append(kept, truncate(msg, budget))
+				first = i
+			}
+			break
+		}
+		budget -= len(msg)
+		kept = append(kept, msg)
+		first = i
+	}
+
+	var b strings.Builder
+	b.WriteString(header.String())
+	if first > 0 {
+		fmt.Fprintf(&b, "[%d earlier message(s) omitted]\n\n", first)
+	}
+	for i := len(kept) - 1; i >= 0; i-- {
+		b.WriteString(kept[i])
+	}
+	return b.String()
+}
+
+// formatThreadMessage formats a single thread message.
+func formatThreadMessage(m ThreadMessage) string {
+	return fmt.Sprintf("[%s] %s (%s):\n%s\n\n", m.CreatedAt.UTC().Format(time.RFC3339), m.Sender, m.Role, strings.TrimSpace(m.Content))
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "\n"
+}
@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBuildThreadPrompt(t *testing.T) {
+	at := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	thread := ThreadContext{
+		Subject:      "Refund request",
+		ContactName:  "Jane Doe",
+		ContactEmail: "jane@example.com",
+		Messages: []ThreadMessage{
+			{Role: RoleCustomer, Sender: "Jane Doe", Content: "Hallo, ich möchte eine Rückerstattung.", CreatedAt: at},
+			{Role: RoleNote, Sender: "Bob", Content: "Order was shipped late.", CreatedAt: at.Add(time.Hour)},
+			{Role: RoleAgent, Sender: "Bob", Content: "Wir prüfen das.", CreatedAt: at.Add(2 * time.Hour)},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		budget      int
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:   "whole thread fits",
+			budget: 1000,
+			contains: []string{
+				"Name: Jane Doe", "Email: jane@example.com", "Subject: Refund request",
+				"Jane Doe (customer):\nHallo", "Bob (private note):\nOrder was shipped late.", "Bob (agent):\nWir prüfen das.",
+			},
+			notContains: []string{"Phone:", "omitted"},
+		},
+		{
+			name:        "oldest messages dropped",
+			budget:      60,
+			contains:    []string{"Name: Jane Doe", "[1 earlier message(s) omitted]", "Order was shipped late.", "Wir prüfen das."},
+			notContains: []string{"Rückerstattung"},
+		},
+		{
+			name:        "only latest message fits",
+			budget:      40,
+			contains:    []string{"[2 earlier message(s) omitted]", "Bob (agent):\nWir prüfen das."},
+			notContains: []string{"Order was shipped late."},
+		},
+		{
+			name:        "latest message truncated",
+			budget:      33,
+			contains:    []string{"[2 earlier message(s) omitted]", "Bob (agent):\nWir pr"},
+			notContains: []string{"Wir prüfen das."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildThreadPrompt(thread, tt.budget)
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("prompt does not contain %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.notContains {
+				if strings.Contains(got, s) {
+					t.Errorf("prompt contains %q:\n%s", s, got)
+				}
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("prompt is not valid UTF-8")
+			}
+		})
+	}
+
+	// Messages are kept in chronological order.
+	got := buildThreadPrompt(thread, 1000)
+	if strings.Index(got, "Hallo") > strings.Index(got, "Wir prüfen") {
+		t.Errorf("messages out of order:\n%s", got)
+	}
+}
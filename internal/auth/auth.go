@@ -33,6 +33,9 @@ type OIDCclaim struct {
 	EmailVerified bool   `json:"email_verified"`
 	Sub           string `json:"sub"`
 	Picture       string `json:"picture"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
 }
 
 // Provider defines an OIDC provider configuration
@@ -43,6 +46,10 @@ type Provider struct {
 	RedirectURL  string
 	ClientID     string
 	ClientSecret string
+
+	// Portal providers also sign contacts in to the portal, redirecting back to PortalRedirectURL.
+	Portal            bool
+	PortalRedirectURL string
 }
 
 // Config holds OIDC providers and cookies security settings
@@ -54,20 +61,22 @@ type Config struct {
 // Auth is the auth service it manages OIDC authentication and sessions.
 // Agent (admin) and portal (contact) use separate session cookies so one login does not overwrite the other.
 type Auth struct {
-	mu         sync.RWMutex
-	cfg        Config
-	i18n       *i18n.I18n
-	oauthCfgs  map[int]oauth2.Config
-	verifiers  map[int]*oidc.IDTokenVerifier
-	sess       *simplesessions.Manager       // agent session (cookie: libredesk_session)
-	portalSess *simplesessions.Manager       // portal/contact session (cookie: libredesk_portal_session)
-	logger     *logf.Logger
-	rd         *redis.Client
+	mu              sync.RWMutex
+	cfg             Config
+	i18n            *i18n.I18n
+	oauthCfgs       map[int]oauth2.Config
+	portalOAuthCfgs map[int]oauth2.Config // portal providers, same as oauthCfgs but with the portal redirect URL
+	verifiers       map[int]*oidc.IDTokenVerifier
+	sess            *simplesessions.Manager // agent session (cookie: libredesk_session)
+	portalSess      *simplesessions.Manager // portal/contact session (cookie: libredesk_portal_session)
+	logger          *logf.Logger
+	rd              *redis.Client
 }
 
 // New creates an Auth service with configured OIDC providers
 func New(cfg Config, i18n *i18n.I18n, rd *redis.Client, logger *logf.Logger) (*Auth, error) {
 	oauthCfgs := make(map[int]oauth2.Config)
+	portalOAuthCfgs := make(map[int]oauth2.Config)
 	verifiers := make(map[int]*oidc.IDTokenVerifier)
 
 	for _, provider := range cfg.Providers {
@@ -89,6 +98,11 @@ func New(cfg Config, i18n *i18n.I18n, rd *redis.Client, logger *logf.Logger) (*A
 
 		oauthCfgs[provider.ID] = oauthCfg
 		verifiers[provider.ID] = verifier
+		if provider.Portal {
+			portalCfg := oauthCfg
+			portalCfg.RedirectURL = provider.PortalRedirectURL
+			portalOAuthCfgs[provider.ID] = portalCfg
+		}
 	}
 
 	// Agent (admin) session — cookie libredesk_session only. Never used for contacts.
@@ -127,14 +141,15 @@ func New(cfg Config, i18n *i18n.I18n, rd *redis.Client, logger *logf.Logger) (*A
 	portalSess.SetCookieHooks(simpleSessGetCookieCB, simpleSessSetCookieCB)
 
 	return &Auth{
-		cfg:        cfg,
-		i18n:       i18n,
-		oauthCfgs:  oauthCfgs,
-		verifiers:  verifiers,
-		sess:       sess,
-		portalSess: portalSess,
-		logger:     logger,
-		rd:         rd,
+		cfg:             cfg,
+		i18n:            i18n,
+		oauthCfgs:       oauthCfgs,
+		portalOAuthCfgs: portalOAuthCfgs,
+		verifiers:       verifiers,
+		sess:            sess,
+		portalSess:      portalSess,
+		logger:          logger,
+		rd:              rd,
 	}, nil
 }
 
@@ -154,6 +169,7 @@ func (a *Auth) Reload(cfg Config) error {
 	defer a.mu.Unlock()
 
 	oauthCfgs := make(map[int]oauth2.Config)
+	portalOAuthCfgs := make(map[int]oauth2.Config)
 	verifiers := make(map[int]*oidc.IDTokenVerifier)
 
 	for _, provider := range cfg.Providers {
@@ -175,10 +191,16 @@ func (a *Auth) Reload(cfg Config) error {
 
 		oauthCfgs[provider.ID] = oauthCfg
 		verifiers[provider.ID] = verifier
+		if provider.Portal {
+			portalCfg := oauthCfg
+			portalCfg.RedirectURL = provider.PortalRedirectURL
+			portalOAuthCfgs[provider.ID] = portalCfg
+		}
 	}
 
 	a.cfg = cfg
 	a.oauthCfgs = oauthCfgs
+	a.portalOAuthCfgs = portalOAuthCfgs
 	a.verifiers = verifiers
 
 	return nil
@@ -195,6 +217,17 @@ func (a *Auth) LoginURL(providerID int, state string) (string, error) {
 	return oauthCfg.AuthCodeURL(state), nil
 }
 
+// PortalLoginURL returns the portal login URL for the given provider, only providers enabled for the portal have one.
+func (a *Auth) PortalLoginURL(providerID int, state string) (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	oauthCfg, ok := a.portalOAuthCfgs[providerID]
+	if !ok {
+		return "", envelope.NewError(envelope.InputError, a.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.provider}"), nil)
+	}
+	return oauthCfg.AuthCodeURL(state), nil
+}
+
 // ExchangeOIDCToken takes an OIDC authorization code, validates it, and returns an OIDC token for subsequent auth.
 func (a *Auth) ExchangeOIDCToken(ctx context.Context, providerID int, code string) (string, OIDCclaim, error) {
 	a.mu.RLock()
@@ -204,7 +237,23 @@ func (a *Auth) ExchangeOIDCToken(ctx context.Context, providerID int, code strin
 	if !ok {
 		return "", OIDCclaim{}, fmt.Errorf("invalid provider ID: %d", providerID)
 	}
+	return a.exchangeOIDCToken(ctx, oauthCfg, providerID, code)
+}
 
+// ExchangePortalOIDCToken is ExchangeOIDCToken for the authorization codes of portal logins.
+func (a *Auth) ExchangePortalOIDCToken(ctx context.Context, providerID int, code string) (string, OIDCclaim, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	oauthCfg, ok := a.portalOAuthCfgs[providerID]
+	if !ok {
+		return "", OIDCclaim{}, fmt.Errorf("invalid portal provider ID: %d", providerID)
+	}
+	return a.exchangeOIDCToken(ctx, oauthCfg, providerID, code)
+}
+
+// exchangeOIDCToken exchanges an authorization code with oauthCfg and verifies the returned ID token.
+func (a *Auth) exchangeOIDCToken(ctx context.Context, oauthCfg oauth2.Config, providerID int, code string) (string, OIDCclaim, error) {
 	verifier, ok := a.verifiers[providerID]
 	if !ok {
 		return "", OIDCclaim{}, fmt.Errorf("invalid provider ID: %d", providerID)
@@ -326,6 +375,43 @@ func (a *Auth) GetSessionValue(r *fastglue.Request, key string) (any, error) {
 	return val, nil
 }
 
+// SetPortalSessionValues sets passed values in the portal session.
+func (a *Auth) SetPortalSessionValues(r *fastglue.Request, values map[string]interface{}) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	sess, err := a.portalSess.Acquire(r.RequestCtx, r, r)
+	if err != nil {
+		a.logger.Error("error acquiring portal session", "error", err)
+		return err
+	}
+
+	if err := sess.SetMulti(values); err != nil {
+		a.logger.Error("error setting portal session values", "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetPortalSessionValue returns the value for the given key from the portal session.
+func (a *Auth) GetPortalSessionValue(r *fastglue.Request, key string) (any, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	sess, err := a.portalSess.Acquire(r.RequestCtx, r, r)
+	if err != nil {
+		a.logger.Error("error acquiring portal session", "error", err)
+		return "", err
+	}
+
+	val, err := sess.Get(key)
+	if err != nil {
+		a.logger.Error("error fetching portal session value", "error", err)
+		return "", err
+	}
+	return val, nil
+}
+
 // SetCSRFCookie sets the CSRF token in the response cookie if not already set.
 func (a *Auth) SetCSRFCookie(r *fastglue.Request) error {
 	a.mu.RLock()
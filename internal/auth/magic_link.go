@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	amodels "github.com/ghotso/libredesk/internal/auth/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/stringutil"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	portalMagicLinkKeyPrefix = "portal_magic_link:"
+	rateLimitKeyPrefix       = "rate_limit:"
+)
+
+// CreatePortalMagicLink stores a single-use portal sign in token that expires after ttl and returns the token.
+func (a *Auth) CreatePortalMagicLink(link amodels.PortalMagicLink, ttl time.Duration) (string, error) {
+	token, err := stringutil.RandomAlphanumeric(32)
+	if err != nil {
+		a.logger.Error("error generating portal magic link token", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, a.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	b, err := json.Marshal(link)
+	if err != nil {
+		a.logger.Error("error marshalling portal magic link", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, a.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	if err := a.rd.Set(context.Background(), portalMagicLinkKeyPrefix+token, b, ttl).Err(); err != nil {
+		a.logger.Error("error storing portal magic link", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, a.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return token, nil
+}
+
+// ConsumePortalMagicLink returns the sign in a magic link token stands for and deletes the token,
+// so that it can't be used again.
+func (a *Auth) ConsumePortalMagicLink(token string) (amodels.PortalMagicLink, error) {
+	var link amodels.PortalMagicLink
+	if token == "" {
+		return link, envelope.NewError(envelope.InputError, a.i18n.T("portal.invalidOrExpiredLink"), nil)
+	}
+	b, err := a.rd.GetDel(context.Background(), portalMagicLinkKeyPrefix+token).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return link, envelope.NewError(envelope.InputError, a.i18n.T("portal.invalidOrExpiredLink"), nil)
+		}
+		a.logger.Error("error fetching portal magic link", "error", err)
+		return link, envelope.NewError(envelope.GeneralError, a.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	if err := json.Unmarshal(b, &link); err != nil {
+		a.logger.Error("error unmarshalling portal magic link", "error", err)
+		return link, envelope.NewError(envelope.GeneralError, a.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return link, nil
+}
+
+// AllowAttempt counts an attempt for key and returns false once more than limit attempts were made in the window,
+// the window starts with the first attempt.
+func (a *Auth) AllowAttempt(key string, limit int, window time.Duration) (bool, error) {
+	var (
+		ctx = context.Background()
+		k   = rateLimitKeyPrefix + key
+	)
+	count, err := a.rd.Incr(ctx, k).Result()
+	if err != nil {
+		a.logger.Error("error counting rate limited attempt", "key", key, "error", err)
+		return false, envelope.NewError(envelope.GeneralError, a.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	if count == 1 {
+		if err := a.rd.Expire(ctx, k, window).Err(); err != nil {
+			a.logger.Error("error setting rate limit window", "key", key, "error", err)
+		}
+	}
+	return count <= int64(limit), nil
+}
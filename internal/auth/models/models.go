@@ -9,3 +9,11 @@ type User struct {
 	Email     string `json:"email,omitempty"`
 	UserType  string `json:"user_type,omitempty"` // "agent" | "contact"
 }
+
+// PortalMagicLink is the portal sign in a magic link token stands for.
+// FirstName and LastName are set when the link registers a new contact.
+type PortalMagicLink struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
@@ -13,6 +13,9 @@ const (
 	PermConversationsUpdatePriority     = "conversations:update_priority"
 	PermConversationsUpdateStatus       = "conversations:update_status"
 	PermConversationsUpdateTags         = "conversations:update_tags"
+	PermConversationsUpdateLinks        = "conversations:update_links"
+	PermConversationsTrackTime          = "conversations:track_time"
+	PermConversationsManageTasks        = "conversations:manage_tasks"
 	PermConversationWrite               = "conversations:write"
 	PermMessagesRead                    = "messages:read"
 	PermMessagesWrite                   = "messages:write"
@@ -73,11 +76,16 @@ const (
 	// AI
 	PermAIManage = "ai:manage"
 
+	// Knowledge base
+	PermKnowledgeBaseManage = "knowledge_base:manage"
+
 	// Contacts
 	PermContactsReadAll = "contacts:read_all"
 	PermContactsRead    = "contacts:read"
 	PermContactsWrite   = "contacts:write"
 	PermContactsBlock   = "contacts:block"
+	PermContactsExport  = "contacts:export"
+	PermContactsErase   = "contacts:erase"
 
 	// Contact Notes
 	PermContactNotesRead   = "contact_notes:read"
@@ -103,6 +111,9 @@ var validPermissions = map[string]struct{}{
 	PermConversationsUpdatePriority:     {},
 	PermConversationsUpdateStatus:       {},
 	PermConversationsUpdateTags:         {},
+	PermConversationsUpdateLinks:        {},
+	PermConversationsTrackTime:          {},
+	PermConversationsManageTasks:        {},
 	PermConversationWrite:               {},
 	PermMessagesRead:                    {},
 	PermMessagesWrite:                   {},
@@ -125,11 +136,14 @@ var validPermissions = map[string]struct{}{
 	PermNotificationSettingsManage:      {},
 	PermOIDCManage:                      {},
 	PermAIManage:                        {},
+	PermKnowledgeBaseManage:             {},
 	PermCustomAttributesManage:          {},
 	PermContactsReadAll:                 {},
 	PermContactsRead:                    {},
 	PermContactsWrite:                   {},
 	PermContactsBlock:                   {},
+	PermContactsExport:                  {},
+	PermContactsErase:                   {},
 	PermContactNotesRead:                {},
 	PermContactNotesWrite:               {},
 	PermContactNotesDelete:              {},
@@ -49,6 +49,7 @@ type Engine struct {
 	rules             []models.Rule
 	rulesMu           sync.RWMutex
 	q                 queries
+	db                *sqlx.DB
 	lo                *logf.Logger
 	i18n              *i18n.I18n
 	conversationStore conversationStore
@@ -73,6 +74,7 @@ type conversationStore interface {
 type queries struct {
 	GetAll                  *sqlx.Stmt `query:"get-all"`
 	GetRule                 *sqlx.Stmt `query:"get-rule"`
+	GetRuleWeights          *sqlx.Stmt `query:"get-rule-weights"`
 	InsertRule              *sqlx.Stmt `query:"insert-rule"`
 	UpdateRule              *sqlx.Stmt `query:"update-rule"`
 	DeleteRule              *sqlx.Stmt `query:"delete-rule"`
@@ -87,6 +89,7 @@ func New(opt Opts) (*Engine, error) {
 	var (
 		q queries
 		e = &Engine{
+			db:        opt.DB,
 			lo:        opt.Lo,
 			i18n:      opt.I18n,
 			taskQueue: make(chan ConversationTask, MaxQueueSize),
@@ -250,17 +253,53 @@ func (e *Engine) DeleteRule(id int) error {
 	return nil
 }
 
-// UpdateRuleWeights updates the weights of the automation rules.
-func (e *Engine) UpdateRuleWeights(weights map[int]int) error {
+// UpdateRuleWeights updates the weights of the automation rules in a single transaction
+// and returns the previous weights keyed by rule ID.
+func (e *Engine) UpdateRuleWeights(weights map[int]int) (map[int]int, error) {
+	ids := make([]int, 0, len(weights))
+	for id := range weights {
+		ids = append(ids, id)
+	}
+
+	tx, err := e.db.Beginx()
+	if err != nil {
+		e.lo.Error("error beginning rule weights update transaction", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorUpdating", "name", e.i18n.Ts("globals.terms.rule")), nil)
+	}
+	defer tx.Rollback()
+
+	var current []struct {
+		ID     int `db:"id"`
+		Weight int `db:"weight"`
+	}
+	if err := tx.Stmtx(e.q.GetRuleWeights).Select(&current, pq.Array(ids)); err != nil {
+		e.lo.Error("error fetching rule weights", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorFetching", "name", e.i18n.Ts("globals.terms.rule")), nil)
+	}
+	if len(current) != len(ids) {
+		return nil, envelope.NewError(envelope.InputError, e.i18n.Ts("globals.messages.notFound", "name", e.i18n.Ts("globals.terms.rule")), nil)
+	}
+
+	oldWeights := make(map[int]int, len(current))
+	for _, r := range current {
+		oldWeights[r.ID] = r.Weight
+	}
+
 	for id, weight := range weights {
-		if _, err := e.q.UpdateRuleWeight.Exec(id, weight); err != nil {
+		if _, err := tx.Stmtx(e.q.UpdateRuleWeight).Exec(id, weight); err != nil {
 			e.lo.Error("error updating rule weight", "error", err)
-			return envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorUpdating", "name", e.i18n.Ts("globals.terms.rule")), nil)
+			return nil, envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorUpdating", "name", e.i18n.Ts("globals.terms.rule")), nil)
 		}
 	}
+
+	if err := tx.Commit(); err != nil {
+		e.lo.Error("error committing rule weights update", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorUpdating", "name", e.i18n.Ts("globals.terms.rule")), nil)
+	}
+
 	// Reload rules.
 	e.ReloadRules()
-	return nil
+	return oldWeights, nil
 }
 
 // UpdateRuleExecutionMode updates the execution mode for a type of rule.
@@ -41,6 +41,15 @@ func (e *Engine) evalConversationRules(rules []models.Rule, conversation cmodels
 			for _, action := range rule.Actions {
 				if err := e.conversationStore.ApplyAction(action, conversation, umodels.User{}); err != nil {
 					e.lo.Error("error applying action on conversation", "action", action, "conversation_uuid", conversation.UUID, "error", err)
+					continue
+				}
+				// Refetch the conversation so the following rules can branch on the classification custom attributes.
+				if action.Type == models.ActionAIClassify {
+					if updated, err := e.conversationStore.GetConversation(0, conversation.UUID, ""); err != nil {
+						e.lo.Error("error refetching conversation after classification", "conversation_uuid", conversation.UUID, "error", err)
+					} else {
+						conversation = updated
+					}
 				}
 			}
 			if rule.ExecutionMode == models.ExecutionModeFirstMatch {
@@ -160,9 +169,12 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 			e.lo.Error("error unrecognized conversation field", "field", rule.Field, "field_type", rule.FieldType, "conversation_uuid", conversation.UUID)
 			return false
 		}
-	} else if rule.FieldType == models.FieldTypeContactCustomAttribute {
+	} else if rule.FieldType == models.FieldTypeContactCustomAttribute || rule.FieldType == models.FieldTypeConversationCustomAttribute {
 		// If the field type is custom attribute, need to extract the value from the custom attributes
 		var attributes json.RawMessage = conversation.Contact.CustomAttributes
+		if rule.FieldType == models.FieldTypeConversationCustomAttribute {
+			attributes = conversation.CustomAttributes
+		}
 
 		// Unmarshal the custom attributes
 		if err := json.Unmarshal(attributes, &customAttributes); err != nil {
@@ -431,6 +431,52 @@ func TestCustomAttributes_StringComparison(t *testing.T) {
 	assert.Equal(t, models.ActionSendCSAT, mockStore.appliedActions[0].Type)
 }
 
+// Test: AI classification - later rules branch on the classification written to the conversation custom attributes
+func TestAIClassify_RoutesOnConversationCustomAttributes(t *testing.T) {
+	mockStore := new(mockConversationStore)
+	mockStore.On("ApplyAction", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	engine := createTestEngine(mockStore)
+
+	conversation := createTestConversation()
+	classified := conversation
+	classified.CustomAttributes = json.RawMessage(`{"ai_sentiment":"negative","ai_urgency":"high"}`)
+	mockStore.On("GetConversation", 0, conversation.UUID, "").Return(classified, nil)
+
+	rules := []models.Rule{
+		createTestRule(
+			[]models.RuleGroup{
+				{
+					LogicalOp: models.OperatorAnd,
+					Rules: []models.RuleDetail{
+						{Field: models.ContactEmail, Operator: models.RuleOperatorSet, FieldType: models.FieldTypeConversationField},
+					},
+				},
+			},
+			[]models.RuleAction{{Type: models.ActionAIClassify, Value: []string{"0"}}},
+			models.OperatorAnd,
+		),
+		createTestRule(
+			[]models.RuleGroup{
+				{
+					LogicalOp: models.OperatorAnd,
+					Rules: []models.RuleDetail{
+						{Field: "ai_sentiment", Operator: models.RuleOperatorEquals, Value: "negative", FieldType: models.FieldTypeConversationCustomAttribute},
+					},
+				},
+			},
+			[]models.RuleAction{{Type: models.ActionAssignTeam, Value: []string{"3"}}},
+			models.OperatorAnd,
+		),
+	}
+
+	engine.evalConversationRules(rules, conversation)
+
+	assert.Equal(t, 2, mockStore.callCount, "Routing rule should see the classification")
+	assert.Equal(t, models.ActionAIClassify, mockStore.appliedActions[0].Type)
+	assert.Equal(t, models.ActionAssignTeam, mockStore.appliedActions[1].Type)
+	mockStore.AssertCalled(t, "GetConversation", 0, conversation.UUID, "")
+}
+
 // Test: Custom attributes - missing field
 func TestCustomAttributes_MissingField(t *testing.T) {
 	mockStore := new(mockConversationStore)
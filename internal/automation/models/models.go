@@ -20,6 +20,7 @@ const (
 	ActionSetTags         = "set_tags"
 	ActionRemoveTags      = "remove_tags"
 	ActionSendCSAT        = "send_csat"
+	ActionAIClassify      = "ai_classify"
 
 	OperatorAnd = "AND"
 	OperatorOR  = "OR"
@@ -61,6 +62,7 @@ const (
 	ExecutionModeFirstMatch = "first_match"
 
 	FieldTypeContactCustomAttribute      = "contact_custom_attribute"
+	FieldTypeConversationCustomAttribute = "conversation_custom_attribute"
 	FieldTypeConversationField           = "conversation"
 )
 
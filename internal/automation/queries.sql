@@ -41,6 +41,9 @@ SET enabled = NOT enabled, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: get-rule-weights
+SELECT id, weight FROM automation_rules WHERE id = ANY($1::INT[]) FOR UPDATE;
+
 -- name: update-rule-weight
 UPDATE automation_rules
 SET weight = $2, updated_at = NOW()
@@ -21,6 +21,7 @@ import (
 	pmodels "github.com/ghotso/libredesk/internal/conversation/priority/models"
 	smodels "github.com/ghotso/libredesk/internal/conversation/status/models"
 	csatModels "github.com/ghotso/libredesk/internal/csat/models"
+	camodels "github.com/ghotso/libredesk/internal/custom_attribute/models"
 	"github.com/ghotso/libredesk/internal/dbutil"
 	"github.com/ghotso/libredesk/internal/envelope"
 	"github.com/ghotso/libredesk/internal/inbox"
@@ -71,6 +72,8 @@ type Manager struct {
 	webhookStore               webhookStore
 	dispatcher                 *notifier.Dispatcher
 	organizationStore          organizationStore
+	classifier                 classifier
+	customAttributeStore       customAttributeStore
 	lo                         *logf.Logger
 	db                         *sqlx.DB
 	i18n                       *i18n.I18n
@@ -83,12 +86,25 @@ type Manager struct {
 	closed                     bool
 	closedMu                   sync.RWMutex
 	wg                         sync.WaitGroup
+
+	// Side conversation messages are sent by the same workers as the conversation messages.
+	outgoingSideMessageQueue       chan models.SideConversationMessage
+	outgoingProcessingSideMessages sync.Map
 }
 
 type organizationStore interface {
 	AddContactToOrganizationsByEmailDomain(contactID int64, email string)
 }
 
+type classifier interface {
+	ClassifyMessage(messageID int, content string) (map[string]string, error)
+}
+
+type customAttributeStore interface {
+	ValidateValues(appliesTo string, values map[string]any) error
+	MissingRequiredOnResolve(customAttributes json.RawMessage) ([]string, error)
+}
+
 type slaStore interface {
 	ApplySLA(startTime time.Time, conversationID, assignedTeamID, slaID int) (slaModels.SLAPolicy, error)
 	CreateNextResponseSLAEvent(conversationID, appliedSLAID, slaPolicyID, assignedTeamID int) (time.Time, error)
@@ -148,7 +164,9 @@ type Opts struct {
 	Lo                       *logf.Logger
 	OutgoingMessageQueueSize int
 	IncomingMessageQueueSize int
-	OrganizationStore        organizationStore // optional; when set, contacts are auto-added to orgs by email domain on incoming message
+	OrganizationStore        organizationStore    // optional; when set, contacts are auto-added to orgs by email domain on incoming message
+	Classifier               classifier           // optional; classifies incoming messages for the AI classification automation action
+	CustomAttributeStore     customAttributeStore // optional; validates conversation custom attribute values before they're saved
 }
 
 // New initializes a new conversation Manager.
@@ -187,7 +205,9 @@ func New(
 		settingsStore:              settingsStore,
 		csatStore:                  csatStore,
 		webhookStore:               webhook,
-		organizationStore:         opts.OrganizationStore,
+		organizationStore:          opts.OrganizationStore,
+		classifier:                 opts.Classifier,
+		customAttributeStore:       opts.CustomAttributeStore,
 		slaStore:                   slaStore,
 		statusStore:                statusStore,
 		priorityStore:              priorityStore,
@@ -198,6 +218,7 @@ func New(
 		incomingMessageQueue:       make(chan models.IncomingMessage, opts.IncomingMessageQueueSize),
 		outgoingMessageQueue:       make(chan models.Message, opts.OutgoingMessageQueueSize),
 		outgoingProcessingMessages: sync.Map{},
+		outgoingSideMessageQueue:   make(chan models.SideConversationMessage, opts.OutgoingMessageQueueSize),
 	}
 
 	return c, nil
@@ -222,6 +243,7 @@ type queries struct {
 	UpdateConversationAssignedUser     *sqlx.Stmt `query:"update-conversation-assigned-user"`
 	UpdateConversationAssignedTeam     *sqlx.Stmt `query:"update-conversation-assigned-team"`
 	UpdateConversationCustomAttributes *sqlx.Stmt `query:"update-conversation-custom-attributes"`
+	MergeConversationCustomAttributes  *sqlx.Stmt `query:"merge-conversation-custom-attributes"`
 	UpdateConversationPriority         *sqlx.Stmt `query:"update-conversation-priority"`
 	UpdateConversationOrganizationID   *sqlx.Stmt `query:"update-conversation-organization-id"`
 	UpdateConversationStatus           *sqlx.Stmt `query:"update-conversation-status"`
@@ -242,6 +264,7 @@ type queries struct {
 	// Draft queries.
 	UpsertConversationDraft *sqlx.Stmt `query:"upsert-conversation-draft"`
 	GetAllUserDrafts        *sqlx.Stmt `query:"get-all-user-drafts"`
+	GetOtherAgentDrafts     *sqlx.Stmt `query:"get-other-agent-drafts"`
 	DeleteConversationDraft *sqlx.Stmt `query:"delete-conversation-draft"`
 	DeleteStaleDrafts       *sqlx.Stmt `query:"delete-stale-drafts"`
 
@@ -253,11 +276,45 @@ type queries struct {
 	GetConversationUUIDFromMessageUUID *sqlx.Stmt `query:"get-conversation-uuid-from-message-uuid"`
 	InsertMessage                      *sqlx.Stmt `query:"insert-message"`
 	UpdateMessageStatus                *sqlx.Stmt `query:"update-message-status"`
+	QueueDueScheduledMessages          *sqlx.Stmt `query:"queue-due-scheduled-messages"`
+	UpdateScheduledMessage             *sqlx.Stmt `query:"update-scheduled-message"`
+	DeleteScheduledMessage             *sqlx.Stmt `query:"delete-scheduled-message"`
+	DeleteScheduledMessagesOnReply     *sqlx.Stmt `query:"delete-scheduled-messages-cancelled-on-reply"`
 	MessageExistsBySourceID            *sqlx.Stmt `query:"message-exists-by-source-id"`
 	GetConversationByMessageID         *sqlx.Stmt `query:"get-conversation-by-message-id"`
 
 	// Mention queries.
 	InsertMention *sqlx.Stmt `query:"insert-mention"`
+
+	// Conversation link queries.
+	GetConversationLinks   *sqlx.Stmt `query:"get-conversation-links"`
+	GetChildConversations  *sqlx.Stmt `query:"get-child-conversations"`
+	LockConversations      *sqlx.Stmt `query:"lock-conversations"`
+	InsertConversationLink *sqlx.Stmt `query:"insert-conversation-link"`
+	DeleteConversationLink *sqlx.Stmt `query:"delete-conversation-link"`
+
+	// Side conversation queries.
+	InsertSideConversation              *sqlx.Stmt `query:"insert-side-conversation"`
+	GetSideConversations                *sqlx.Stmt `query:"get-side-conversations"`
+	GetSideConversation                 *sqlx.Stmt `query:"get-side-conversation"`
+	GetSideConversationByID             *sqlx.Stmt `query:"get-side-conversation-by-id"`
+	GetSideConversationBySourceID       *sqlx.Stmt `query:"get-side-conversation-by-source-id"`
+	GetSideConversationMessages         *sqlx.Stmt `query:"get-side-conversation-messages"`
+	GetSideConversationSourceIDs        *sqlx.Stmt `query:"get-side-conversation-source-ids"`
+	InsertSideConversationMessage       *sqlx.Stmt `query:"insert-side-conversation-message"`
+	GetOutgoingPendingSideMessages      *sqlx.Stmt `query:"get-outgoing-pending-side-conversation-messages"`
+	UpdateSideConversationMessageStatus *sqlx.Stmt `query:"update-side-conversation-message-status"`
+	SideConversationMessageExists       *sqlx.Stmt `query:"side-conversation-message-exists"`
+	GetConversationBySideMessageID      *sqlx.Stmt `query:"get-conversation-by-side-conversation-message-id"`
+
+	// Conversation task queries.
+	GetConversationTasks    *sqlx.Stmt `query:"get-conversation-tasks"`
+	GetConversationTask     *sqlx.Stmt `query:"get-conversation-task"`
+	GetUserTasks            *sqlx.Stmt `query:"get-user-tasks"`
+	InsertConversationTask  *sqlx.Stmt `query:"insert-conversation-task"`
+	UpdateConversationTask  *sqlx.Stmt `query:"update-conversation-task"`
+	DeleteConversationTask  *sqlx.Stmt `query:"delete-conversation-task"`
+	GetDueConversationTasks *sqlx.Stmt `query:"get-due-conversation-tasks"`
 }
 
 // CreateConversation creates a new conversation and returns its ID and UUID.
@@ -303,8 +360,9 @@ func (c *Manager) GetConversation(id int, uuid, refNum string) (models.Conversat
 	return conversation, nil
 }
 
-// GetConversationsForContact returns conversations visible to the contact (own + org-shared when organizationID > 0).
-func (c *Manager) GetConversationsForContact(contactID, organizationID int, page, pageSize int) ([]models.PortalConversationListItem, int, error) {
+// GetConversationsForContact returns conversations visible to the contact (own + org-shared when organizationID > 0),
+// organization admins see the conversations of all organization members.
+func (c *Manager) GetConversationsForContact(contactID, organizationID int, organizationAdmin bool, page, pageSize int) ([]models.PortalConversationListItem, int, error) {
 	if pageSize <= 0 {
 		pageSize = 20
 	}
@@ -313,7 +371,7 @@ func (c *Manager) GetConversationsForContact(contactID, organizationID int, page
 	}
 	offset := (page - 1) * pageSize
 	var list []models.PortalConversationListItem
-	if err := c.q.GetConversationsForContact.Select(&list, contactID, organizationID, pageSize, offset); err != nil {
+	if err := c.q.GetConversationsForContact.Select(&list, contactID, organizationID, organizationAdmin, pageSize, offset); err != nil {
 		c.lo.Error("error fetching conversations for contact", "error", err)
 		return nil, 0, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
 	}
@@ -753,6 +811,17 @@ func (c *Manager) UpdateConversationStatus(uuid string, statusID int, status, sn
 		return nil
 	}
 
+	// Make sure the custom attributes required before resolving are set, this applies to agents, macros and automations alike.
+	if statusID == smodels.DefaultStatusIDResolved && c.customAttributeStore != nil {
+		missing, err := c.customAttributeStore.MissingRequiredOnResolve(conversationBeforeChange.CustomAttributes)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return envelope.NewError(envelope.InputError, c.i18n.Ts("conversation.resolveWithoutRequiredAttributes", "names", strings.Join(missing, ", ")), nil)
+		}
+	}
+
 	// Update the conversation status (by status_id so renamed default statuses still work).
 	if _, err := c.q.UpdateConversationStatus.Exec(uuid, statusID, snoozeUntil); err != nil {
 		c.lo.Error("error updating conversation status", "error", err)
@@ -1105,8 +1174,8 @@ func (m *Manager) ApplySLA(conversation models.Conversation, policyID int, actor
 // ApplyAction applies an action to a conversation, this can be called from multiple packages across the app to perform actions on conversations.
 // all actions are executed on behalf of the provided user if the user is not provided, system user is used.
 func (m *Manager) ApplyAction(action amodels.RuleAction, conv models.Conversation, user umodels.User) error {
-	// CSAT action does not require a value.
-	if len(action.Value) == 0 && action.Type != amodels.ActionSendCSAT {
+	// CSAT and AI classification actions do not require a value.
+	if len(action.Value) == 0 && action.Type != amodels.ActionSendCSAT && action.Type != amodels.ActionAIClassify {
 		return fmt.Errorf("empty value for action %s", action.Type)
 	}
 
@@ -1171,6 +1240,7 @@ func (m *Manager) ApplyAction(action amodels.RuleAction, conv models.Conversatio
 			cc,
 			bcc,
 			map[string]any{}, /**meta**/
+			time.Time{},      /**send_at**/
 		)
 		if err != nil {
 			return fmt.Errorf("sending reply: %w", err)
@@ -1185,12 +1255,77 @@ func (m *Manager) ApplyAction(action amodels.RuleAction, conv models.Conversatio
 		return m.SetConversationTags(conv.UUID, action.Type, action.Value, user)
 	case amodels.ActionSendCSAT:
 		return m.SendCSATReply(user.ID, conv)
+	case amodels.ActionAIClassify:
+		return m.classifyConversation(conv)
 	default:
 		return fmt.Errorf("unknown action: %s", action.Type)
 	}
 	return nil
 }
 
+// classifyConversation classifies the latest incoming message of a conversation and
+// writes the result to the conversation custom attributes.
+func (m *Manager) classifyConversation(conv models.Conversation) error {
+	if m.classifier == nil {
+		return fmt.Errorf("AI classification is not available")
+	}
+
+	private := false
+	messages, _, err := m.GetConversationMessages(conv.UUID, 1, 1, &private, []string{models.MessageIncoming})
+	if err != nil {
+		return fmt.Errorf("fetching latest incoming message: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	content := messages[0].TextContent
+	if content == "" {
+		content = stringutil.HTML2Text(messages[0].Content)
+	}
+	if content == "" {
+		return nil
+	}
+
+	classification, err := m.classifier.ClassifyMessage(messages[0].ID, content)
+	if err != nil {
+		return fmt.Errorf("classifying message: %w", err)
+	}
+
+	attributes := map[string]any{}
+	for k, v := range classification {
+		if v != "" {
+			attributes[k] = v
+		}
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	return m.mergeConversationCustomAttributes(conv.UUID, attributes)
+}
+
+// mergeConversationCustomAttributes validates the given custom attributes and merges them into
+// the stored ones, leaving the other keys untouched.
+func (c *Manager) mergeConversationCustomAttributes(uuid string, customAttributes map[string]any) error {
+	if c.customAttributeStore != nil {
+		if err := c.customAttributeStore.ValidateValues(camodels.AppliesToConversation, customAttributes); err != nil {
+			return err
+		}
+	}
+	jsonb, err := json.Marshal(customAttributes)
+	if err != nil {
+		c.lo.Error("error marshalling custom attributes", "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	var merged json.RawMessage
+	if err := c.q.MergeConversationCustomAttributes.Get(&merged, uuid, jsonb); err != nil {
+		c.lo.Error("error merging conversation custom attributes", "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	// Broadcast the merged custom attributes.
+	c.BroadcastConversationUpdate(uuid, "custom_attributes", merged)
+	return nil
+}
+
 // RemoveConversationAssignee removes assigned user from a conversation.
 func (m *Manager) RemoveConversationAssignee(uuid, typ string, actor umodels.User) error {
 	if _, err := m.q.RemoveConversationAssignee.Exec(uuid, typ); err != nil {
@@ -1246,7 +1381,7 @@ func (m *Manager) SendCSATReply(actorUserID int, conversation models.Conversatio
 	}
 
 	// Queue CSAT reply.
-	_, err = m.QueueReply(nil /**media**/, conversation.InboxID, actorUserID, conversation.UUID, message, to, cc, bcc, meta)
+	_, err = m.QueueReply(nil /**media**/, conversation.InboxID, actorUserID, conversation.UUID, message, to, cc, bcc, meta, time.Time{} /**send_at**/)
 	if err != nil {
 		m.lo.Error("error sending CSAT reply", "conversation_uuid", conversation.UUID, "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.csat}"), nil)
@@ -1264,8 +1399,13 @@ func (m *Manager) DeleteConversation(uuid string) error {
 	return nil
 }
 
-// UpdateConversationCustomAttributes updates the custom attributes of a conversation.
+// UpdateConversationCustomAttributes validates and updates the custom attributes of a conversation.
 func (c *Manager) UpdateConversationCustomAttributes(uuid string, customAttributes map[string]any) error {
+	if c.customAttributeStore != nil {
+		if err := c.customAttributeStore.ValidateValues(camodels.AppliesToConversation, customAttributes); err != nil {
+			return err
+		}
+	}
 	jsonb, err := json.Marshal(customAttributes)
 	if err != nil {
 		c.lo.Error("error marshalling custom attributes", "error", err)
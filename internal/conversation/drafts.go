@@ -31,6 +31,16 @@ func (m *Manager) GetAllUserDrafts(userID int) ([]models.ConversationDraft, erro
 	return drafts, nil
 }
 
+// GetOtherAgentDrafts returns the agents other than the given user who edited a draft in the conversation within the window.
+func (m *Manager) GetOtherAgentDrafts(conversationUUID string, userID int, window time.Duration) ([]models.DraftAgent, error) {
+	var agents = make([]models.DraftAgent, 0)
+	if err := m.q.GetOtherAgentDrafts.Select(&agents, conversationUUID, userID, time.Now().Add(-window)); err != nil {
+		m.lo.Error("error fetching other agent drafts", "conversation_uuid", conversationUUID, "user_id", userID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "drafts"), nil)
+	}
+	return agents, nil
+}
+
 // DeleteConversationDraft deletes a draft for a conversation by ID or UUID.
 func (m *Manager) DeleteConversationDraft(conversationID int, uuid string, userID int) error {
 	var uuidParam any
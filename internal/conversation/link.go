@@ -0,0 +1,157 @@
+package conversation
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ghotso/libredesk/internal/conversation/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	umodels "github.com/ghotso/libredesk/internal/user/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetConversationLinks returns the links of a conversation.
+func (m *Manager) GetConversationLinks(uuid string) ([]models.ConversationLink, error) {
+	var links = make([]models.ConversationLink, 0)
+	if err := m.q.GetConversationLinks.Select(&links, uuid); err != nil {
+		m.lo.Error("error fetching conversation links", "uuid", uuid, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.link}"), nil)
+	}
+	return links, nil
+}
+
+// GetChildConversations returns the child conversations of a conversation with their assignees and contact.
+func (m *Manager) GetChildConversations(uuid string) ([]models.Conversation, error) {
+	var children = make([]models.Conversation, 0)
+	if err := m.q.GetChildConversations.Select(&children, uuid); err != nil {
+		m.lo.Error("error fetching child conversations", "uuid", uuid, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return children, nil
+}
+
+// LinkConversations links a conversation to another as its parent, child or related conversation and records the
+// link as an activity in both. Links are a single level deep, a parent cannot have a parent and a child cannot have children.
+func (m *Manager) LinkConversations(conversation, linked models.Conversation, relation string, actor umodels.User) error {
+	if conversation.ID == linked.ID {
+		return envelope.NewError(envelope.InputError, m.i18n.T("conversation.cannotLinkToItself"), nil)
+	}
+
+	var (
+		linkType = models.LinkTypeParentChild
+		parent   = conversation
+		child    = linked
+	)
+	switch relation {
+	case models.LinkRelationChild:
+	case models.LinkRelationParent:
+		parent, child = linked, conversation
+	case models.LinkRelationRelated:
+		linkType = models.LinkTypeRelated
+	default:
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`relation`"), nil)
+	}
+
+	// The nesting check and the insert run in a transaction that locks both conversations, so that concurrent links
+	// of the same conversations can't nest.
+	var errCreating = envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.link}"), nil)
+	tx, err := m.db.Beginx()
+	if err != nil {
+		m.lo.Error("error beginning conversation link transaction", "error", err)
+		return errCreating
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Stmtx(m.q.LockConversations).Exec(pq.Array([]int{parent.ID, child.ID})); err != nil {
+		m.lo.Error("error locking linked conversations", "conversation_id", conversation.ID, "linked_conversation_id", linked.ID, "error", err)
+		return errCreating
+	}
+	if linkType == models.LinkTypeParentChild {
+		if err := m.checkLinkNesting(tx, parent.UUID, models.LinkRelationParent); err != nil {
+			return err
+		}
+		if err := m.checkLinkNesting(tx, child.UUID, models.LinkRelationChild); err != nil {
+			return err
+		}
+	}
+
+	var id int
+	if err := tx.Stmtx(m.q.InsertConversationLink).Get(&id, linkType, parent.ID, child.ID, actor.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return envelope.NewError(envelope.ConflictError, m.i18n.T("conversation.alreadyLinked"), nil)
+		}
+		m.lo.Error("error inserting conversation link", "conversation_id", conversation.ID, "linked_conversation_id", linked.ID, "error", err)
+		return errCreating
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing conversation link", "conversation_id", conversation.ID, "linked_conversation_id", linked.ID, "error", err)
+		return errCreating
+	}
+
+	// Record the link in both conversations, describing the other conversation.
+	inverse := relation
+	switch relation {
+	case models.LinkRelationChild:
+		inverse = models.LinkRelationParent
+	case models.LinkRelationParent:
+		inverse = models.LinkRelationChild
+	}
+	m.insertLinkActivity(models.ActivityLinkAdded, conversation.UUID, linked.ReferenceNumber, relation, actor)
+	m.insertLinkActivity(models.ActivityLinkAdded, linked.UUID, conversation.ReferenceNumber, inverse, actor)
+	return nil
+}
+
+// UnlinkConversations removes a link of a conversation and records the removal as an activity in both linked conversations.
+func (m *Manager) UnlinkConversations(conversation models.Conversation, linkID int, actor umodels.User) error {
+	links, err := m.GetConversationLinks(conversation.UUID)
+	if err != nil {
+		return err
+	}
+	var link models.ConversationLink
+	for _, l := range links {
+		if l.ID == linkID {
+			link = l
+			break
+		}
+	}
+	if link.ID == 0 {
+		return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.link}"), nil)
+	}
+
+	if _, err := m.q.DeleteConversationLink.Exec(linkID, conversation.ID); err != nil {
+		m.lo.Error("error deleting conversation link", "id", linkID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.link}"), nil)
+	}
+
+	m.insertLinkActivity(models.ActivityLinkRemoved, conversation.UUID, link.ReferenceNumber, "", actor)
+	m.insertLinkActivity(models.ActivityLinkRemoved, link.ConversationUUID, conversation.ReferenceNumber, "", actor)
+	return nil
+}
+
+// checkLinkNesting returns an error if a conversation about to become a parent or child would nest links,
+// a would be parent must not have a parent and a would be child must not have children.
+func (m *Manager) checkLinkNesting(tx *sqlx.Tx, uuid, role string) error {
+	var links []models.ConversationLink
+	if err := tx.Stmtx(m.q.GetConversationLinks).Select(&links, uuid); err != nil {
+		m.lo.Error("error fetching conversation links", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.link}"), nil)
+	}
+	for _, link := range links {
+		if link.Relation == role {
+			return envelope.NewError(envelope.InputError, m.i18n.T("conversation.nestedLinksNotAllowed"), nil)
+		}
+	}
+	return nil
+}
+
+// insertLinkActivity records a link change in a conversation, relation is what the other conversation is to it.
+func (m *Manager) insertLinkActivity(activityType, conversationUUID, otherReferenceNumber, relation string, actor umodels.User) {
+	value := "#" + otherReferenceNumber
+	if relation != "" {
+		value = fmt.Sprintf("#%s as %s conversation", otherReferenceNumber, relation)
+	}
+	if err := m.InsertConversationActivity(activityType, conversationUUID, value, actor); err != nil {
+		m.lo.Error("error inserting conversation link activity", "conversation_uuid", conversationUUID, "error", err)
+	}
+}
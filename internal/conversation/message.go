@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/ghotso/libredesk/internal/attachment"
 	amodels "github.com/ghotso/libredesk/internal/automation/models"
 	"github.com/ghotso/libredesk/internal/conversation/models"
@@ -58,6 +59,9 @@ func (m *Manager) Run(ctx context.Context, incomingQWorkers, outgoingQWorkers, s
 		case <-ctx.Done():
 			return
 		case <-dbScanner.C:
+			m.queueDueScheduledMessages()
+			m.queuePendingSideConversationMessages()
+
 			var (
 				pendingMessages = []models.Message{}
 				messageIDs      = m.getOutgoingProcessingMessageIDs()
@@ -88,10 +92,21 @@ func (m *Manager) Close() {
 	defer m.closedMu.Unlock()
 	m.closed = true
 	close(m.outgoingMessageQueue)
+	close(m.outgoingSideMessageQueue)
 	close(m.incomingMessageQueue)
 	m.wg.Wait()
 }
 
+// IncomingQueueLen returns the number of messages waiting in the incoming message queue.
+func (m *Manager) IncomingQueueLen() int {
+	return len(m.incomingMessageQueue)
+}
+
+// OutgoingQueueLen returns the number of messages waiting in the outgoing message queue.
+func (m *Manager) OutgoingQueueLen() int {
+	return len(m.outgoingMessageQueue)
+}
+
 // IncomingMessageWorker processes incoming messages from the incoming message queue.
 func (m *Manager) IncomingMessageWorker(ctx context.Context) {
 	for {
@@ -102,14 +117,17 @@ func (m *Manager) IncomingMessageWorker(ctx context.Context) {
 			if !ok {
 				return
 			}
+			start := time.Now()
 			if err := m.processIncomingMessage(msg); err != nil {
 				m.lo.Error("error processing incoming msg", "error", err)
+				metrics.GetOrCreateCounter(`libredesk_incoming_messages_failed_total`).Inc()
 			}
+			metrics.GetOrCreateSummary(`libredesk_incoming_message_processing_seconds`).UpdateDuration(start)
 		}
 	}
 }
 
-// MessageSenderWorker sends outgoing pending messages.
+// MessageSenderWorker sends outgoing pending messages, including side conversation messages.
 func (m *Manager) MessageSenderWorker(ctx context.Context) {
 	for {
 		select {
@@ -119,7 +137,14 @@ func (m *Manager) MessageSenderWorker(ctx context.Context) {
 			if !ok {
 				return
 			}
+			start := time.Now()
 			m.sendOutgoingMessage(message)
+			metrics.GetOrCreateSummary(`libredesk_outgoing_message_processing_seconds`).UpdateDuration(start)
+		case message, ok := <-m.outgoingSideMessageQueue:
+			if !ok {
+				return
+			}
+			m.sendSideConversationMessage(message)
 		}
 	}
 }
@@ -390,7 +415,7 @@ func (m *Manager) SendPrivateNote(media []mmodels.Media, senderID int, conversat
 	return message, nil
 }
 
-// CreateContactMessage creates a contact message in a conversation.
+// CreateContactMessage creates a contact message in a conversation, the scheduled replies that are cancelled when the contact replies are cancelled.
 func (m *Manager) CreateContactMessage(media []mmodels.Media, contactID int, conversationUUID, content, contentType string) (models.Message, error) {
 	message := models.Message{
 		ConversationUUID: conversationUUID,
@@ -406,11 +431,34 @@ func (m *Manager) CreateContactMessage(media []mmodels.Media, contactID int, con
 	if err := m.InsertMessage(&message); err != nil {
 		return models.Message{}, err
 	}
+	m.cancelScheduledMessagesOnReply(message.ConversationID, conversationUUID)
 	return message, nil
 }
 
 // QueueReply queues a reply message in a conversation.
-func (m *Manager) QueueReply(media []mmodels.Media, inboxID, senderID int, conversationUUID, content string, to, cc, bcc []string, meta map[string]interface{}) (models.Message, error) {
+// A non-zero sendAt schedules the reply to be sent at that time.
+func (m *Manager) QueueReply(media []mmodels.Media, inboxID, senderID int, conversationUUID, content string, to, cc, bcc []string, meta map[string]interface{}, sendAt time.Time) (models.Message, error) {
+	return m.queueReply(media, inboxID, senderID, conversationUUID, content, to, cc, bcc, meta, sendAt, null.Int{})
+}
+
+// QueueBroadcastReply queues a copy of a parent conversation reply in a child conversation, addressed to the child's
+// contact. The copy is sent along with the reply, and is deleted or updated along with the reply while it is scheduled.
+func (m *Manager) QueueBroadcastReply(reply models.Message, media []mmodels.Media, inboxID int, conversationUUID string, to []string) (models.Message, error) {
+	var replyMeta map[string]any
+	if err := json.Unmarshal(reply.Meta, &replyMeta); err != nil {
+		m.lo.Error("error unmarshalling message meta", "uuid", reply.UUID, "error", err)
+	}
+	meta := map[string]any{"broadcast_from": reply.ConversationUUID}
+	for _, key := range []string{"cancel_on_reply", "undo_send"} {
+		if val, ok := replyMeta[key]; ok {
+			meta[key] = val
+		}
+	}
+	return m.queueReply(media, inboxID, reply.SenderID, conversationUUID, reply.Content, to, nil, nil, meta, reply.SendAt.Time, null.IntFrom(reply.ID))
+}
+
+// queueReply queues a reply, parentMessageID is set for the copies of a parent conversation reply.
+func (m *Manager) queueReply(media []mmodels.Media, inboxID, senderID int, conversationUUID, content string, to, cc, bcc []string, meta map[string]interface{}, sendAt time.Time, parentMessageID null.Int) (models.Message, error) {
 	var (
 		message = models.Message{}
 	)
@@ -449,18 +497,29 @@ func (m *Manager) QueueReply(media []mmodels.Media, inboxID, senderID int, conve
 	}
 
 	// Insert Message.
+	status := models.MessageStatusPending
+	if !sendAt.IsZero() {
+		if !sendAt.After(time.Now()) {
+			return message, envelope.NewError(envelope.InputError, m.i18n.T("conversation.sendAtInPast"), nil)
+		}
+		status = models.MessageStatusScheduled
+	}
 	message = models.Message{
 		ConversationUUID: conversationUUID,
 		SenderID:         senderID,
 		Type:             models.MessageOutgoing,
 		SenderType:       models.SenderTypeAgent,
-		Status:           models.MessageStatusPending,
+		Status:           status,
 		Content:          content,
 		ContentType:      models.ContentTypeHTML,
 		Private:          false,
 		Media:            media,
 		Meta:             metaJSON,
 		SourceID:         null.StringFrom(sourceID),
+		ParentMessageID:  parentMessageID,
+	}
+	if status == models.MessageStatusScheduled {
+		message.SendAt = null.TimeFrom(sendAt)
 	}
 	if err := m.InsertMessage(&message); err != nil {
 		return models.Message{}, err
@@ -468,6 +527,171 @@ func (m *Manager) QueueReply(media []mmodels.Media, inboxID, senderID int, conve
 	return message, nil
 }
 
+// UpdateScheduledMessage updates the content, send time and whether a contact reply cancels a message that is not sent yet.
+func (m *Manager) UpdateScheduledMessage(uuid, content string, sendAt time.Time, cancelOnReply bool) (models.Message, error) {
+	if !sendAt.After(time.Now()) {
+		return models.Message{}, envelope.NewError(envelope.InputError, m.i18n.T("conversation.sendAtInPast"), nil)
+	}
+	meta, _ := json.Marshal(map[string]any{"cancel_on_reply": cancelOnReply})
+
+	// The copies of the message in child conversations are updated along with it.
+	var updated []string
+	if err := m.q.UpdateScheduledMessage.Select(&updated, uuid, content, stringutil.HTML2Text(content), sendAt, meta); err != nil {
+		m.lo.Error("error updating scheduled message", "uuid", uuid, "error", err)
+		return models.Message{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.message}"), nil)
+	}
+	if !slices.Contains(updated, uuid) {
+		return models.Message{}, envelope.NewError(envelope.InputError, m.i18n.T("conversation.messageNotScheduled"), nil)
+	}
+
+	var message models.Message
+	for _, u := range updated {
+		msg, err := m.GetMessage(u)
+		if err != nil {
+			return msg, err
+		}
+		// Replies held for undo send are only visible to their sender.
+		userIDs := []int{}
+		if msg.HeldForUndo() {
+			userIDs = []int{msg.SenderID}
+		}
+		m.broadcastMessageUpdateToUsers(userIDs, msg.ConversationUUID, u, "content", msg.Content)
+		m.broadcastMessageUpdateToUsers(userIDs, msg.ConversationUUID, u, "send_at", msg.SendAt)
+		if u == uuid {
+			message = msg
+		}
+	}
+	return message, nil
+}
+
+// CancelScheduledMessage deletes a message that is not sent yet.
+func (m *Manager) CancelScheduledMessage(uuid string) error {
+	deleted, err := m.deleteScheduledMessage(uuid)
+	if err != nil {
+		m.lo.Error("error cancelling scheduled message", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.message}"), nil)
+	}
+	if !deleted {
+		return envelope.NewError(envelope.InputError, m.i18n.T("conversation.messageNotScheduled"), nil)
+	}
+	return nil
+}
+
+// deleteScheduledMessage deletes a scheduled message along with its scheduled copies in child conversations and
+// broadcasts the deletions, it returns false if the message is not scheduled.
+func (m *Manager) deleteScheduledMessage(uuid string) (bool, error) {
+	var deleted []struct {
+		ID               int    `db:"id"`
+		UUID             string `db:"uuid"`
+		ConversationUUID string `db:"conversation_uuid"`
+	}
+	if err := m.q.DeleteScheduledMessage.Select(&deleted, uuid); err != nil {
+		return false, err
+	}
+	found := false
+	for _, d := range deleted {
+		if d.UUID == uuid {
+			found = true
+		}
+		m.BroadcastMessageUpdate(d.ConversationUUID, d.UUID, "deleted", true)
+	}
+	return found, nil
+}
+
+// RecallMessage deletes a reply that is still held in the undo send period of its sender
+// and restores it to the sender's draft of the conversation along with its attachments.
+func (m *Manager) RecallMessage(uuid string, userID int) (models.ConversationDraft, error) {
+	var draft models.ConversationDraft
+
+	message, err := m.GetMessage(uuid)
+	if err != nil {
+		return draft, err
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(message.Meta, &meta); err != nil {
+		m.lo.Error("error unmarshalling message meta", "uuid", uuid, "error", err)
+	}
+	if undoSend, _ := meta["undo_send"].(bool); !undoSend || message.SenderID != userID {
+		return draft, envelope.NewError(envelope.InputError, m.i18n.T("conversation.messageCannotBeRecalled"), nil)
+	}
+
+	// Fetch the attachments before they are detached from the deleted message.
+	media, err := m.mediaStore.GetByModel(message.ID, mmodels.ModelMessages)
+	if err != nil {
+		m.lo.Error("error fetching recalled message media", "uuid", uuid, "error", err)
+	}
+
+	deleted, err := m.deleteScheduledMessage(uuid)
+	if err != nil {
+		m.lo.Error("error recalling message", "uuid", uuid, "error", err)
+		return draft, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.message}"), nil)
+	}
+	if !deleted {
+		return draft, envelope.NewError(envelope.InputError, m.i18n.T("conversation.messageCannotBeRecalled"), nil)
+	}
+
+	// Same draft meta the reply box saves.
+	draftMeta := map[string]any{}
+	if len(media) > 0 {
+		attachments := make([]map[string]any, 0, len(media))
+		for _, med := range media {
+			attachments = append(attachments, map[string]any{
+				"id":           med.ID,
+				"size":         med.Size,
+				"uuid":         med.UUID,
+				"filename":     med.Filename,
+				"content_type": med.ContentType,
+			})
+		}
+		draftMeta["attachments"] = attachments
+	}
+	draftMetaJSON, _ := json.Marshal(draftMeta)
+
+	if draft, err = m.UpsertConversationDraft(message.ConversationID, userID, message.Content, draftMetaJSON); err != nil {
+		return draft, err
+	}
+	draft.ConversationUUID = message.ConversationUUID
+	m.BroadcastMessageRecalled(userID, uuid, draft)
+	return draft, nil
+}
+
+// cancelScheduledMessagesOnReply deletes the scheduled messages of a conversation that are cancelled when the contact replies.
+func (m *Manager) cancelScheduledMessagesOnReply(conversationID int, conversationUUID string) {
+	var uuids []string
+	if err := m.q.DeleteScheduledMessagesOnReply.Select(&uuids, conversationID); err != nil {
+		m.lo.Error("error cancelling scheduled messages on contact reply", "conversation_id", conversationID, "error", err)
+		return
+	}
+	for _, uuid := range uuids {
+		m.lo.Info("cancelled scheduled message as contact replied", "conversation_uuid", conversationUUID, "message_uuid", uuid)
+		m.BroadcastMessageUpdate(conversationUUID, uuid, "deleted", true)
+	}
+}
+
+// queueDueScheduledMessages moves the scheduled messages that are due to pending so they are sent. The new message
+// broadcast and the message created webhook that were held back when the message was scheduled are sent now.
+func (m *Manager) queueDueScheduledMessages() {
+	var messages []models.Message
+	if err := m.q.QueueDueScheduledMessages.Select(&messages); err != nil {
+		m.lo.Error("error queueing due scheduled messages", "error", err)
+		return
+	}
+	for _, message := range messages {
+		// The conversation last message was not updated when the message was scheduled.
+		m.UpdateConversationLastMessage(message.ConversationID, message.ConversationUUID, message.TextContent, message.SenderType, message.Type, message.Private, message.CreatedAt)
+		m.BroadcastMessageUpdate(message.ConversationUUID, message.UUID, "status", models.MessageStatusPending)
+		m.BroadcastMessageUpdate(message.ConversationUUID, message.UUID, "created_at", message.CreatedAt.Format(time.RFC3339))
+		m.BroadcastNewMessage(&message)
+
+		queued, err := m.GetMessage(message.UUID)
+		if err != nil {
+			m.lo.Error("error fetching queued scheduled message", "uuid", message.UUID, "error", err)
+			continue
+		}
+		m.webhookStore.TriggerEvent(wmodels.EventMessageCreated, &queued)
+	}
+}
+
 // InsertMessage inserts a message and attaches the media to the message.
 func (m *Manager) InsertMessage(message *models.Message) error {
 	if message.Private {
@@ -489,7 +713,7 @@ func (m *Manager) InsertMessage(message *models.Message) error {
 	if err := m.q.InsertMessage.Get(message,
 		message.Type, message.Status, message.ConversationID, message.ConversationUUID,
 		message.Content, message.TextContent, message.SenderID, message.SenderType,
-		message.Private, message.ContentType, message.SourceID, message.Meta); err != nil {
+		message.Private, message.ContentType, message.SourceID, message.Meta, message.SendAt, message.ParentMessageID); err != nil {
 		m.lo.Error("error inserting message in db", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.message}"), nil)
 	}
@@ -509,10 +733,17 @@ func (m *Manager) InsertMessage(message *models.Message) error {
 	}
 
 	// Update conversation last message details (also conditionally updates last_interaction if not activity/private).
-	m.UpdateConversationLastMessage(message.ConversationID, message.ConversationUUID, lastMessage, message.SenderType, message.Type, message.Private, message.CreatedAt)
+	// Scheduled messages update it once they are due.
+	if message.Status != models.MessageStatusScheduled {
+		m.UpdateConversationLastMessage(message.ConversationID, message.ConversationUUID, lastMessage, message.SenderType, message.Type, message.Private, message.CreatedAt)
+	}
 
-	// Broadcast new message.
-	m.BroadcastNewMessage(message)
+	// Broadcast new message, scheduled messages are only shown to their sender until they are queued.
+	if message.Status == models.MessageStatusScheduled {
+		m.broadcastNewMessageToUsers([]int{message.SenderID}, message)
+	} else {
+		m.BroadcastNewMessage(message)
+	}
 
 	// Refetch the message to get all fields populated (e.g., author, media URLs).
 	refetchedMessage, err := m.GetMessage(message.UUID)
@@ -522,8 +753,10 @@ func (m *Manager) InsertMessage(message *models.Message) error {
 		*message = refetchedMessage
 	}
 
-	// Trigger webhook for new message created.
-	m.webhookStore.TriggerEvent(wmodels.EventMessageCreated, message)
+	// Trigger webhook for new message created, scheduled messages trigger it once they are queued.
+	if message.Status != models.MessageStatusScheduled {
+		m.webhookStore.TriggerEvent(wmodels.EventMessageCreated, message)
+	}
 
 	return nil
 }
@@ -633,6 +866,10 @@ func (m *Manager) getMessageActivityContent(activityType, newValue, actorName st
 		content = fmt.Sprintf("%s removed tag %s", actorName, newValue)
 	case models.ActivitySLASet:
 		content = fmt.Sprintf("%s set %s SLA policy", actorName, newValue)
+	case models.ActivityLinkAdded:
+		content = fmt.Sprintf("%s linked %s", actorName, newValue)
+	case models.ActivityLinkRemoved:
+		content = fmt.Sprintf("%s unlinked %s", actorName, newValue)
 	default:
 		return "", fmt.Errorf("invalid activity type %s", activityType)
 	}
@@ -644,6 +881,15 @@ func (m *Manager) getMessageActivityContent(activityType, newValue, actorName st
 // conversations, and creates a new conversation if necessary. It also
 // inserts the message, uploads any attachments, and queues the conversation evaluation of automation rules.
 func (m *Manager) processIncomingMessage(in models.IncomingMessage) error {
+	// Replies to side conversations stay in the side conversation, their senders are third parties and not contacts.
+	matched, err := m.processSideConversationReply(in)
+	if err != nil {
+		return err
+	}
+	if matched {
+		return nil
+	}
+
 	// Find or create contact and set sender ID in message.
 	if err := m.userStore.CreateContact(&in.Contact); err != nil {
 		m.lo.Error("error upserting contact", "error", err)
@@ -749,6 +995,9 @@ func (m *Manager) processIncomingMessage(in models.IncomingMessage) error {
 		return nil
 	}
 
+	// Cancel scheduled replies that should not be sent once the contact replies.
+	m.cancelScheduledMessagesOnReply(in.Message.ConversationID, in.Message.ConversationUUID)
+
 	// Reopen conversation if it's not Open.
 	systemUser, err := m.userStore.GetSystemUser()
 	if err != nil {
@@ -793,7 +1042,7 @@ func (m *Manager) MessageExists(messageID string) (bool, error) {
 	_, err := m.messageExistsBySourceID([]string{messageID})
 	if err != nil {
 		if errors.Is(err, errConversationNotFound) {
-			return false, nil
+			return m.sideConversationMessageExists(messageID)
 		}
 		m.lo.Error("error fetching message from db", "error", err)
 		return false, err
@@ -39,10 +39,11 @@ var (
 	MentionTypeAgent = "agent"
 	MentionTypeTeam  = "team"
 
-	MessageStatusPending  = "pending"
-	MessageStatusSent     = "sent"
-	MessageStatusFailed   = "failed"
-	MessageStatusReceived = "received"
+	MessageStatusPending   = "pending"
+	MessageStatusSent      = "sent"
+	MessageStatusFailed    = "failed"
+	MessageStatusReceived  = "received"
+	MessageStatusScheduled = "scheduled"
 
 	ActivityStatusChange       = "status_change"
 	ActivityPriorityChange     = "priority_change"
@@ -52,6 +53,15 @@ var (
 	ActivityTagAdded           = "tag_added"
 	ActivityTagRemoved         = "tag_removed"
 	ActivitySLASet             = "sla_set"
+	ActivityLinkAdded          = "link_added"
+	ActivityLinkRemoved        = "link_removed"
+
+	LinkTypeParentChild = "parent_child"
+	LinkTypeRelated     = "related"
+
+	LinkRelationParent  = "parent"
+	LinkRelationChild   = "child"
+	LinkRelationRelated = "related"
 
 	ContentTypeText = "text"
 	ContentTypeHTML = "html"
@@ -181,6 +191,9 @@ type PortalConversationListItem struct {
 	Subject          null.String `db:"subject" json:"subject"`
 	LastMessageAt    null.Time   `db:"last_message_at" json:"last_message_at"`
 	Status           string      `db:"status" json:"status"`
+	ContactID        int         `db:"contact_id" json:"contact_id"`
+	ContactFirstName string      `db:"contact_first_name" json:"contact_first_name"`
+	ContactLastName  string      `db:"contact_last_name" json:"contact_last_name"`
 }
 
 type PreviousConversation struct {
@@ -247,6 +260,8 @@ type Message struct {
 	Author           MessageAuthor          `db:"author" json:"author"`
 	InboxID          int                    `db:"inbox_id" json:"-"`
 	Meta             json.RawMessage        `db:"meta" json:"meta"`
+	SendAt           null.Time              `db:"send_at" json:"send_at"`
+	ParentMessageID  null.Int               `db:"parent_message_id" json:"-"`
 	Attachments      attachment.Attachments `db:"attachments" json:"attachments"`
 	From             string                 `db:"from"  json:"-"`
 	Subject          string                 `db:"subject" json:"-"`
@@ -284,6 +299,19 @@ func (m *Message) HasCSAT() bool {
 	return isCsat
 }
 
+// HeldForUndo returns true if the message is a reply that is still held in the undo send period of its sender.
+func (m *Message) HeldForUndo() bool {
+	if m.Status != MessageStatusScheduled {
+		return false
+	}
+	var meta map[string]interface{}
+	if err := json.Unmarshal([]byte(m.Meta), &meta); err != nil {
+		return false
+	}
+	undoSend, _ := meta["undo_send"].(bool)
+	return undoSend
+}
+
 // IncomingMessage links a message with the contact information and inbox id.
 type IncomingMessage struct {
 	ConversationUUIDFromReplyTo string // UUID extracted from plus-addressed recipient (e.g., inbox+conv-{uuid}@domain)
@@ -316,8 +344,85 @@ type ConversationDraft struct {
 	Meta             json.RawMessage `db:"meta" json:"meta"`
 }
 
+// DraftAgent represents another agent with a draft in progress in a conversation.
+type DraftAgent struct {
+	UserID    int       `db:"user_id" json:"user_id"`
+	FirstName string    `db:"first_name" json:"first_name"`
+	LastName  string    `db:"last_name" json:"last_name"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
 // MentionInput represents a mention in a private note from frontend.
 type MentionInput struct {
 	Type string `json:"type"` // "agent" or "team"
 	ID   int    `json:"id"`
 }
+
+// SideConversation is an email thread with third parties started from a conversation, kept apart from the contact's thread.
+type SideConversation struct {
+	ID               int            `db:"id" json:"id"`
+	CreatedAt        time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time      `db:"updated_at" json:"updated_at"`
+	UUID             string         `db:"uuid" json:"uuid"`
+	ConversationID   int            `db:"conversation_id" json:"conversation_id"`
+	ConversationUUID string         `db:"conversation_uuid" json:"conversation_uuid"`
+	InboxID          int            `db:"inbox_id" json:"inbox_id"`
+	CreatedByID      null.Int       `db:"created_by_id" json:"created_by_id"`
+	Subject          string         `db:"subject" json:"subject"`
+	To               pq.StringArray `db:"to" json:"to"`
+	CC               pq.StringArray `db:"cc" json:"cc"`
+	LastMessageAt    null.Time      `db:"last_message_at" json:"last_message_at"`
+	LastMessage      null.String    `db:"last_message" json:"last_message"`
+}
+
+// SideConversationMessage represents a message in a side conversation.
+type SideConversationMessage struct {
+	ID                 int         `db:"id" json:"id"`
+	CreatedAt          time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time   `db:"updated_at" json:"updated_at"`
+	UUID               string      `db:"uuid" json:"uuid"`
+	SideConversationID int         `db:"side_conversation_id" json:"side_conversation_id"`
+	Type               string      `db:"type" json:"type"`
+	Status             string      `db:"status" json:"status"`
+	SenderID           null.Int    `db:"sender_id" json:"sender_id"`
+	FromAddress        string      `db:"from_address" json:"from_address"`
+	Content            string      `db:"content" json:"content"`
+	TextContent        string      `db:"text_content" json:"text_content"`
+	SourceID           string      `db:"source_id" json:"-"`
+
+	Attachments attachment.Attachments `db:"attachments" json:"attachments"`
+}
+
+// ConversationTask is a task attached to a conversation, e.g. to check back on it on a date, with a reminder when it comes due.
+type ConversationTask struct {
+	ID                int         `db:"id" json:"id"`
+	CreatedAt         time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time   `db:"updated_at" json:"updated_at"`
+	Title             string      `db:"title" json:"title"`
+	DueAt             null.Time   `db:"due_at" json:"due_at"`
+	CompletedAt       null.Time   `db:"completed_at" json:"completed_at"`
+	CreatedByID       null.Int    `db:"created_by_id" json:"created_by_id"`
+	AssignedUserID    int         `db:"assigned_user_id" json:"assigned_user_id"`
+	AssigneeFirstName string      `db:"assignee_first_name" json:"assignee_first_name"`
+	AssigneeLastName  string      `db:"assignee_last_name" json:"assignee_last_name"`
+	ConversationID    int         `db:"conversation_id" json:"-"`
+	ConversationUUID  string      `db:"conversation_uuid" json:"conversation_uuid"`
+	ReferenceNumber   string      `db:"reference_number" json:"reference_number"`
+	Subject           null.String `db:"subject" json:"subject"`
+}
+
+// ConversationLink represents a link to another conversation as seen from the conversation it is fetched for.
+type ConversationLink struct {
+	ID        int       `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	Type      string    `db:"type" json:"type"`
+	// Relation is what the linked conversation is to the conversation, one of parent, child or related.
+	Relation         string      `db:"relation" json:"relation"`
+	ConversationID   int         `db:"conversation_id" json:"conversation_id"`
+	ConversationUUID string      `db:"conversation_uuid" json:"conversation_uuid"`
+	ReferenceNumber  string      `db:"reference_number" json:"reference_number"`
+	Subject          null.String `db:"subject" json:"subject"`
+	Status           null.String `db:"status" json:"status"`
+	ContactFirstName string      `db:"contact_first_name" json:"contact_first_name"`
+	ContactLastName  string      `db:"contact_last_name" json:"contact_last_name"`
+}
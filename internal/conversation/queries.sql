@@ -224,12 +224,20 @@ SELECT COUNT(*) OVER() AS total,
     c.reference_number,
     c.subject,
     c.last_message_at,
-    s.name AS status
+    s.name AS status,
+    c.contact_id,
+    u.first_name AS contact_first_name,
+    u.last_name AS contact_last_name
 FROM conversations c
 JOIN conversation_statuses s ON c.status_id = s.id
-WHERE c.contact_id = $1 OR ($2 > 0 AND c.organization_id = $2)
+JOIN users u ON u.id = c.contact_id
+WHERE c.contact_id = $1 OR ($2 > 0 AND (
+    c.organization_id = $2
+    -- Organization admins also see the conversations of every member.
+    OR ($3 AND c.contact_id IN (SELECT contact_id FROM organization_members WHERE organization_id = $2))
+))
 ORDER BY c.last_message_at DESC NULLS LAST, c.id DESC
-LIMIT $3 OFFSET $4;
+LIMIT $4 OFFSET $5;
 
 -- name: get-contact-previous-conversations
 SELECT
@@ -276,6 +284,7 @@ SET status_id = $2,
     resolved_at = COALESCE(resolved_at, CASE WHEN $2 IN (3, 4) THEN NOW() END),
     closed_at = COALESCE(closed_at, CASE WHEN $2 = 4 THEN NOW() END),
     snoozed_until = CASE WHEN $2 = 2 THEN $3::timestamptz ELSE snoozed_until END,
+    reopened_at = CASE WHEN status_id IN (3, 4) AND $2 NOT IN (3, 4) THEN NOW() ELSE reopened_at END,
     updated_at = NOW()
 WHERE uuid = $1;
 
@@ -404,6 +413,13 @@ SET custom_attributes = $2,
     updated_at = NOW()
 WHERE uuid = $1;
 
+-- name: merge-conversation-custom-attributes
+UPDATE conversations
+SET custom_attributes = COALESCE(custom_attributes, '{}'::jsonb) || $2::jsonb,
+    updated_at = NOW()
+WHERE uuid = $1
+RETURNING custom_attributes;
+
 -- name: update-conversation-waiting-since
 UPDATE conversations
 SET waiting_since = $2,
@@ -424,6 +440,7 @@ UPDATE conversations
 SET 
   status_id = 1,
   snoozed_until = NULL,
+  reopened_at = CASE WHEN status_id IN (3, 4) THEN NOW() ELSE reopened_at END,
   updated_at = NOW(),
   assigned_user_id = CASE
     WHEN EXISTS (
@@ -506,6 +523,7 @@ SELECT
     m.sender_type,
     m.sender_id,
     m.meta,
+    m.send_at,
     c.uuid as conversation_uuid,
     u.id AS "author.id",
     u.first_name AS "author.first_name",
@@ -551,6 +569,7 @@ SELECT
    m.sender_id,
    m.sender_type,
    m.meta,
+   m.send_at,
    $1::uuid AS conversation_uuid,
    u.id AS "author.id",
    u.first_name AS "author.first_name",
@@ -591,11 +610,11 @@ inserted_msg AS (
    INSERT INTO conversation_messages (
        "type", status, conversation_id, "content", 
        text_content, sender_id, sender_type, private,
-       content_type, source_id, meta
+       content_type, source_id, meta, send_at, parent_message_id
    )
    VALUES (
        $1, $2, (SELECT id FROM conversation_id),
-       $5, $6, $7, $8, $9, $10, $11, $12
+       $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
    )
    RETURNING *
 )
@@ -609,6 +628,50 @@ WHERE source_id = ANY($1::text []);
 -- name: update-message-status
 update conversation_messages set status = $1, updated_at = NOW() where uuid = $2;
 
+-- name: queue-due-scheduled-messages
+-- Moves due scheduled messages to pending for the outgoing scanner to pick up. The created at is moved to
+-- the send time so the message is shown in the thread where it was sent.
+UPDATE conversation_messages m
+SET status = 'pending', created_at = NOW(), updated_at = NOW()
+FROM conversations c
+WHERE c.id = m.conversation_id AND m.status = 'scheduled' AND m.send_at <= NOW()
+RETURNING m.id, m.uuid, m.created_at, m.type, m.private, m.sender_type, m.text_content, m.conversation_id, c.uuid AS conversation_uuid;
+
+-- name: update-scheduled-message
+-- Updates a scheduled message along with its scheduled copies in child conversations.
+UPDATE conversation_messages
+SET content = $2, text_content = $3, send_at = $4, meta = meta || $5::JSONB, updated_at = NOW()
+WHERE (uuid = $1 OR parent_message_id = (SELECT id FROM conversation_messages WHERE uuid = $1)) AND status = 'scheduled'
+RETURNING uuid;
+
+-- name: delete-scheduled-message
+-- Deletes a scheduled message along with its scheduled copies in child conversations.
+WITH deleted AS (
+    DELETE FROM conversation_messages
+    WHERE (uuid = $1 OR parent_message_id = (SELECT id FROM conversation_messages WHERE uuid = $1)) AND status = 'scheduled'
+    RETURNING id, uuid, conversation_id
+),
+detached AS (
+    -- Unlinked media is removed by the media cleaner.
+    UPDATE media SET model_id = NULL
+    WHERE model_type = 'messages' AND model_id IN (SELECT id FROM deleted)
+)
+SELECT d.id, d.uuid, c.uuid AS conversation_uuid
+FROM deleted d
+INNER JOIN conversations c ON c.id = d.conversation_id;
+
+-- name: delete-scheduled-messages-cancelled-on-reply
+WITH deleted AS (
+    DELETE FROM conversation_messages
+    WHERE conversation_id = $1 AND status = 'scheduled' AND (meta->>'cancel_on_reply')::BOOLEAN IS TRUE
+    RETURNING id, uuid
+),
+detached AS (
+    UPDATE media SET model_id = NULL
+    WHERE model_type = 'messages' AND model_id IN (SELECT id FROM deleted)
+)
+SELECT uuid FROM deleted;
+
 -- name: get-latest-message
 SELECT
     m.created_at,
@@ -643,6 +706,18 @@ INNER JOIN conversations c ON cd.conversation_id = c.id
 WHERE cd.user_id = $1
 ORDER BY cd.updated_at DESC;
 
+-- name: get-other-agent-drafts
+-- Returns the agents other than the given user with a non-empty draft in the conversation edited after the cutoff.
+SELECT cd.user_id, u.first_name, u.last_name, cd.updated_at
+FROM conversation_drafts cd
+INNER JOIN conversations c ON cd.conversation_id = c.id
+INNER JOIN users u ON cd.user_id = u.id
+WHERE c.uuid = $1
+AND cd.user_id != $2
+AND cd.updated_at > $3
+AND TRIM(cd.content) != ''
+ORDER BY cd.updated_at DESC;
+
 -- name: delete-conversation-draft
 DELETE FROM conversation_drafts
 WHERE conversation_id IN (
@@ -672,4 +747,220 @@ DO UPDATE SET
     last_seen_at = (SELECT created_at - INTERVAL '1 second' FROM conversation_messages
                     WHERE conversation_id = (SELECT id FROM conversations WHERE uuid = $2)
                     ORDER BY created_at DESC LIMIT 1),
-    updated_at = NOW();
\ No newline at end of file
+    updated_at = NOW();
+-- name: insert-side-conversation
+WITH sc AS (
+    INSERT INTO side_conversations (conversation_id, inbox_id, created_by_id, subject, "to", cc)
+    VALUES ($1, $2, $3, $4, $5, $6)
+    RETURNING *
+)
+SELECT sc.*, c.uuid AS conversation_uuid, NULL::TEXT AS last_message
+FROM sc
+INNER JOIN conversations c ON c.id = sc.conversation_id;
+
+-- name: get-side-conversations
+SELECT sc.*, c.uuid AS conversation_uuid, lm.text_content AS last_message
+FROM side_conversations sc
+INNER JOIN conversations c ON c.id = sc.conversation_id
+LEFT JOIN LATERAL (
+    SELECT text_content FROM side_conversation_messages
+    WHERE side_conversation_id = sc.id
+    ORDER BY created_at DESC LIMIT 1
+) lm ON TRUE
+WHERE c.uuid = $1
+ORDER BY COALESCE(sc.last_message_at, sc.created_at) DESC;
+
+-- name: get-side-conversation
+SELECT sc.*, c.uuid AS conversation_uuid, NULL::TEXT AS last_message
+FROM side_conversations sc
+INNER JOIN conversations c ON c.id = sc.conversation_id
+WHERE sc.uuid = $1 AND c.uuid = $2;
+
+-- name: get-side-conversation-by-id
+SELECT sc.*, c.uuid AS conversation_uuid, NULL::TEXT AS last_message
+FROM side_conversations sc
+INNER JOIN conversations c ON c.id = sc.conversation_id
+WHERE sc.id = $1;
+
+-- name: get-side-conversation-by-source-id
+-- Returns the side conversation of the message a reply is to.
+SELECT sc.*, c.uuid AS conversation_uuid, NULL::TEXT AS last_message
+FROM side_conversation_messages scm
+INNER JOIN side_conversations sc ON sc.id = scm.side_conversation_id
+INNER JOIN conversations c ON c.id = sc.conversation_id
+WHERE scm.source_id = $1;
+
+-- name: get-side-conversation-messages
+SELECT scm.*,
+   COALESCE(
+     (SELECT json_agg(
+       json_build_object(
+         'name', filename,
+         'content_type', content_type,
+         'uuid', uuid,
+         'size', size,
+         'content_id', content_id,
+         'disposition', disposition
+       ) ORDER BY filename
+     ) FROM media
+     WHERE model_type = 'side_conversation_messages' AND model_id = scm.id),
+   '[]'::json) AS attachments
+FROM side_conversation_messages scm
+WHERE scm.side_conversation_id = $1
+ORDER BY scm.created_at ASC;
+
+-- name: get-side-conversation-source-ids
+-- Returns the source IDs of the messages before a message, a message references them.
+SELECT source_id FROM side_conversation_messages
+WHERE side_conversation_id = $1 AND id < $2
+ORDER BY id DESC
+LIMIT $3;
+
+-- name: get-outgoing-pending-side-conversation-messages
+SELECT * FROM side_conversation_messages
+WHERE status = 'pending' AND "type" = 'outgoing'
+AND NOT(id = ANY($1::BIGINT[]))
+ORDER BY id;
+
+-- name: insert-side-conversation-message
+WITH msg AS (
+    INSERT INTO side_conversation_messages (side_conversation_id, "type", status, sender_id, from_address, "content", text_content, source_id)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    ON CONFLICT (source_id) DO NOTHING
+    RETURNING *
+), sc AS (
+    UPDATE side_conversations SET last_message_at = msg.created_at, updated_at = NOW()
+    FROM msg
+    WHERE side_conversations.id = msg.side_conversation_id
+)
+SELECT * FROM msg;
+
+-- name: update-side-conversation-message-status
+UPDATE side_conversation_messages SET status = $2, updated_at = NOW()
+WHERE uuid = $1;
+
+-- name: get-conversation-by-side-conversation-message-id
+SELECT
+    c.id,
+    c.uuid,
+    c.assigned_team_id,
+    c.assigned_user_id
+FROM side_conversation_messages scm
+JOIN side_conversations sc ON sc.id = scm.side_conversation_id
+JOIN conversations c ON c.id = sc.conversation_id
+WHERE scm.id = $1;
+
+-- name: side-conversation-message-exists
+SELECT EXISTS(SELECT 1 FROM side_conversation_messages WHERE source_id = $1);
+
+-- name: get-conversation-links
+-- Returns the links of a conversation with the relation of each linked conversation to it.
+WITH conv AS (
+    SELECT id FROM conversations WHERE uuid = $1
+)
+SELECT l.id, l.created_at, l.type,
+    CASE
+        WHEN l.type = 'related' THEN 'related'
+        WHEN l.conversation_id = conv.id THEN 'child'
+        ELSE 'parent'
+    END AS relation,
+    c.id AS conversation_id, c.uuid AS conversation_uuid, c.reference_number, c.subject, s.name AS status,
+    u.first_name AS contact_first_name, u.last_name AS contact_last_name
+FROM conversation_links l
+CROSS JOIN conv
+INNER JOIN conversations c ON c.id = CASE WHEN l.conversation_id = conv.id THEN l.linked_conversation_id ELSE l.conversation_id END
+LEFT JOIN conversation_statuses s ON s.id = c.status_id
+INNER JOIN users u ON u.id = c.contact_id
+WHERE l.conversation_id = conv.id OR l.linked_conversation_id = conv.id
+ORDER BY l.created_at;
+
+-- name: get-child-conversations
+SELECT c.id, c.uuid, c.reference_number, c.inbox_id, c.assigned_user_id, c.assigned_team_id, c.contact_id,
+    ct.id AS "contact.id", ct.first_name AS "contact.first_name", ct.last_name AS "contact.last_name",
+    ct.email AS "contact.email", ct.enabled AS "contact.enabled"
+FROM conversation_links l
+INNER JOIN conversations c ON c.id = l.linked_conversation_id
+INNER JOIN users ct ON ct.id = c.contact_id
+WHERE l.type = 'parent_child' AND l.conversation_id = (SELECT id FROM conversations WHERE uuid = $1)
+ORDER BY l.created_at;
+
+-- name: lock-conversations
+-- Locks the rows of the conversations so that concurrent link changes of the same conversations are serialized.
+SELECT id FROM conversations WHERE id = ANY($1::INT[]) ORDER BY id FOR UPDATE;
+
+-- name: insert-conversation-link
+INSERT INTO conversation_links ("type", conversation_id, linked_conversation_id, created_by_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING
+RETURNING id;
+
+-- name: delete-conversation-link
+DELETE FROM conversation_links
+WHERE id = $1 AND (conversation_id = $2 OR linked_conversation_id = $2);
+
+-- name: get-conversation-tasks
+SELECT t.id, t.created_at, t.updated_at, t.title, t.due_at, t.completed_at, t.created_by_id, t.assigned_user_id,
+    u.first_name AS assignee_first_name, u.last_name AS assignee_last_name,
+    c.uuid AS conversation_uuid, c.reference_number, c.subject
+FROM conversation_tasks t
+INNER JOIN conversations c ON c.id = t.conversation_id
+INNER JOIN users u ON u.id = t.assigned_user_id
+WHERE c.uuid = $1
+ORDER BY t.completed_at IS NOT NULL, t.due_at NULLS LAST, t.created_at;
+
+-- name: get-conversation-task
+SELECT t.id, t.created_at, t.updated_at, t.title, t.due_at, t.completed_at, t.created_by_id, t.assigned_user_id,
+    u.first_name AS assignee_first_name, u.last_name AS assignee_last_name,
+    c.uuid AS conversation_uuid, c.reference_number, c.subject
+FROM conversation_tasks t
+INNER JOIN conversations c ON c.id = t.conversation_id
+INNER JOIN users u ON u.id = t.assigned_user_id
+WHERE c.uuid = $1 AND t.id = $2;
+
+-- name: get-user-tasks
+-- Returns the tasks assigned to an agent, open tasks only unless $2 is true.
+SELECT t.id, t.created_at, t.updated_at, t.title, t.due_at, t.completed_at, t.created_by_id, t.assigned_user_id,
+    u.first_name AS assignee_first_name, u.last_name AS assignee_last_name,
+    c.uuid AS conversation_uuid, c.reference_number, c.subject
+FROM conversation_tasks t
+INNER JOIN conversations c ON c.id = t.conversation_id
+INNER JOIN users u ON u.id = t.assigned_user_id
+WHERE t.assigned_user_id = $1 AND ($2 OR t.completed_at IS NULL)
+ORDER BY t.completed_at IS NOT NULL, t.due_at NULLS LAST, t.created_at;
+
+-- name: insert-conversation-task
+INSERT INTO conversation_tasks (conversation_id, created_by_id, assigned_user_id, title, due_at)
+SELECT c.id, $2, $3, $4, $5
+FROM conversations c
+WHERE c.uuid = $1
+RETURNING id;
+
+-- name: update-conversation-task
+-- A new due date clears the sent reminder so it is sent again when the task comes due.
+UPDATE conversation_tasks
+SET title = $2,
+    assigned_user_id = $3,
+    reminded_at = CASE WHEN due_at IS DISTINCT FROM $4 THEN NULL ELSE reminded_at END,
+    due_at = $4,
+    completed_at = CASE WHEN $5 THEN COALESCE(completed_at, NOW()) ELSE NULL END,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: delete-conversation-task
+DELETE FROM conversation_tasks
+WHERE id = $1;
+
+-- name: get-due-conversation-tasks
+-- Marks the open tasks that came due as reminded and returns them, so each reminder is sent once.
+WITH due AS (
+    UPDATE conversation_tasks
+    SET reminded_at = NOW()
+    WHERE completed_at IS NULL AND reminded_at IS NULL AND due_at <= NOW()
+    RETURNING *
+)
+SELECT t.id, t.created_at, t.updated_at, t.title, t.due_at, t.completed_at, t.created_by_id, t.assigned_user_id,
+    u.first_name AS assignee_first_name, u.last_name AS assignee_last_name,
+    c.id AS conversation_id, c.uuid AS conversation_uuid, c.reference_number, c.subject
+FROM due t
+INNER JOIN conversations c ON c.id = t.conversation_id
+INNER JOIN users u ON u.id = t.assigned_user_id;
@@ -0,0 +1,325 @@
+package conversation
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/ghotso/libredesk/internal/attachment"
+	"github.com/ghotso/libredesk/internal/conversation/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/inbox"
+	mmodels "github.com/ghotso/libredesk/internal/media/models"
+	"github.com/ghotso/libredesk/internal/stringutil"
+	wsmodels "github.com/ghotso/libredesk/internal/ws/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
+
+// maxSideConversationReferences is the number of earlier messages referenced by a side conversation message.
+const maxSideConversationReferences = 20
+
+// CreateSideConversation starts a side conversation with third parties from a conversation and queues its first message
+// to be sent through the inbox of the conversation.
+func (m *Manager) CreateSideConversation(conversationUUID string, senderID int, subject string, to, cc []string, content string) (models.SideConversation, models.SideConversationMessage, error) {
+	var (
+		side    models.SideConversation
+		message models.SideConversationMessage
+	)
+
+	to = stringutil.RemoveEmpty(to)
+	cc = stringutil.RemoveEmpty(cc)
+	if len(to) == 0 {
+		return side, message, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`to`"), nil)
+	}
+	for _, addr := range slices.Concat(to, cc) {
+		if !stringutil.ValidEmail(addr) {
+			return side, message, envelope.NewError(envelope.InputError, m.i18n.T("globals.messages.invalidEmailAddress"), nil)
+		}
+	}
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return side, message, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "{globals.terms.subject}"), nil)
+	}
+
+	conversation, err := m.GetConversation(0, conversationUUID, "")
+	if err != nil {
+		return side, message, err
+	}
+	if _, err := m.getSideConversationInbox(conversation.InboxID); err != nil {
+		return side, message, err
+	}
+
+	if err := m.q.InsertSideConversation.Get(&side, conversation.ID, conversation.InboxID, senderID, subject, pq.StringArray(to), pq.StringArray(cc)); err != nil {
+		m.lo.Error("error inserting side conversation", "conversation_uuid", conversationUUID, "error", err)
+		return side, message, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.sideConversation}"), nil)
+	}
+
+	message, err = m.SendSideConversationMessage(side, senderID, content)
+	if err != nil {
+		return side, message, err
+	}
+	side.LastMessageAt = null.TimeFrom(message.CreatedAt)
+	side.LastMessage = null.StringFrom(message.TextContent)
+	return side, message, nil
+}
+
+// GetSideConversations returns the side conversations of a conversation, most recently active first.
+func (m *Manager) GetSideConversations(conversationUUID string) ([]models.SideConversation, error) {
+	var sides = make([]models.SideConversation, 0)
+	if err := m.q.GetSideConversations.Select(&sides, conversationUUID); err != nil {
+		m.lo.Error("error fetching side conversations", "conversation_uuid", conversationUUID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.sideConversation}"), nil)
+	}
+	return sides, nil
+}
+
+// GetSideConversation returns a side conversation of a conversation.
+func (m *Manager) GetSideConversation(conversationUUID, uuid string) (models.SideConversation, error) {
+	var side models.SideConversation
+	if err := m.q.GetSideConversation.Get(&side, uuid, conversationUUID); err != nil {
+		if err == sql.ErrNoRows {
+			return side, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.sideConversation}"), nil)
+		}
+		m.lo.Error("error fetching side conversation", "uuid", uuid, "error", err)
+		return side, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.sideConversation}"), nil)
+	}
+	return side, nil
+}
+
+// GetSideConversationMessages returns the messages of a side conversation, oldest first.
+func (m *Manager) GetSideConversationMessages(sideConversationID int) ([]models.SideConversationMessage, error) {
+	var messages = make([]models.SideConversationMessage, 0)
+	if err := m.q.GetSideConversationMessages.Select(&messages, sideConversationID); err != nil {
+		m.lo.Error("error fetching side conversation messages", "side_conversation_id", sideConversationID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	return messages, nil
+}
+
+// SendSideConversationMessage queues a message to the recipients of a side conversation, it is emailed by the message
+// sender workers threaded on the earlier messages. Side conversation emails carry no conversation UUID so replies to
+// them never match the contact's thread.
+func (m *Manager) SendSideConversationMessage(side models.SideConversation, senderID int, content string) (models.SideConversationMessage, error) {
+	var message models.SideConversationMessage
+
+	if strings.TrimSpace(stringutil.HTML2Text(content)) == "" {
+		return message, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "{globals.terms.message}"), nil)
+	}
+
+	inb, err := m.getSideConversationInbox(side.InboxID)
+	if err != nil {
+		return message, err
+	}
+
+	sourceID, err := stringutil.GenerateEmailMessageID(side.UUID, inb.FromAddress())
+	if err != nil {
+		m.lo.Error("error generating source message id", "error", err)
+		return message, envelope.NewError(envelope.GeneralError, m.i18n.T("conversation.errorGeneratingMessageID"), nil)
+	}
+
+	textContent := stringutil.HTML2Text(content)
+	if err := m.q.InsertSideConversationMessage.Get(&message, side.ID, models.MessageOutgoing, models.MessageStatusPending, senderID, inb.FromAddress(), content, textContent, sourceID); err != nil {
+		m.lo.Error("error inserting side conversation message", "side_conversation_id", side.ID, "error", err)
+		return message, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSending", "name", "{globals.terms.message}"), nil)
+	}
+	m.broadcastSideConversationMessage(side, message)
+	return message, nil
+}
+
+// queuePendingSideConversationMessages pushes the pending side conversation messages that aren't being sent to the outgoing queue.
+func (m *Manager) queuePendingSideConversationMessages() {
+	var (
+		messages   = []models.SideConversationMessage{}
+		messageIDs = make([]int, 0)
+	)
+	m.outgoingProcessingSideMessages.Range(func(key, _ any) bool {
+		if k, ok := key.(int); ok {
+			messageIDs = append(messageIDs, k)
+		}
+		return true
+	})
+	if err := m.q.GetOutgoingPendingSideMessages.Select(&messages, pq.Array(messageIDs)); err != nil {
+		m.lo.Error("error fetching pending side conversation messages", "error", err)
+		return
+	}
+	for _, message := range messages {
+		m.outgoingProcessingSideMessages.Store(message.ID, message.ID)
+		m.outgoingSideMessageQueue <- message
+	}
+}
+
+// sendSideConversationMessage emails a pending side conversation message and updates its status.
+func (m *Manager) sendSideConversationMessage(message models.SideConversationMessage) {
+	defer m.outgoingProcessingSideMessages.Delete(message.ID)
+
+	var side models.SideConversation
+	if err := m.q.GetSideConversationByID.Get(&side, message.SideConversationID); err != nil {
+		m.lo.Error("error fetching side conversation", "side_conversation_id", message.SideConversationID, "error", err)
+		return
+	}
+
+	message.Status = models.MessageStatusSent
+	if err := m.emailSideConversationMessage(side, message); err != nil {
+		m.lo.Error("error sending side conversation message", "side_conversation_id", side.ID, "message_id", message.ID, "error", err)
+		message.Status = models.MessageStatusFailed
+	}
+	if _, err := m.q.UpdateSideConversationMessageStatus.Exec(message.UUID, message.Status); err != nil {
+		m.lo.Error("error updating side conversation message status", "uuid", message.UUID, "error", err)
+	}
+	m.broadcastSideConversationMessage(side, message)
+}
+
+// emailSideConversationMessage emails a side conversation message through the inbox of the side conversation.
+func (m *Manager) emailSideConversationMessage(side models.SideConversation, message models.SideConversationMessage) error {
+	inb, err := m.getSideConversationInbox(side.InboxID)
+	if err != nil {
+		return err
+	}
+
+	var references []string
+	if err := m.q.GetSideConversationSourceIDs.Select(&references, side.ID, message.ID, maxSideConversationReferences); err != nil {
+		m.lo.Error("error fetching side conversation source IDs", "side_conversation_id", side.ID, "error", err)
+	}
+	slices.Reverse(references)
+
+	subject := side.Subject
+	if len(references) > 0 {
+		subject = "Re: " + subject
+	}
+	email := models.Message{
+		From:        message.FromAddress,
+		To:          side.To,
+		CC:          side.CC,
+		Subject:     subject,
+		Content:     message.Content,
+		ContentType: models.ContentTypeHTML,
+		AltContent:  message.TextContent,
+		SourceID:    null.StringFrom(message.SourceID),
+		References:  references,
+	}
+	if len(references) > 0 {
+		email.InReplyTo = references[len(references)-1]
+	}
+	return inb.Send(email)
+}
+
+// processSideConversationReply stores an incoming email that replies to a side conversation in it, it returns false
+// if the email does not reply to a side conversation. Only the message replied to is matched, an email that merely
+// references a side conversation message, or that comes from someone the side conversation isn't with, is processed
+// as a regular message.
+func (m *Manager) processSideConversationReply(in models.IncomingMessage) (bool, error) {
+	replyTo := in.Message.InReplyTo
+	if replyTo == "" && len(in.Message.References) > 0 {
+		replyTo = in.Message.References[0]
+	}
+	if replyTo == "" || in.Message.SourceID.String == "" {
+		return false, nil
+	}
+
+	var side models.SideConversation
+	if err := m.q.GetSideConversationBySourceID.Get(&side, replyTo); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		m.lo.Error("error fetching side conversation by source ID", "source_id", replyTo, "error", err)
+		return false, err
+	}
+
+	from := in.Contact.Email.String
+	if !slices.ContainsFunc(slices.Concat(side.To, side.CC), func(addr string) bool { return strings.EqualFold(addr, from) }) {
+		m.lo.Debug("side conversation reply from unknown sender, processing as a message", "side_conversation_uuid", side.UUID, "from", from)
+		return false, nil
+	}
+
+	// Attachments are uploaded with the conversation UUID like the conversation messages.
+	in.Message.ConversationUUID = side.ConversationUUID
+	if err := m.uploadMessageAttachments(&in.Message); err != nil {
+		m.lo.Error("error uploading side conversation reply attachments", "side_conversation_id", side.ID, "error", err)
+		return false, err
+	}
+
+	var (
+		message     models.SideConversationMessage
+		content     = in.Message.Content
+		textContent = stringutil.HTML2Text(content)
+	)
+	if in.Message.ContentType == models.ContentTypeText {
+		textContent = content
+	}
+	if err := m.q.InsertSideConversationMessage.Get(&message, side.ID, models.MessageIncoming, models.MessageStatusReceived, nil, from, content, textContent, in.Message.SourceID.String); err != nil {
+		// Already stored.
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+		m.lo.Error("error inserting side conversation reply", "side_conversation_id", side.ID, "error", err)
+		return false, err
+	}
+	m.lo.Debug("matched side conversation reply", "side_conversation_uuid", side.UUID, "conversation_uuid", side.ConversationUUID, "from", from)
+
+	message.Attachments = make(attachment.Attachments, 0, len(in.Message.Media))
+	for _, media := range in.Message.Media {
+		if err := m.mediaStore.Attach(media.ID, mmodels.ModelSideConversationMessages, message.ID); err != nil {
+			m.lo.Error("error attaching media to side conversation reply", "media_id", media.ID, "error", err)
+			continue
+		}
+		message.Attachments = append(message.Attachments, attachment.Attachment{
+			Name:        media.Filename,
+			ContentType: media.ContentType,
+			UUID:        media.UUID,
+			Size:        media.Size,
+			ContentID:   media.ContentID,
+			Disposition: media.Disposition.String,
+		})
+	}
+
+	m.broadcastSideConversationMessage(side, message)
+	return true, nil
+}
+
+// GetConversationBySideConversationMessageID returns the conversation of a side conversation message.
+func (m *Manager) GetConversationBySideConversationMessageID(id int) (models.Conversation, error) {
+	var conversation models.Conversation
+	if err := m.q.GetConversationBySideMessageID.Get(&conversation, id); err != nil {
+		if err == sql.ErrNoRows {
+			return conversation, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
+		}
+		m.lo.Error("error fetching conversation by side conversation message ID", "id", id, "error", err)
+		return conversation, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return conversation, nil
+}
+
+// sideConversationMessageExists returns true if a side conversation message with the given source ID exists.
+func (m *Manager) sideConversationMessageExists(sourceID string) (bool, error) {
+	var exists bool
+	if err := m.q.SideConversationMessageExists.Get(&exists, sourceID); err != nil {
+		m.lo.Error("error checking side conversation message existence", "source_id", sourceID, "error", err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// getSideConversationInbox returns the inbox side conversation messages are sent through, only email inboxes can send them.
+func (m *Manager) getSideConversationInbox(inboxID int) (inbox.Inbox, error) {
+	inb, err := m.inboxStore.Get(inboxID)
+	if err != nil {
+		return nil, err
+	}
+	if inb.Channel() != inbox.ChannelEmail {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.T("conversation.sideConversationRequiresEmail"), nil)
+	}
+	return inb, nil
+}
+
+// broadcastSideConversationMessage broadcasts a new side conversation message, or its new status, to all users.
+func (m *Manager) broadcastSideConversationMessage(side models.SideConversation, message models.SideConversationMessage) {
+	m.broadcastToUsers([]int{}, wsmodels.Message{
+		Type: wsmodels.MessageTypeNewSideConversationMessage,
+		Data: map[string]interface{}{
+			"conversation_uuid":      side.ConversationUUID,
+			"side_conversation_uuid": side.UUID,
+			"message":                message,
+		},
+	})
+}
@@ -0,0 +1,148 @@
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/conversation/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	nmodels "github.com/ghotso/libredesk/internal/notification/models"
+	"github.com/volatiletech/null/v9"
+)
+
+const maxTaskTitleLength = 500
+
+// GetConversationTasks returns the tasks of a conversation, open tasks first by due date.
+func (m *Manager) GetConversationTasks(uuid string) ([]models.ConversationTask, error) {
+	var tasks = make([]models.ConversationTask, 0)
+	if err := m.q.GetConversationTasks.Select(&tasks, uuid); err != nil {
+		m.lo.Error("error fetching conversation tasks", "uuid", uuid, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.task}"), nil)
+	}
+	return tasks, nil
+}
+
+// GetConversationTask returns a task of a conversation.
+func (m *Manager) GetConversationTask(uuid string, id int) (models.ConversationTask, error) {
+	var task models.ConversationTask
+	if err := m.q.GetConversationTask.Get(&task, uuid, id); err != nil {
+		if err == sql.ErrNoRows {
+			return task, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.task}"), nil)
+		}
+		m.lo.Error("error fetching conversation task", "uuid", uuid, "id", id, "error", err)
+		return task, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.task}"), nil)
+	}
+	return task, nil
+}
+
+// GetUserTasks returns the tasks assigned to an agent across conversations, completed tasks are included if includeCompleted is set.
+func (m *Manager) GetUserTasks(userID int, includeCompleted bool) ([]models.ConversationTask, error) {
+	var tasks = make([]models.ConversationTask, 0)
+	if err := m.q.GetUserTasks.Select(&tasks, userID, includeCompleted); err != nil {
+		m.lo.Error("error fetching user tasks", "user_id", userID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.task}"), nil)
+	}
+	return tasks, nil
+}
+
+// CreateConversationTask adds a task assigned to an agent to a conversation.
+func (m *Manager) CreateConversationTask(uuid, title string, assignedUserID int, dueAt null.Time, createdByID int) (models.ConversationTask, error) {
+	title, err := m.validateTask(title, assignedUserID)
+	if err != nil {
+		return models.ConversationTask{}, err
+	}
+
+	var id int
+	if err := m.q.InsertConversationTask.Get(&id, uuid, createdByID, assignedUserID, title, dueAt); err != nil {
+		if err == sql.ErrNoRows {
+			return models.ConversationTask{}, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
+		}
+		m.lo.Error("error inserting conversation task", "uuid", uuid, "error", err)
+		return models.ConversationTask{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.task}"), nil)
+	}
+	return m.GetConversationTask(uuid, id)
+}
+
+// UpdateConversationTask updates a task of a conversation, changing the due date sends the reminder again when it comes due.
+func (m *Manager) UpdateConversationTask(uuid string, id int, title string, assignedUserID int, dueAt null.Time, completed bool) (models.ConversationTask, error) {
+	title, err := m.validateTask(title, assignedUserID)
+	if err != nil {
+		return models.ConversationTask{}, err
+	}
+	if _, err := m.GetConversationTask(uuid, id); err != nil {
+		return models.ConversationTask{}, err
+	}
+
+	if _, err := m.q.UpdateConversationTask.Exec(id, title, assignedUserID, dueAt, completed); err != nil {
+		m.lo.Error("error updating conversation task", "id", id, "error", err)
+		return models.ConversationTask{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.task}"), nil)
+	}
+	return m.GetConversationTask(uuid, id)
+}
+
+// DeleteConversationTask deletes a task of a conversation.
+func (m *Manager) DeleteConversationTask(uuid string, id int) error {
+	if _, err := m.GetConversationTask(uuid, id); err != nil {
+		return err
+	}
+	if _, err := m.q.DeleteConversationTask.Exec(id); err != nil {
+		m.lo.Error("error deleting conversation task", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.task}"), nil)
+	}
+	return nil
+}
+
+// RunTaskReminder sends the reminders of tasks that came due every interval until the context is cancelled.
+func (m *Manager) RunTaskReminder(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sendTaskReminders(ctx)
+		}
+	}
+}
+
+// sendTaskReminders notifies the assignees of open tasks that came due, each task is reminded once.
+func (m *Manager) sendTaskReminders(ctx context.Context) {
+	var tasks []models.ConversationTask
+	if err := m.q.GetDueConversationTasks.SelectContext(ctx, &tasks); err != nil {
+		m.lo.Error("error fetching due conversation tasks", "error", err)
+		return
+	}
+	for _, task := range tasks {
+		m.dispatcher.Send(notifier.Notification{
+			Type:             nmodels.NotificationTypeTaskDue,
+			RecipientIDs:     []int{task.AssignedUserID},
+			Title:            fmt.Sprintf("Task due #%s: %s", task.ReferenceNumber, task.Title),
+			Body:             task.Subject,
+			ConversationID:   null.IntFrom(task.ConversationID),
+			ConversationUUID: task.ConversationUUID,
+		})
+	}
+	if len(tasks) > 0 {
+		m.lo.Info("sent task reminders", "count", len(tasks))
+	}
+}
+
+// validateTask validates a task's title and assignee and returns the trimmed title.
+func (m *Manager) validateTask(title string, assignedUserID int) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`title`"), nil)
+	}
+	if len([]rune(title)) > maxTaskTitleLength {
+		return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.tooLong", "name", "`title`", "max", fmt.Sprint(maxTaskTitleLength)), nil)
+	}
+	if _, err := m.userStore.GetAgent(assignedUserID, ""); err != nil {
+		return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`assigned_user_id`"), nil)
+	}
+	return title, nil
+}
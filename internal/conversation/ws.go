@@ -10,7 +10,12 @@ import (
 
 // BroadcastNewMessage broadcasts a new message to all users.
 func (m *Manager) BroadcastNewMessage(message *cmodels.Message) {
-	m.broadcastToUsers([]int{}, wsmodels.Message{
+	m.broadcastNewMessageToUsers([]int{}, message)
+}
+
+// broadcastNewMessageToUsers broadcasts a new message to the given users, all users if none are given.
+func (m *Manager) broadcastNewMessageToUsers(userIDs []int, message *cmodels.Message) {
+	m.broadcastToUsers(userIDs, wsmodels.Message{
 		Type: wsmodels.MessageTypeNewMessage,
 		Data: map[string]interface{}{
 			"conversation_uuid": message.ConversationUUID,
@@ -26,6 +31,11 @@ func (m *Manager) BroadcastNewMessage(message *cmodels.Message) {
 
 // BroadcastMessageUpdate broadcasts a message update to all users.
 func (m *Manager) BroadcastMessageUpdate(conversationUUID, messageUUID, prop string, value any) {
+	m.broadcastMessageUpdateToUsers([]int{}, conversationUUID, messageUUID, prop, value)
+}
+
+// broadcastMessageUpdateToUsers broadcasts a message update to the given users, all users if none are given.
+func (m *Manager) broadcastMessageUpdateToUsers(userIDs []int, conversationUUID, messageUUID, prop string, value any) {
 	message := wsmodels.Message{
 		Type: wsmodels.MessageTypeMessagePropUpdate,
 		Data: map[string]interface{}{
@@ -35,7 +45,19 @@ func (m *Manager) BroadcastMessageUpdate(conversationUUID, messageUUID, prop str
 			"value":             value,
 		},
 	}
-	m.broadcastToUsers([]int{}, message)
+	m.broadcastToUsers(userIDs, message)
+}
+
+// BroadcastMessageRecalled notifies the agent who recalled a held reply of the draft it was restored to.
+func (m *Manager) BroadcastMessageRecalled(userID int, messageUUID string, draft cmodels.ConversationDraft) {
+	m.broadcastToUsers([]int{userID}, wsmodels.Message{
+		Type: wsmodels.MessageTypeMessageRecalled,
+		Data: map[string]interface{}{
+			"conversation_uuid": draft.ConversationUUID,
+			"uuid":              messageUUID,
+			"draft":             draft,
+		},
+	})
 }
 
 // BroadcastConversationUpdate broadcasts a conversation update to all users.
@@ -39,6 +39,7 @@ type queries struct {
 	InsertCustomAttribute  *sqlx.Stmt `query:"insert-custom-attribute"`
 	DeleteCustomAttribute  *sqlx.Stmt `query:"delete-custom-attribute"`
 	UpdateCustomAttribute  *sqlx.Stmt `query:"update-custom-attribute"`
+	LookupExists           *sqlx.Stmt `query:"lookup-exists"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -80,7 +81,7 @@ func (m *Manager) GetAll(appliesTo string) ([]models.CustomAttribute, error) {
 // Create creates a new custom attribute.
 func (m *Manager) Create(attr models.CustomAttribute) (models.CustomAttribute, error) {
 	var createdAttr models.CustomAttribute
-	if err := m.q.InsertCustomAttribute.Get(&createdAttr, attr.AppliesTo, attr.Name, attr.Description, attr.Key, pq.Array(attr.Values), attr.DataType, attr.Regex, attr.RegexHint); err != nil {
+	if err := m.q.InsertCustomAttribute.Get(&createdAttr, attr.AppliesTo, attr.Name, attr.Description, attr.Key, pq.Array(attr.Values), attr.DataType, attr.Regex, attr.RegexHint, attr.MinValue, attr.MaxValue, attr.LookupType, attr.RequiredOnResolve); err != nil {
 		if dbutil.IsUniqueViolationError(err) {
 			return models.CustomAttribute{}, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", m.i18n.P("globals.terms.customAttribute")), nil)
 		}
@@ -93,7 +94,7 @@ func (m *Manager) Create(attr models.CustomAttribute) (models.CustomAttribute, e
 // Update updates a custom attribute by ID.
 func (m *Manager) Update(id int, attr models.CustomAttribute) (models.CustomAttribute, error) {
 	var updatedAttr models.CustomAttribute
-	if err := m.q.UpdateCustomAttribute.Get(&updatedAttr, id, attr.AppliesTo, attr.Name, attr.Description, pq.Array(attr.Values), attr.Regex, attr.RegexHint); err != nil {
+	if err := m.q.UpdateCustomAttribute.Get(&updatedAttr, id, attr.AppliesTo, attr.Name, attr.Description, pq.Array(attr.Values), attr.Regex, attr.RegexHint, attr.MinValue, attr.MaxValue, attr.RequiredOnResolve); err != nil {
 		m.lo.Error("error updating custom attribute", "error", err)
 		return models.CustomAttribute{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.customAttribute}"), nil)
 	}
@@ -4,6 +4,31 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
+
+const (
+	AppliesToContact      = "contact"
+	AppliesToConversation = "conversation"
+
+	DataTypeText      = "text"
+	DataTypeNumber    = "number"
+	DataTypeCheckbox  = "checkbox"
+	DataTypeDate      = "date"
+	DataTypeDatetime  = "datetime"
+	DataTypeLink      = "link"
+	DataTypeList      = "list"
+	DataTypeMultiList = "multi_list"
+	DataTypeLookup    = "lookup"
+
+	LookupTypeContact      = "contact"
+	LookupTypeOrganization = "organization"
+	LookupTypeAgent        = "agent"
+)
+
+var (
+	DataTypes   = []string{DataTypeText, DataTypeNumber, DataTypeCheckbox, DataTypeDate, DataTypeDatetime, DataTypeLink, DataTypeList, DataTypeMultiList, DataTypeLookup}
+	LookupTypes = []string{LookupTypeContact, LookupTypeOrganization, LookupTypeAgent}
 )
 
 type CustomAttribute struct {
@@ -18,4 +43,11 @@ type CustomAttribute struct {
 	DataType    string         `db:"data_type" json:"data_type"`
 	Regex       string         `db:"regex" json:"regex"`
 	RegexHint   string         `db:"regex_hint" json:"regex_hint"`
+	// MinValue and MaxValue bound the value of number attributes.
+	MinValue null.Float64 `db:"min_value" json:"min_value"`
+	MaxValue null.Float64 `db:"max_value" json:"max_value"`
+	// LookupType is the kind of record the ID of a lookup attribute references.
+	LookupType string `db:"lookup_type" json:"lookup_type"`
+	// RequiredOnResolve blocks resolving a conversation until the attribute is set, conversation attributes only.
+	RequiredOnResolve bool `db:"required_on_resolve" json:"required_on_resolve"`
 }
@@ -10,7 +10,11 @@ SELECT
     values,
     data_type,
     regex,
-    regex_hint
+    regex_hint,
+    min_value,
+    max_value,
+    lookup_type,
+    required_on_resolve
 FROM
     custom_attribute_definitions
 WHERE
@@ -32,7 +36,11 @@ SELECT
     values,
     data_type,
     regex,
-    regex_hint
+    regex_hint,
+    min_value,
+    max_value,
+    lookup_type,
+    required_on_resolve
 FROM
     custom_attribute_definitions
 WHERE
@@ -40,9 +48,9 @@ WHERE
 
 -- name: insert-custom-attribute
 INSERT INTO
-    custom_attribute_definitions (applies_to, name, description, key, values, data_type, regex, regex_hint)
+    custom_attribute_definitions (applies_to, name, description, key, values, data_type, regex, regex_hint, min_value, max_value, lookup_type, required_on_resolve)
 VALUES
-    ($1, $2, $3, $4, $5, $6, $7, $8)
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *
 
 -- name: delete-custom-attribute
@@ -61,7 +69,19 @@ SET
     values = $5,
     regex = $6,
     regex_hint = $7,
+    min_value = $8,
+    max_value = $9,
+    required_on_resolve = $10,
     updated_at = NOW()
 WHERE
     id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: lookup-exists
+SELECT
+    CASE $1
+        WHEN 'contact' THEN EXISTS (SELECT 1 FROM users WHERE id = $2 AND type = 'contact' AND deleted_at IS NULL)
+        WHEN 'agent' THEN EXISTS (SELECT 1 FROM users WHERE id = $2 AND type = 'agent' AND deleted_at IS NULL)
+        WHEN 'organization' THEN EXISTS (SELECT 1 FROM organizations WHERE id = $2)
+        ELSE FALSE
+    END;
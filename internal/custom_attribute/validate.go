@@ -0,0 +1,212 @@
+package customAttribute
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/custom_attribute/models"
+	"github.com/ghotso/libredesk/internal/envelope"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	errInvalidValue  = errors.New("invalid value")
+	errBelowMin      = errors.New("value below minimum")
+	errAboveMax      = errors.New("value above maximum")
+	errRegexMismatch = errors.New("value does not match regex")
+)
+
+// ValidateValues validates custom attribute values against the definitions of the attributes that apply to appliesTo,
+// values without a definition are not validated and empty values are treated as unset.
+func (m *Manager) ValidateValues(appliesTo string, values map[string]any) error {
+	attrs, err := m.GetAll(appliesTo)
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		value, ok := values[attr.Key]
+		if !ok || IsEmpty(value) {
+			continue
+		}
+		if err := validateValue(attr, value); err != nil {
+			return m.valueError(attr, err)
+		}
+		if attr.DataType != models.DataTypeLookup {
+			continue
+		}
+		id, _ := lookupID(value)
+		var exists bool
+		if err := m.q.LookupExists.Get(&exists, attr.LookupType, id); err != nil {
+			m.lo.Error("error checking custom attribute lookup", "key", attr.Key, "lookup_type", attr.LookupType, "id", id, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.customAttribute")), nil)
+		}
+		if !exists {
+			return m.valueError(attr, errInvalidValue)
+		}
+	}
+	return nil
+}
+
+// MissingRequiredOnResolve returns the names of the conversation attributes that must be set before resolving
+// the conversation and are not set in its custom attributes.
+func (m *Manager) MissingRequiredOnResolve(customAttributes json.RawMessage) ([]string, error) {
+	values := map[string]any{}
+	if len(customAttributes) > 0 {
+		if err := json.Unmarshal(customAttributes, &values); err != nil {
+			m.lo.Error("error unmarshalling conversation custom attributes", "error", err)
+			return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.customAttribute")), nil)
+		}
+	}
+	attrs, err := m.GetAll(models.AppliesToConversation)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, attr := range attrs {
+		if attr.RequiredOnResolve && IsEmpty(values[attr.Key]) {
+			missing = append(missing, attr.Name)
+		}
+	}
+	return missing, nil
+}
+
+// valueError returns the input error for a value of attr that failed validation.
+func (m *Manager) valueError(attr models.CustomAttribute, err error) error {
+	var msg string
+	switch {
+	case errors.Is(err, errBelowMin):
+		msg = m.i18n.Ts("admin.customAttributes.belowMin", "name", attr.Name, "min", formatNumber(attr.MinValue.Float64))
+	case errors.Is(err, errAboveMax):
+		msg = m.i18n.Ts("admin.customAttributes.aboveMax", "name", attr.Name, "max", formatNumber(attr.MaxValue.Float64))
+	case errors.Is(err, errRegexMismatch) && attr.RegexHint != "":
+		msg = m.i18n.Ts("admin.customAttributes.invalidValueHint", "name", attr.Name, "hint", attr.RegexHint)
+	default:
+		msg = m.i18n.Ts("globals.messages.invalid", "name", attr.Name)
+	}
+	return envelope.NewError(envelope.InputError, msg, nil)
+}
+
+// validateValue validates a non-empty value against the data type and constraints of attr,
+// lookup values are only checked to be IDs here.
+func validateValue(attr models.CustomAttribute, value any) error {
+	switch attr.DataType {
+	case models.DataTypeText:
+		s, ok := value.(string)
+		if !ok {
+			return errInvalidValue
+		}
+		// Regexes that don't compile are skipped, same as the editor.
+		if attr.Regex != "" {
+			if re, err := regexp.Compile(attr.Regex); err == nil && !re.MatchString(s) {
+				return errRegexMismatch
+			}
+		}
+	case models.DataTypeNumber:
+		n, ok := toNumber(value)
+		if !ok {
+			return errInvalidValue
+		}
+		if attr.MinValue.Valid && n < attr.MinValue.Float64 {
+			return errBelowMin
+		}
+		if attr.MaxValue.Valid && n > attr.MaxValue.Float64 {
+			return errAboveMax
+		}
+	case models.DataTypeCheckbox:
+		if _, ok := value.(bool); !ok {
+			return errInvalidValue
+		}
+	case models.DataTypeDate:
+		if s, ok := value.(string); !ok || !parses(dateLayout, s) {
+			return errInvalidValue
+		}
+	case models.DataTypeDatetime:
+		if s, ok := value.(string); !ok || !parses(time.RFC3339, s) {
+			return errInvalidValue
+		}
+	case models.DataTypeLink:
+		s, ok := value.(string)
+		if !ok {
+			return errInvalidValue
+		}
+		u, err := url.ParseRequestURI(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errInvalidValue
+		}
+	case models.DataTypeList:
+		if s, ok := value.(string); !ok || !slices.Contains(attr.Values, s) {
+			return errInvalidValue
+		}
+	case models.DataTypeMultiList:
+		items, ok := value.([]any)
+		if !ok {
+			return errInvalidValue
+		}
+		for _, item := range items {
+			if s, ok := item.(string); !ok || !slices.Contains(attr.Values, s) {
+				return errInvalidValue
+			}
+		}
+	case models.DataTypeLookup:
+		if _, ok := lookupID(value); !ok {
+			return errInvalidValue
+		}
+	}
+	return nil
+}
+
+// IsEmpty returns true if a custom attribute value is unset.
+func IsEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// toNumber returns the number in a value, numbers set from text inputs are stored as strings.
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// lookupID returns the record ID in the value of a lookup attribute.
+func lookupID(value any) (int, bool) {
+	n, ok := toNumber(value)
+	if !ok || n <= 0 || n != math.Trunc(n) || n > math.MaxInt32 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// parses returns true if s is a time in layout.
+func parses(layout, s string) bool {
+	_, err := time.Parse(layout, s)
+	return err == nil
+}
+
+// formatNumber formats a number bound without trailing zeros.
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
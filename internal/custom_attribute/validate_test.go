@@ -0,0 +1,81 @@
+package customAttribute
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ghotso/libredesk/internal/custom_attribute/models"
+	"github.com/volatiletech/null/v9"
+)
+
+func TestValidateValue(t *testing.T) {
+	var (
+		text      = models.CustomAttribute{DataType: models.DataTypeText, Regex: `^[A-Z]{3}$`}
+		number    = models.CustomAttribute{DataType: models.DataTypeNumber, MinValue: null.Float64From(1), MaxValue: null.Float64From(10)}
+		list      = models.CustomAttribute{DataType: models.DataTypeList, Values: []string{"low", "high"}}
+		multiList = models.CustomAttribute{DataType: models.DataTypeMultiList, Values: []string{"a", "b"}}
+	)
+
+	tests := []struct {
+		name  string
+		attr  models.CustomAttribute
+		value any
+		want  error
+	}{
+		{"text matches regex", text, "ABC", nil},
+		{"text does not match regex", text, "abc", errRegexMismatch},
+		{"text with invalid regex", models.CustomAttribute{DataType: models.DataTypeText, Regex: `(`}, "abc", nil},
+		{"text not a string", text, 1.0, errInvalidValue},
+		{"number in range", number, 5.0, nil},
+		{"number as string", number, "10", nil},
+		{"number below min", number, 0.5, errBelowMin},
+		{"number above max", number, "11", errAboveMax},
+		{"number not a number", number, "ten", errInvalidValue},
+		{"checkbox", models.CustomAttribute{DataType: models.DataTypeCheckbox}, false, nil},
+		{"checkbox not a bool", models.CustomAttribute{DataType: models.DataTypeCheckbox}, "true", errInvalidValue},
+		{"date", models.CustomAttribute{DataType: models.DataTypeDate}, "2024-02-29", nil},
+		{"date with time", models.CustomAttribute{DataType: models.DataTypeDate}, "2024-02-29T10:00:00Z", errInvalidValue},
+		{"datetime", models.CustomAttribute{DataType: models.DataTypeDatetime}, "2024-02-29T10:00:00+05:30", nil},
+		{"datetime without zone", models.CustomAttribute{DataType: models.DataTypeDatetime}, "2024-02-29T10:00", errInvalidValue},
+		{"link", models.CustomAttribute{DataType: models.DataTypeLink}, "https://example.com/a?b=c", nil},
+		{"link without scheme", models.CustomAttribute{DataType: models.DataTypeLink}, "example.com", errInvalidValue},
+		{"link with other scheme", models.CustomAttribute{DataType: models.DataTypeLink}, "javascript:alert(1)", errInvalidValue},
+		{"list value", list, "high", nil},
+		{"list unknown value", list, "medium", errInvalidValue},
+		{"multi list values", multiList, []any{"a", "b"}, nil},
+		{"multi list unknown value", multiList, []any{"a", "c"}, errInvalidValue},
+		{"multi list not a list", multiList, "a", errInvalidValue},
+		{"lookup id", models.CustomAttribute{DataType: models.DataTypeLookup}, 42.0, nil},
+		{"lookup id as string", models.CustomAttribute{DataType: models.DataTypeLookup}, "42", nil},
+		{"lookup fractional id", models.CustomAttribute{DataType: models.DataTypeLookup}, 4.2, errInvalidValue},
+		{"lookup negative id", models.CustomAttribute{DataType: models.DataTypeLookup}, -1.0, errInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateValue(tt.attr, tt.value); !errors.Is(got, tt.want) {
+				t.Errorf("validateValue(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	tests := []struct {
+		value any
+		want  bool
+	}{
+		{nil, true},
+		{"", true},
+		{"  ", true},
+		{[]any{}, true},
+		{"a", false},
+		{false, false},
+		{0.0, false},
+		{[]any{"a"}, false},
+	}
+	for _, tt := range tests {
+		if got := IsEmpty(tt.value); got != tt.want {
+			t.Errorf("IsEmpty(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
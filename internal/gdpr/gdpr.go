@@ -0,0 +1,289 @@
+// Package gdpr handles data subject requests for contacts, exporting all the data held about
+// a contact and erasing it.
+package gdpr
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/ghotso/libredesk/internal/dbutil"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/image"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/lib/pq"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+)
+
+const (
+	// erasedText replaces redacted message bodies and conversation subjects.
+	erasedText = "[erased]"
+	// erasedName replaces the name of an erased contact.
+	erasedName = "Erased contact"
+)
+
+// Manager handles contact data exports and erasure.
+type Manager struct {
+	q     queries
+	lo    *logf.Logger
+	i18n  *i18n.I18n
+	db    *sqlx.DB
+	media mediaStore
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+}
+
+type mediaStore interface {
+	GetBlob(name string) ([]byte, error)
+	Delete(name string) error
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetContact               *sqlx.Stmt `query:"get-contact"`
+	GetContactChannels       *sqlx.Stmt `query:"get-contact-channels"`
+	GetContactNotes          *sqlx.Stmt `query:"get-contact-notes"`
+	GetContactConversations  *sqlx.Stmt `query:"get-contact-conversations"`
+	GetContactMessages       *sqlx.Stmt `query:"get-contact-messages"`
+	GetSideConversations     *sqlx.Stmt `query:"get-contact-side-conversations"`
+	GetTimeEntries           *sqlx.Stmt `query:"get-contact-time-entries"`
+	GetTasks                 *sqlx.Stmt `query:"get-contact-tasks"`
+	GetContactMedia          *sqlx.Stmt `query:"get-contact-media"`
+	GetContactErasableMedia  *sqlx.Stmt `query:"get-contact-erasable-media"`
+	DeleteContactMedia       *sqlx.Stmt `query:"delete-contact-media"`
+	AnonymizeContact         *sqlx.Stmt `query:"anonymize-contact"`
+	AnonymizeContactChannels *sqlx.Stmt `query:"anonymize-contact-channels"`
+	DeleteContactNotes       *sqlx.Stmt `query:"delete-contact-notes"`
+	RedactConversations      *sqlx.Stmt `query:"redact-contact-conversations"`
+	RedactMessages           *sqlx.Stmt `query:"redact-contact-messages"`
+	RedactSideConversations  *sqlx.Stmt `query:"redact-contact-side-conversations"`
+	RedactSideMessages       *sqlx.Stmt `query:"redact-contact-side-conversation-messages"`
+	RedactTimeEntries        *sqlx.Stmt `query:"redact-contact-time-entries"`
+	RedactTasks              *sqlx.Stmt `query:"redact-contact-tasks"`
+	RedactCSATFeedback       *sqlx.Stmt `query:"redact-contact-csat-feedback"`
+	DeleteDrafts             *sqlx.Stmt `query:"delete-contact-drafts"`
+	DeleteEmbeddings         *sqlx.Stmt `query:"delete-contact-embeddings"`
+}
+
+// erasableMedia is a message or side conversation message attachment deleted when its contact is erased.
+type erasableMedia struct {
+	UUID        string `db:"uuid"`
+	ContentType string `db:"content_type"`
+}
+
+// contactMedia is a message or side conversation message attachment of a contact.
+type contactMedia struct {
+	UUID        string `db:"uuid"`
+	Filename    string `db:"filename"`
+	MessageUUID string `db:"message_uuid"`
+}
+
+// New creates and returns a new instance of the Manager.
+// The media store is used to read attachments for exports and delete them on erasure.
+func New(opts Opts, media mediaStore) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:     q,
+		lo:    opts.Lo,
+		i18n:  opts.I18n,
+		db:    opts.DB,
+		media: media,
+	}, nil
+}
+
+// Export writes a ZIP archive of all the data held about the contact to w: profile with custom attributes,
+// channels, notes, conversations, messages, side conversations, time entries, tasks and message attachments.
+func (m *Manager) Export(contactID int, w io.Writer) error {
+	if err := m.checkContact(contactID); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		stmt *sqlx.Stmt
+	}{
+		{"profile.json", m.q.GetContact},
+		{"channels.json", m.q.GetContactChannels},
+		{"notes.json", m.q.GetContactNotes},
+		{"conversations.json", m.q.GetContactConversations},
+		{"messages.json", m.q.GetContactMessages},
+		{"side_conversations.json", m.q.GetSideConversations},
+		{"time_entries.json", m.q.GetTimeEntries},
+		{"tasks.json", m.q.GetTasks},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		var data json.RawMessage
+		if err := f.stmt.Get(&data, contactID); err != nil {
+			m.lo.Error("error fetching contact data for export", "contact_id", contactID, "file", f.name, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.contact}"), nil)
+		}
+		if err := writeJSON(zw, f.name, data); err != nil {
+			m.lo.Error("error writing contact export", "contact_id", contactID, "file", f.name, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorExporting", "name", "{globals.terms.contact}"), nil)
+		}
+	}
+
+	var media []contactMedia
+	if err := m.q.GetContactMedia.Select(&media, contactID); err != nil {
+		m.lo.Error("error fetching contact media for export", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.media}"), nil)
+	}
+	used := make(map[string]bool, len(media))
+	for _, md := range media {
+		blob, err := m.media.GetBlob(md.UUID)
+		if err != nil {
+			// Skip files missing from the store, the message still references them in messages.json.
+			m.lo.Error("error reading media for contact export", "contact_id", contactID, "uuid", md.UUID, "error", err)
+			continue
+		}
+		fw, err := zw.Create(attachmentPath(md.MessageUUID, md.Filename, used))
+		if err == nil {
+			_, err = fw.Write(blob)
+		}
+		if err != nil {
+			m.lo.Error("error writing contact export", "contact_id", contactID, "uuid", md.UUID, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorExporting", "name", "{globals.terms.contact}"), nil)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		m.lo.Error("error writing contact export", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorExporting", "name", "{globals.terms.contact}"), nil)
+	}
+	return nil
+}
+
+// Erase anonymizes the contact, deletes their notes, attachments and AI embeddings and redacts the bodies of
+// their conversations, messages, side conversations, the notes of their time entries and the titles of their tasks. Conversation and message rows, timestamps, statuses and
+// CSAT ratings are kept so that reports stay accurate.
+func (m *Manager) Erase(contactID int) error {
+	if err := m.checkContact(contactID); err != nil {
+		return err
+	}
+
+	var media []erasableMedia
+	if err := m.q.GetContactErasableMedia.Select(&media, contactID); err != nil {
+		m.lo.Error("error fetching contact media for erasure", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.media}"), nil)
+	}
+
+	tx, err := m.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		m.lo.Error("error starting transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+	}
+	defer tx.Rollback()
+
+	mediaUUIDs := make([]string, 0, len(media))
+	for _, md := range media {
+		mediaUUIDs = append(mediaUUIDs, md.UUID)
+	}
+	steps := []struct {
+		stmt *sqlx.Stmt
+		args []any
+	}{
+		{m.q.AnonymizeContact, []any{contactID, erasedName}},
+		{m.q.AnonymizeContactChannels, []any{contactID}},
+		{m.q.DeleteContactNotes, []any{contactID}},
+		{m.q.RedactConversations, []any{contactID, erasedText}},
+		{m.q.RedactMessages, []any{contactID, erasedText}},
+		{m.q.RedactSideConversations, []any{contactID, erasedText}},
+		{m.q.RedactSideMessages, []any{contactID, erasedText}},
+		{m.q.RedactTimeEntries, []any{contactID, erasedText}},
+		{m.q.RedactTasks, []any{contactID, erasedText}},
+		{m.q.RedactCSATFeedback, []any{contactID}},
+		{m.q.DeleteDrafts, []any{contactID}},
+		{m.q.DeleteEmbeddings, []any{contactID}},
+		{m.q.DeleteContactMedia, []any{pq.Array(mediaUUIDs)}},
+	}
+	for _, s := range steps {
+		if _, err := tx.Stmtx(s.stmt).Exec(s.args...); err != nil {
+			m.lo.Error("error erasing contact data", "contact_id", contactID, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing contact erasure", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+	}
+
+	// Delete the files of the attachments and their thumbnails once their rows are deleted, failures are logged
+	// and left to be cleaned up manually.
+	for _, md := range media {
+		if err := m.media.Delete(md.UUID); err != nil {
+			m.lo.Error("error deleting media of erased contact", "contact_id", contactID, "uuid", md.UUID, "error", err)
+		}
+		if strings.HasPrefix(md.ContentType, "image/") {
+			if err := m.media.Delete(image.ThumbPrefix + md.UUID); err != nil {
+				m.lo.Error("error deleting media thumbnail of erased contact", "contact_id", contactID, "uuid", md.UUID, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkContact returns a not found error if the contact does not exist.
+func (m *Manager) checkContact(contactID int) error {
+	var profile json.RawMessage
+	if err := m.q.GetContact.Get(&profile, contactID); err != nil {
+		m.lo.Error("error fetching contact", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.contact}"), nil)
+	}
+	if string(profile) == "null" {
+		return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.contact}"), nil)
+	}
+	return nil
+}
+
+// writeJSON writes indented JSON data as a file in the archive.
+func writeJSON(zw *zip.Writer, name string, data json.RawMessage) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(fw)
+	return err
+}
+
+// attachmentPath returns the archive path of a message attachment, `attachments/<message uuid>/<filename>`.
+// Filenames are stripped of directories and made unique within the message.
+func attachmentPath(messageUUID, filename string, used map[string]bool) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		name = "attachment"
+	}
+	p := path.Join("attachments", messageUUID, name)
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; used[p]; i++ {
+		p = path.Join("attachments", messageUUID, fmt.Sprintf("%s (%d)%s", base, i, ext))
+	}
+	used[p] = true
+	return p
+}
@@ -0,0 +1,26 @@
+package gdpr
+
+import "testing"
+
+func TestAttachmentPath(t *testing.T) {
+	used := map[string]bool{}
+	tests := []struct {
+		messageUUID string
+		filename    string
+		expected    string
+	}{
+		{"m1", "invoice.pdf", "attachments/m1/invoice.pdf"},
+		{"m1", "invoice.pdf", "attachments/m1/invoice (1).pdf"},
+		{"m1", "invoice.pdf", "attachments/m1/invoice (2).pdf"},
+		{"m2", "invoice.pdf", "attachments/m2/invoice.pdf"},
+		{"m2", "../../etc/passwd", "attachments/m2/passwd"},
+		{"m2", `C:\Users\me\photo.png`, "attachments/m2/photo.png"},
+		{"m2", "", "attachments/m2/attachment"},
+		{"m2", "..", "attachments/m2/attachment (1)"},
+	}
+	for _, tt := range tests {
+		if got := attachmentPath(tt.messageUUID, tt.filename, used); got != tt.expected {
+			t.Errorf("attachmentPath(%q, %q) = %q, want %q", tt.messageUUID, tt.filename, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+-- name: get-contact
+SELECT COALESCE(row_to_json(u), 'null'::json) FROM (
+    SELECT id, created_at, updated_at, enabled, email, first_name, last_name,
+        phone_number_country_code, phone_number, country, avatar_url, custom_attributes,
+        last_active_at, last_login_at
+    FROM users
+    WHERE id = $1 AND type = 'contact' AND deleted_at IS NULL
+) u;
+
+-- name: get-contact-channels
+SELECT COALESCE(json_agg(c ORDER BY c.id), '[]'::json) FROM (
+    SELECT cc.id, cc.created_at, cc.inbox_id, i.name AS inbox_name, cc.identifier
+    FROM contact_channels cc
+    JOIN inboxes i ON i.id = cc.inbox_id
+    WHERE cc.contact_id = $1
+) c;
+
+-- name: get-contact-notes
+SELECT COALESCE(json_agg(n ORDER BY n.created_at), '[]'::json) FROM (
+    SELECT cn.id, cn.created_at, cn.updated_at, cn.note,
+        CONCAT(u.first_name, ' ', u.last_name) AS author
+    FROM contact_notes cn
+    JOIN users u ON u.id = cn.user_id
+    WHERE cn.contact_id = $1
+) n;
+
+-- name: get-contact-conversations
+SELECT COALESCE(json_agg(c ORDER BY c.created_at), '[]'::json) FROM (
+    SELECT c.id, c.created_at, c.updated_at, c.uuid, c.reference_number, c.subject,
+        i.name AS inbox_name, s.name AS status, c.custom_attributes,
+        c.first_reply_at, c.resolved_at, c.closed_at,
+        (SELECT row_to_json(r) FROM (
+            SELECT cr.rating, cr.feedback, cr.response_timestamp
+            FROM csat_responses cr
+            WHERE cr.conversation_id = c.id AND cr.response_timestamp IS NOT NULL
+        ) r) AS csat
+    FROM conversations c
+    JOIN inboxes i ON i.id = c.inbox_id
+    JOIN conversation_statuses s ON s.id = c.status_id
+    WHERE c.contact_id = $1
+) c;
+
+-- name: get-contact-messages
+-- Private notes and activity messages are internal to the agents and are not exported.
+SELECT COALESCE(json_agg(m ORDER BY m.created_at), '[]'::json) FROM (
+    SELECT m.id, m.created_at, m.uuid, c.uuid AS conversation_uuid, m.type, m.sender_type,
+        CASE WHEN m.sender_type = 'contact' THEN NULL ELSE CONCAT(u.first_name, ' ', u.last_name) END AS sender_name,
+        m.content_type, m.content, m.text_content, m.meta
+    FROM conversation_messages m
+    JOIN conversations c ON c.id = m.conversation_id
+    JOIN users u ON u.id = m.sender_id
+    WHERE c.contact_id = $1
+        AND m.private = false
+        AND m.type IN ('incoming', 'outgoing')
+) m;
+
+-- name: get-contact-side-conversations
+-- Side conversations with third parties quote the contact's conversations, so they are exported along with them.
+SELECT COALESCE(json_agg(sc ORDER BY sc.created_at), '[]'::json) FROM (
+    SELECT sc.id, sc.created_at, sc.uuid, c.uuid AS conversation_uuid, sc.subject, sc."to", sc.cc,
+        (SELECT COALESCE(json_agg(m ORDER BY m.created_at), '[]'::json) FROM (
+            SELECT scm.created_at, scm.uuid, scm.type, scm.from_address, scm.content, scm.text_content
+            FROM side_conversation_messages scm
+            WHERE scm.side_conversation_id = sc.id
+        ) m) AS messages
+    FROM side_conversations sc
+    JOIN conversations c ON c.id = sc.conversation_id
+    WHERE c.contact_id = $1
+) sc;
+
+-- name: get-contact-time-entries
+-- Notes of the time agents spent on the contact's conversations can describe the contact's issue.
+SELECT COALESCE(json_agg(t ORDER BY t.started_at), '[]'::json) FROM (
+    SELECT t.id, t.created_at, t.started_at, c.uuid AS conversation_uuid, t.duration_seconds, t.note,
+        CONCAT(u.first_name, ' ', u.last_name) AS agent
+    FROM conversation_time_entries t
+    JOIN conversations c ON c.id = t.conversation_id
+    JOIN users u ON u.id = t.user_id
+    WHERE c.contact_id = $1
+) t;
+
+-- name: get-contact-tasks
+SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]'::json) FROM (
+    SELECT t.id, t.created_at, c.uuid AS conversation_uuid, t.title, t.due_at, t.completed_at
+    FROM conversation_tasks t
+    JOIN conversations c ON c.id = t.conversation_id
+    WHERE c.contact_id = $1
+) t;
+
+-- name: get-contact-media
+SELECT uuid, filename, message_uuid FROM (
+    SELECT md.id, md.uuid, md.filename, m.uuid AS message_uuid
+    FROM media md
+    JOIN conversation_messages m ON m.id = md.model_id AND md.model_type = 'messages'
+    JOIN conversations c ON c.id = m.conversation_id
+    WHERE c.contact_id = $1
+        AND m.private = false
+    UNION ALL
+    SELECT md.id, md.uuid, md.filename, scm.uuid AS message_uuid
+    FROM media md
+    JOIN side_conversation_messages scm ON scm.id = md.model_id AND md.model_type = 'side_conversation_messages'
+    JOIN side_conversations sc ON sc.id = scm.side_conversation_id
+    JOIN conversations c ON c.id = sc.conversation_id
+    WHERE c.contact_id = $1
+) md
+ORDER BY id;
+
+-- name: get-contact-erasable-media
+SELECT md.uuid, md.content_type
+FROM media md
+JOIN conversation_messages m ON m.id = md.model_id AND md.model_type = 'messages'
+JOIN conversations c ON c.id = m.conversation_id
+WHERE c.contact_id = $1
+UNION ALL
+SELECT md.uuid, md.content_type
+FROM media md
+JOIN side_conversation_messages scm ON scm.id = md.model_id AND md.model_type = 'side_conversation_messages'
+JOIN side_conversations sc ON sc.id = scm.side_conversation_id
+JOIN conversations c ON c.id = sc.conversation_id
+WHERE c.contact_id = $1;
+
+-- name: delete-contact-media
+-- The files of the media are deleted from the store once the erasure is committed.
+DELETE FROM media WHERE uuid = ANY($1::UUID[]);
+
+-- name: anonymize-contact
+UPDATE users
+SET email = NULL,
+    first_name = $2,
+    last_name = '',
+    phone_number_country_code = NULL,
+    phone_number = NULL,
+    country = NULL,
+    avatar_url = NULL,
+    custom_attributes = '{}'::jsonb,
+    "password" = NULL,
+    reset_password_token = NULL,
+    reset_password_token_expiry = NULL,
+    enabled = false,
+    updated_at = NOW()
+WHERE id = $1 AND type = 'contact';
+
+-- name: anonymize-contact-channels
+UPDATE contact_channels
+SET identifier = CONCAT('erased-', id),
+    updated_at = NOW()
+WHERE contact_id = $1;
+
+-- name: delete-contact-notes
+DELETE FROM contact_notes WHERE contact_id = $1;
+
+-- name: redact-contact-conversations
+-- Timestamps, status, assignment and SLA data are kept for reports.
+UPDATE conversations
+SET subject = $2,
+    last_message = $2,
+    last_interaction = $2,
+    custom_attributes = '{}'::jsonb,
+    updated_at = NOW()
+WHERE contact_id = $1;
+
+-- name: redact-contact-messages
+-- Message type, sender and timestamps are kept for reports.
+UPDATE conversation_messages m
+SET content = $2,
+    text_content = $2,
+    meta = '{}'::jsonb,
+    updated_at = NOW()
+FROM conversations c
+WHERE c.id = m.conversation_id
+    AND c.contact_id = $1;
+
+-- name: redact-contact-side-conversations
+-- The third parties the side conversations were sent to are kept out of the erased conversations too.
+UPDATE side_conversations sc
+SET subject = $2,
+    "to" = '{}',
+    cc = '{}',
+    updated_at = NOW()
+FROM conversations c
+WHERE c.id = sc.conversation_id
+    AND c.contact_id = $1;
+
+-- name: redact-contact-side-conversation-messages
+UPDATE side_conversation_messages scm
+SET content = $2,
+    text_content = $2,
+    updated_at = NOW()
+FROM side_conversations sc
+JOIN conversations c ON c.id = sc.conversation_id
+WHERE sc.id = scm.side_conversation_id
+    AND c.contact_id = $1;
+
+-- name: redact-contact-time-entries
+-- Durations are kept for reports.
+UPDATE conversation_time_entries t
+SET note = $2,
+    updated_at = NOW()
+FROM conversations c
+WHERE c.id = t.conversation_id
+    AND c.contact_id = $1
+    AND t.note <> '';
+
+-- name: redact-contact-tasks
+-- Assignees and due dates are kept so open tasks still show up for their agents.
+UPDATE conversation_tasks t
+SET title = $2,
+    updated_at = NOW()
+FROM conversations c
+WHERE c.id = t.conversation_id
+    AND c.contact_id = $1;
+
+-- name: redact-contact-csat-feedback
+-- Ratings are kept for reports.
+UPDATE csat_responses cr
+SET feedback = NULL,
+    updated_at = NOW()
+FROM conversations c
+WHERE c.id = cr.conversation_id
+    AND c.contact_id = $1;
+
+-- name: delete-contact-drafts
+DELETE FROM conversation_drafts d
+USING conversations c
+WHERE c.id = d.conversation_id
+    AND c.contact_id = $1;
+
+-- name: delete-contact-embeddings
+-- The AI embeddings of the conversations hold their messages, they are indexed again from the redacted messages.
+DELETE FROM ai_embeddings e
+USING conversations c
+WHERE e.source_type = 'conversation' AND c.id = e.source_id
+    AND c.contact_id = $1;
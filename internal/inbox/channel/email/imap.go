@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/ghotso/libredesk/internal/attachment"
 	"github.com/ghotso/libredesk/internal/conversation/models"
 	"github.com/ghotso/libredesk/internal/envelope"
@@ -53,9 +54,12 @@ func (e *Email) ReadIncomingMessages(ctx context.Context, cfg imodels.IMAPConfig
 				return nil
 			}
 
+			start := time.Now()
 			if err := e.processMailbox(ctx, scanInboxSince, cfg); err != nil && err != context.Canceled {
 				e.lo.Error("error searching emails", "error", err)
+				metrics.GetOrCreateCounter(fmt.Sprintf(`libredesk_imap_fetch_errors_total{inbox_id="%d"}`, e.Identifier())).Inc()
 			}
+			metrics.GetOrCreateSummary(fmt.Sprintf(`libredesk_imap_fetch_duration_seconds{inbox_id="%d"}`, e.Identifier())).UpdateDuration(start)
 			e.lo.Info("email search complete", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
 		}
 	}
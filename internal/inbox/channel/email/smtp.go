@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/ghotso/libredesk/internal/conversation/models"
 	imodels "github.com/ghotso/libredesk/internal/inbox/models"
 	"github.com/ghotso/libredesk/internal/stringutil"
@@ -235,7 +236,11 @@ func (e *Email) Send(m models.Message) error {
 			email.Text = []byte(m.AltContent)
 		}
 	}
-	return server.Send(email)
+	if err := server.Send(email); err != nil {
+		metrics.GetOrCreateCounter(fmt.Sprintf(`libredesk_smtp_send_errors_total{source="inbox",inbox_id="%d"}`, e.Identifier())).Inc()
+		return err
+	}
+	return nil
 }
 
 // buildPlusAddress creates a plus-addressed email for conversation matching.
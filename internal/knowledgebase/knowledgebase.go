@@ -0,0 +1,450 @@
+// Package knowledgebase manages the help center categories and articles that are published on the portal.
+package knowledgebase
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ghotso/libredesk/internal/dbutil"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/knowledgebase/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+
+	reSlug   = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	reLocale = regexp.MustCompile(`^[a-z]{2,3}(?:-[A-Za-z0-9]{2,8})?$`)
+)
+
+const (
+	maxTitleLength = 200
+	maxSlugLength  = 140
+	// maxSearchTerms caps the number of words of a search that are matched.
+	maxSearchTerms = 10
+	// MaxSearchResults is the maximum number of articles a search returns.
+	MaxSearchResults = 50
+)
+
+// Manager manages the knowledge base.
+type Manager struct {
+	q    queries
+	lo   *logf.Logger
+	i18n *i18n.I18n
+}
+
+// Opts contains the dependencies for the knowledge base manager.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetCategories           *sqlx.Stmt `query:"get-categories"`
+	GetCategory             *sqlx.Stmt `query:"get-category"`
+	InsertCategory          *sqlx.Stmt `query:"insert-category"`
+	UpdateCategory          *sqlx.Stmt `query:"update-category"`
+	DeleteCategory          *sqlx.Stmt `query:"delete-category"`
+	GetArticles             *sqlx.Stmt `query:"get-articles"`
+	GetArticle              *sqlx.Stmt `query:"get-article"`
+	GetArticleTranslations  *sqlx.Stmt `query:"get-article-translations"`
+	InsertArticle           *sqlx.Stmt `query:"insert-article"`
+	UpdateArticle           *sqlx.Stmt `query:"update-article"`
+	DeleteArticle           *sqlx.Stmt `query:"delete-article"`
+	UpsertTranslation       *sqlx.Stmt `query:"upsert-translation"`
+	UpdateTranslationStatus *sqlx.Stmt `query:"update-translation-status"`
+	DeleteTranslation       *sqlx.Stmt `query:"delete-translation"`
+	GetTranslationVersions  *sqlx.Stmt `query:"get-translation-versions"`
+	GetPublicCategories     *sqlx.Stmt `query:"get-public-categories"`
+	GetPublicArticles       *sqlx.Stmt `query:"get-public-articles"`
+	GetPublicArticle        *sqlx.Stmt `query:"get-public-article"`
+	SearchArticles          *sqlx.Stmt `query:"search-articles"`
+}
+
+// New creates and returns a new instance of the knowledge base Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:    q,
+		lo:   opts.Lo,
+		i18n: opts.I18n,
+	}, nil
+}
+
+// GetCategories returns all categories.
+func (m *Manager) GetCategories() ([]models.Category, error) {
+	var categories = make([]models.Category, 0)
+	if err := m.q.GetCategories.Select(&categories); err != nil {
+		m.lo.Error("error fetching knowledge base categories", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.category")), nil)
+	}
+	return categories, nil
+}
+
+// GetCategory returns a category by ID.
+func (m *Manager) GetCategory(id int) (models.Category, error) {
+	var category models.Category
+	if err := m.q.GetCategory.Get(&category, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return category, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.category}"), nil)
+		}
+		m.lo.Error("error fetching knowledge base category", "id", id, "error", err)
+		return category, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.category}"), nil)
+	}
+	return category, nil
+}
+
+// CreateCategory creates a category, the slug is generated from the name when empty.
+func (m *Manager) CreateCategory(c models.Category) (models.Category, error) {
+	if err := m.validateCategory(&c); err != nil {
+		return c, err
+	}
+	var created models.Category
+	if err := m.q.InsertCategory.Get(&created, c.Name, c.Description, c.Slug, c.Position); err != nil {
+		if dbutil.IsUniqueViolationError(err) {
+			return created, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "`slug`"), nil)
+		}
+		m.lo.Error("error creating knowledge base category", "error", err)
+		return created, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.category}"), nil)
+	}
+	return created, nil
+}
+
+// UpdateCategory updates a category.
+func (m *Manager) UpdateCategory(id int, c models.Category) (models.Category, error) {
+	if err := m.validateCategory(&c); err != nil {
+		return c, err
+	}
+	var updated models.Category
+	if err := m.q.UpdateCategory.Get(&updated, id, c.Name, c.Description, c.Slug, c.Position); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return updated, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.category}"), nil)
+		}
+		if dbutil.IsUniqueViolationError(err) {
+			return updated, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "`slug`"), nil)
+		}
+		m.lo.Error("error updating knowledge base category", "id", id, "error", err)
+		return updated, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.category}"), nil)
+	}
+	return updated, nil
+}
+
+// DeleteCategory deletes a category, its articles are left without a category.
+func (m *Manager) DeleteCategory(id int) error {
+	res, err := m.q.DeleteCategory.Exec(id)
+	if err != nil {
+		m.lo.Error("error deleting knowledge base category", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.category}"), nil)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.category}"), nil)
+	}
+	return nil
+}
+
+// GetArticles returns the articles, optionally of a category, with the title and status of their translation in the locale.
+func (m *Manager) GetArticles(locale string, categoryID int) ([]models.ArticleListItem, error) {
+	var articles = make([]models.ArticleListItem, 0)
+	if err := m.q.GetArticles.Select(&articles, locale, categoryID); err != nil {
+		m.lo.Error("error fetching knowledge base articles", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.article")), nil)
+	}
+	return articles, nil
+}
+
+// GetArticle returns an article along with all its translations.
+func (m *Manager) GetArticle(id int) (models.Article, error) {
+	var article models.Article
+	if err := m.q.GetArticle.Get(&article, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return article, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.article}"), nil)
+		}
+		m.lo.Error("error fetching knowledge base article", "id", id, "error", err)
+		return article, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.article}"), nil)
+	}
+	article.Translations = make([]models.Translation, 0)
+	if err := m.q.GetArticleTranslations.Select(&article.Translations, id); err != nil {
+		m.lo.Error("error fetching knowledge base article translations", "id", id, "error", err)
+		return article, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.translation")), nil)
+	}
+	return article, nil
+}
+
+// CreateArticle creates an article along with its first translation as a draft.
+func (m *Manager) CreateArticle(categoryID null.Int, slug string, position int, locale, title, body string, userID int) (models.Article, error) {
+	if slug == "" {
+		slug = Slugify(title)
+	}
+	if err := m.validateArticle(slug); err != nil {
+		return models.Article{}, err
+	}
+	if err := m.validateTranslation(locale, title); err != nil {
+		return models.Article{}, err
+	}
+
+	var article models.Article
+	if err := m.q.InsertArticle.Get(&article, categoryID, slug, position); err != nil {
+		if dbutil.IsUniqueViolationError(err) {
+			return article, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "`slug`"), nil)
+		}
+		m.lo.Error("error creating knowledge base article", "error", err)
+		return article, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.article}"), nil)
+	}
+	if _, err := m.SaveTranslation(article.ID, locale, title, body, userID); err != nil {
+		return article, err
+	}
+	return m.GetArticle(article.ID)
+}
+
+// UpdateArticle updates the category, slug and position of an article.
+func (m *Manager) UpdateArticle(id int, categoryID null.Int, slug string, position int) (models.Article, error) {
+	if err := m.validateArticle(slug); err != nil {
+		return models.Article{}, err
+	}
+	var article models.Article
+	if err := m.q.UpdateArticle.Get(&article, id, categoryID, slug, position); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return article, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.article}"), nil)
+		}
+		if dbutil.IsUniqueViolationError(err) {
+			return article, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "`slug`"), nil)
+		}
+		m.lo.Error("error updating knowledge base article", "id", id, "error", err)
+		return article, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.article}"), nil)
+	}
+	return m.GetArticle(id)
+}
+
+// DeleteArticle deletes an article along with its translations and versions.
+func (m *Manager) DeleteArticle(id int) error {
+	res, err := m.q.DeleteArticle.Exec(id)
+	if err != nil {
+		m.lo.Error("error deleting knowledge base article", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.article}"), nil)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.article}"), nil)
+	}
+	return nil
+}
+
+// SaveTranslation creates or updates the translation of an article in a locale and records it as a new version.
+// New translations are drafts, published translations stay published and keep the changes as their draft until
+// published again.
+func (m *Manager) SaveTranslation(articleID int, locale, title, body string, userID int) (models.Translation, error) {
+	var t models.Translation
+	if err := m.validateTranslation(locale, title); err != nil {
+		return t, err
+	}
+	var user null.Int
+	if userID > 0 {
+		user = null.IntFrom(userID)
+	}
+	if err := m.q.UpsertTranslation.Get(&t, articleID, locale, strings.TrimSpace(title), body, user); err != nil {
+		if dbutil.IsForeignKeyError(err) {
+			return t, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.article}"), nil)
+		}
+		m.lo.Error("error saving knowledge base article translation", "article_id", articleID, "locale", locale, "error", err)
+		return t, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.translation}"), nil)
+	}
+	return t, nil
+}
+
+// SetTranslationStatus publishes or unpublishes the translation of an article.
+func (m *Manager) SetTranslationStatus(articleID int, locale, status string) (models.Translation, error) {
+	var t models.Translation
+	if status != models.StatusDraft && status != models.StatusPublished {
+		return t, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`status`"), nil)
+	}
+	if err := m.q.UpdateTranslationStatus.Get(&t, articleID, locale, status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return t, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.translation}"), nil)
+		}
+		m.lo.Error("error updating knowledge base article translation status", "article_id", articleID, "locale", locale, "error", err)
+		return t, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.translation}"), nil)
+	}
+	return t, nil
+}
+
+// DeleteTranslation deletes the translation of an article in a locale along with its versions.
+func (m *Manager) DeleteTranslation(articleID int, locale string) error {
+	res, err := m.q.DeleteTranslation.Exec(articleID, locale)
+	if err != nil {
+		m.lo.Error("error deleting knowledge base article translation", "article_id", articleID, "locale", locale, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.translation}"), nil)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.translation}"), nil)
+	}
+	return nil
+}
+
+// GetVersions returns the saved versions of the translation of an article, newest first.
+func (m *Manager) GetVersions(articleID int, locale string) ([]models.Version, error) {
+	var versions = make([]models.Version, 0)
+	if err := m.q.GetTranslationVersions.Select(&versions, articleID, locale); err != nil {
+		m.lo.Error("error fetching knowledge base article versions", "article_id", articleID, "locale", locale, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.translation}"), nil)
+	}
+	return versions, nil
+}
+
+// GetPublicCategories returns the categories that have articles published in the locale or the default locale.
+func (m *Manager) GetPublicCategories(locale, defaultLocale string) ([]models.Category, error) {
+	var categories = make([]models.Category, 0)
+	if err := m.q.GetPublicCategories.Select(&categories, locale, defaultLocale); err != nil {
+		m.lo.Error("error fetching public knowledge base categories", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.category")), nil)
+	}
+	return categories, nil
+}
+
+// GetPublicArticles returns the published articles of a category in the locale, falling back to the default locale.
+func (m *Manager) GetPublicArticles(categorySlug, locale, defaultLocale string) ([]models.PublicArticle, error) {
+	var articles = make([]models.PublicArticle, 0)
+	if err := m.q.GetPublicArticles.Select(&articles, categorySlug, locale, defaultLocale); err != nil {
+		m.lo.Error("error fetching public knowledge base articles", "category", categorySlug, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.article")), nil)
+	}
+	return articles, nil
+}
+
+// GetPublicArticle returns a published article in the locale, falling back to the default locale, with its body rendered to HTML.
+func (m *Manager) GetPublicArticle(slug, locale, defaultLocale string) (models.PublicArticle, error) {
+	var article models.PublicArticle
+	if err := m.q.GetPublicArticle.Get(&article, slug, locale, defaultLocale); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return article, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.article}"), nil)
+		}
+		m.lo.Error("error fetching public knowledge base article", "slug", slug, "error", err)
+		return article, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.article}"), nil)
+	}
+	html, err := RenderMarkdown(article.Body)
+	if err != nil {
+		m.lo.Error("error rendering knowledge base article", "slug", slug, "error", err)
+		return article, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.article}"), nil)
+	}
+	article.HTML = html
+	return article, nil
+}
+
+// Search returns the published articles matching the text, best match first. With matchAny an article has to
+// match only one of the words, which suits suggesting articles while a subject is typed.
+func (m *Manager) Search(text, locale, defaultLocale string, limit int, matchAny bool) ([]models.PublicArticle, error) {
+	var articles = make([]models.PublicArticle, 0)
+	query := buildTSQuery(text, matchAny)
+	if query == "" {
+		return articles, nil
+	}
+	if limit <= 0 || limit > MaxSearchResults {
+		limit = MaxSearchResults
+	}
+	if err := m.q.SearchArticles.Select(&articles, query, locale, defaultLocale, limit); err != nil {
+		m.lo.Error("error searching knowledge base articles", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSearching", "name", m.i18n.P("globals.terms.article")), nil)
+	}
+	return articles, nil
+}
+
+// validateCategory validates a category and generates its slug from the name when empty.
+func (m *Manager) validateCategory(c *models.Category) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" || len(c.Name) > maxTitleLength {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`name`"), nil)
+	}
+	if c.Slug == "" {
+		c.Slug = Slugify(c.Name)
+	}
+	if !validSlug(c.Slug) {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`slug`"), nil)
+	}
+	return nil
+}
+
+// validateArticle validates the slug of an article.
+func (m *Manager) validateArticle(slug string) error {
+	if !validSlug(slug) {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`slug`"), nil)
+	}
+	return nil
+}
+
+// validateTranslation validates the locale and title of a translation.
+func (m *Manager) validateTranslation(locale, title string) error {
+	if !ValidLocale(locale) {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`locale`"), nil)
+	}
+	if title = strings.TrimSpace(title); title == "" || len(title) > maxTitleLength {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`title`"), nil)
+	}
+	return nil
+}
+
+// ValidLocale reports whether locale looks like a language code such as `en` or `pt-BR`.
+func ValidLocale(locale string) bool {
+	return reLocale.MatchString(locale)
+}
+
+// validSlug reports whether slug is made of lowercase letters, digits and single hyphens.
+func validSlug(slug string) bool {
+	return len(slug) <= maxSlugLength && reSlug.MatchString(slug)
+}
+
+// Slugify returns a URL slug of s made of lowercase ASCII letters, digits and hyphens, accents are stripped.
+func Slugify(s string) string {
+	var (
+		b      strings.Builder
+		hyphen bool
+	)
+	for _, r := range norm.NFKD.String(strings.ToLower(s)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks left over from stripping accents.
+		default:
+			hyphen = true
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	return strings.TrimRight(b.String()[:min(b.Len(), maxSlugLength)], "-")
+}
+
+// buildTSQuery returns a prefix matching tsquery of the words in text, which are all required unless matchAny is set.
+func buildTSQuery(text string, matchAny bool) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	for i := range words {
+		words[i] = words[i] + ":*"
+	}
+	op := " & "
+	if matchAny {
+		op = " | "
+	}
+	return strings.Join(words, op)
+}
@@ -0,0 +1,64 @@
+package knowledgebase
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Getting Started", "getting-started"},
+		{"  Reset   your password!  ", "reset-your-password"},
+		{"Rückerstattung & Zahlungen", "ruckerstattung-zahlungen"},
+		{"API v2.0 -- FAQ", "api-v2-0-faq"},
+		{"日本語", ""},
+		{strings.Repeat("a", 200), strings.Repeat("a", maxSlugLength)},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.in); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if got := Slugify(tt.in); got != "" && !validSlug(got) {
+			t.Errorf("Slugify(%q) = %q is not a valid slug", tt.in, got)
+		}
+	}
+}
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		matchAny bool
+		want     string
+	}{
+		{"all words", "Reset Password", false, "reset:* & password:*"},
+		{"any word", "reset password", true, "reset:* | password:*"},
+		{"operators stripped", "reset' | !password & (x:*)", false, "reset:* & password:* & x:*"},
+		{"unicode", "Rückerstattung beantragen", false, "rückerstattung:* & beantragen:*"},
+		{"empty", "  !?  ", false, ""},
+		{"capped", strings.Repeat("a ", 20), true, strings.TrimSuffix(strings.Repeat("a:* | ", maxSearchTerms), " | ")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildTSQuery(tt.text, tt.matchAny); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidLocale(t *testing.T) {
+	for _, l := range []string{"en", "de", "pt-BR", "zh-Hans"} {
+		if !ValidLocale(l) {
+			t.Errorf("ValidLocale(%q) = false, want true", l)
+		}
+	}
+	for _, l := range []string{"", "e", "english", "en_US", "EN", "en-"} {
+		if ValidLocale(l) {
+			t.Errorf("ValidLocale(%q) = true, want false", l)
+		}
+	}
+}
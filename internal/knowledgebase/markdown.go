@@ -0,0 +1,24 @@
+package knowledgebase
+
+import (
+	"bytes"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	// Raw HTML in article bodies is omitted by goldmark, the sanitizer drops what is left unsafe such as javascript: links.
+	markdown   = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	htmlPolicy = bluemonday.UGCPolicy()
+)
+
+// RenderMarkdown renders the markdown body of an article to sanitized HTML for the portal.
+func RenderMarkdown(body string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(body), &buf); err != nil {
+		return "", err
+	}
+	return htmlPolicy.Sanitize(buf.String()), nil
+}
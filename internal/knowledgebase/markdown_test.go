@@ -0,0 +1,23 @@
+package knowledgebase
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	got, err := RenderMarkdown("# Reset your password\n\nOpen **Settings**.\n\n<script>alert(1)</script>\n\n[link](javascript:alert(1))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<h1", "Reset your password</h1>", "<strong>Settings</strong>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to contain %q", got, want)
+		}
+	}
+	for _, unsafe := range []string{"<script", "javascript:"} {
+		if strings.Contains(got, unsafe) {
+			t.Errorf("got %q, want no %q", got, unsafe)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
+
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+)
+
+// Category groups articles in the knowledge base.
+type Category struct {
+	ID           int       `db:"id" json:"id"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	Name         string    `db:"name" json:"name"`
+	Description  string    `db:"description" json:"description"`
+	Slug         string    `db:"slug" json:"slug"`
+	Position     int       `db:"position" json:"position"`
+	ArticleCount int       `db:"article_count" json:"article_count"`
+}
+
+// Article is a knowledge base article, its content lives in one translation per locale.
+type Article struct {
+	ID           int           `db:"id" json:"id"`
+	CreatedAt    time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `db:"updated_at" json:"updated_at"`
+	CategoryID   null.Int      `db:"category_id" json:"category_id"`
+	Slug         string        `db:"slug" json:"slug"`
+	Position     int           `db:"position" json:"position"`
+	Translations []Translation `db:"-" json:"translations"`
+}
+
+// ArticleListItem is an article along with the title and status of its translation in the listed locale.
+type ArticleListItem struct {
+	ID         int            `db:"id" json:"id"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updated_at"`
+	CategoryID null.Int       `db:"category_id" json:"category_id"`
+	Slug       string         `db:"slug" json:"slug"`
+	Position   int            `db:"position" json:"position"`
+	Title      null.String    `db:"title" json:"title"`
+	Status     null.String    `db:"status" json:"status"`
+	Locales    pq.StringArray `db:"locales" json:"locales"`
+}
+
+// Translation is the content of an article in a locale.
+type Translation struct {
+	ID          int       `db:"id" json:"id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ArticleID   int       `db:"article_id" json:"article_id"`
+	Locale      string    `db:"locale" json:"locale"`
+	Title       string    `db:"title" json:"title"`
+	Body        string    `db:"body" json:"body"`
+	Status      string    `db:"status" json:"status"`
+	Version     int       `db:"version" json:"version"`
+	PublishedAt null.Time `db:"published_at" json:"published_at"`
+	// DraftTitle and DraftBody are the saved changes of a published translation that are not published yet.
+	DraftTitle null.String `db:"draft_title" json:"draft_title"`
+	DraftBody  null.String `db:"draft_body" json:"draft_body"`
+}
+
+// Version is a saved revision of an article translation.
+type Version struct {
+	ID        int         `db:"id" json:"id"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	Version   int         `db:"version" json:"version"`
+	Title     string      `db:"title" json:"title"`
+	Body      string      `db:"body" json:"body"`
+	UserID    null.Int    `db:"user_id" json:"user_id"`
+	UserName  null.String `db:"user_name" json:"user_name"`
+}
+
+// PublicArticle is a published article translation as shown on the portal.
+type PublicArticle struct {
+	ID           int         `db:"id" json:"id"`
+	Slug         string      `db:"slug" json:"slug"`
+	CategoryID   null.Int    `db:"category_id" json:"category_id"`
+	CategorySlug null.String `db:"category_slug" json:"category_slug"`
+	Locale       string      `db:"locale" json:"locale"`
+	Title        string      `db:"title" json:"title"`
+	Body         string      `db:"body" json:"body,omitempty"`
+	Snippet      string      `db:"snippet" json:"snippet,omitempty"`
+	HTML         string      `db:"-" json:"html,omitempty"`
+	UpdatedAt    time.Time   `db:"updated_at" json:"updated_at"`
+	URL          string      `db:"-" json:"url"`
+}
@@ -0,0 +1,148 @@
+-- name: get-categories
+SELECT c.id, c.created_at, c.updated_at, c.name, c.description, c.slug, c.position,
+    (SELECT COUNT(*) FROM kb_articles a WHERE a.category_id = c.id) AS article_count
+FROM kb_categories c
+ORDER BY c.position, c.name;
+
+-- name: get-category
+SELECT c.id, c.created_at, c.updated_at, c.name, c.description, c.slug, c.position,
+    (SELECT COUNT(*) FROM kb_articles a WHERE a.category_id = c.id) AS article_count
+FROM kb_categories c
+WHERE c.id = $1;
+
+-- name: insert-category
+INSERT INTO kb_categories (name, description, slug, position)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at, updated_at, name, description, slug, position, 0 AS article_count;
+
+-- name: update-category
+UPDATE kb_categories
+SET name = $2, description = $3, slug = $4, position = $5, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, description, slug, position, 0 AS article_count;
+
+-- name: delete-category
+DELETE FROM kb_categories WHERE id = $1;
+
+-- name: get-articles
+-- Articles with the title and status of their translation in the locale, optionally of a category.
+SELECT a.id, a.updated_at, a.category_id, a.slug, a.position, t.title, t.status,
+    COALESCE((SELECT array_agg(locale ORDER BY locale) FROM kb_article_translations WHERE article_id = a.id), '{}') AS locales
+FROM kb_articles a
+LEFT JOIN kb_article_translations t ON t.article_id = a.id AND t.locale = $1
+WHERE ($2 = 0 OR a.category_id = $2)
+ORDER BY a.position, a.id;
+
+-- name: get-article
+SELECT id, created_at, updated_at, category_id, slug, position FROM kb_articles WHERE id = $1;
+
+-- name: get-article-translations
+SELECT id, created_at, updated_at, article_id, locale, title, body, draft_title, draft_body, status, version, published_at
+FROM kb_article_translations
+WHERE article_id = $1
+ORDER BY locale;
+
+-- name: insert-article
+INSERT INTO kb_articles (category_id, slug, position)
+VALUES ($1, $2, $3)
+RETURNING id, created_at, updated_at, category_id, slug, position;
+
+-- name: update-article
+UPDATE kb_articles
+SET category_id = $2, slug = $3, position = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, category_id, slug, position;
+
+-- name: delete-article
+DELETE FROM kb_articles WHERE id = $1;
+
+-- name: upsert-translation
+-- Saves the translation as a new version, it is left in its current status. Changes to a published translation
+-- are kept as its draft until it is published again, so the portal keeps showing the published title and body.
+WITH saved AS (
+    INSERT INTO kb_article_translations (article_id, locale, title, body)
+    VALUES ($1, $2, $3, $4)
+    ON CONFLICT (article_id, locale) DO UPDATE
+    SET title = CASE WHEN kb_article_translations.status = 'published' THEN kb_article_translations.title ELSE EXCLUDED.title END,
+        body = CASE WHEN kb_article_translations.status = 'published' THEN kb_article_translations.body ELSE EXCLUDED.body END,
+        draft_title = CASE WHEN kb_article_translations.status = 'published' THEN EXCLUDED.title ELSE NULL END,
+        draft_body = CASE WHEN kb_article_translations.status = 'published' THEN EXCLUDED.body ELSE NULL END,
+        version = kb_article_translations.version + 1,
+        updated_at = NOW()
+    RETURNING *
+),
+version AS (
+    INSERT INTO kb_article_versions (translation_id, version, title, body, user_id)
+    SELECT id, version, COALESCE(draft_title, title), COALESCE(draft_body, body), $5 FROM saved
+)
+SELECT id, created_at, updated_at, article_id, locale, title, body, draft_title, draft_body, status, version, published_at FROM saved;
+
+-- name: update-translation-status
+-- The draft of a published translation replaces its title and body when it is published or unpublished.
+UPDATE kb_article_translations
+SET status = $3,
+    title = COALESCE(draft_title, title),
+    body = COALESCE(draft_body, body),
+    draft_title = NULL,
+    draft_body = NULL,
+    published_at = CASE WHEN $3 = 'published' THEN NOW() ELSE published_at END,
+    updated_at = NOW()
+WHERE article_id = $1 AND locale = $2
+RETURNING id, created_at, updated_at, article_id, locale, title, body, draft_title, draft_body, status, version, published_at;
+
+-- name: delete-translation
+DELETE FROM kb_article_translations WHERE article_id = $1 AND locale = $2;
+
+-- name: get-translation-versions
+SELECT v.id, v.created_at, v.version, v.title, v.body, v.user_id,
+    CASE WHEN u.id IS NULL THEN NULL ELSE CONCAT(u.first_name, ' ', u.last_name) END AS user_name
+FROM kb_article_versions v
+JOIN kb_article_translations t ON t.id = v.translation_id
+LEFT JOIN users u ON u.id = v.user_id
+WHERE t.article_id = $1 AND t.locale = $2
+ORDER BY v.version DESC;
+
+-- name: get-public-categories
+-- Categories with at least one article published in the locale or the default locale.
+SELECT c.id, c.created_at, c.updated_at, c.name, c.description, c.slug, c.position,
+    COUNT(DISTINCT a.id) AS article_count
+FROM kb_categories c
+JOIN kb_articles a ON a.category_id = c.id
+JOIN kb_article_translations t ON t.article_id = a.id AND t.status = 'published' AND t.locale IN ($1, $2)
+GROUP BY c.id
+ORDER BY c.position, c.name;
+
+-- name: get-public-articles
+-- Published articles of a category in the locale, falling back to the default locale.
+SELECT DISTINCT ON (a.position, a.id) a.id, a.slug, a.category_id, c.slug AS category_slug, t.locale, t.title, '' AS body, '' AS snippet, t.updated_at
+FROM kb_articles a
+JOIN kb_categories c ON c.id = a.category_id
+JOIN kb_article_translations t ON t.article_id = a.id AND t.status = 'published' AND t.locale IN ($2, $3)
+WHERE c.slug = $1
+ORDER BY a.position, a.id, (t.locale = $2) DESC;
+
+-- name: get-public-article
+SELECT a.id, a.slug, a.category_id, c.slug AS category_slug, t.locale, t.title, t.body, '' AS snippet, t.updated_at
+FROM kb_articles a
+LEFT JOIN kb_categories c ON c.id = a.category_id
+JOIN kb_article_translations t ON t.article_id = a.id AND t.status = 'published' AND t.locale IN ($2, $3)
+WHERE a.slug = $1
+ORDER BY (t.locale = $2) DESC
+LIMIT 1;
+
+-- name: search-articles
+-- Full text search of the published articles in the locale, falling back to the default locale.
+-- $1 is a tsquery built by the app from the search terms.
+SELECT id, slug, category_id, category_slug, locale, title, '' AS body, snippet, updated_at FROM (
+    SELECT DISTINCT ON (a.id) a.id, a.slug, a.category_id, c.slug AS category_slug, t.locale, t.title, t.updated_at,
+        ts_headline('simple', t.body, q.query, 'MaxWords=30, MinWords=10, StartSel=, StopSel=') AS snippet,
+        ts_rank(t.search, q.query) AS rank
+    FROM kb_article_translations t
+    JOIN kb_articles a ON a.id = t.article_id
+    LEFT JOIN kb_categories c ON c.id = a.category_id
+    CROSS JOIN LATERAL (SELECT to_tsquery('simple', $1) AS query) q
+    WHERE t.status = 'published' AND t.locale IN ($2, $3) AND t.search @@ q.query
+    ORDER BY a.id, (t.locale = $2) DESC
+) results
+ORDER BY rank DESC
+LIMIT $4;
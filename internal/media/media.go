@@ -2,6 +2,7 @@
 package media
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"embed"
@@ -166,6 +167,28 @@ func (m *Manager) GetBlob(name string) ([]byte, error) {
 	return m.store.GetBlob(name)
 }
 
+// Copy stores a copy of a media file along with its thumbnail and inserts an unattached entry for it in db, so that
+// the copy can be attached to another model and deleted independently of the original.
+func (m *Manager) Copy(media models.Media) (models.Media, error) {
+	blob, err := m.store.GetBlob(media.UUID)
+	if err != nil {
+		m.lo.Error("error fetching media to copy", "uuid", media.UUID, "error", err)
+		return models.Media{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.media}"), nil)
+	}
+	cp, err := m.UploadAndInsert(media.Filename, media.ContentType, media.ContentID, null.String{}, null.Int{}, bytes.NewReader(blob), media.Size, media.Disposition, media.Meta)
+	if err != nil {
+		return cp, err
+	}
+
+	// Only images have a thumbnail to copy.
+	if thumb, err := m.store.GetBlob(image.ThumbPrefix + media.UUID); err == nil {
+		if _, err := m.store.Put(image.ThumbPrefix+cp.UUID, media.ContentType, bytes.NewReader(thumb)); err != nil {
+			m.lo.Error("error copying media thumbnail", "uuid", media.UUID, "error", err)
+		}
+	}
+	return cp, nil
+}
+
 // GetURL returns the URL for accessing a media file by its name.
 func (m *Manager) GetURL(uuid, contentType, fileName string) string {
 	// Keep some content types inline.
@@ -12,6 +12,8 @@ const (
 	ModelMessages = "messages"
 	ModelUser     = "users"
 
+	ModelSideConversationMessages = "side_conversation_messages"
+
 	DispositionInline = "inline"
 )
 
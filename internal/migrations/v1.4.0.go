@@ -0,0 +1,790 @@
+package migrations
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/koanf/v2"
+	"github.com/knadh/stuffbin"
+)
+
+// V1_4_0 updates the database schema to v1.4.0.
+func V1_4_0(db *sqlx.DB, fs stuffbin.FileSystem, ko *koanf.Koanf) error {
+	// Each feature of the release is a separate idempotent step, run in order.
+	for _, step := range []func(*sqlx.DB) error{
+		v140PerformanceReports,
+		v140ReportSchedules,
+		v140AuditTrail,
+		v140ContactDataRights,
+		v140ChatChannels,
+		v140PushSubscriptions,
+		v140NotificationPreferences,
+		v140AIProviders,
+		v140AIPrompts,
+		v140AIEmbeddings,
+		v140AIClassifications,
+		v140KnowledgeBase,
+		v140ScheduledMessages,
+		v140UndoSend,
+		v140SideConversations,
+		v140ConversationLinks,
+		v140TimeTracking,
+		v140ConversationTasks,
+		v140CustomAttributeOptions,
+		v140TicketForms,
+		v140PortalSignIn,
+		v140PortalOIDC,
+		v140OrganizationMemberRoles,
+	} {
+		if err := step(db); err != nil {
+			return err
+		}
+	}
+	_ = fs
+	_ = ko
+	return nil
+}
+
+// v140PerformanceReports adds conversation reopen tracking for the agent and team performance reports.
+func v140PerformanceReports(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE conversations
+		ADD COLUMN IF NOT EXISTS reopened_at TIMESTAMPTZ NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140ReportSchedules adds scheduled report emails.
+func v140ReportSchedules(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'report_schedule_frequency') THEN
+				CREATE TYPE report_schedule_frequency AS ENUM ('daily', 'weekly', 'monthly');
+			END IF;
+		END$$;
+
+		CREATE TABLE IF NOT EXISTS report_schedules (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			name TEXT NOT NULL,
+			frequency report_schedule_frequency NOT NULL,
+			reports TEXT[] NOT NULL DEFAULT '{}',
+			format TEXT NOT NULL DEFAULT 'xlsx',
+			recipient_ids INT[] NOT NULL DEFAULT '{}',
+			enabled BOOLEAN DEFAULT true NOT NULL,
+			last_sent_at TIMESTAMPTZ NULL,
+			next_run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			CONSTRAINT constraint_report_schedules_on_name CHECK (length(name) <= 255),
+			CONSTRAINT constraint_report_schedules_on_format CHECK (format IN ('csv', 'xlsx')),
+			CONSTRAINT constraint_report_schedules_on_reports_not_empty CHECK (array_length(reports, 1) > 0),
+			CONSTRAINT constraint_report_schedules_on_recipient_ids_not_empty CHECK (array_length(recipient_ids, 1) > 0)
+		);
+		CREATE INDEX IF NOT EXISTS index_report_schedules_on_next_run_at ON report_schedules(next_run_at) WHERE enabled = true;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add email notification template for scheduled reports.
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM templates WHERE "name" = 'Scheduled report') THEN
+				INSERT INTO templates
+					("type", body, is_default, "name", subject, is_builtin)
+					VALUES (
+					'email_notification'::template_type,
+'<p>Hi {{ .Recipient.FirstName }},</p>
+
+<p>Your {{ .Report.Frequency }} report <strong>{{ .Report.Name }}</strong> for the last {{ .Report.Days }} day(s) is attached.</p>
+
+<ul>
+{{ range .Report.Summary }}
+<li>{{ .Label }}: {{ .Value }}</li>
+{{ end }}
+</ul>
+
+<p>
+<a href="{{ RootURL }}/reports/overview">View Reports</a>
+</p>
+
+<p>
+Best regards,<br>
+Libredesk
+</p>',
+					false,
+					'Scheduled report',
+					'{{ .Report.Name }} - {{ .Report.Date }}',
+					true
+				);
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140AuditTrail adds the audit trail of admin changes.
+func v140AuditTrail(db *sqlx.DB) error {
+	for _, v := range []string{"entity_created", "entity_updated", "entity_deleted"} {
+		if _, err := db.Exec(`ALTER TYPE activity_log_type ADD VALUE IF NOT EXISTS '` + v + `';`); err != nil {
+			return err
+		}
+	}
+
+	_, err := db.Exec(`
+		ALTER TABLE activity_logs
+		ADD COLUMN IF NOT EXISTS changes JSONB NULL;
+		CREATE INDEX IF NOT EXISTS index_activity_logs_on_target_model_type_target_model_id ON activity_logs (target_model_type, target_model_id);
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140ContactDataRights adds contact data export and erasure.
+func v140ContactDataRights(db *sqlx.DB) error {
+	for _, v := range []string{"contact_data_exported", "contact_data_erased"} {
+		if _, err := db.Exec(`ALTER TYPE activity_log_type ADD VALUE IF NOT EXISTS '` + v + `';`); err != nil {
+			return err
+		}
+	}
+
+	// Add contact data export and erasure permissions to Admin role.
+	for _, permission := range []string{"contacts:export", "contacts:erase"} {
+		_, err := db.Exec(`
+			UPDATE roles
+			SET permissions = array_append(permissions, $1)
+			WHERE name = 'Admin' AND NOT ($1 = ANY(permissions));
+		`, permission)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// v140ChatChannels adds chat app notification channels for agents and teams.
+func v140ChatChannels(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_chat_identities (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			provider TEXT NOT NULL,
+			webhook_url TEXT NOT NULL,
+			enabled BOOLEAN DEFAULT TRUE NOT NULL,
+			CONSTRAINT constraint_user_chat_identities_on_webhook_url CHECK (length(webhook_url) <= 2000),
+			CONSTRAINT constraint_user_chat_identities_on_user_id_provider_unique UNIQUE (user_id, provider)
+		);
+		ALTER TABLE teams
+		ADD COLUMN IF NOT EXISTS chat_provider TEXT NULL,
+		ADD COLUMN IF NOT EXISTS chat_webhook_url TEXT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140PushSubscriptions adds browser push subscriptions.
+func v140PushSubscriptions(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_push_subscriptions (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			endpoint TEXT NOT NULL,
+			p256dh TEXT NOT NULL,
+			auth TEXT NOT NULL,
+			user_agent TEXT DEFAULT '' NOT NULL,
+			CONSTRAINT constraint_user_push_subscriptions_on_endpoint CHECK (length(endpoint) <= 2000),
+			CONSTRAINT constraint_user_push_subscriptions_on_user_agent CHECK (length(user_agent) <= 1000),
+			CONSTRAINT constraint_user_push_subscriptions_on_endpoint_unique UNIQUE (endpoint)
+		);
+		CREATE INDEX IF NOT EXISTS index_user_push_subscriptions_on_user_id ON user_push_subscriptions(user_id);
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140NotificationPreferences adds per-agent notification preferences with quiet hours and a daily digest.
+func v140NotificationPreferences(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_notification_settings (
+			user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			timezone TEXT DEFAULT 'UTC' NOT NULL,
+			quiet_hours_enabled BOOLEAN DEFAULT FALSE NOT NULL,
+			quiet_hours_start TEXT DEFAULT '22:00' NOT NULL,
+			quiet_hours_end TEXT DEFAULT '07:00' NOT NULL,
+			digest_enabled BOOLEAN DEFAULT FALSE NOT NULL,
+			digest_time TEXT DEFAULT '08:00' NOT NULL,
+			last_digest_at TIMESTAMPTZ NULL,
+			CONSTRAINT constraint_user_notification_settings_on_timezone CHECK (length(timezone) <= 140)
+		);
+
+		CREATE TABLE IF NOT EXISTS user_notification_preferences (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			notification_type user_notification_type NOT NULL,
+			in_app BOOLEAN DEFAULT TRUE NOT NULL,
+			email BOOLEAN DEFAULT TRUE NOT NULL,
+			push BOOLEAN DEFAULT TRUE NOT NULL,
+			chat BOOLEAN DEFAULT TRUE NOT NULL,
+			CONSTRAINT constraint_user_notification_preferences_on_user_id_notification_type_unique UNIQUE (user_id, notification_type)
+		);
+
+		CREATE TABLE IF NOT EXISTS notification_digest_items (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			notification_type user_notification_type NOT NULL,
+			title TEXT NOT NULL,
+			body TEXT NULL,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_notification_digest_items_on_user_id ON notification_digest_items(user_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add email notification template for the daily notification digest.
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM templates WHERE "name" = 'Notification digest') THEN
+				INSERT INTO templates
+					("type", body, is_default, "name", subject, is_builtin)
+					VALUES (
+					'email_notification'::template_type,
+'<p>Hi {{ .Recipient.FirstName }},</p>
+
+<p>You have {{ .Digest.Count }} notification(s) since your last digest.</p>
+
+<ul>
+{{ range .Digest.Items }}
+<li>
+{{ if .ConversationUUID.Valid }}<a href="{{ RootURL }}/inboxes/all/conversation/{{ .ConversationUUID.String }}">{{ .Title }}</a>{{ else }}{{ .Title }}{{ end }}
+{{ if .Body.Valid }}<br>{{ .Body.String }}{{ end }}
+</li>
+{{ end }}
+</ul>
+
+<p>
+Best regards,<br>
+Libredesk
+</p>',
+					false,
+					'Notification digest',
+					'Your notification digest - {{ .Digest.Date }}',
+					true
+				);
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140AIProviders adds the Claude and OpenAI compatible AI providers and a provider per prompt.
+func v140AIProviders(db *sqlx.DB) error {
+	for _, v := range []string{"claude", "openai_compatible"} {
+		if _, err := db.Exec(`ALTER TYPE ai_provider ADD VALUE IF NOT EXISTS '` + v + `';`); err != nil {
+			return err
+		}
+	}
+
+	// New enum values can only be used once the ALTER TYPE statements above are committed.
+	_, err := db.Exec(`
+		INSERT INTO ai_providers ("name", provider, config, is_default)
+		VALUES
+		('claude', 'claude', '{"api_key": "", "model": "claude-sonnet-4-5"}'::jsonb, false),
+		('openai_compatible', 'openai_compatible', '{"api_key": "", "base_url": "http://localhost:11434/v1", "model": ""}'::jsonb, false)
+		ON CONFLICT ("name") DO NOTHING;
+
+		ALTER TABLE ai_prompts
+		ADD COLUMN IF NOT EXISTS provider_id INT REFERENCES ai_providers(id) ON DELETE SET NULL ON UPDATE CASCADE NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140AIPrompts adds the conversation summary, classification and suggested reply AI prompts.
+func v140AIPrompts(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE ai_prompts
+		ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'rewrite';
+
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'constraint_prompts_on_kind') THEN
+				ALTER TABLE ai_prompts ADD CONSTRAINT constraint_prompts_on_kind CHECK (kind IN ('rewrite', 'conversation', 'classification'));
+			END IF;
+		END$$;
+
+		INSERT INTO ai_prompts ("key", "content", title, kind)
+		VALUES
+		('conversation_summary', 'You are a support agent handing over a conversation to a colleague. Summarize the conversation below in a few short bullet points: who the customer is, what they need, what has been tried or promised so far, including relevant private notes, and what the next step is. Reply in English with only the summary.', 'Conversation Summary', 'conversation'),
+		('classify_message', 'Classify the customer message below. Reply with only a JSON object with the keys intent (a short snake_case label such as billing, refund, bug_report, feature_request, account_access or general_question), sentiment (one of positive, neutral, negative), language (the ISO 639-1 code of the language of the message) and urgency (one of low, medium, high, urgent).', 'Classify Message', 'classification'),
+		('suggested_reply', 'You are a helpful customer support agent. Write the next reply to the customer in the conversation below. Reply in the same language the customer writes in. Use the private notes as internal context but never reveal them. Do not make promises that are not backed by the conversation. Reply with only the message body, without a subject line.', 'Suggested Reply', 'conversation')
+		ON CONFLICT ("key") DO NOTHING;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140AIEmbeddings adds the embeddings index of resolved conversations and macros.
+func v140AIEmbeddings(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'ai_embedding_source') THEN
+				CREATE TYPE ai_embedding_source AS ENUM ('conversation', 'macro');
+			END IF;
+		END$$;
+
+		CREATE TABLE IF NOT EXISTS ai_embeddings (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			source_type ai_embedding_source NOT NULL,
+			-- ID of the resolved conversation or macro, not a foreign key as it points to either table.
+			source_id BIGINT NOT NULL,
+			title TEXT NOT NULL,
+			content TEXT NOT NULL,
+			-- Plain float array so the pgvector extension is not required, when installed it ranks them instead of the app.
+			embedding FLOAT8[] NOT NULL,
+			model TEXT NOT NULL,
+			-- Resolved at of the conversation or updated at of the macro when it was indexed.
+			source_updated_at TIMESTAMPTZ NOT NULL,
+			CONSTRAINT constraint_ai_embeddings_unique_source UNIQUE (source_type, source_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140AIClassifications adds the cached message classifications of the AI classification automation action.
+func v140AIClassifications(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ai_message_classifications (
+			-- Classification of an incoming message cached for the AI classification automation action.
+			message_id BIGINT PRIMARY KEY REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			intent TEXT NOT NULL DEFAULT '',
+			sentiment TEXT NOT NULL DEFAULT '',
+			"language" TEXT NOT NULL DEFAULT '',
+			urgency TEXT NOT NULL DEFAULT ''
+		);
+
+		INSERT INTO custom_attribute_definitions (applies_to, "name", description, key, values, data_type)
+		VALUES
+		('conversation', 'AI intent', 'Intent of the latest incoming message set by the AI classification automation action.', 'ai_intent', '{}', 'text'),
+		('conversation', 'AI sentiment', 'Sentiment of the latest incoming message set by the AI classification automation action.', 'ai_sentiment', '{positive,neutral,negative}', 'list'),
+		('conversation', 'AI language', 'ISO 639-1 language code of the latest incoming message set by the AI classification automation action.', 'ai_language', '{}', 'text'),
+		('conversation', 'AI urgency', 'Urgency of the latest incoming message set by the AI classification automation action.', 'ai_urgency', '{low,medium,high,urgent}', 'list')
+		ON CONFLICT (key, applies_to) DO NOTHING;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140KnowledgeBase adds the knowledge base.
+func v140KnowledgeBase(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'kb_article_status') THEN
+				CREATE TYPE kb_article_status AS ENUM ('draft', 'published');
+			END IF;
+		END$$;
+
+		CREATE TABLE IF NOT EXISTS kb_categories (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			"name" TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			slug TEXT NOT NULL,
+			position INT NOT NULL DEFAULT 0,
+			CONSTRAINT constraint_kb_categories_on_slug_unique UNIQUE (slug)
+		);
+
+		CREATE TABLE IF NOT EXISTS kb_articles (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			category_id INT REFERENCES kb_categories(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			slug TEXT NOT NULL,
+			position INT NOT NULL DEFAULT 0,
+			CONSTRAINT constraint_kb_articles_on_slug_unique UNIQUE (slug)
+		);
+		CREATE INDEX IF NOT EXISTS index_kb_articles_on_category_id ON kb_articles(category_id);
+
+		CREATE TABLE IF NOT EXISTS kb_article_translations (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			article_id INT REFERENCES kb_articles(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			locale TEXT NOT NULL,
+			title TEXT NOT NULL,
+			body TEXT NOT NULL,
+			draft_title TEXT NULL,
+			draft_body TEXT NULL,
+			status kb_article_status NOT NULL DEFAULT 'draft',
+			version INT NOT NULL DEFAULT 1,
+			published_at TIMESTAMPTZ NULL,
+			search TSVECTOR GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', body), 'B')
+			) STORED,
+			CONSTRAINT constraint_kb_article_translations_on_article_id_and_locale_unique UNIQUE (article_id, locale)
+		);
+		CREATE INDEX IF NOT EXISTS index_kb_article_translations_on_search ON kb_article_translations USING GIN (search);
+
+		CREATE TABLE IF NOT EXISTS kb_article_versions (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			translation_id INT REFERENCES kb_article_translations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			version INT NOT NULL,
+			title TEXT NOT NULL,
+			body TEXT NOT NULL,
+			user_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_kb_article_versions_on_translation_id ON kb_article_versions(translation_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'knowledge_base:manage')
+		WHERE name = 'Admin' AND NOT ('knowledge_base:manage' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140ScheduledMessages adds messages scheduled to be sent later.
+func v140ScheduledMessages(db *sqlx.DB) error {
+	_, err := db.Exec(`ALTER TYPE message_status ADD VALUE IF NOT EXISTS 'scheduled';`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE conversation_messages
+		ADD COLUMN IF NOT EXISTS send_at TIMESTAMPTZ NULL;
+		CREATE INDEX IF NOT EXISTS index_conversation_messages_on_send_at ON conversation_messages (send_at) WHERE status = 'scheduled';
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140UndoSend adds the per-agent undo send grace period.
+func v140UndoSend(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE users
+		ADD COLUMN IF NOT EXISTS undo_send_seconds INT DEFAULT 0 NOT NULL;
+
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'constraint_users_on_undo_send_seconds') THEN
+				ALTER TABLE users ADD CONSTRAINT constraint_users_on_undo_send_seconds CHECK (undo_send_seconds = 0 OR undo_send_seconds BETWEEN 5 AND 30);
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140SideConversations adds side conversations with third parties.
+func v140SideConversations(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS side_conversations (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			"uuid" UUID DEFAULT gen_random_uuid() NOT NULL UNIQUE,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			-- Side conversations keep the inbox they were started from even if the conversation moves to another inbox.
+			inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			created_by_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			subject TEXT NOT NULL,
+			"to" TEXT[] NOT NULL,
+			cc TEXT[] DEFAULT '{}' NOT NULL,
+			last_message_at TIMESTAMPTZ NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_side_conversations_on_conversation_id ON side_conversations (conversation_id);
+
+		CREATE TABLE IF NOT EXISTS side_conversation_messages (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			"uuid" UUID DEFAULT gen_random_uuid() NOT NULL UNIQUE,
+			side_conversation_id INT REFERENCES side_conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"type" message_type NOT NULL,
+			status message_status NOT NULL,
+			-- Agent who sent an outgoing message, incoming messages only have the from address.
+			sender_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			from_address TEXT NOT NULL,
+			"content" TEXT NOT NULL,
+			text_content TEXT NOT NULL,
+			source_id TEXT NOT NULL UNIQUE
+		);
+		CREATE INDEX IF NOT EXISTS index_side_conversation_messages_on_side_conversation_id ON side_conversation_messages (side_conversation_id);
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140ConversationLinks adds parent/child and related conversation links.
+func v140ConversationLinks(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'conversation_link_type') THEN
+				CREATE TYPE conversation_link_type AS ENUM ('parent_child', 'related');
+			END IF;
+		END$$;
+
+		CREATE TABLE IF NOT EXISTS conversation_links (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			"type" conversation_link_type NOT NULL,
+			-- For parent_child links the conversation is the parent and the linked conversation the child.
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			linked_conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			created_by_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			CONSTRAINT constraint_conversation_links_on_self CHECK (conversation_id != linked_conversation_id)
+		);
+		-- Two conversations are linked at most once, and a child has a single parent.
+		CREATE UNIQUE INDEX IF NOT EXISTS index_uniq_conversation_links_on_conversations ON conversation_links (LEAST(conversation_id, linked_conversation_id), GREATEST(conversation_id, linked_conversation_id));
+		CREATE UNIQUE INDEX IF NOT EXISTS index_uniq_conversation_links_on_child ON conversation_links (linked_conversation_id) WHERE "type" = 'parent_child';
+		CREATE INDEX IF NOT EXISTS index_conversation_links_on_conversation_id ON conversation_links (conversation_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_links_on_linked_conversation_id ON conversation_links (linked_conversation_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE conversation_messages
+		ADD COLUMN IF NOT EXISTS parent_message_id BIGINT REFERENCES conversation_messages(id) ON DELETE SET NULL ON UPDATE CASCADE NULL;
+		CREATE INDEX IF NOT EXISTS index_conversation_messages_on_parent_message_id ON conversation_messages (parent_message_id) WHERE parent_message_id IS NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'conversations:update_links')
+		WHERE name IN ('Admin', 'Agent') AND NOT ('conversations:update_links' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140TimeTracking adds time entries on conversations.
+func v140TimeTracking(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'time_entry_source') THEN
+				CREATE TYPE time_entry_source AS ENUM ('manual', 'automatic');
+			END IF;
+		END$$;
+
+		CREATE TABLE IF NOT EXISTS conversation_time_entries (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"source" time_entry_source NOT NULL DEFAULT 'manual',
+			duration_seconds INT NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			billable BOOLEAN NOT NULL DEFAULT false,
+			-- When the tracked work started, defaults to when the entry was created.
+			started_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+			CONSTRAINT constraint_conversation_time_entries_on_duration_seconds CHECK (duration_seconds > 0),
+			CONSTRAINT constraint_conversation_time_entries_on_note CHECK (length(note) <= 1000)
+		);
+		CREATE INDEX IF NOT EXISTS index_conversation_time_entries_on_conversation_id ON conversation_time_entries (conversation_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_time_entries_on_user_id ON conversation_time_entries (user_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_time_entries_on_started_at ON conversation_time_entries (started_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'conversations:track_time')
+		WHERE name IN ('Admin', 'Agent') AND NOT ('conversations:track_time' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140ConversationTasks adds conversation tasks with due reminders.
+func v140ConversationTasks(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS conversation_tasks (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			created_by_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			assigned_user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			title TEXT NOT NULL,
+			due_at TIMESTAMPTZ NULL,
+			completed_at TIMESTAMPTZ NULL,
+			-- Set once the due reminder is sent, cleared when the due date changes.
+			reminded_at TIMESTAMPTZ NULL,
+			CONSTRAINT constraint_conversation_tasks_on_title CHECK (length(title) BETWEEN 1 AND 500)
+		);
+		CREATE INDEX IF NOT EXISTS index_conversation_tasks_on_conversation_id ON conversation_tasks (conversation_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_tasks_on_assigned_user_id ON conversation_tasks (assigned_user_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_tasks_on_due_at ON conversation_tasks (due_at) WHERE completed_at IS NULL AND reminded_at IS NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TYPE user_notification_type ADD VALUE IF NOT EXISTS 'task_due';`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'conversations:manage_tasks')
+		WHERE name IN ('Admin', 'Agent') AND NOT ('conversations:manage_tasks' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140CustomAttributeOptions adds the number range, lookup type and required on resolve options of custom attributes.
+func v140CustomAttributeOptions(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE custom_attribute_definitions
+			ADD COLUMN IF NOT EXISTS min_value NUMERIC NULL,
+			ADD COLUMN IF NOT EXISTS max_value NUMERIC NULL,
+			ADD COLUMN IF NOT EXISTS lookup_type TEXT DEFAULT '' NOT NULL,
+			ADD COLUMN IF NOT EXISTS required_on_resolve BOOLEAN DEFAULT false NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140TicketForms adds the portal ticket forms.
+func v140TicketForms(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticket_forms (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			"name" TEXT NOT NULL,
+			description TEXT DEFAULT '' NOT NULL,
+			inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			enabled BOOLEAN DEFAULT true NOT NULL,
+			fields JSONB DEFAULT '[]'::jsonb NOT NULL,
+			CONSTRAINT constraint_ticket_forms_on_name CHECK (length("name") <= 140),
+			CONSTRAINT constraint_ticket_forms_on_description CHECK (length(description) <= 1000)
+		);
+		CREATE INDEX IF NOT EXISTS index_ticket_forms_on_inbox_id ON ticket_forms (inbox_id);
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140PortalSignIn adds the portal magic link sign in and self-registration settings.
+func v140PortalSignIn(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		INSERT INTO settings ("key", value) VALUES
+			('app.portal_magic_link_enabled', 'false'::jsonb),
+			('app.portal_self_registration_enabled', 'false'::jsonb),
+			('app.portal_registration_domains', '[]'::jsonb),
+			('app.portal_registration_organizations', 'false'::jsonb)
+		ON CONFLICT ("key") DO NOTHING;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140PortalOIDC adds the flag that lets contacts sign in to the portal with an OIDC provider.
+func v140PortalOIDC(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE oidc ADD COLUMN IF NOT EXISTS portal BOOLEAN DEFAULT false NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// v140OrganizationMemberRoles adds the roles of organization members.
+func v140OrganizationMemberRoles(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'organization_member_role') THEN
+				CREATE TYPE organization_member_role AS ENUM ('member', 'admin');
+			END IF;
+		END$$;
+
+		ALTER TABLE organization_members ADD COLUMN IF NOT EXISTS "role" organization_member_role DEFAULT 'member' NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
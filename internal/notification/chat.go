@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// Chat providers post notifications to incoming webhook URLs of chat apps.
+const (
+	ProviderSlack   = "slack"
+	ProviderTeams   = "teams"
+	ProviderWebhook = "webhook"
+)
+
+// ChatProviders lists the providers that agents and teams can link a chat channel for.
+var ChatProviders = []string{ProviderSlack, ProviderTeams, ProviderWebhook}
+
+// IsChatProvider returns true if the provider posts to chat webhook URLs.
+func IsChatProvider(provider string) bool {
+	return slices.Contains(ChatProviders, provider)
+}
+
+// ErrPrivateDestination is returned when a chat webhook URL resolves to a private, loopback or link-local address.
+var ErrPrivateDestination = errors.New("destination address is not public")
+
+// NewChatHTTPClient returns an HTTP client that refuses to connect to private, loopback and link-local addresses
+// unless allowPrivate is set, webhook URLs are entered by agents and must not reach internal services. The check runs
+// on the resolved address at dial time, so DNS names pointing to internal addresses are refused too.
+func NewChatHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if allowPrivate {
+		return &http.Client{Timeout: timeout, Transport: &http.Transport{DialContext: dialer.DialContext}}
+	}
+	dialer.Control = func(network, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		if !IsPublicAddr(addrPort.Addr()) {
+			return ErrPrivateDestination
+		}
+		return nil
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+}
+
+// IsPublicAddr returns true if the address is a globally routable unicast address.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && addr.IsGlobalUnicast() && !addr.IsPrivate() && !addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() && !addr.IsUnspecified()
+}
+
+// PostJSON posts the payload as JSON to the URL and returns an error on a non-2xx response. The response body isn't
+// part of the error, it could leak the contents of the destination to the caller.
+func PostJSON(client *http.Client, url string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshalling payload: %w", err)
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/notification/models"
+	"github.com/ghotso/libredesk/internal/template"
+)
+
+// RunDigests periodically emails the daily digest to agents whose digest time has passed and the emails queued during
+// quiet hours to agents whose quiet hours have ended.
+func (d *Dispatcher) RunDigests(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.sendDueDigests(time.Now())
+		}
+	}
+}
+
+// sendDueDigests sends the digest of every agent that is due.
+func (d *Dispatcher) sendDueDigests(now time.Time) {
+	recipients, err := d.inApp.GetDigestRecipients()
+	if err != nil {
+		d.lo.Error("error fetching digest recipients", "error", err)
+		return
+	}
+	for _, r := range recipients {
+		if !digestDue(r.NotificationSettings, now) && !quietHoursDigestDue(r.NotificationSettings, now) {
+			continue
+		}
+		if err := d.sendDigest(r, now); err != nil {
+			d.lo.Error("error sending notification digest", "user_id", r.UserID, "error", err)
+		}
+	}
+}
+
+// sendDigest emails the pending digest items of an agent and clears them.
+func (d *Dispatcher) sendDigest(r models.DigestRecipient, now time.Time) error {
+	items, err := d.inApp.GetDigestItems(r.UserID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	content, subject, err := d.template.RenderStoredEmailTemplate(template.TmplNotificationDigest,
+		map[string]any{
+			"Digest": map[string]any{
+				"Count": len(items),
+				"Date":  now.In(location(r.Timezone)).Format("2006-01-02"),
+				"Items": items,
+			},
+			"Recipient": map[string]any{
+				"FirstName": r.FirstName,
+				"LastName":  r.LastName,
+				"FullName":  r.FirstName + " " + r.LastName,
+				"Email":     r.Email,
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("rendering template %s: %w", template.TmplNotificationDigest, err)
+	}
+
+	if err := d.outbound.Send(Message{
+		RecipientEmails: []string{r.Email},
+		Subject:         subject,
+		Content:         content,
+		Provider:        ProviderEmail,
+	}); err != nil {
+		return fmt.Errorf("queueing digest email: %w", err)
+	}
+	return d.inApp.CompleteDigest(r.UserID, items[len(items)-1].ID, now)
+}
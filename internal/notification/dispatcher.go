@@ -2,8 +2,10 @@ package notifier
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/ghotso/libredesk/internal/notification/models"
+	"github.com/ghotso/libredesk/internal/template"
 	wsmodels "github.com/ghotso/libredesk/internal/ws/models"
 	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/logf"
@@ -42,11 +44,12 @@ type EmailNotification struct {
 	Content    string
 }
 
-// Dispatcher coordinates sending notifications through multiple channels: WS, DB, email.
+// Dispatcher coordinates sending notifications through multiple channels: WS, DB, email, browser push and the chat channels linked by agents.
 type Dispatcher struct {
 	inApp    *UserNotificationManager
 	outbound *Service
 	wsHub    WSHub
+	template *template.Manager
 	lo       *logf.Logger
 }
 
@@ -55,6 +58,7 @@ type DispatcherOpts struct {
 	InApp    *UserNotificationManager
 	Outbound *Service
 	WSHub    WSHub
+	Template *template.Manager
 	Lo       *logf.Logger
 }
 
@@ -64,28 +68,27 @@ func NewDispatcher(opts DispatcherOpts) *Dispatcher {
 		inApp:    opts.InApp,
 		outbound: opts.Outbound,
 		wsHub:    opts.WSHub,
+		template: opts.Template,
 		lo:       opts.Lo,
 	}
 }
 
 // Send sends a notification through all configured channels.
 // For each recipient: creates in-app notification (DB), broadcasts via Websocket,
-// and sends email if Email field is provided.
+// posts to the recipient's linked chat channels and browsers and sends email if Email field is provided.
+// Channels are picked by the recipient's notification preferences.
 func (d *Dispatcher) Send(n Notification) {
 	for i, recipientID := range n.RecipientIDs {
-		d.sendToRecipient(recipientID, n)
-
-		if d.outbound != nil && n.Email != nil {
-			var email string
+		var email EmailNotification
+		if n.Email != nil {
+			email = EmailNotification{Subject: n.Email.Subject, Content: n.Email.Content}
 			if i < len(n.Email.Recipients) {
-				email = n.Email.Recipients[i]
+				email.Recipients = n.Email.Recipients[i : i+1]
 			} else if len(n.Email.Recipients) == 1 {
-				email = n.Email.Recipients[0] // Broadcast mode
-			}
-			if email != "" {
-				d.sendEmail(recipientID, email, n.Email.Subject, n.Email.Content, n.Type)
+				email.Recipients = n.Email.Recipients // Broadcast mode
 			}
 		}
+		d.sendToChannels(recipientID, n, email)
 	}
 }
 
@@ -93,13 +96,51 @@ func (d *Dispatcher) Send(n Notification) {
 // This is useful when email content is personalized per recipient.
 func (d *Dispatcher) SendWithEmails(n Notification, emails []EmailNotification) {
 	for i, recipientID := range n.RecipientIDs {
+		var email EmailNotification
+		if i < len(emails) {
+			email = emails[i]
+		}
+		d.sendToChannels(recipientID, n, email)
+	}
+}
+
+// sendToChannels sends the notification to a recipient on the channels their preferences allow.
+// Emails go to the daily digest when it is enabled. During quiet hours push and chat are skipped and emails are
+// queued in the digest, which is sent once quiet hours end when the daily digest is off. SLA notifications are
+// urgent and are sent during quiet hours too.
+func (d *Dispatcher) sendToChannels(recipientID int, n Notification, email EmailNotification) {
+	prefs, err := d.inApp.GetPreferences(recipientID)
+	if err != nil {
+		// Fall back to the defaults, a notification is better than none.
+		prefs = models.NotificationPreferences{Settings: defaultSettings}
+	}
+	quiet := inQuietHours(prefs.Settings, time.Now()) && !bypassesQuietHours(n.Type)
+
+	if prefs.Allows(n.Type, models.ChannelInApp) {
 		d.sendToRecipient(recipientID, n)
+	}
+	if !quiet && prefs.Allows(n.Type, models.ChannelChat) {
+		d.sendChat(recipientID, n)
+	}
+	if !quiet && prefs.Allows(n.Type, models.ChannelPush) {
+		d.sendPush(recipientID, n)
+	}
 
-		if d.outbound != nil && i < len(emails) && len(emails[i].Recipients) > 0 {
-			e := emails[i]
-			d.sendEmail(recipientID, e.Recipients[0], e.Subject, e.Content, n.Type)
+	if d.outbound == nil || len(email.Recipients) == 0 || !prefs.Allows(n.Type, models.ChannelEmail) {
+		return
+	}
+	if prefs.Settings.DigestEnabled || quiet {
+		if err := d.inApp.AddDigestItem(recipientID, n.Type, n.Title, n.Body, n.ConversationID); err != nil {
+			d.lo.Error("error adding notification to digest", "recipient_id", recipientID, "type", n.Type, "error", err)
 		}
+		return
 	}
+	d.sendEmail(recipientID, email.Recipients[0], email.Subject, email.Content, n.Type)
+}
+
+// bypassesQuietHours returns true for the urgent notification types that are sent during quiet hours.
+func bypassesQuietHours(nType models.NotificationType) bool {
+	return nType == models.NotificationTypeSLAWarning || nType == models.NotificationTypeSLABreach
 }
 
 // sendToRecipient creates in-app notification and broadcasts via Websocket.
@@ -129,6 +170,75 @@ func (d *Dispatcher) sendToRecipient(recipientID int, n Notification) *models.Us
 	return &notification
 }
 
+// SendToChannel posts a notification to a chat channel that is not linked to an agent, e.g. a team channel.
+func (d *Dispatcher) SendToChannel(provider, webhookURL string, n Notification) {
+	if d.outbound == nil || !d.outbound.HasProvider(provider) {
+		d.lo.Warn("chat provider not configured", "provider", provider)
+		return
+	}
+	if err := d.outbound.Send(chatMessage(provider, webhookURL, n)); err != nil {
+		d.lo.Error("error sending chat notification", "provider", provider, "type", n.Type, "error", err)
+	}
+}
+
+// sendChat posts the notification to the chat channels linked by the recipient.
+func (d *Dispatcher) sendChat(recipientID int, n Notification) {
+	if d.outbound == nil {
+		return
+	}
+	identities, err := d.inApp.GetChatIdentities(recipientID)
+	if err != nil {
+		return
+	}
+	for _, identity := range identities {
+		if !identity.Enabled || !d.outbound.HasProvider(identity.Provider) {
+			continue
+		}
+		if err := d.outbound.Send(chatMessage(identity.Provider, identity.WebhookURL, n)); err != nil {
+			d.lo.Error("error sending chat notification",
+				"recipient_id", recipientID,
+				"provider", identity.Provider,
+				"type", n.Type,
+				"error", err)
+		}
+	}
+}
+
+// sendPush pushes the notification to the browsers the recipient subscribed to Web Push from.
+func (d *Dispatcher) sendPush(recipientID int, n Notification) {
+	if d.outbound == nil || !d.outbound.HasProvider(ProviderWebPush) {
+		return
+	}
+	subscriptions, err := d.inApp.GetPushSubscriptions(recipientID)
+	if err != nil || len(subscriptions) == 0 {
+		return
+	}
+	if err := d.outbound.Send(Message{
+		Subject:           n.Title,
+		Content:           n.Body.String,
+		Provider:          ProviderWebPush,
+		Type:              string(n.Type),
+		PushSubscriptions: subscriptions,
+		ConversationUUID:  n.ConversationUUID,
+	}); err != nil {
+		d.lo.Error("error sending push notification",
+			"recipient_id", recipientID,
+			"type", n.Type,
+			"error", err)
+	}
+}
+
+// chatMessage builds the outbound message of a notification for a chat channel.
+func chatMessage(provider, webhookURL string, n Notification) Message {
+	return Message{
+		Subject:     n.Title,
+		Content:     n.Body.String,
+		Provider:    provider,
+		WebhookURLs: []string{webhookURL},
+		Type:        string(n.Type),
+	}
+}
+
 // sendEmail sends an email notification through the outbound service.
 func (d *Dispatcher) sendEmail(recipientID int, email, subject, content string, nType models.NotificationType) {
 	if err := d.outbound.Send(Message{
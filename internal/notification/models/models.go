@@ -15,6 +15,24 @@ const (
 	NotificationTypeAssignment NotificationType = "assignment"
 	NotificationTypeSLAWarning NotificationType = "sla_warning"
 	NotificationTypeSLABreach  NotificationType = "sla_breach"
+	NotificationTypeTaskDue    NotificationType = "task_due"
+)
+
+// NotificationTypes lists the notification types agents can set channel preferences for.
+var NotificationTypes = []NotificationType{
+	NotificationTypeMention,
+	NotificationTypeAssignment,
+	NotificationTypeSLAWarning,
+	NotificationTypeSLABreach,
+	NotificationTypeTaskDue,
+}
+
+// Notification channels an agent can turn on or off per notification type.
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+	ChannelChat  = "chat"
 )
 
 // UserNotification represents an in-app notification for a user.
@@ -40,6 +58,96 @@ type UserNotification struct {
 	MessageUUID      null.String `db:"message_uuid" json:"message_uuid"`
 }
 
+// ChatIdentity is a chat channel linked by an agent to receive their notifications.
+type ChatIdentity struct {
+	ID         int       `db:"id" json:"id"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	UserID     int       `db:"user_id" json:"user_id"`
+	Provider   string    `db:"provider" json:"provider"`
+	WebhookURL string    `db:"webhook_url" json:"webhook_url"`
+	Enabled    bool      `db:"enabled" json:"enabled"`
+}
+
+// PushSubscription is a browser Web Push subscription of an agent, one per device and browser.
+type PushSubscription struct {
+	ID        int       `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	Endpoint  string    `db:"endpoint" json:"endpoint"`
+	P256dh    string    `db:"p256dh" json:"-"`
+	Auth      string    `db:"auth" json:"-"`
+	UserAgent string    `db:"user_agent" json:"user_agent"`
+}
+
+// NotificationSettings holds the quiet hours and digest settings of an agent.
+// Times are `HH:MM` in the agent's timezone.
+type NotificationSettings struct {
+	Timezone          string    `db:"timezone" json:"timezone"`
+	QuietHoursEnabled bool      `db:"quiet_hours_enabled" json:"quiet_hours_enabled"`
+	QuietHoursStart   string    `db:"quiet_hours_start" json:"quiet_hours_start"`
+	QuietHoursEnd     string    `db:"quiet_hours_end" json:"quiet_hours_end"`
+	DigestEnabled     bool      `db:"digest_enabled" json:"digest_enabled"`
+	DigestTime        string    `db:"digest_time" json:"digest_time"`
+	LastDigestAt      null.Time `db:"last_digest_at" json:"-"`
+}
+
+// TypePreference holds the channels an agent receives a notification type on.
+type TypePreference struct {
+	NotificationType NotificationType `db:"notification_type" json:"notification_type"`
+	InApp            bool             `db:"in_app" json:"in_app"`
+	Email            bool             `db:"email" json:"email"`
+	Push             bool             `db:"push" json:"push"`
+	Chat             bool             `db:"chat" json:"chat"`
+}
+
+// NotificationPreferences holds all notification preferences of an agent.
+type NotificationPreferences struct {
+	Settings NotificationSettings `json:"settings"`
+	Types    []TypePreference     `json:"types"`
+}
+
+// Allows returns true if the agent receives the notification type on the channel.
+// Types without a saved preference are received on every channel.
+func (p NotificationPreferences) Allows(t NotificationType, channel string) bool {
+	for _, tp := range p.Types {
+		if tp.NotificationType != t {
+			continue
+		}
+		switch channel {
+		case ChannelInApp:
+			return tp.InApp
+		case ChannelEmail:
+			return tp.Email
+		case ChannelPush:
+			return tp.Push
+		case ChannelChat:
+			return tp.Chat
+		}
+	}
+	return true
+}
+
+// DigestRecipient is an agent with a daily digest enabled and pending digest items.
+type DigestRecipient struct {
+	NotificationSettings
+	UserID    int    `db:"user_id"`
+	Email     string `db:"email"`
+	FirstName string `db:"first_name"`
+	LastName  string `db:"last_name"`
+}
+
+// DigestItem is a notification held back for the daily digest email of an agent.
+type DigestItem struct {
+	ID               int              `db:"id" json:"id"`
+	CreatedAt        time.Time        `db:"created_at" json:"created_at"`
+	NotificationType NotificationType `db:"notification_type" json:"notification_type"`
+	Title            string           `db:"title" json:"title"`
+	Body             null.String      `db:"body" json:"body"`
+	ConversationUUID null.String      `db:"conversation_uuid" json:"conversation_uuid"`
+}
+
 // NotificationStats holds notification statistics for a user.
 type NotificationStats struct {
 	UnreadCount int `db:"unread_count" json:"unread_count"`
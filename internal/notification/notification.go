@@ -6,11 +6,13 @@ import (
 	"sync"
 
 	"github.com/ghotso/libredesk/internal/attachment"
+	"github.com/ghotso/libredesk/internal/notification/models"
 	"github.com/zerodha/logf"
 )
 
 const (
-	ProviderEmail = "email"
+	ProviderEmail   = "email"
+	ProviderWebPush = "webpush"
 )
 
 // Message represents a message to be sent as a notification.
@@ -31,6 +33,14 @@ type Message struct {
 	AltContent string
 	// Additional email headers
 	Headers map[string][]string
+	// Incoming webhook URLs of the chat channels to post to, used by the chat providers
+	WebhookURLs []string
+	// Type of the notification, e.g. `mention`, sent by the generic webhook provider
+	Type string
+	// Browser push subscriptions to push to, used by the Web Push provider
+	PushSubscriptions []models.PushSubscription
+	// UUID of the conversation the notification is about, opened when a push notification is clicked
+	ConversationUUID string
 }
 
 // Notifier defines the interface for sending notifications through various providers.
@@ -62,6 +72,12 @@ func NewService(providers map[string]Notifier, concurrency, capacity int, logger
 	}
 }
 
+// HasProvider returns true if the provider is configured.
+func (s *Service) HasProvider(name string) bool {
+	_, ok := s.providers[name]
+	return ok
+}
+
 // Send sends a message to the message channel.
 func (s *Service) Send(message Message) error {
 	s.mu.Lock()
@@ -79,6 +95,16 @@ func (s *Service) Send(message Message) error {
 	}
 }
 
+// SendNow sends a message synchronously through its provider, bypassing the queue.
+// It is meant for test messages where the caller needs the delivery error.
+func (s *Service) SendNow(message Message) error {
+	provider, ok := s.providers[message.Provider]
+	if !ok {
+		return fmt.Errorf("unsupported provider: %s", message.Provider)
+	}
+	return provider.Send(message)
+}
+
 // Run starts the worker pool to process messages.
 func (s *Service) Run(ctx context.Context) {
 	for range s.concurrency {
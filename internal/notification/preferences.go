@@ -0,0 +1,182 @@
+package notifier
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/notification/models"
+	"github.com/volatiletech/null/v9"
+)
+
+// clockLayout is the layout of quiet hours and digest times.
+const clockLayout = "15:04"
+
+// defaultSettings are the notification settings of agents that have not saved any.
+var defaultSettings = models.NotificationSettings{
+	Timezone:        "UTC",
+	QuietHoursStart: "22:00",
+	QuietHoursEnd:   "07:00",
+	DigestTime:      "08:00",
+}
+
+// GetPreferences returns the notification preferences of a user, with every notification type listed.
+func (m *UserNotificationManager) GetPreferences(userID int) (models.NotificationPreferences, error) {
+	prefs := models.NotificationPreferences{Settings: defaultSettings}
+	if err := m.q.GetNotificationSettings.Get(&prefs.Settings, userID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		m.lo.Error("error fetching notification settings", "user_id", userID, "error", err)
+		return prefs, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+
+	var saved []models.TypePreference
+	if err := m.q.GetTypePreferences.Select(&saved, userID); err != nil {
+		m.lo.Error("error fetching notification type preferences", "user_id", userID, "error", err)
+		return prefs, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+	prefs.Types = make([]models.TypePreference, 0, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		tp := models.TypePreference{NotificationType: t, InApp: true, Email: true, Push: true, Chat: true}
+		if i := slices.IndexFunc(saved, func(s models.TypePreference) bool { return s.NotificationType == t }); i >= 0 {
+			tp = saved[i]
+		}
+		prefs.Types = append(prefs.Types, tp)
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences validates and saves the notification preferences of a user.
+func (m *UserNotificationManager) UpdatePreferences(userID int, prefs models.NotificationPreferences) (models.NotificationPreferences, error) {
+	s := prefs.Settings
+	if _, err := time.LoadLocation(s.Timezone); err != nil || s.Timezone == "" {
+		return prefs, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`timezone`"), nil)
+	}
+	for name, v := range map[string]string{"quiet_hours_start": s.QuietHoursStart, "quiet_hours_end": s.QuietHoursEnd, "digest_time": s.DigestTime} {
+		if _, err := time.Parse(clockLayout, v); err != nil {
+			return prefs, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`"+name+"`"), nil)
+		}
+	}
+	for _, tp := range prefs.Types {
+		if !slices.Contains(models.NotificationTypes, tp.NotificationType) {
+			return prefs, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`notification_type`"), nil)
+		}
+	}
+
+	// Settings and type preferences are saved together so a failure never leaves them half-saved.
+	tx, err := m.db.Beginx()
+	if err != nil {
+		m.lo.Error("error beginning notification preferences transaction", "user_id", userID, "error", err)
+		return prefs, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Stmtx(m.q.UpsertNotificationSettings).Exec(userID, s.Timezone, s.QuietHoursEnabled, s.QuietHoursStart, s.QuietHoursEnd, s.DigestEnabled, s.DigestTime); err != nil {
+		m.lo.Error("error saving notification settings", "user_id", userID, "error", err)
+		return prefs, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+	for _, tp := range prefs.Types {
+		if _, err := tx.Stmtx(m.q.UpsertTypePreference).Exec(userID, tp.NotificationType, tp.InApp, tp.Email, tp.Push, tp.Chat); err != nil {
+			m.lo.Error("error saving notification type preference", "user_id", userID, "notification_type", tp.NotificationType, "error", err)
+			return prefs, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.notificationPreference}"), nil)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing notification preferences", "user_id", userID, "error", err)
+		return prefs, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+	return m.GetPreferences(userID)
+}
+
+// AddDigestItem holds a notification back for the daily digest email of a user.
+func (m *UserNotificationManager) AddDigestItem(userID int, nType models.NotificationType, title string, body null.String, conversationID null.Int) error {
+	if _, err := m.q.InsertDigestItem.Exec(userID, nType, title, body, conversationID); err != nil {
+		m.lo.Error("error inserting digest item", "user_id", userID, "error", err)
+		return fmt.Errorf("inserting digest item: %w", err)
+	}
+	return nil
+}
+
+// GetDigestRecipients returns the users with notifications pending for the daily digest or queued during quiet hours.
+func (m *UserNotificationManager) GetDigestRecipients() ([]models.DigestRecipient, error) {
+	var recipients []models.DigestRecipient
+	if err := m.q.GetDigestRecipients.Select(&recipients); err != nil {
+		return nil, fmt.Errorf("fetching digest recipients: %w", err)
+	}
+	return recipients, nil
+}
+
+// GetDigestItems returns the notifications pending for the digest of a user, oldest first.
+func (m *UserNotificationManager) GetDigestItems(userID int) ([]models.DigestItem, error) {
+	var items []models.DigestItem
+	if err := m.q.GetDigestItems.Select(&items, userID); err != nil {
+		return nil, fmt.Errorf("fetching digest items: %w", err)
+	}
+	return items, nil
+}
+
+// CompleteDigest deletes the digest items up to lastItemID and records when the digest was sent.
+func (m *UserNotificationManager) CompleteDigest(userID, lastItemID int, sentAt time.Time) error {
+	if _, err := m.q.DeleteDigestItems.Exec(userID, lastItemID); err != nil {
+		return fmt.Errorf("deleting digest items: %w", err)
+	}
+	if _, err := m.q.UpdateLastDigestAt.Exec(userID, sentAt); err != nil {
+		return fmt.Errorf("updating last digest time: %w", err)
+	}
+	return nil
+}
+
+// inQuietHours returns true if the time falls in the quiet hours of the settings.
+// Quiet hours that end before they start span midnight, e.g. 22:00 to 07:00.
+func inQuietHours(s models.NotificationSettings, now time.Time) bool {
+	if !s.QuietHoursEnabled {
+		return false
+	}
+	start, err1 := time.Parse(clockLayout, s.QuietHoursStart)
+	end, err2 := time.Parse(clockLayout, s.QuietHoursEnd)
+	if err1 != nil || err2 != nil || start.Equal(end) {
+		return false
+	}
+
+	local := now.In(location(s.Timezone))
+	minute := local.Hour()*60 + local.Minute()
+	startMin, endMin := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	if startMin < endMin {
+		return minute >= startMin && minute < endMin
+	}
+	return minute >= startMin || minute < endMin
+}
+
+// digestDue returns true if the daily digest time of the settings has passed today
+// in the user's timezone and no digest has been sent since.
+func digestDue(s models.NotificationSettings, now time.Time) bool {
+	if !s.DigestEnabled {
+		return false
+	}
+	at, err := time.Parse(clockLayout, s.DigestTime)
+	if err != nil {
+		return false
+	}
+	local := now.In(location(s.Timezone))
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, local.Location())
+	if local.Before(scheduled) {
+		return false
+	}
+	return !s.LastDigestAt.Valid || s.LastDigestAt.Time.Before(scheduled)
+}
+
+// quietHoursDigestDue returns true if the emails queued during quiet hours are due, they are sent once quiet hours
+// end when the daily digest is off.
+func quietHoursDigestDue(s models.NotificationSettings, now time.Time) bool {
+	return !s.DigestEnabled && !inQuietHours(s, now)
+}
+
+// location returns the time zone of the given name, falling back to UTC.
+func location(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
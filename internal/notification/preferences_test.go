@@ -0,0 +1,131 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/notification/models"
+	"github.com/volatiletech/null/v9"
+)
+
+func TestInQuietHours(t *testing.T) {
+	berlin := models.NotificationSettings{Timezone: "Europe/Berlin", QuietHoursEnabled: true, QuietHoursStart: "22:00", QuietHoursEnd: "07:00"}
+	daytime := models.NotificationSettings{Timezone: "UTC", QuietHoursEnabled: true, QuietHoursStart: "12:00", QuietHoursEnd: "13:30"}
+	disabled := berlin
+	disabled.QuietHoursEnabled = false
+
+	tests := []struct {
+		name     string
+		settings models.NotificationSettings
+		now      time.Time
+		want     bool
+	}{
+		// 21:30 UTC is 23:30 in Berlin during summer time.
+		{"after start across midnight", berlin, time.Date(2026, 7, 1, 21, 30, 0, 0, time.UTC), true},
+		{"before end across midnight", berlin, time.Date(2026, 7, 1, 4, 59, 0, 0, time.UTC), true},
+		{"at end across midnight", berlin, time.Date(2026, 7, 1, 5, 0, 0, 0, time.UTC), false},
+		{"outside across midnight", berlin, time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC), false},
+		{"inside same day", daytime, time.Date(2026, 7, 1, 13, 0, 0, 0, time.UTC), true},
+		{"outside same day", daytime, time.Date(2026, 7, 1, 13, 30, 0, 0, time.UTC), false},
+		{"disabled", disabled, time.Date(2026, 7, 1, 21, 30, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inQuietHours(tt.settings, tt.now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestDue(t *testing.T) {
+	// 08:00 in New York is 12:00 UTC during summer time.
+	settings := models.NotificationSettings{Timezone: "America/New_York", DigestEnabled: true, DigestTime: "08:00"}
+	sentToday := settings
+	sentToday.LastDigestAt = null.TimeFrom(time.Date(2026, 7, 1, 12, 5, 0, 0, time.UTC))
+	sentYesterday := settings
+	sentYesterday.LastDigestAt = null.TimeFrom(time.Date(2026, 6, 30, 12, 5, 0, 0, time.UTC))
+	disabled := settings
+	disabled.DigestEnabled = false
+
+	tests := []struct {
+		name     string
+		settings models.NotificationSettings
+		now      time.Time
+		want     bool
+	}{
+		{"before digest time", settings, time.Date(2026, 7, 1, 11, 59, 0, 0, time.UTC), false},
+		{"never sent", settings, time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC), true},
+		{"sent yesterday", sentYesterday, time.Date(2026, 7, 1, 12, 1, 0, 0, time.UTC), true},
+		{"already sent today", sentToday, time.Date(2026, 7, 1, 18, 0, 0, 0, time.UTC), false},
+		{"disabled", disabled, time.Date(2026, 7, 1, 12, 1, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digestDue(tt.settings, tt.now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferencesAllows(t *testing.T) {
+	prefs := models.NotificationPreferences{
+		Types: []models.TypePreference{
+			{NotificationType: models.NotificationTypeMention, InApp: true, Email: false, Push: true, Chat: false},
+		},
+	}
+	tests := []struct {
+		nType   models.NotificationType
+		channel string
+		want    bool
+	}{
+		{models.NotificationTypeMention, models.ChannelInApp, true},
+		{models.NotificationTypeMention, models.ChannelEmail, false},
+		{models.NotificationTypeMention, models.ChannelPush, true},
+		{models.NotificationTypeMention, models.ChannelChat, false},
+		// Types without a saved preference are received everywhere.
+		{models.NotificationTypeSLABreach, models.ChannelEmail, true},
+	}
+	for _, tt := range tests {
+		if got := prefs.Allows(tt.nType, tt.channel); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.nType, tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestQuietHoursDigestDue(t *testing.T) {
+	quiet := models.NotificationSettings{Timezone: "UTC", QuietHoursEnabled: true, QuietHoursStart: "22:00", QuietHoursEnd: "07:00"}
+	withDigest := quiet
+	withDigest.DigestEnabled = true
+	withDigest.DigestTime = "08:00"
+
+	tests := []struct {
+		name     string
+		settings models.NotificationSettings
+		now      time.Time
+		want     bool
+	}{
+		{"during quiet hours", quiet, time.Date(2026, 7, 1, 23, 0, 0, 0, time.UTC), false},
+		{"after quiet hours", quiet, time.Date(2026, 7, 1, 7, 0, 0, 0, time.UTC), true},
+		{"daily digest enabled", withDigest, time.Date(2026, 7, 1, 7, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quietHoursDigestDue(tt.settings, tt.now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBypassesQuietHours(t *testing.T) {
+	for _, nType := range []models.NotificationType{models.NotificationTypeSLAWarning, models.NotificationTypeSLABreach} {
+		if !bypassesQuietHours(nType) {
+			t.Errorf("expected %s to bypass quiet hours", nType)
+		}
+	}
+	if bypassesQuietHours(models.NotificationTypeMention) {
+		t.Errorf("expected %s not to bypass quiet hours", models.NotificationTypeMention)
+	}
+}
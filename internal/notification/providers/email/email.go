@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"net/textproto"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/ghotso/libredesk/internal/attachment"
 	"github.com/ghotso/libredesk/internal/inbox/channel/email"
 	"github.com/ghotso/libredesk/internal/inbox/models"
@@ -52,7 +53,11 @@ func (e *Email) Name() string {
 // send sends an email message.
 func (e *Email) send(em smtppool.Email) error {
 	srv := e.selectSmtpPool()
-	return srv.Send(em)
+	if err := srv.Send(em); err != nil {
+		metrics.GetOrCreateCounter(`libredesk_smtp_send_errors_total{source="notification"}`).Inc()
+		return err
+	}
+	return nil
 }
 
 // selectSmtpPool selects a random SMTP pool if multiple are available.
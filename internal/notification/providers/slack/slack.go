@@ -0,0 +1,64 @@
+// Package slack posts notifications to Slack incoming webhooks.
+package slack
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/zerodha/logf"
+)
+
+// Slack implements the Notifier interface for Slack incoming webhooks.
+type Slack struct {
+	lo     *logf.Logger
+	client *http.Client
+}
+
+// Opts contains options for creating a new Slack notifier.
+type Opts struct {
+	Lo      *logf.Logger
+	Timeout time.Duration
+	// AllowPrivateNetworks allows webhook URLs on private, loopback and link-local addresses.
+	AllowPrivateNetworks bool
+}
+
+// payload is the body of a Slack incoming webhook request.
+type payload struct {
+	Text string `json:"text"`
+}
+
+// mrkdwnEscaper escapes the control characters of Slack's mrkdwn format.
+var mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// New initializes a new Slack notifier.
+func New(opts Opts) *Slack {
+	return &Slack{
+		lo:     opts.Lo,
+		client: notifier.NewChatHTTPClient(opts.Timeout, opts.AllowPrivateNetworks),
+	}
+}
+
+// Send posts the message to every webhook URL of the message.
+func (s *Slack) Send(msg notifier.Message) error {
+	text := "*" + mrkdwnEscaper.Replace(msg.Subject) + "*"
+	if msg.Content != "" {
+		text += "\n" + mrkdwnEscaper.Replace(msg.Content)
+	}
+
+	var errs []error
+	for _, url := range msg.WebhookURLs {
+		if err := notifier.PostJSON(s.client, url, payload{Text: text}); err != nil {
+			s.lo.Error("error posting slack notification", "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Name returns the name of the provider.
+func (s *Slack) Name() string {
+	return notifier.ProviderSlack
+}
@@ -0,0 +1,55 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/zerodha/logf"
+)
+
+func TestSend(t *testing.T) {
+	var got payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("got content type %q, want application/json", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("error decoding payload: %v", err)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	s := New(Opts{Lo: &lo, Timeout: time.Second, AllowPrivateNetworks: true})
+	err := s.Send(notifier.Message{
+		Subject:     "SLA breach: First response for #100",
+		Content:     "Overdue by <5m> & counting",
+		WebhookURLs: []string{srv.URL},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "*SLA breach: First response for #100*\nOverdue by &lt;5m&gt; &amp; counting"
+	if got.Text != want {
+		t.Errorf("got text %q, want %q", got.Text, want)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	s := New(Opts{Lo: &lo, Timeout: time.Second, AllowPrivateNetworks: true})
+	if err := s.Send(notifier.Message{Subject: "Test", WebhookURLs: []string{srv.URL}}); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
@@ -0,0 +1,67 @@
+// Package teams posts notifications to Microsoft Teams incoming webhook connectors.
+package teams
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/zerodha/logf"
+)
+
+// Teams implements the Notifier interface for Microsoft Teams connectors.
+type Teams struct {
+	lo     *logf.Logger
+	client *http.Client
+}
+
+// Opts contains options for creating a new Teams notifier.
+type Opts struct {
+	Lo      *logf.Logger
+	Timeout time.Duration
+	// AllowPrivateNetworks allows webhook URLs on private, loopback and link-local addresses.
+	AllowPrivateNetworks bool
+}
+
+// messageCard is the legacy actionable message card accepted by Teams connectors.
+type messageCard struct {
+	Type    string `json:"@type"`
+	Context string `json:"@context"`
+	Summary string `json:"summary"`
+	Title   string `json:"title"`
+	Text    string `json:"text,omitempty"`
+}
+
+// New initializes a new Teams notifier.
+func New(opts Opts) *Teams {
+	return &Teams{
+		lo:     opts.Lo,
+		client: notifier.NewChatHTTPClient(opts.Timeout, opts.AllowPrivateNetworks),
+	}
+}
+
+// Send posts the message to every webhook URL of the message.
+func (t *Teams) Send(msg notifier.Message) error {
+	card := messageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Summary: msg.Subject,
+		Title:   msg.Subject,
+		Text:    msg.Content,
+	}
+
+	var errs []error
+	for _, url := range msg.WebhookURLs {
+		if err := notifier.PostJSON(t.client, url, card); err != nil {
+			t.lo.Error("error posting teams notification", "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Name returns the name of the provider.
+func (t *Teams) Name() string {
+	return notifier.ProviderTeams
+}
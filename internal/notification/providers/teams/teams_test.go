@@ -0,0 +1,63 @@
+package teams
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/zerodha/logf"
+)
+
+func TestSend(t *testing.T) {
+	var cards []messageCard
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var card messageCard
+		if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+			t.Errorf("error decoding payload: %v", err)
+		}
+		cards = append(cards, card)
+		w.Write([]byte("1"))
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	tm := New(Opts{Lo: &lo, Timeout: time.Second, AllowPrivateNetworks: true})
+	err := tm.Send(notifier.Message{
+		Subject:     "You were mentioned in #100",
+		Content:     "Can you take a look?",
+		WebhookURLs: []string{srv.URL, srv.URL + "/second"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cards) != 2 {
+		t.Fatalf("got %d requests, want 2", len(cards))
+	}
+	want := messageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Summary: "You were mentioned in #100",
+		Title:   "You were mentioned in #100",
+		Text:    "Can you take a look?",
+	}
+	if cards[0] != want {
+		t.Errorf("got card %+v, want %+v", cards[0], want)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	tm := New(Opts{Lo: &lo, Timeout: time.Second, AllowPrivateNetworks: true})
+	if err := tm.Send(notifier.Message{Subject: "Test", WebhookURLs: []string{srv.URL}}); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
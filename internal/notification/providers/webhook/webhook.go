@@ -0,0 +1,65 @@
+// Package webhook posts notifications as JSON to generic incoming webhook URLs.
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/zerodha/logf"
+)
+
+// Webhook implements the Notifier interface for generic JSON webhooks.
+type Webhook struct {
+	lo     *logf.Logger
+	client *http.Client
+}
+
+// Opts contains options for creating a new Webhook notifier.
+type Opts struct {
+	Lo      *logf.Logger
+	Timeout time.Duration
+	// AllowPrivateNetworks allows webhook URLs on private, loopback and link-local addresses.
+	AllowPrivateNetworks bool
+}
+
+// payload is the body posted to the webhook URL.
+type payload struct {
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// New initializes a new Webhook notifier.
+func New(opts Opts) *Webhook {
+	return &Webhook{
+		lo:     opts.Lo,
+		client: notifier.NewChatHTTPClient(opts.Timeout, opts.AllowPrivateNetworks),
+	}
+}
+
+// Send posts the message to every webhook URL of the message.
+func (w *Webhook) Send(msg notifier.Message) error {
+	p := payload{
+		Type:      msg.Type,
+		Title:     msg.Subject,
+		Body:      msg.Content,
+		Timestamp: time.Now().UTC(),
+	}
+
+	var errs []error
+	for _, url := range msg.WebhookURLs {
+		if err := notifier.PostJSON(w.client, url, p); err != nil {
+			w.lo.Error("error posting webhook notification", "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Name returns the name of the provider.
+func (w *Webhook) Name() string {
+	return notifier.ProviderWebhook
+}
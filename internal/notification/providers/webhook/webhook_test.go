@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/zerodha/logf"
+)
+
+func TestSend(t *testing.T) {
+	var got payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("error decoding payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	wh := New(Opts{Lo: &lo, Timeout: time.Second, AllowPrivateNetworks: true})
+	err := wh.Send(notifier.Message{
+		Type:        "assignment",
+		Subject:     "Conversation #100 assigned to you",
+		Content:     "Refund request",
+		WebhookURLs: []string{srv.URL},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Type != "assignment" || got.Title != "Conversation #100 assigned to you" || got.Body != "Refund request" {
+		t.Errorf("got payload %+v", got)
+	}
+	if got.Timestamp.IsZero() {
+		t.Error("expected timestamp to be set")
+	}
+}
+
+func TestSendUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	wh := New(Opts{Lo: &lo, Timeout: time.Second, AllowPrivateNetworks: true})
+	if err := wh.Send(notifier.Message{Subject: "Test", WebhookURLs: []string{url}}); err == nil {
+		t.Error("expected error for unreachable URL")
+	}
+}
+
+func TestSendPrivateNetwork(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer srv.Close()
+
+	lo := logf.New(logf.Opts{})
+	wh := New(Opts{Lo: &lo, Timeout: time.Second})
+	if err := wh.Send(notifier.Message{Subject: "Test", WebhookURLs: []string{srv.URL}}); !errors.Is(err, notifier.ErrPrivateDestination) {
+		t.Errorf("got error %v, want %v", err, notifier.ErrPrivateDestination)
+	}
+	if called {
+		t.Error("expected the loopback server not to be called")
+	}
+}
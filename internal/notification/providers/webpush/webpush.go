@@ -0,0 +1,348 @@
+// Package webpush sends browser Web Push notifications signed with VAPID keys.
+// Payloads are encrypted with the aes128gcm content encoding of RFC 8291.
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/ghotso/libredesk/internal/notification/models"
+	"github.com/zerodha/logf"
+)
+
+const (
+	// recordSize is the record size of the encrypted payload, a payload is always sent as a single record.
+	recordSize = 4096
+
+	// maxPayloadSize is the largest plaintext that fits in a single record along with the header and the GCM tag.
+	maxPayloadSize = recordSize - 16 - 1 - 86
+
+	// jwtExpiry is the validity of the VAPID JWT, push services reject tokens valid for more than 24 hours.
+	jwtExpiry = 12 * time.Hour
+)
+
+// ErrExpiredSubscription is returned when the push service reports a subscription as gone.
+var ErrExpiredSubscription = errors.New("push subscription expired")
+
+// pushServiceHosts are the hosts, or the parent domains of the hosts, of the push services of the major browsers.
+var pushServiceHosts = []string{
+	"fcm.googleapis.com",
+	"android.googleapis.com",
+	"updates.push.services.mozilla.com",
+	"push.services.mozilla.com",
+	"notify.windows.com",
+	"push.apple.com",
+}
+
+// IsPushServiceEndpoint returns true if the endpoint is an https URL of a known browser push service. Endpoints are
+// sent by browsers, only accepting known push services keeps the server from posting to arbitrary URLs.
+func IsPushServiceEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Port() != "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range pushServiceHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebPush implements the Notifier interface for browser Web Push.
+type WebPush struct {
+	lo        *logf.Logger
+	client    *http.Client
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+	ttl       time.Duration
+	onExpired func(endpoint string)
+}
+
+// Opts contains options for creating a new WebPush notifier.
+type Opts struct {
+	Lo      *logf.Logger
+	Timeout time.Duration
+	// VAPID key pair as unpadded base64url, the public key is the uncompressed P-256 point.
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	// Subject is the contact of the sender, a `mailto:` or `https:` URL.
+	Subject string
+	// TTL is how long the push service should retain undelivered notifications.
+	TTL time.Duration
+	// OnExpired is called with the endpoint of every subscription the push service reports as gone.
+	OnExpired func(endpoint string)
+}
+
+// payload is the JSON body delivered to the service worker of the agent's browser.
+type payload struct {
+	Type             string `json:"type"`
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	ConversationUUID string `json:"conversation_uuid,omitempty"`
+}
+
+// New initializes a new WebPush notifier.
+func New(opts Opts) (*WebPush, error) {
+	d, err := base64.RawURLEncoding.DecodeString(opts.VAPIDPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding VAPID private key: %w", err)
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), d)
+	if err != nil {
+		return nil, fmt.Errorf("parsing VAPID private key: %w", err)
+	}
+	publicKey, err := encodePublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if opts.VAPIDPublicKey != "" && opts.VAPIDPublicKey != publicKey {
+		return nil, errors.New("VAPID public key does not match the private key")
+	}
+	if opts.Subject == "" {
+		return nil, errors.New("VAPID subject is required")
+	}
+	return &WebPush{
+		lo:        opts.Lo,
+		client:    &http.Client{Timeout: opts.Timeout},
+		key:       key,
+		publicKey: publicKey,
+		subject:   opts.Subject,
+		ttl:       opts.TTL,
+		onExpired: opts.OnExpired,
+	}, nil
+}
+
+// GenerateVAPIDKeys generates a new VAPID key pair encoded as unpadded base64url.
+func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	d, err := key.Bytes()
+	if err != nil {
+		return "", "", err
+	}
+	publicKey, err = encodePublicKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return publicKey, base64.RawURLEncoding.EncodeToString(d), nil
+}
+
+// PublicKey returns the VAPID public key browsers subscribe with.
+func (w *WebPush) PublicKey() string {
+	return w.publicKey
+}
+
+// Send pushes the message to every push subscription of the message.
+func (w *WebPush) Send(msg notifier.Message) error {
+	b, err := json.Marshal(payload{
+		Type:             msg.Type,
+		Title:            msg.Subject,
+		Body:             msg.Content,
+		ConversationUUID: msg.ConversationUUID,
+	})
+	if err != nil {
+		return fmt.Errorf("marshalling payload: %w", err)
+	}
+	if len(b) > maxPayloadSize {
+		return fmt.Errorf("push payload of %d bytes exceeds %d bytes", len(b), maxPayloadSize)
+	}
+
+	var errs []error
+	for _, sub := range msg.PushSubscriptions {
+		err := w.push(sub, b)
+		if errors.Is(err, ErrExpiredSubscription) {
+			w.lo.Info("removing expired push subscription", "endpoint", sub.Endpoint)
+			if w.onExpired != nil {
+				w.onExpired(sub.Endpoint)
+			}
+			continue
+		}
+		if err != nil {
+			w.lo.Error("error sending push notification", "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Name returns the name of the provider.
+func (w *WebPush) Name() string {
+	return notifier.ProviderWebPush
+}
+
+// push encrypts the payload for the subscription and posts it to the subscription endpoint.
+func (w *WebPush) push(sub models.PushSubscription, b []byte) error {
+	body, err := encrypt(b, sub.P256dh, sub.Auth)
+	if err != nil {
+		return fmt.Errorf("encrypting payload: %w", err)
+	}
+	token, err := w.vapidToken(sub.Endpoint)
+	if err != nil {
+		return fmt.Errorf("signing VAPID token: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(w.ttl.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", "vapid t="+token+", k="+w.publicKey)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		io.Copy(io.Discard, resp.Body)
+		return ErrExpiredSubscription
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected response status %d: %s", resp.StatusCode, msg)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// vapidToken returns the ES256 signed JWT identifying the sender to the push service of the endpoint.
+func (w *WebPush) vapidToken(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(jwtExpiry).Unix(),
+		"sub": w.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, w.key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// encrypt encrypts the plaintext for the browser's P-256 key and auth secret as a single aes128gcm record.
+func encrypt(plaintext []byte, p256dh, authSecret string) ([]byte, error) {
+	uaPublicBytes, err := decodeKey(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("decoding p256dh key: %w", err)
+	}
+	auth, err := decodeKey(authSecret)
+	if err != nil {
+		return nil, fmt.Errorf("decoding auth secret: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing p256dh key: %w", err)
+	}
+
+	// Ephemeral application server key pair, the public key is sent as the key ID of the record.
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublicBytes)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, auth, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, key ID length and key ID, followed by the record
+	// padded with the 0x02 last record delimiter.
+	out := make([]byte, 0, 16+4+1+len(asPublicBytes)+len(plaintext)+1+gcm.Overhead())
+	out = append(out, salt...)
+	out = binary.BigEndian.AppendUint32(out, recordSize)
+	out = append(out, byte(len(asPublicBytes)))
+	out = append(out, asPublicBytes...)
+	record := append(plaintext[:len(plaintext):len(plaintext)], 0x02)
+	return gcm.Seal(out, nonce, record, nil), nil
+}
+
+// encodePublicKey returns the uncompressed public point of the key as unpadded base64url.
+func encodePublicKey(key *ecdsa.PrivateKey) (string, error) {
+	pub, err := key.PublicKey.ECDH()
+	if err != nil {
+		return "", fmt.Errorf("converting VAPID public key: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(pub.Bytes()), nil
+}
+
+// decodeKey decodes a base64url key of a push subscription, browsers may or may not pad them.
+func decodeKey(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
@@ -0,0 +1,266 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/ghotso/libredesk/internal/notification/models"
+	"github.com/zerodha/logf"
+)
+
+// browser holds the keys of a push subscription, as generated by the user agent.
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) browser {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return browser{key: key, auth: auth}
+}
+
+func (b browser) subscription(endpoint string) models.PushSubscription {
+	return models.PushSubscription{
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+// decrypt decrypts an aes128gcm push message the way the user agent does.
+func (b browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt, rs, idLen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	if rs != recordSize {
+		t.Fatalf("got record size %d, want %d", rs, recordSize)
+	}
+	asPublic, err := ecdh.P256().NewPublicKey(body[21 : 21+idLen])
+	if err != nil {
+		t.Fatalf("error parsing key ID: %v", err)
+	}
+	secret, err := b.key.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := "WebPush: info\x00" + string(b.key.PublicKey().Bytes()) + string(asPublic.Bytes())
+	ikm, _ := hkdf.Key(sha256.New, secret, b.auth, info, 32)
+	prk, _ := hkdf.Extract(sha256.New, ikm, salt)
+	cek, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, body[21+idLen:], nil)
+	if err != nil {
+		t.Fatalf("error decrypting record: %v", err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatalf("record is missing the last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+// verifyVAPID checks the ES256 signature and claims of the VAPID authorization header.
+func verifyVAPID(t *testing.T, header, publicKey, audience string) {
+	t.Helper()
+	params, ok := strings.CutPrefix(header, "vapid ")
+	if !ok {
+		t.Fatalf("got authorization %q, want vapid scheme", header)
+	}
+	var token, k string
+	for _, p := range strings.Split(params, ", ") {
+		if v, ok := strings.CutPrefix(p, "t="); ok {
+			token = v
+		} else if v, ok := strings.CutPrefix(p, "k="); ok {
+			k = v
+		}
+	}
+	if k != publicKey {
+		t.Errorf("got key %q, want %q", k, publicKey)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("got %d JWT parts, want 3", len(parts))
+	}
+	pub, _ := base64.RawURLEncoding.DecodeString(publicKey)
+	x, y := elliptic.Unmarshal(elliptic.P256(), pub)
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Fatal("invalid VAPID signature")
+	}
+
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	b, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if err := json.Unmarshal(b, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Aud != audience {
+		t.Errorf("got aud %q, want %q", claims.Aud, audience)
+	}
+	if claims.Sub != "mailto:admin@example.com" {
+		t.Errorf("got sub %q", claims.Sub)
+	}
+	if exp := time.Unix(claims.Exp, 0); exp.Before(time.Now()) || exp.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("got exp %v, want within 24 hours", exp)
+	}
+}
+
+func newWebPush(t *testing.T, onExpired func(string)) *WebPush {
+	t.Helper()
+	publicKey, privateKey, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo := logf.New(logf.Opts{})
+	w, err := New(Opts{
+		Lo:              &lo,
+		Timeout:         time.Second,
+		VAPIDPublicKey:  publicKey,
+		VAPIDPrivateKey: privateKey,
+		Subject:         "mailto:admin@example.com",
+		TTL:             time.Hour,
+		OnExpired:       onExpired,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestSend(t *testing.T) {
+	var (
+		b       = newBrowser(t)
+		w       = newWebPush(t, nil)
+		got     payload
+		srvURL  string
+		headers http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		verifyVAPID(t, r.Header.Get("Authorization"), w.PublicKey(), srvURL)
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b.decrypt(t, body), &got); err != nil {
+			t.Errorf("error decoding payload: %v", err)
+		}
+		rw.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	err := w.Send(notifier.Message{
+		Subject:           "New mention",
+		Content:           "You were mentioned in #100",
+		Type:              "mention",
+		ConversationUUID:  "f3a1",
+		PushSubscriptions: []models.PushSubscription{b.subscription(srv.URL + "/push/abc")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := payload{Type: "mention", Title: "New mention", Body: "You were mentioned in #100", ConversationUUID: "f3a1"}
+	if got != want {
+		t.Errorf("got payload %+v, want %+v", got, want)
+	}
+	if ce := headers.Get("Content-Encoding"); ce != "aes128gcm" {
+		t.Errorf("got content encoding %q, want aes128gcm", ce)
+	}
+	if ttl := headers.Get("TTL"); ttl != "3600" {
+		t.Errorf("got TTL %q, want 3600", ttl)
+	}
+}
+
+func TestSendExpired(t *testing.T) {
+	var expired []string
+	w := newWebPush(t, func(endpoint string) { expired = append(expired, endpoint) })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			rw.WriteHeader(http.StatusGone)
+		case "/error":
+			rw.WriteHeader(http.StatusTooManyRequests)
+		default:
+			rw.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	b := newBrowser(t)
+	err := w.Send(notifier.Message{
+		Subject: "SLA breach",
+		PushSubscriptions: []models.PushSubscription{
+			b.subscription(srv.URL + "/gone"),
+			b.subscription(srv.URL + "/ok"),
+			b.subscription(srv.URL + "/error"),
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("got error %v, want the 429 response", err)
+	}
+	if len(expired) != 1 || expired[0] != srv.URL+"/gone" {
+		t.Errorf("got expired endpoints %v, want only /gone", expired)
+	}
+}
+
+func TestNewMismatchedKeys(t *testing.T) {
+	publicKey, _, _ := GenerateVAPIDKeys()
+	_, privateKey, _ := GenerateVAPIDKeys()
+	lo := logf.New(logf.Opts{})
+	if _, err := New(Opts{Lo: &lo, VAPIDPublicKey: publicKey, VAPIDPrivateKey: privateKey, Subject: "mailto:a@b.c"}); err == nil {
+		t.Error("expected an error for mismatched VAPID keys")
+	}
+}
+
+func TestIsPushServiceEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     bool
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc", true},
+		{"https://updates.push.services.mozilla.com/wpush/v2/abc", true},
+		{"https://wns2-db5p.notify.windows.com/w/?token=abc", true},
+		{"https://web.push.apple.com/abc", true},
+		{"http://fcm.googleapis.com/fcm/send/abc", false},
+		{"https://fcm.googleapis.com:8443/fcm/send/abc", false},
+		{"https://evilfcm.googleapis.com.example.com/abc", false},
+		{"https://notfcm.googleapis.com.attacker.io/abc", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://example.com/push", false},
+		{"not a url", false},
+	}
+	for _, tt := range tests {
+		if got := IsPushServiceEndpoint(tt.endpoint); got != tt.want {
+			t.Errorf("IsPushServiceEndpoint(%q) = %v, want %v", tt.endpoint, got, tt.want)
+		}
+	}
+}
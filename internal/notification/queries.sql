@@ -38,3 +38,98 @@ DELETE FROM user_notifications WHERE user_id = $1;
 
 -- name: delete-old-notifications
 DELETE FROM user_notifications WHERE created_at < NOW() - INTERVAL '30 days';
+
+-- name: get-chat-identities
+SELECT id, created_at, updated_at, user_id, provider, webhook_url, enabled
+FROM user_chat_identities
+WHERE user_id = $1
+ORDER BY provider;
+
+-- name: upsert-chat-identity
+INSERT INTO user_chat_identities (user_id, provider, webhook_url, enabled)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, provider)
+DO UPDATE SET webhook_url = EXCLUDED.webhook_url, enabled = EXCLUDED.enabled, updated_at = NOW()
+RETURNING id, created_at, updated_at, user_id, provider, webhook_url, enabled;
+
+-- name: delete-chat-identity
+DELETE FROM user_chat_identities WHERE user_id = $1 AND provider = $2;
+
+-- name: get-push-subscriptions
+SELECT id, created_at, updated_at, user_id, endpoint, p256dh, auth, user_agent
+FROM user_push_subscriptions
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: upsert-push-subscription
+INSERT INTO user_push_subscriptions (user_id, endpoint, p256dh, auth, user_agent)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (endpoint)
+DO UPDATE SET p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth, user_agent = EXCLUDED.user_agent, updated_at = NOW()
+-- An endpoint of another user is left alone, no row is returned then.
+WHERE user_push_subscriptions.user_id = EXCLUDED.user_id
+RETURNING id, created_at, updated_at, user_id, endpoint, p256dh, auth, user_agent;
+
+-- name: delete-push-subscription
+DELETE FROM user_push_subscriptions WHERE user_id = $1 AND endpoint = $2;
+
+-- name: delete-push-subscription-by-endpoint
+DELETE FROM user_push_subscriptions WHERE endpoint = $1;
+
+-- name: delete-stale-push-subscriptions
+DELETE FROM user_push_subscriptions WHERE updated_at < NOW() - INTERVAL '90 days';
+
+-- name: get-notification-settings
+SELECT timezone, quiet_hours_enabled, quiet_hours_start, quiet_hours_end, digest_enabled, digest_time, last_digest_at
+FROM user_notification_settings
+WHERE user_id = $1;
+
+-- name: upsert-notification-settings
+INSERT INTO user_notification_settings (user_id, timezone, quiet_hours_enabled, quiet_hours_start, quiet_hours_end, digest_enabled, digest_time)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id)
+DO UPDATE SET timezone = EXCLUDED.timezone,
+    quiet_hours_enabled = EXCLUDED.quiet_hours_enabled,
+    quiet_hours_start = EXCLUDED.quiet_hours_start,
+    quiet_hours_end = EXCLUDED.quiet_hours_end,
+    digest_enabled = EXCLUDED.digest_enabled,
+    digest_time = EXCLUDED.digest_time,
+    updated_at = NOW();
+
+-- name: get-type-preferences
+SELECT notification_type, in_app, email, push, chat
+FROM user_notification_preferences
+WHERE user_id = $1;
+
+-- name: upsert-type-preference
+INSERT INTO user_notification_preferences (user_id, notification_type, in_app, email, push, chat)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, notification_type)
+DO UPDATE SET in_app = EXCLUDED.in_app, email = EXCLUDED.email, push = EXCLUDED.push, chat = EXCLUDED.chat, updated_at = NOW();
+
+-- name: insert-digest-item
+INSERT INTO notification_digest_items (user_id, notification_type, title, body, conversation_id)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: get-digest-recipients
+SELECT s.user_id, s.timezone, s.quiet_hours_enabled, s.quiet_hours_start, s.quiet_hours_end, s.digest_enabled, s.digest_time, s.last_digest_at,
+    u.email, u.first_name, u.last_name
+FROM user_notification_settings s
+INNER JOIN users u ON u.id = s.user_id
+WHERE u.enabled = true
+    AND u.deleted_at IS NULL
+    AND u.email IS NOT NULL
+    AND EXISTS (SELECT 1 FROM notification_digest_items d WHERE d.user_id = s.user_id);
+
+-- name: get-digest-items
+SELECT d.id, d.created_at, d.notification_type, d.title, d.body, c.uuid AS conversation_uuid
+FROM notification_digest_items d
+LEFT JOIN conversations c ON c.id = d.conversation_id
+WHERE d.user_id = $1
+ORDER BY d.created_at;
+
+-- name: delete-digest-items
+DELETE FROM notification_digest_items WHERE user_id = $1 AND id <= $2;
+
+-- name: update-last-digest-at
+UPDATE user_notification_settings SET last_digest_at = $2 WHERE user_id = $1;
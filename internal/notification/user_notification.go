@@ -24,6 +24,7 @@ type UserNotificationManager struct {
 	lo   *logf.Logger
 	i18n *i18n.I18n
 	q    queries
+	db   *sqlx.DB
 }
 
 type UserNotificationOpts struct {
@@ -41,6 +42,25 @@ type queries struct {
 	DeleteNotification     *sqlx.Stmt `query:"delete-notification"`
 	DeleteAllNotifications *sqlx.Stmt `query:"delete-all-notifications"`
 	DeleteOldNotifications *sqlx.Stmt `query:"delete-old-notifications"`
+	GetChatIdentities      *sqlx.Stmt `query:"get-chat-identities"`
+	UpsertChatIdentity     *sqlx.Stmt `query:"upsert-chat-identity"`
+	DeleteChatIdentity     *sqlx.Stmt `query:"delete-chat-identity"`
+
+	GetPushSubscriptions             *sqlx.Stmt `query:"get-push-subscriptions"`
+	UpsertPushSubscription           *sqlx.Stmt `query:"upsert-push-subscription"`
+	DeletePushSubscription           *sqlx.Stmt `query:"delete-push-subscription"`
+	DeletePushSubscriptionByEndpoint *sqlx.Stmt `query:"delete-push-subscription-by-endpoint"`
+	DeleteStalePushSubscriptions     *sqlx.Stmt `query:"delete-stale-push-subscriptions"`
+
+	GetNotificationSettings    *sqlx.Stmt `query:"get-notification-settings"`
+	UpsertNotificationSettings *sqlx.Stmt `query:"upsert-notification-settings"`
+	GetTypePreferences         *sqlx.Stmt `query:"get-type-preferences"`
+	UpsertTypePreference       *sqlx.Stmt `query:"upsert-type-preference"`
+	InsertDigestItem           *sqlx.Stmt `query:"insert-digest-item"`
+	GetDigestRecipients        *sqlx.Stmt `query:"get-digest-recipients"`
+	GetDigestItems             *sqlx.Stmt `query:"get-digest-items"`
+	DeleteDigestItems          *sqlx.Stmt `query:"delete-digest-items"`
+	UpdateLastDigestAt         *sqlx.Stmt `query:"update-last-digest-at"`
 }
 
 // NewUserNotificationManager creates and returns a new instance of UserNotificationManager.
@@ -53,6 +73,7 @@ func NewUserNotificationManager(opts UserNotificationOpts) (*UserNotificationMan
 		q:    q,
 		lo:   opts.Lo,
 		i18n: opts.I18n,
+		db:   opts.DB,
 	}, nil
 }
 
@@ -144,12 +165,96 @@ func (m *UserNotificationManager) DeleteOldNotifications(ctx context.Context) er
 	return nil
 }
 
+// GetChatIdentities retrieves the chat channels linked by a user.
+func (m *UserNotificationManager) GetChatIdentities(userID int) ([]models.ChatIdentity, error) {
+	var identities = make([]models.ChatIdentity, 0)
+	if err := m.q.GetChatIdentities.Select(&identities, userID); err != nil {
+		m.lo.Error("error fetching chat identities", "user_id", userID, "error", err)
+		return identities, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.chatIdentity}"), nil)
+	}
+	return identities, nil
+}
+
+// UpsertChatIdentity links or updates the chat channel of a user for the provider.
+func (m *UserNotificationManager) UpsertChatIdentity(userID int, provider, webhookURL string, enabled bool) (models.ChatIdentity, error) {
+	var identity models.ChatIdentity
+	if err := m.q.UpsertChatIdentity.Get(&identity, userID, provider, webhookURL, enabled); err != nil {
+		m.lo.Error("error upserting chat identity", "user_id", userID, "provider", provider, "error", err)
+		return identity, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.chatIdentity}"), nil)
+	}
+	return identity, nil
+}
+
+// DeleteChatIdentity unlinks the chat channel of a user for the provider.
+func (m *UserNotificationManager) DeleteChatIdentity(userID int, provider string) error {
+	if _, err := m.q.DeleteChatIdentity.Exec(userID, provider); err != nil {
+		m.lo.Error("error deleting chat identity", "user_id", userID, "provider", provider, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.chatIdentity}"), nil)
+	}
+	return nil
+}
+
+// GetPushSubscriptions retrieves the browser push subscriptions of a user.
+func (m *UserNotificationManager) GetPushSubscriptions(userID int) ([]models.PushSubscription, error) {
+	var subscriptions = make([]models.PushSubscription, 0)
+	if err := m.q.GetPushSubscriptions.Select(&subscriptions, userID); err != nil {
+		m.lo.Error("error fetching push subscriptions", "user_id", userID, "error", err)
+		return subscriptions, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.pushSubscription}"), nil)
+	}
+	return subscriptions, nil
+}
+
+// UpsertPushSubscription saves the browser push subscription of a user. Endpoints subscribed by another user are
+// rejected, the other user has to unsubscribe first.
+func (m *UserNotificationManager) UpsertPushSubscription(userID int, endpoint, p256dh, auth, userAgent string) (models.PushSubscription, error) {
+	var subscription models.PushSubscription
+	if err := m.q.UpsertPushSubscription.Get(&subscription, userID, endpoint, p256dh, auth, userAgent); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return subscription, envelope.NewError(envelope.ConflictError, m.i18n.T("user.pushSubscriptionOfOtherUser"), nil)
+		}
+		m.lo.Error("error upserting push subscription", "user_id", userID, "error", err)
+		return subscription, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.pushSubscription}"), nil)
+	}
+	return subscription, nil
+}
+
+// DeletePushSubscription deletes the browser push subscription of a user.
+func (m *UserNotificationManager) DeletePushSubscription(userID int, endpoint string) error {
+	if _, err := m.q.DeletePushSubscription.Exec(userID, endpoint); err != nil {
+		m.lo.Error("error deleting push subscription", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.pushSubscription}"), nil)
+	}
+	return nil
+}
+
+// DeleteExpiredPushSubscription deletes a push subscription the push service reported as expired.
+func (m *UserNotificationManager) DeleteExpiredPushSubscription(endpoint string) {
+	if _, err := m.q.DeletePushSubscriptionByEndpoint.Exec(endpoint); err != nil {
+		m.lo.Error("error deleting expired push subscription", "error", err)
+	}
+}
+
+// DeleteStalePushSubscriptions deletes push subscriptions that have not been renewed by their browser in 90 days.
+func (m *UserNotificationManager) DeleteStalePushSubscriptions(ctx context.Context) error {
+	res, err := m.q.DeleteStalePushSubscriptions.ExecContext(ctx)
+	if err != nil {
+		m.lo.Error("error deleting stale push subscriptions", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.pushSubscription}"), nil)
+	}
+	rowsAffected, _ := res.RowsAffected()
+	m.lo.Info("deleted stale push subscriptions", "rows_affected", rowsAffected)
+	return nil
+}
+
 // RunNotificationCleaner runs a background job to delete old notifications every 24 hours.
 func (m *UserNotificationManager) RunNotificationCleaner(ctx context.Context) {
 	time.Sleep(10 * time.Second)
 	if err := m.DeleteOldNotifications(ctx); err != nil {
 		m.lo.Error("error cleaning old notifications", "error", err)
 	}
+	if err := m.DeleteStalePushSubscriptions(ctx); err != nil {
+		m.lo.Error("error cleaning stale push subscriptions", "error", err)
+	}
 
 	ticker := time.NewTicker(24 * time.Hour)
 	defer ticker.Stop()
@@ -162,6 +267,9 @@ func (m *UserNotificationManager) RunNotificationCleaner(ctx context.Context) {
 			if err := m.DeleteOldNotifications(ctx); err != nil {
 				m.lo.Error("error cleaning old notifications", "error", err)
 			}
+			if err := m.DeleteStalePushSubscriptions(ctx); err != nil {
+				m.lo.Error("error cleaning stale push subscriptions", "error", err)
+			}
 		}
 	}
 }
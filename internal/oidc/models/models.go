@@ -26,7 +26,11 @@ type OIDC struct {
 	Provider     string    `db:"provider" json:"provider"`
 	ProviderURL  string    `db:"provider_url" json:"provider_url"`
 	LogoURL      string    `db:"logo_url" json:"logo_url"`
+	Portal       bool      `db:"portal" json:"portal"`
 	RedirectURI  string    `db:"-" json:"redirect_uri"`
+
+	// PortalRedirectURI is the callback of portal sign ins, it's registered with the provider next to RedirectURI.
+	PortalRedirectURI string `db:"-" json:"portal_redirect_uri"`
 }
 
 // SetProviderLogo sets the logo URL if not already set.
@@ -18,6 +18,8 @@ var (
 	//go:embed queries.sql
 	efs         embed.FS
 	redirectURL = "/api/v1/oidc/%d/finish"
+
+	portalRedirectURL = "/api/v1/portal/oidc/%d/finish"
 )
 
 // Manager handles oidc-related operations.
@@ -89,6 +91,7 @@ func (o *Manager) Get(id int) (models.OIDC, error) {
 		return models.OIDC{}, err
 	}
 	oidc.RedirectURI = fmt.Sprintf(rootURL+redirectURL, oidc.ID)
+	oidc.PortalRedirectURI = fmt.Sprintf(rootURL+portalRedirectURL, oidc.ID)
 	return oidc, nil
 }
 
@@ -114,6 +117,7 @@ func (o *Manager) GetAll() ([]models.OIDC, error) {
 	// Set logo and redirect URL for each record
 	for i := range oidc {
 		oidc[i].RedirectURI = fmt.Sprintf(rootURL+redirectURL, oidc[i].ID)
+		oidc[i].PortalRedirectURI = fmt.Sprintf(rootURL+portalRedirectURL, oidc[i].ID)
 		oidc[i].SetProviderLogo()
 	}
 	return oidc, nil
@@ -128,7 +132,7 @@ func (o *Manager) Create(oidc models.OIDC) (models.OIDC, error) {
 	}
 
 	var createdOIDC models.OIDC
-	if err := o.q.InsertOIDC.Get(&createdOIDC, oidc.Name, oidc.Provider, oidc.ProviderURL, encryptedClientID, encryptedClientSecret, oidc.LogoURL); err != nil {
+	if err := o.q.InsertOIDC.Get(&createdOIDC, oidc.Name, oidc.Provider, oidc.ProviderURL, encryptedClientID, encryptedClientSecret, oidc.LogoURL, oidc.Portal); err != nil {
 		o.lo.Error("error inserting oidc", "error", err)
 		return models.OIDC{}, envelope.NewError(envelope.GeneralError, o.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.oidcProvider}"), nil)
 	}
@@ -160,7 +164,7 @@ func (o *Manager) Update(id int, oidc models.OIDC) (models.OIDC, error) {
 	}
 
 	var updatedOIDC models.OIDC
-	if err := o.q.UpdateOIDC.Get(&updatedOIDC, id, oidc.Name, oidc.Provider, oidc.ProviderURL, encryptedClientID, encryptedClientSecret, oidc.Enabled, oidc.LogoURL); err != nil {
+	if err := o.q.UpdateOIDC.Get(&updatedOIDC, id, oidc.Name, oidc.Provider, oidc.ProviderURL, encryptedClientID, encryptedClientSecret, oidc.Enabled, oidc.LogoURL, oidc.Portal); err != nil {
 		o.lo.Error("error updating oidc", "error", err)
 		return models.OIDC{}, envelope.NewError(envelope.GeneralError, o.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.oidcProvider}"), nil)
 	}
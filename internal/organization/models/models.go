@@ -6,6 +6,12 @@ import (
 	"github.com/volatiletech/null/v9"
 )
 
+// Member roles, admins see every conversation of the organization in the portal and manage its members.
+const (
+	MemberRoleMember = "member"
+	MemberRoleAdmin  = "admin"
+)
+
 // Organization represents an organization that groups contacts.
 type Organization struct {
 	ID          int         `db:"id" json:"id"`
@@ -23,6 +29,7 @@ type OrganizationMember struct {
 	OrganizationID         int       `db:"organization_id" json:"organization_id"`
 	ContactID              int64     `db:"contact_id" json:"contact_id"`
 	ShareTicketsByDefault  bool      `db:"share_tickets_by_default" json:"share_tickets_by_default"`
+	Role                   string    `db:"role" json:"role"`
 	ContactFirstName       string    `db:"contact_first_name" json:"contact_first_name,omitempty"`
 	ContactLastName        string    `db:"contact_last_name" json:"contact_last_name,omitempty"`
 	ContactEmail           null.String `db:"contact_email" json:"contact_email,omitempty"`
@@ -30,8 +37,14 @@ type OrganizationMember struct {
 
 // Membership holds organization membership info for a contact (for access and create logic).
 type Membership struct {
-	OrganizationID        int  `db:"organization_id" json:"organization_id"`
-	ShareTicketsByDefault bool `db:"share_tickets_by_default" json:"share_tickets_by_default"`
+	OrganizationID        int    `db:"organization_id" json:"organization_id"`
+	ShareTicketsByDefault bool   `db:"share_tickets_by_default" json:"share_tickets_by_default"`
+	Role                  string `db:"role" json:"role"`
+}
+
+// IsAdmin returns true if the contact is an admin of the organization.
+func (m Membership) IsAdmin() bool {
+	return m.Role == MemberRoleAdmin
 }
 
 // ContactOrganizationMembership is a contact's membership in an organization (for list by contact).
@@ -39,6 +52,7 @@ type ContactOrganizationMembership struct {
 	OrganizationID        int  `db:"organization_id" json:"organization_id"`
 	OrganizationName      string `db:"organization_name" json:"organization_name"`
 	ShareTicketsByDefault bool  `db:"share_tickets_by_default" json:"share_tickets_by_default"`
+	Role                  string `db:"role" json:"role"`
 }
 
 // OrganizationDomain is a domain associated with an organization (for auto-adding contacts by email).
@@ -36,22 +36,22 @@ type Opts struct {
 }
 
 type queries struct {
-	GetOrganizations                  *sqlx.Stmt `query:"get-organizations"`
-	GetOrganization                   *sqlx.Stmt `query:"get-organization"`
-	InsertOrganization                *sqlx.Stmt `query:"insert-organization"`
-	UpdateOrganization                *sqlx.Stmt `query:"update-organization"`
-	DeleteOrganization                *sqlx.Stmt `query:"delete-organization"`
-	GetOrganizationMembers            *sqlx.Stmt `query:"get-organization-members"`
-	GetMembershipForContact           *sqlx.Stmt `query:"get-membership-for-contact"`
-	GetMembershipsForContact          *sqlx.Stmt `query:"get-memberships-for-contact"`
-	AddMember                         *sqlx.Stmt `query:"add-member"`
-	RemoveMember                      *sqlx.Stmt `query:"remove-member"`
-	UpdateMemberShareTicketsByDefault *sqlx.Stmt `query:"update-member-share-tickets-by-default"`
-	ContactInOrganization             *sqlx.Stmt `query:"contact-in-organization"`
-	GetOrganizationDomains            *sqlx.Stmt `query:"get-organization-domains"`
-	AddOrganizationDomain             *sqlx.Stmt `query:"add-organization-domain"`
-	RemoveOrganizationDomain          *sqlx.Stmt `query:"remove-organization-domain"`
-	FindOrganizationsByEmailDomain    *sqlx.Stmt `query:"find-organizations-by-email-domain"`
+	GetOrganizations               *sqlx.Stmt `query:"get-organizations"`
+	GetOrganization                *sqlx.Stmt `query:"get-organization"`
+	InsertOrganization             *sqlx.Stmt `query:"insert-organization"`
+	UpdateOrganization             *sqlx.Stmt `query:"update-organization"`
+	DeleteOrganization             *sqlx.Stmt `query:"delete-organization"`
+	GetOrganizationMembers         *sqlx.Stmt `query:"get-organization-members"`
+	GetMembershipForContact        *sqlx.Stmt `query:"get-membership-for-contact"`
+	GetMembershipsForContact       *sqlx.Stmt `query:"get-memberships-for-contact"`
+	AddMember                      *sqlx.Stmt `query:"add-member"`
+	RemoveMember                   *sqlx.Stmt `query:"remove-member"`
+	UpdateMember                   *sqlx.Stmt `query:"update-member"`
+	ContactInOrganization          *sqlx.Stmt `query:"contact-in-organization"`
+	GetOrganizationDomains         *sqlx.Stmt `query:"get-organization-domains"`
+	AddOrganizationDomain          *sqlx.Stmt `query:"add-organization-domain"`
+	RemoveOrganizationDomain       *sqlx.Stmt `query:"remove-organization-domain"`
+	FindOrganizationsByEmailDomain *sqlx.Stmt `query:"find-organizations-by-email-domain"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -184,19 +184,27 @@ func (m *Manager) RemoveMember(organizationID int, contactID int64) error {
 	return nil
 }
 
-// UpdateMemberShareTicketsByDefault updates the share_tickets_by_default flag for a member.
-func (m *Manager) UpdateMemberShareTicketsByDefault(organizationID int, contactID int64, shareTicketsByDefault bool) (models.OrganizationMember, error) {
+// UpdateMember updates the share_tickets_by_default flag and the role of a member, null values are left unchanged.
+func (m *Manager) UpdateMember(organizationID int, contactID int64, shareTicketsByDefault null.Bool, role null.String) (models.OrganizationMember, error) {
 	var member models.OrganizationMember
-	if err := m.q.UpdateMemberShareTicketsByDefault.Get(&member, organizationID, contactID, shareTicketsByDefault); err != nil {
+	if role.Valid && !ValidMemberRole(role.String) {
+		return member, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`role`"), nil)
+	}
+	if err := m.q.UpdateMember.Get(&member, organizationID, contactID, shareTicketsByDefault, role); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return member, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "organization member"), nil)
 		}
-		m.lo.Error("error updating member share_tickets_by_default", "error", err)
+		m.lo.Error("error updating organization member", "error", err)
 		return member, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "organization member"), nil)
 	}
 	return member, nil
 }
 
+// ValidMemberRole returns true if role is one of the organization member roles.
+func ValidMemberRole(role string) bool {
+	return role == models.MemberRoleMember || role == models.MemberRoleAdmin
+}
+
 // ContactInOrganization returns whether the contact is a member of the organization.
 func (m *Manager) ContactInOrganization(organizationID int, contactID int64) (bool, error) {
 	var exists bool
@@ -14,7 +14,7 @@ UPDATE organizations SET name = $2, description = $3, updated_at = now() WHERE i
 DELETE FROM organizations WHERE id = $1;
 
 -- name: get-organization-members
-SELECT om.id, om.created_at, om.updated_at, om.organization_id, om.contact_id, om.share_tickets_by_default,
+SELECT om.id, om.created_at, om.updated_at, om.organization_id, om.contact_id, om.share_tickets_by_default, om."role",
   u.first_name AS contact_first_name, u.last_name AS contact_last_name, u.email AS contact_email
 FROM organization_members om
 JOIN users u ON u.id = om.contact_id
@@ -22,10 +22,11 @@ WHERE om.organization_id = $1 AND u.deleted_at IS NULL AND u.type = 'contact'
 ORDER BY u.first_name, u.last_name;
 
 -- name: get-membership-for-contact
-SELECT organization_id, share_tickets_by_default FROM organization_members WHERE contact_id = $1 LIMIT 1;
+SELECT organization_id, share_tickets_by_default, "role" FROM organization_members WHERE contact_id = $1
+ORDER BY "role" = 'admin' DESC, id LIMIT 1;
 
 -- name: get-memberships-for-contact
-SELECT om.organization_id, o.name AS organization_name, om.share_tickets_by_default
+SELECT om.organization_id, o.name AS organization_name, om.share_tickets_by_default, om."role"
 FROM organization_members om
 JOIN organizations o ON o.id = om.organization_id
 WHERE om.contact_id = $1
@@ -35,15 +36,18 @@ ORDER BY o.name;
 INSERT INTO organization_members (organization_id, contact_id, share_tickets_by_default)
 VALUES ($1, $2, $3)
 ON CONFLICT (organization_id, contact_id) DO UPDATE SET share_tickets_by_default = $3, updated_at = now()
-RETURNING id, created_at, updated_at, organization_id, contact_id, share_tickets_by_default;
+RETURNING id, created_at, updated_at, organization_id, contact_id, share_tickets_by_default, "role";
 
 -- name: remove-member
 DELETE FROM organization_members WHERE organization_id = $1 AND contact_id = $2;
 
--- name: update-member-share-tickets-by-default
-UPDATE organization_members SET share_tickets_by_default = $3, updated_at = now()
+-- name: update-member
+UPDATE organization_members SET
+  share_tickets_by_default = COALESCE($3, share_tickets_by_default),
+  "role" = COALESCE($4::organization_member_role, "role"),
+  updated_at = now()
 WHERE organization_id = $1 AND contact_id = $2
-RETURNING id, created_at, updated_at, organization_id, contact_id, share_tickets_by_default;
+RETURNING id, created_at, updated_at, organization_id, contact_id, share_tickets_by_default, "role";
 
 -- name: contact-in-organization
 SELECT EXISTS(SELECT 1 FROM organization_members WHERE organization_id = $1 AND contact_id = $2);
@@ -0,0 +1,430 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	// maxSheetNameLen is the maximum length of an XLSX sheet name.
+	maxSheetNameLen = 31
+)
+
+// Table is a flat, tabular rendering of a report used for CSV and XLSX exports.
+type Table struct {
+	Name    string
+	Headers []string
+	Rows    [][]string
+}
+
+// field is a single key/value pair of a JSON object, kept in document order.
+type field struct {
+	key string
+	val any
+}
+
+// object is a JSON object with its keys in document order.
+type object []field
+
+// IsValidFormat returns true if the given export format is supported.
+func IsValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatXLSX
+}
+
+// ContentType returns the MIME type for the given export format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewTable converts report data into a table.
+//
+// A list of objects becomes one row per object. An object whose values are all lists
+// of objects (eg: chart series) becomes one row per list item with a leading `series`
+// column. Any other object becomes `metric` / `value` rows with nested keys joined by dots.
+func NewTable(name string, data any) (Table, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return Table{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return Table{}, err
+	}
+
+	t := Table{Name: name}
+	switch val := v.(type) {
+	case []any:
+		t.addRecords("", val)
+	case object:
+		if isSeries(val) {
+			for _, f := range val {
+				items, _ := f.val.([]any)
+				t.addRecords(f.key, items)
+			}
+			break
+		}
+		t.Headers = []string{"metric", "value"}
+		var flat object
+		flatten("", val, &flat)
+		for _, f := range flat {
+			t.Rows = append(t.Rows, []string{f.key, formatScalar(f.val)})
+		}
+	default:
+		t.Headers = []string{"value"}
+		t.Rows = [][]string{{formatScalar(val)}}
+	}
+	return t, nil
+}
+
+// Export writes the tables in the given format. CSV supports a single table only.
+func Export(w io.Writer, format string, tables ...Table) error {
+	switch format {
+	case FormatCSV:
+		if len(tables) != 1 {
+			return fmt.Errorf("csv export expects exactly one table, got %d", len(tables))
+		}
+		return writeCSV(w, tables[0])
+	case FormatXLSX:
+		return writeXLSX(w, tables)
+	default:
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// addRecords appends one row per item, growing the headers as new keys appear.
+// When series is set, it is prepended to every row in a `series` column.
+func (t *Table) addRecords(series string, items []any) {
+	if series != "" && (len(t.Headers) == 0 || t.Headers[0] != "series") {
+		t.Headers = append([]string{"series"}, t.Headers...)
+		for i := range t.Rows {
+			t.Rows[i] = append([]string{""}, t.Rows[i]...)
+		}
+	}
+
+	index := make(map[string]int, len(t.Headers))
+	for i, h := range t.Headers {
+		index[h] = i
+	}
+
+	for _, item := range items {
+		var flat object
+		flatten("", item, &flat)
+		if len(flat) == 1 && flat[0].key == "" {
+			flat[0].key = "value"
+		}
+
+		row := make([]string, len(t.Headers))
+		if series != "" {
+			row[0] = series
+		}
+		for _, f := range flat {
+			i, ok := index[f.key]
+			if !ok {
+				i = len(t.Headers)
+				index[f.key] = i
+				t.Headers = append(t.Headers, f.key)
+				for j := range t.Rows {
+					t.Rows[j] = append(t.Rows[j], "")
+				}
+				row = append(row, "")
+			}
+			row[i] = formatScalar(f.val)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+}
+
+// isSeries returns true if every value of the object is a list of objects or null,
+// and at least one of them is a list.
+func isSeries(obj object) bool {
+	hasList := false
+	for _, f := range obj {
+		switch v := f.val.(type) {
+		case nil:
+		case []any:
+			for _, item := range v {
+				if _, ok := item.(object); !ok {
+					return false
+				}
+			}
+			hasList = true
+		default:
+			return false
+		}
+	}
+	return hasList
+}
+
+// flatten flattens nested objects and lists into dot separated keys. List indices start at 1.
+func flatten(prefix string, v any, out *object) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch val := v.(type) {
+	case object:
+		for _, f := range val {
+			flatten(join(f.key), f.val, out)
+		}
+	case []any:
+		for i, item := range val {
+			flatten(join(strconv.Itoa(i+1)), item, out)
+		}
+	default:
+		*out = append(*out, field{key: prefix, val: val})
+	}
+}
+
+// decodeOrdered decodes the next JSON value, preserving the key order of objects.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := object{}
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := kt.(string)
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, field{key: key, val: v})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		list := []any{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unexpected delimiter %q", delim)
+}
+
+// formatScalar returns the string form of a decoded JSON scalar.
+func formatScalar(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// isNumeric returns true if the cell value is a plain number.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// writeCSV writes the table as CSV. Text cells that spreadsheet apps would
+// evaluate as formulas are prefixed with a quote.
+func writeCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Headers); err != nil {
+		return err
+	}
+	if err := writeCSVRows(cw, t.Rows); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeCSVRows writes rows to a CSV writer, escaping formulas like writeCSV.
+func writeCSVRows(cw *csv.Writer, rows [][]string) error {
+	for _, row := range rows {
+		out := make([]string, len(row))
+		for i, cell := range row {
+			if cell != "" && !isNumeric(cell) && strings.ContainsAny(cell[:1], "=+-@\t\r") {
+				cell = "'" + cell
+			}
+			out[i] = cell
+		}
+		if err := cw.Write(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeXLSX writes the tables as a minimal XLSX workbook with one sheet per table.
+func writeXLSX(w io.Writer, tables []Table) error {
+	var (
+		zw       = zip.NewWriter(w)
+		sheets   strings.Builder
+		rels     strings.Builder
+		types    strings.Builder
+		usedName = map[string]bool{}
+	)
+
+	for i, t := range tables {
+		n := i + 1
+		name := sheetName(t.Name, n, usedName)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+
+		f, err := zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", n))
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, sheetXML(t)); err != nil {
+			return err
+		}
+	}
+
+	files := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+	}
+	for _, file := range files {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, file.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// sheetXML renders a table as worksheet XML using inline strings.
+func sheetXML(t Table) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeRow := func(r int, cells []string) {
+		fmt.Fprintf(&b, `<row r="%d">`, r)
+		for c, cell := range cells {
+			if cell == "" {
+				continue
+			}
+			ref := columnName(c) + strconv.Itoa(r)
+			if isNumeric(cell) {
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, cell)
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(cell))
+		}
+		b.WriteString(`</row>`)
+	}
+
+	writeRow(1, t.Headers)
+	for i, row := range t.Rows {
+		writeRow(i+2, row)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// sheetName returns a valid, unique XLSX sheet name.
+func sheetName(name string, n int, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Sheet" + strconv.Itoa(n)
+	}
+	if r := []rune(name); len(r) > maxSheetNameLen {
+		name = string(r[:maxSheetNameLen])
+	}
+	for used[strings.ToLower(name)] {
+		suffix := "_" + strconv.Itoa(n)
+		r := []rune(name)
+		if len(r)+len(suffix) > maxSheetNameLen {
+			r = r[:maxSheetNameLen-len(suffix)]
+		}
+		name = string(r) + suffix
+		n++
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// columnName returns the spreadsheet column name for a zero based index, eg: 0 => A, 26 => AA.
+func columnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+// xmlEscape escapes text for use in XML content and attributes.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewTable(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantHeaders []string
+		wantRows    [][]string
+	}{
+		{
+			name:        "object becomes metric rows",
+			data:        `{"open": 4, "agents": {"online": 2, "away": 1}, "rate": 12.5}`,
+			wantHeaders: []string{"metric", "value"},
+			wantRows:    [][]string{{"open", "4"}, {"agents.online", "2"}, {"agents.away", "1"}, {"rate", "12.5"}},
+		},
+		{
+			name:        "list of objects keeps key order",
+			data:        `[{"id": 1, "name": "Jane", "csat": 4.5}, {"id": 2, "name": "Joe", "csat": 0}]`,
+			wantHeaders: []string{"id", "name", "csat"},
+			wantRows:    [][]string{{"1", "Jane", "4.5"}, {"2", "Joe", "0"}},
+		},
+		{
+			name:        "series object adds series column",
+			data:        `{"new": [{"date": "2024-01-01", "count": 3}], "resolved": null, "closed": [{"date": "2024-01-02", "count": 1}]}`,
+			wantHeaders: []string{"series", "date", "count"},
+			wantRows:    [][]string{{"new", "2024-01-01", "3"}, {"closed", "2024-01-02", "1"}},
+		},
+		{
+			name:        "mixed object flattens lists with indices",
+			data:        `{"top": [{"tag": "billing"}], "tagged": 1}`,
+			wantHeaders: []string{"metric", "value"},
+			wantRows:    [][]string{{"top.1.tag", "billing"}, {"tagged", "1"}},
+		},
+		{
+			name:        "new keys in later rows extend earlier rows",
+			data:        `[{"a": 1}, {"a": 2, "b": true}]`,
+			wantHeaders: []string{"a", "b"},
+			wantRows:    [][]string{{"1", ""}, {"2", "true"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := NewTable("test", json.RawMessage(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(table.Headers, tt.wantHeaders) {
+				t.Errorf("headers: got %v, want %v", table.Headers, tt.wantHeaders)
+			}
+			if !reflect.DeepEqual(table.Rows, tt.wantRows) {
+				t.Errorf("rows: got %v, want %v", table.Rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	table := Table{
+		Headers: []string{"name", "value"},
+		Rows:    [][]string{{"=HYPERLINK(\"x\")", "-1.5"}, {"plain, text", "2"}},
+	}
+	var buf bytes.Buffer
+	if err := Export(&buf, FormatCSV, table); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "name,value\n\"'=HYPERLINK(\"\"x\"\")\",-1.5\n\"plain, text\",2\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	if err := Export(&buf, FormatCSV, table, table); err == nil {
+		t.Error("expected error exporting multiple tables as csv")
+	}
+}
+
+func TestExportXLSX(t *testing.T) {
+	tables := []Table{
+		{Name: "agents", Headers: []string{"name", "count"}, Rows: [][]string{{"A & B", "3"}}},
+		{Name: "agents", Headers: []string{"metric"}, Rows: [][]string{{"x"}}},
+	}
+	var buf bytes.Buffer
+	if err := Export(&buf, FormatXLSX, tables...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("error opening %s: %v", f.Name, err)
+		}
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `name="agents_2"`) {
+		t.Errorf("expected duplicate sheet name to be made unique: %s", files["xl/workbook.xml"])
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	if !strings.Contains(sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">A &amp; B</t></is></c>`) {
+		t.Errorf("expected escaped inline string cell: %s", sheet)
+	}
+	if !strings.Contains(sheet, `<c r="B2"><v>3</v></c>`) {
+		t.Errorf("expected numeric cell: %s", sheet)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d): got %s, want %s", i, got, want)
+		}
+	}
+}
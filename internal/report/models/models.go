@@ -1,16 +1,82 @@
 package models
 
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
+
 type OverviewSLA struct {
-	FirstResponseMetCount         int     `json:"first_response_met_count" db:"first_response_met_count"`
-	FirstResponseBreachedCount    int     `json:"first_response_breached_count" db:"first_response_breached_count"`
-	AvgFirstResponseTimeSec       float64 `json:"avg_first_response_time_sec" db:"avg_first_response_time_sec"`
-	NextResponseMetCount          int     `json:"next_response_met_count" db:"next_response_met_count"`
-	NextResponseBreachedCount     int     `json:"next_response_breached_count" db:"next_response_breached_count"`
-	AvgNextResponseTimeSec        float64 `json:"avg_next_response_time_sec" db:"avg_next_response_time_sec"`
-	ResolutionMetCount            int     `json:"resolution_met_count" db:"resolution_met_count"`
-	ResolutionBreachedCount       int     `json:"resolution_breached_count" db:"resolution_breached_count"`
-	AvgResolutionTimeSec          float64 `json:"avg_resolution_time_sec" db:"avg_resolution_time_sec"`
+	FirstResponseMetCount          int     `json:"first_response_met_count" db:"first_response_met_count"`
+	FirstResponseBreachedCount     int     `json:"first_response_breached_count" db:"first_response_breached_count"`
+	AvgFirstResponseTimeSec        float64 `json:"avg_first_response_time_sec" db:"avg_first_response_time_sec"`
+	NextResponseMetCount           int     `json:"next_response_met_count" db:"next_response_met_count"`
+	NextResponseBreachedCount      int     `json:"next_response_breached_count" db:"next_response_breached_count"`
+	AvgNextResponseTimeSec         float64 `json:"avg_next_response_time_sec" db:"avg_next_response_time_sec"`
+	ResolutionMetCount             int     `json:"resolution_met_count" db:"resolution_met_count"`
+	ResolutionBreachedCount        int     `json:"resolution_breached_count" db:"resolution_breached_count"`
+	AvgResolutionTimeSec           float64 `json:"avg_resolution_time_sec" db:"avg_resolution_time_sec"`
 	FirstResponseCompliancePercent float64 `json:"first_response_compliance_percent" db:"first_response_compliance_percent"`
 	NextResponseCompliancePercent  float64 `json:"next_response_compliance_percent" db:"next_response_compliance_percent"`
 	ResolutionCompliancePercent    float64 `json:"resolution_compliance_percent" db:"resolution_compliance_percent"`
 }
+
+// PerformanceRow is a single agent or team row in the performance report.
+type PerformanceRow struct {
+	ID                      int     `json:"id" db:"id"`
+	Name                    string  `json:"name" db:"name"`
+	ConversationsHandled    int     `json:"conversations_handled" db:"conversations_handled"`
+	FirstResponseMedianSec  float64 `json:"first_response_median_sec" db:"first_response_median_sec"`
+	FirstResponseP90Sec     float64 `json:"first_response_p90_sec" db:"first_response_p90_sec"`
+	AvgResolutionTimeSec    float64 `json:"avg_resolution_time_sec" db:"avg_resolution_time_sec"`
+	MedianResolutionTimeSec float64 `json:"median_resolution_time_sec" db:"median_resolution_time_sec"`
+	RepliesPerConversation  float64 `json:"replies_per_conversation" db:"replies_per_conversation"`
+	ReopenRatePercent       float64 `json:"reopen_rate_percent" db:"reopen_rate_percent"`
+	SLACompliancePercent    float64 `json:"sla_compliance_percent" db:"sla_compliance_percent"`
+	CSATAverage             float64 `json:"csat_average" db:"csat_average"`
+}
+
+// TimeTrackingRow is a single agent, conversation, contact or organization row in the time tracking report.
+type TimeTrackingRow struct {
+	ID                 int    `json:"id" db:"id"`
+	Name               string `json:"name" db:"name"`
+	Entries            int    `json:"entries" db:"entries"`
+	TotalSeconds       int    `json:"total_seconds" db:"total_seconds"`
+	BillableSeconds    int    `json:"billable_seconds" db:"billable_seconds"`
+	NonBillableSeconds int    `json:"non_billable_seconds" db:"non_billable_seconds"`
+}
+
+// PerformanceConversation is a conversation backing a performance report row.
+type PerformanceConversation struct {
+	Total            int          `json:"-" db:"total"`
+	UUID             string       `json:"uuid" db:"uuid"`
+	ReferenceNumber  string       `json:"reference_number" db:"reference_number"`
+	Subject          null.String  `json:"subject" db:"subject"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+	FirstReplyAt     null.Time    `json:"first_reply_at" db:"first_reply_at"`
+	ResolvedAt       null.Time    `json:"resolved_at" db:"resolved_at"`
+	ReopenedAt       null.Time    `json:"reopened_at" db:"reopened_at"`
+	Status           string       `json:"status" db:"status"`
+	ContactName      string       `json:"contact_name" db:"contact_name"`
+	FirstResponseSec null.Float64 `json:"first_response_sec" db:"first_response_sec"`
+	ResolutionSec    null.Float64 `json:"resolution_sec" db:"resolution_sec"`
+	Replies          int          `json:"replies" db:"replies"`
+	SLAStatus        null.String  `json:"sla_status" db:"sla_status"`
+	CSATRating       null.Float64 `json:"csat_rating" db:"csat_rating"`
+}
+
+// Schedule is a report emailed periodically to a list of agents.
+type Schedule struct {
+	ID           int            `json:"id" db:"id"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
+	Name         string         `json:"name" db:"name"`
+	Frequency    string         `json:"frequency" db:"frequency"`
+	Reports      pq.StringArray `json:"reports" db:"reports"`
+	Format       string         `json:"format" db:"format"`
+	RecipientIDs pq.Int64Array  `json:"recipient_ids" db:"recipient_ids"`
+	Enabled      bool           `json:"enabled" db:"enabled"`
+	LastSentAt   null.Time      `json:"last_sent_at" db:"last_sent_at"`
+	NextRunAt    time.Time      `json:"next_run_at" db:"next_run_at"`
+}
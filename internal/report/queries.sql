@@ -360,4 +360,319 @@ SELECT
         END
     ) AS result
 FROM
-    tagging;
\ No newline at end of file
+    tagging;
+-- name: get-performance
+-- $1 = entity type, agent or team. The conversations are grouped by their assigned agent or team.
+WITH entities AS (
+    SELECT
+        u.id,
+        TRIM(CONCAT(u.first_name, ' ', u.last_name)) AS name
+    FROM
+        users u
+    WHERE
+        $1 = 'agent'
+        AND u.type = 'agent'
+        AND u.email != 'System'
+        AND u.deleted_at IS NULL
+    UNION ALL
+    SELECT
+        t.id,
+        t.name
+    FROM
+        teams t
+    WHERE
+        $1 = 'team'
+),
+convs AS (
+    SELECT
+        c.id,
+        CASE WHEN $1 = 'team' THEN c.assigned_team_id ELSE c.assigned_user_id END AS entity_id,
+        EXTRACT(EPOCH FROM (c.first_reply_at - c.created_at)) AS first_response_sec,
+        EXTRACT(EPOCH FROM (c.resolved_at - c.created_at)) AS resolution_sec,
+        c.resolved_at IS NOT NULL AS resolved,
+        c.reopened_at IS NOT NULL AS reopened
+    FROM
+        conversations c
+    WHERE
+        CASE WHEN $1 = 'team' THEN c.assigned_team_id ELSE c.assigned_user_id END IS NOT NULL
+        AND c.created_at >= CASE
+            WHEN %d = 0 THEN CURRENT_DATE
+            ELSE NOW() - INTERVAL '%d days'
+        END
+),
+replies AS (
+    SELECT
+        m.conversation_id,
+        COUNT(*) AS count
+    FROM
+        conversation_messages m
+    WHERE
+        m.conversation_id IN (SELECT id FROM convs)
+        AND m.type = 'outgoing'
+        AND m.private = false
+    GROUP BY
+        m.conversation_id
+),
+sla AS (
+    SELECT
+        a.conversation_id,
+        SUM((a.first_response_met_at IS NOT NULL)::int + (a.resolution_met_at IS NOT NULL)::int) AS met,
+        SUM((a.first_response_breached_at IS NOT NULL)::int + (a.resolution_breached_at IS NOT NULL)::int) AS breached
+    FROM
+        applied_slas a
+    WHERE
+        a.conversation_id IN (SELECT id FROM convs)
+    GROUP BY
+        a.conversation_id
+),
+csat AS (
+    SELECT
+        cr.conversation_id,
+        AVG(cr.rating) AS rating
+    FROM
+        csat_responses cr
+    WHERE
+        cr.rating > 0
+        AND cr.conversation_id IN (SELECT id FROM convs)
+    GROUP BY
+        cr.conversation_id
+)
+SELECT
+    e.id,
+    e.name,
+    COUNT(cv.id) AS conversations_handled,
+    COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY cv.first_response_sec) FILTER (WHERE cv.first_response_sec IS NOT NULL), 0) AS first_response_median_sec,
+    COALESCE(PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY cv.first_response_sec) FILTER (WHERE cv.first_response_sec IS NOT NULL), 0) AS first_response_p90_sec,
+    COALESCE(AVG(cv.resolution_sec) FILTER (WHERE cv.resolved), 0) AS avg_resolution_time_sec,
+    COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY cv.resolution_sec) FILTER (WHERE cv.resolved), 0) AS median_resolution_time_sec,
+    CASE
+        WHEN COUNT(cv.id) > 0 THEN ROUND(COALESCE(SUM(r.count), 0)::numeric / COUNT(cv.id)::numeric, 1)
+        ELSE 0
+    END AS replies_per_conversation,
+    CASE
+        WHEN COUNT(cv.id) FILTER (WHERE cv.resolved) > 0
+        THEN ROUND((COUNT(cv.id) FILTER (WHERE cv.reopened)::numeric / COUNT(cv.id) FILTER (WHERE cv.resolved)::numeric) * 100, 1)
+        ELSE 0
+    END AS reopen_rate_percent,
+    CASE
+        WHEN COALESCE(SUM(s.met + s.breached), 0) > 0
+        THEN ROUND((SUM(s.met)::numeric / SUM(s.met + s.breached)::numeric) * 100, 1)
+        ELSE 0
+    END AS sla_compliance_percent,
+    COALESCE(ROUND(AVG(cs.rating), 2), 0) AS csat_average
+FROM
+    entities e
+    LEFT JOIN convs cv ON cv.entity_id = e.id
+    LEFT JOIN replies r ON r.conversation_id = cv.id
+    LEFT JOIN sla s ON s.conversation_id = cv.id
+    LEFT JOIN csat cs ON cs.conversation_id = cv.id
+GROUP BY
+    e.id,
+    e.name
+ORDER BY
+    conversations_handled DESC,
+    e.name ASC;
+
+-- name: get-performance-conversations
+-- $1 = entity type (agent or team), $2 = entity ID, $3 = offset, $4 = limit.
+SELECT
+    COUNT(*) OVER() AS total,
+    c.uuid,
+    c.reference_number,
+    c.subject,
+    c.created_at,
+    c.first_reply_at,
+    c.resolved_at,
+    c.reopened_at,
+    s.name AS status,
+    TRIM(CONCAT(ct.first_name, ' ', ct.last_name)) AS contact_name,
+    EXTRACT(EPOCH FROM (c.first_reply_at - c.created_at)) AS first_response_sec,
+    EXTRACT(EPOCH FROM (c.resolved_at - c.created_at)) AS resolution_sec,
+    (
+        SELECT COUNT(*)
+        FROM conversation_messages m
+        WHERE m.conversation_id = c.id AND m.type = 'outgoing' AND m.private = false
+    ) AS replies,
+    (
+        SELECT a.status
+        FROM applied_slas a
+        WHERE a.conversation_id = c.id
+        ORDER BY a.created_at DESC
+        LIMIT 1
+    ) AS sla_status,
+    (
+        SELECT AVG(cr.rating)
+        FROM csat_responses cr
+        WHERE cr.conversation_id = c.id AND cr.rating > 0
+    ) AS csat_rating
+FROM
+    conversations c
+    INNER JOIN conversation_statuses s ON s.id = c.status_id
+    INNER JOIN users ct ON ct.id = c.contact_id
+WHERE
+    CASE
+        WHEN $1 = 'team' THEN c.assigned_team_id = $2
+        ELSE c.assigned_user_id = $2
+    END
+    AND c.created_at >= CASE
+        WHEN %d = 0 THEN CURRENT_DATE
+        ELSE NOW() - INTERVAL '%d days'
+    END
+ORDER BY
+    c.created_at DESC
+OFFSET $3 LIMIT $4;
+
+-- name: get-time-tracking
+-- $1 = group (agent, conversation, contact or organization).
+-- Conversations not shared with an organization count towards the contact's first organization.
+WITH entries AS (
+    SELECT
+        t.user_id,
+        t.conversation_id,
+        c.contact_id,
+        COALESCE(c.organization_id, (
+            SELECT om.organization_id
+            FROM organization_members om
+            WHERE om.contact_id = c.contact_id
+            ORDER BY om.created_at
+            LIMIT 1
+        )) AS organization_id,
+        t.duration_seconds,
+        t.billable
+    FROM
+        conversation_time_entries t
+        INNER JOIN conversations c ON c.id = t.conversation_id
+    WHERE
+        t.started_at >= CASE
+            WHEN %d = 0 THEN CURRENT_DATE
+            ELSE NOW() - INTERVAL '%d days'
+        END
+),
+grouped AS (
+    SELECT
+        CASE $1
+            WHEN 'conversation' THEN e.conversation_id
+            WHEN 'contact' THEN e.contact_id
+            WHEN 'organization' THEN e.organization_id
+            ELSE e.user_id
+        END AS id,
+        COUNT(*) AS entries,
+        SUM(e.duration_seconds) AS total_seconds,
+        COALESCE(SUM(e.duration_seconds) FILTER (WHERE e.billable), 0) AS billable_seconds
+    FROM
+        entries e
+    GROUP BY
+        1
+)
+SELECT
+    g.id,
+    CASE $1
+        WHEN 'conversation' THEN (SELECT TRIM(CONCAT('#', c.reference_number, ' ', c.subject)) FROM conversations c WHERE c.id = g.id)
+        WHEN 'organization' THEN (SELECT o.name FROM organizations o WHERE o.id = g.id)
+        ELSE (SELECT TRIM(CONCAT(u.first_name, ' ', u.last_name)) FROM users u WHERE u.id = g.id)
+    END AS name,
+    g.entries,
+    g.total_seconds,
+    g.billable_seconds,
+    g.total_seconds - g.billable_seconds AS non_billable_seconds
+FROM
+    grouped g
+WHERE
+    g.id IS NOT NULL
+ORDER BY
+    g.total_seconds DESC,
+    g.id ASC;
+
+-- name: get-report-schedules
+SELECT
+    id,
+    created_at,
+    updated_at,
+    name,
+    frequency,
+    reports,
+    format,
+    recipient_ids,
+    enabled,
+    last_sent_at,
+    next_run_at
+FROM
+    report_schedules
+ORDER BY created_at DESC;
+
+-- name: get-report-schedule
+SELECT
+    id,
+    created_at,
+    updated_at,
+    name,
+    frequency,
+    reports,
+    format,
+    recipient_ids,
+    enabled,
+    last_sent_at,
+    next_run_at
+FROM
+    report_schedules
+WHERE
+    id = $1;
+
+-- name: get-due-report-schedules
+SELECT
+    id,
+    created_at,
+    updated_at,
+    name,
+    frequency,
+    reports,
+    format,
+    recipient_ids,
+    enabled,
+    last_sent_at,
+    next_run_at
+FROM
+    report_schedules
+WHERE
+    enabled = true AND
+    next_run_at <= NOW()
+ORDER BY next_run_at;
+
+-- name: insert-report-schedule
+INSERT INTO
+    report_schedules (name, frequency, reports, format, recipient_ids, enabled, next_run_at)
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: update-report-schedule
+UPDATE
+    report_schedules
+SET
+    name = $2,
+    frequency = $3,
+    reports = $4,
+    format = $5,
+    recipient_ids = $6,
+    enabled = $7,
+    next_run_at = CASE WHEN frequency != $3::report_schedule_frequency THEN $8 ELSE next_run_at END,
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING *;
+
+-- name: delete-report-schedule
+DELETE FROM
+    report_schedules
+WHERE
+    id = $1;
+
+-- name: update-report-schedule-sent
+-- $3 is false when sending the report failed, the schedule then only moves to its next run.
+UPDATE
+    report_schedules
+SET
+    last_sent_at = CASE WHEN $3::BOOLEAN THEN NOW() ELSE last_sent_at END,
+    next_run_at = $2
+WHERE
+    id = $1;
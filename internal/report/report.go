@@ -5,12 +5,18 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"slices"
 
 	"github.com/ghotso/libredesk/internal/dbutil"
 	"github.com/ghotso/libredesk/internal/envelope"
+	notifier "github.com/ghotso/libredesk/internal/notification"
 	"github.com/ghotso/libredesk/internal/report/models"
+	"github.com/ghotso/libredesk/internal/template"
+	umodels "github.com/ghotso/libredesk/internal/user/models"
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/go-i18n"
 	"github.com/zerodha/logf"
@@ -21,28 +27,63 @@ var (
 	efs embed.FS
 )
 
+const (
+	// PerformanceAgent and PerformanceTeam are the entity types a performance report is grouped by.
+	PerformanceAgent = "agent"
+	PerformanceTeam  = "team"
+
+	performanceConversationsMaxPageSize = 100
+	// exportBatchSize is the number of rows converted and written at a time by streamed exports.
+	exportBatchSize = 500
+)
+
+// TimeTrackingGroups are what the time tracking report can be grouped by.
+var TimeTrackingGroups = []string{"agent", "conversation", "contact", "organization"}
+
 type Manager struct {
-	q    queries
-	lo   *logf.Logger
-	i18n *i18n.I18n
-	db   *sqlx.DB
+	q         queries
+	lo        *logf.Logger
+	i18n      *i18n.I18n
+	db        *sqlx.DB
+	template  *template.Manager
+	userStore userStore
+	notifier  *notifier.Service
 }
 
 // Opts contains options for initializing the report Manager.
+// The template manager, user store and notifier are used to email scheduled reports.
 type Opts struct {
-	DB   *sqlx.DB
-	Lo   *logf.Logger
-	I18n *i18n.I18n
+	DB        *sqlx.DB
+	Lo        *logf.Logger
+	I18n      *i18n.I18n
+	Template  *template.Manager
+	UserStore userStore
+	Notifier  *notifier.Service
+}
+
+type userStore interface {
+	GetAgent(int, string) (umodels.User, error)
 }
 
 // queries contains prepared SQL queries.
 type queries struct {
-	GetOverviewCharts          string `query:"get-overview-charts"`
-	GetOverviewCounts          string `query:"get-overview-counts"`
-	GetOverviewSLA             string `query:"get-overview-sla-counts"`
-	GetOverviewCSAT            string `query:"get-overview-csat"`
-	GetOverviewMessageVolume   string `query:"get-overview-message-volume"`
-	GetOverviewTagDistribution string `query:"get-overview-tag-distribution"`
+	GetOverviewCharts           string `query:"get-overview-charts"`
+	GetOverviewCounts           string `query:"get-overview-counts"`
+	GetOverviewSLA              string `query:"get-overview-sla-counts"`
+	GetOverviewCSAT             string `query:"get-overview-csat"`
+	GetOverviewMessageVolume    string `query:"get-overview-message-volume"`
+	GetOverviewTagDistribution  string `query:"get-overview-tag-distribution"`
+	GetPerformance              string `query:"get-performance"`
+	GetPerformanceConversations string `query:"get-performance-conversations"`
+	GetTimeTracking             string `query:"get-time-tracking"`
+
+	GetSchedules         *sqlx.Stmt `query:"get-report-schedules"`
+	GetSchedule          *sqlx.Stmt `query:"get-report-schedule"`
+	GetDueSchedules      *sqlx.Stmt `query:"get-due-report-schedules"`
+	InsertSchedule       *sqlx.Stmt `query:"insert-report-schedule"`
+	UpdateSchedule       *sqlx.Stmt `query:"update-report-schedule"`
+	DeleteSchedule       *sqlx.Stmt `query:"delete-report-schedule"`
+	UpdateScheduleSentAt *sqlx.Stmt `query:"update-report-schedule-sent"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -52,10 +93,13 @@ func New(opts Opts) (*Manager, error) {
 		return nil, err
 	}
 	return &Manager{
-		q:    q,
-		lo:   opts.Lo,
-		i18n: opts.I18n,
-		db:   opts.DB,
+		q:         q,
+		lo:        opts.Lo,
+		i18n:      opts.I18n,
+		db:        opts.DB,
+		template:  opts.Template,
+		userStore: opts.UserStore,
+		notifier:  opts.Notifier,
 	}, nil
 }
 
@@ -196,3 +240,129 @@ func (m *Manager) GetOverviewTagDistribution(days int) (json.RawMessage, error)
 	}
 	return stats, nil
 }
+
+// GetAgentPerformance returns per-agent performance metrics for conversations created in the last `days` days.
+func (m *Manager) GetAgentPerformance(days int) ([]models.PerformanceRow, error) {
+	return m.getPerformance(PerformanceAgent, days)
+}
+
+// GetTeamPerformance returns per-team performance metrics for conversations created in the last `days` days.
+func (m *Manager) GetTeamPerformance(days int) ([]models.PerformanceRow, error) {
+	return m.getPerformance(PerformanceTeam, days)
+}
+
+// GetPerformanceConversations returns the conversations backing an agent or team performance row.
+func (m *Manager) GetPerformanceConversations(entityType string, entityID, days, page, pageSize int) ([]models.PerformanceConversation, error) {
+	var conversations = make([]models.PerformanceConversation, 0)
+	if entityType != PerformanceAgent && entityType != PerformanceTeam {
+		return conversations, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`type`"), nil)
+	}
+	if pageSize > performanceConversationsMaxPageSize {
+		pageSize = performanceConversationsMaxPageSize
+	}
+
+	query := fmt.Sprintf(m.q.GetPerformanceConversations, days, days)
+	if err := m.db.Select(&conversations, query, entityType, entityID, (page-1)*pageSize, pageSize); err != nil {
+		m.lo.Error("error fetching performance conversations", "type", entityType, "id", entityID, "error", err)
+		return conversations, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return conversations, nil
+}
+
+// ExportPerformanceConversations writes all the conversations behind an agent or team performance row in the given
+// export format. CSV rows are streamed from the database in batches, XLSX workbooks are written once all rows are read.
+func (m *Manager) ExportPerformanceConversations(w io.Writer, format, entityType string, entityID, days int) error {
+	if entityType != PerformanceAgent && entityType != PerformanceTeam {
+		return fmt.Errorf("invalid performance type: %s", entityType)
+	}
+
+	// A NULL limit returns all the rows.
+	query := fmt.Sprintf(m.q.GetPerformanceConversations, days, days)
+	rows, err := m.db.Queryx(query, entityType, entityID, 0, nil)
+	if err != nil {
+		return fmt.Errorf("fetching performance conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		name  = fmt.Sprintf("%s-%d-conversations", entityType, entityID)
+		table = Table{Name: name}
+		batch = make([]models.PerformanceConversation, 0, exportBatchSize)
+		cw    = csv.NewWriter(w)
+	)
+	writeBatch := func() error {
+		t, err := NewTable(name, batch)
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+		if format == FormatXLSX {
+			table.Headers = t.Headers
+			table.Rows = append(table.Rows, t.Rows...)
+			return nil
+		}
+		if table.Headers == nil {
+			table.Headers = t.Headers
+			if err := cw.Write(t.Headers); err != nil {
+				return err
+			}
+		}
+		if err := writeCSVRows(cw, t.Rows); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	for rows.Next() {
+		var c models.PerformanceConversation
+		if err := rows.StructScan(&c); err != nil {
+			return fmt.Errorf("scanning performance conversation: %w", err)
+		}
+		batch = append(batch, c)
+		if len(batch) == exportBatchSize {
+			if err := writeBatch(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("fetching performance conversations: %w", err)
+	}
+	if len(batch) > 0 || table.Headers == nil {
+		if err := writeBatch(); err != nil {
+			return err
+		}
+	}
+
+	if format == FormatXLSX {
+		return writeXLSX(w, []Table{table})
+	}
+	return nil
+}
+
+// GetTimeTracking returns the time tracked on conversations in the last `days` days grouped by agent, conversation, contact or organization.
+func (m *Manager) GetTimeTracking(groupBy string, days int) ([]models.TimeTrackingRow, error) {
+	var rows = make([]models.TimeTrackingRow, 0)
+	if !slices.Contains(TimeTrackingGroups, groupBy) {
+		return rows, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`group_by`"), nil)
+	}
+
+	query := fmt.Sprintf(m.q.GetTimeTracking, days, days)
+	if err := m.db.Select(&rows, query, groupBy); err != nil {
+		m.lo.Error("error fetching time tracking report", "group_by", groupBy, "error", err)
+		return rows, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.report}"), nil)
+	}
+	return rows, nil
+}
+
+// getPerformance returns the performance metrics of each agent or team for the last `days` days.
+func (m *Manager) getPerformance(entityType string, days int) ([]models.PerformanceRow, error) {
+	var rows = make([]models.PerformanceRow, 0)
+	query := fmt.Sprintf(m.q.GetPerformance, days, days)
+	if err := m.db.Select(&rows, query, entityType); err != nil {
+		m.lo.Error("error fetching performance report", "type", entityType, "error", err)
+		return rows, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.report}"), nil)
+	}
+	return rows, nil
+}
@@ -0,0 +1,337 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/attachment"
+	"github.com/ghotso/libredesk/internal/envelope"
+	notifier "github.com/ghotso/libredesk/internal/notification"
+	"github.com/ghotso/libredesk/internal/report/models"
+	"github.com/ghotso/libredesk/internal/template"
+	"github.com/lib/pq"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+
+	// Report keys that can be exported and scheduled.
+	ReportOverviewCounts   = "overview_counts"
+	ReportOverviewCharts   = "overview_charts"
+	ReportOverviewSLA      = "overview_sla"
+	ReportOverviewCSAT     = "overview_csat"
+	ReportOverviewMessages = "overview_messages"
+	ReportOverviewTags     = "overview_tags"
+	ReportAgents           = "agents"
+	ReportTeams            = "teams"
+)
+
+// Reports lists the report keys in the order they appear in exports.
+var Reports = []string{
+	ReportOverviewCounts,
+	ReportOverviewCharts,
+	ReportOverviewSLA,
+	ReportOverviewCSAT,
+	ReportOverviewMessages,
+	ReportOverviewTags,
+	ReportAgents,
+	ReportTeams,
+}
+
+// frequencyDays is the number of days of data covered by each schedule frequency.
+var frequencyDays = map[string]int{
+	FrequencyDaily:   1,
+	FrequencyWeekly:  7,
+	FrequencyMonthly: 30,
+}
+
+// summaryItem is a single line in the scheduled report email.
+type summaryItem struct {
+	Label string
+	Value string
+}
+
+// IsValidFrequency returns true if the given schedule frequency is supported.
+func IsValidFrequency(frequency string) bool {
+	_, ok := frequencyDays[frequency]
+	return ok
+}
+
+// IsValidReport returns true if the given report key can be exported.
+func IsValidReport(key string) bool {
+	return slices.Contains(Reports, key)
+}
+
+// GetReport returns the data of the report with the given key for the last `days` days.
+func (m *Manager) GetReport(key string, days int) (any, error) {
+	switch key {
+	case ReportOverviewCounts:
+		return m.GetOverViewCounts()
+	case ReportOverviewCharts:
+		return m.GetOverviewChart(days)
+	case ReportOverviewSLA:
+		return m.GetOverviewSLA(days)
+	case ReportOverviewCSAT:
+		return m.GetOverviewCSAT(days)
+	case ReportOverviewMessages:
+		return m.GetOverviewMessageVolume(days)
+	case ReportOverviewTags:
+		return m.GetOverviewTagDistribution(days)
+	case ReportAgents:
+		return m.GetAgentPerformance(days)
+	case ReportTeams:
+		return m.GetTeamPerformance(days)
+	}
+	return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.report}"), nil)
+}
+
+// GetSchedules returns all report schedules.
+func (m *Manager) GetSchedules() ([]models.Schedule, error) {
+	var schedules = make([]models.Schedule, 0)
+	if err := m.q.GetSchedules.Select(&schedules); err != nil {
+		m.lo.Error("error fetching report schedules", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.report}"), nil)
+	}
+	return schedules, nil
+}
+
+// GetSchedule returns a report schedule by ID.
+func (m *Manager) GetSchedule(id int) (models.Schedule, error) {
+	var schedule models.Schedule
+	if err := m.q.GetSchedule.Get(&schedule, id); err != nil {
+		if err == sql.ErrNoRows {
+			return schedule, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.report}"), nil)
+		}
+		m.lo.Error("error fetching report schedule", "id", id, "error", err)
+		return schedule, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.report}"), nil)
+	}
+	return schedule, nil
+}
+
+// CreateSchedule creates a new report schedule, the first report is sent at the next period boundary.
+func (m *Manager) CreateSchedule(schedule models.Schedule) (models.Schedule, error) {
+	var result models.Schedule
+	if err := m.q.InsertSchedule.Get(&result, schedule.Name, schedule.Frequency, pq.Array(schedule.Reports), schedule.Format,
+		pq.Array(schedule.RecipientIDs), schedule.Enabled, nextRunAt(schedule.Frequency, time.Now())); err != nil {
+		m.lo.Error("error inserting report schedule", "error", err)
+		return result, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.report}"), nil)
+	}
+	return result, nil
+}
+
+// UpdateSchedule updates a report schedule by ID, the next run is recomputed only when the frequency changes.
+func (m *Manager) UpdateSchedule(id int, schedule models.Schedule) (models.Schedule, error) {
+	var result models.Schedule
+	if err := m.q.UpdateSchedule.Get(&result, id, schedule.Name, schedule.Frequency, pq.Array(schedule.Reports), schedule.Format,
+		pq.Array(schedule.RecipientIDs), schedule.Enabled, nextRunAt(schedule.Frequency, time.Now())); err != nil {
+		if err == sql.ErrNoRows {
+			return result, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.report}"), nil)
+		}
+		m.lo.Error("error updating report schedule", "id", id, "error", err)
+		return result, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.report}"), nil)
+	}
+	return result, nil
+}
+
+// DeleteSchedule deletes a report schedule by ID.
+func (m *Manager) DeleteSchedule(id int) error {
+	if _, err := m.q.DeleteSchedule.Exec(id); err != nil {
+		m.lo.Error("error deleting report schedule", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.report}"), nil)
+	}
+	return nil
+}
+
+// RunScheduler periodically sends the report schedules that are due.
+func (m *Manager) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sendDueSchedules()
+		}
+	}
+}
+
+// sendDueSchedules sends every due report schedule and moves it to its next run.
+func (m *Manager) sendDueSchedules() {
+	var schedules []models.Schedule
+	if err := m.q.GetDueSchedules.Select(&schedules); err != nil {
+		m.lo.Error("error fetching due report schedules", "error", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		sent := true
+		if err := m.sendSchedule(schedule); err != nil {
+			m.lo.Error("error sending scheduled report", "schedule_id", schedule.ID, "error", err)
+			sent = false
+		}
+		// Move to the next run even on failure so a broken schedule does not retry on every tick,
+		// the last sent time is only set when the report was sent.
+		if _, err := m.q.UpdateScheduleSentAt.Exec(schedule.ID, nextRunAt(schedule.Frequency, time.Now()), sent); err != nil {
+			m.lo.Error("error updating report schedule next run", "schedule_id", schedule.ID, "error", err)
+		}
+	}
+}
+
+// sendSchedule builds the exports of a schedule and emails them to each recipient.
+// It fails if the report could not be emailed to any recipient.
+func (m *Manager) sendSchedule(schedule models.Schedule) error {
+	var (
+		days   = frequencyDays[schedule.Frequency]
+		date   = time.Now().Format("2006-01-02")
+		tables = make([]Table, 0, len(schedule.Reports))
+	)
+	for _, key := range schedule.Reports {
+		data, err := m.GetReport(key, days)
+		if err != nil {
+			return fmt.Errorf("fetching report %s: %w", key, err)
+		}
+		table, err := NewTable(key, data)
+		if err != nil {
+			return fmt.Errorf("building report %s: %w", key, err)
+		}
+		tables = append(tables, table)
+	}
+
+	attachments, err := exportAttachments(schedule.Name, schedule.Format, date, tables)
+	if err != nil {
+		return err
+	}
+
+	summary, err := m.summary()
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, id := range schedule.RecipientIDs {
+		agent, err := m.userStore.GetAgent(int(id), "")
+		if err != nil {
+			m.lo.Error("error fetching scheduled report recipient", "schedule_id", schedule.ID, "user_id", id, "error", err)
+			continue
+		}
+		if !agent.Enabled || agent.Email.String == "" {
+			continue
+		}
+
+		content, subject, err := m.template.RenderStoredEmailTemplate(template.TmplScheduledReport,
+			map[string]any{
+				"Report": map[string]any{
+					"Name":      schedule.Name,
+					"Frequency": schedule.Frequency,
+					"Days":      days,
+					"Date":      date,
+					"Summary":   summary,
+				},
+				"Recipient": map[string]any{
+					"FirstName": agent.FirstName,
+					"LastName":  agent.LastName,
+					"FullName":  agent.FullName(),
+					"Email":     agent.Email.String,
+				},
+			})
+		if err != nil {
+			return fmt.Errorf("rendering template %s: %w", template.TmplScheduledReport, err)
+		}
+
+		if err := m.notifier.Send(notifier.Message{
+			RecipientEmails: []string{agent.Email.String},
+			Subject:         subject,
+			Content:         content,
+			Provider:        notifier.ProviderEmail,
+			Attachments:     attachments,
+		}); err != nil {
+			m.lo.Error("error sending scheduled report email", "schedule_id", schedule.ID, "user_id", id, "error", err)
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return fmt.Errorf("report not emailed to any of the %d recipients", len(schedule.RecipientIDs))
+	}
+	return nil
+}
+
+// summary returns the overview counts listed in the body of scheduled report emails.
+func (m *Manager) summary() ([]summaryItem, error) {
+	counts, err := m.GetOverViewCounts()
+	if err != nil {
+		return nil, err
+	}
+	table, err := NewTable(ReportOverviewCounts, counts)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]summaryItem, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		if len(row) == 2 {
+			items = append(items, summaryItem{Label: row[0], Value: row[1]})
+		}
+	}
+	return items, nil
+}
+
+// exportAttachments renders the report tables as email attachments.
+// XLSX exports are a single workbook with one sheet per report, CSV exports are one file per report.
+func exportAttachments(name, format, date string, tables []Table) ([]attachment.Attachment, error) {
+	var files []attachment.Attachment
+	add := func(filename string, tables ...Table) error {
+		var buf bytes.Buffer
+		if err := Export(&buf, format, tables...); err != nil {
+			return fmt.Errorf("exporting %s: %w", filename, err)
+		}
+		contentType := ContentType(format)
+		files = append(files, attachment.Attachment{
+			Name:        filename,
+			Size:        buf.Len(),
+			Content:     buf.Bytes(),
+			ContentType: contentType,
+			Disposition: attachment.DispositionAttachment,
+			Header:      attachment.MakeHeader(contentType, "", filename, "base64", attachment.DispositionAttachment),
+		})
+		return nil
+	}
+
+	if format == FormatXLSX {
+		if err := add(fmt.Sprintf("%s-%s.%s", name, date, format), tables...); err != nil {
+			return nil, err
+		}
+		return files, nil
+	}
+	for _, t := range tables {
+		if err := add(fmt.Sprintf("%s-%s-%s.%s", name, t.Name, date, format), t); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// nextRunAt returns the start of the period following `from` for the given frequency:
+// the next midnight, the next Monday or the first day of the next month.
+func nextRunAt(frequency string, from time.Time) time.Time {
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	switch frequency {
+	case FrequencyWeekly:
+		offset := (int(time.Monday) - int(day.Weekday()) + 7) % 7
+		if offset == 0 {
+			offset = 7
+		}
+		return day.AddDate(0, 0, offset)
+	case FrequencyMonthly:
+		return time.Date(from.Year(), from.Month()+1, 1, 0, 0, 0, 0, from.Location())
+	default:
+		return day.AddDate(0, 0, 1)
+	}
+}
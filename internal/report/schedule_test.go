@@ -0,0 +1,57 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRunAt(t *testing.T) {
+	// Wednesday.
+	from := time.Date(2024, time.January, 31, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name      string
+		frequency string
+		from      time.Time
+		expected  time.Time
+	}{
+		{
+			name:      "daily runs next midnight",
+			frequency: FrequencyDaily,
+			from:      from,
+			expected:  time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "weekly runs next monday",
+			frequency: FrequencyWeekly,
+			from:      from,
+			expected:  time.Date(2024, time.February, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "weekly on a monday runs the following monday",
+			frequency: FrequencyWeekly,
+			from:      time.Date(2024, time.February, 5, 0, 0, 0, 0, time.UTC),
+			expected:  time.Date(2024, time.February, 12, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "monthly runs first of next month",
+			frequency: FrequencyMonthly,
+			from:      from,
+			expected:  time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "monthly in december runs next year",
+			frequency: FrequencyMonthly,
+			from:      time.Date(2024, time.December, 15, 0, 0, 0, 0, time.UTC),
+			expected:  time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := nextRunAt(tt.frequency, tt.from)
+			if !result.Equal(tt.expected) {
+				t.Errorf("got %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
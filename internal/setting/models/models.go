@@ -1,18 +1,32 @@
 package models
 
 type General struct {
-	SiteName                    string   `json:"app.site_name"`
-	Lang                        string   `json:"app.lang"`
-	MaxFileUploadSize           int      `json:"app.max_file_upload_size"`
-	FaviconURL                  string   `json:"app.favicon_url"`
-	LogoURL                     string   `json:"app.logo_url"`
-	RootURL                     string   `json:"app.root_url"`
-	AllowedFileUploadExtensions []string `json:"app.allowed_file_upload_extensions"`
-	Timezone                    string   `json:"app.timezone"`
-	BusinessHoursID             string   `json:"app.business_hours_id"`
-	PortalEnabled               bool     `json:"app.portal_enabled"`
-	PortalDefaultInboxID        int      `json:"app.portal_default_inbox_id"`
-	OrganizationsEnabled        bool     `json:"app.organizations_enabled"`
+	SiteName                        string   `json:"app.site_name"`
+	Lang                            string   `json:"app.lang"`
+	MaxFileUploadSize               int      `json:"app.max_file_upload_size"`
+	FaviconURL                      string   `json:"app.favicon_url"`
+	LogoURL                         string   `json:"app.logo_url"`
+	RootURL                         string   `json:"app.root_url"`
+	AllowedFileUploadExtensions     []string `json:"app.allowed_file_upload_extensions"`
+	Timezone                        string   `json:"app.timezone"`
+	BusinessHoursID                 string   `json:"app.business_hours_id"`
+	PortalEnabled                   bool     `json:"app.portal_enabled"`
+	PortalDefaultInboxID            int      `json:"app.portal_default_inbox_id"`
+	PortalMagicLinkEnabled          bool     `json:"app.portal_magic_link_enabled"`
+	PortalSelfRegistrationEnabled   bool     `json:"app.portal_self_registration_enabled"`
+	PortalRegistrationDomains       []string `json:"app.portal_registration_domains"`
+	PortalRegistrationOrganizations bool     `json:"app.portal_registration_organizations"`
+	OrganizationsEnabled            bool     `json:"app.organizations_enabled"`
+}
+
+// Portal is the part of the general settings saved from the portal settings page.
+type Portal struct {
+	PortalEnabled                   bool     `json:"app.portal_enabled"`
+	PortalDefaultInboxID            int      `json:"app.portal_default_inbox_id"`
+	PortalMagicLinkEnabled          bool     `json:"app.portal_magic_link_enabled"`
+	PortalSelfRegistrationEnabled   bool     `json:"app.portal_self_registration_enabled"`
+	PortalRegistrationDomains       []string `json:"app.portal_registration_domains"`
+	PortalRegistrationOrganizations bool     `json:"app.portal_registration_organizations"`
 }
 
 type EmailNotification struct {
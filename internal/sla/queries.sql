@@ -148,6 +148,7 @@ SELECT a.id,
    c.reference_number as conversation_reference_number,
    c.subject as conversation_subject,
    c.assigned_user_id as conversation_assigned_user_id,
+   c.assigned_team_id as conversation_assigned_team_id,
    c.status_id as conversation_status_id,
    s.name as conversation_status
 FROM applied_slas a INNER JOIN conversations c on a.conversation_id = c.id
@@ -8,9 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	businesshours "github.com/ghotso/libredesk/internal/business_hours"
 	bmodels "github.com/ghotso/libredesk/internal/business_hours/models"
 	"github.com/ghotso/libredesk/internal/dbutil"
@@ -45,6 +47,10 @@ const (
 
 	NotificationTypeWarning = "warning"
 	NotificationTypeBreach  = "breach"
+
+	// Notification recipients that are alerted in a team chat channel instead of an agent.
+	RecipientAssignedTeam = "assigned_team"
+	RecipientTeamPrefix   = "team:"
 )
 
 var metricLabels = map[string]string{
@@ -468,9 +474,11 @@ func (m *Manager) runSLAEvaluation(ctx context.Context, interval time.Duration)
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			start := time.Now()
 			if err := m.evaluatePendingSLAs(ctx); err != nil {
 				m.lo.Error("error processing pending SLAs", "error", err)
 			}
+			metrics.GetOrCreateSummary(`libredesk_sla_evaluation_duration_seconds{type="sla"}`).UpdateDuration(start)
 		}
 	}
 }
@@ -486,9 +494,11 @@ func (m *Manager) runSLAEventEvaluation(ctx context.Context, interval time.Durat
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			start := time.Now()
 			if err := m.evaluatePendingSLAEvents(ctx); err != nil {
 				m.lo.Error("error marking SLA events as breached", "error", err)
 			}
+			metrics.GetOrCreateSummary(`libredesk_sla_evaluation_duration_seconds{type="event"}`).UpdateDuration(start)
 		}
 	}
 }
@@ -589,6 +599,15 @@ func (m *Manager) SendNotification(scheduledNotification models.ScheduledSLANoti
 			continue
 		}
 
+		// Team recipients are alerted in the chat channel of the team.
+		if teamID, ok := teamRecipientID(recipientS, appliedSLA); ok {
+			m.sendTeamNotification(teamID, scheduledNotification, appliedSLA, slaEvent)
+			if _, err := m.q.UpdateSLANotificationProcessed.Exec(scheduledNotification.ID); err != nil {
+				m.lo.Error("error marking notification as processed", "error", err)
+			}
+			continue
+		}
+
 		// Get recipient agent, recipient can be a specific agent or assigned user.
 		recipientID, err := strconv.Atoi(recipientS)
 		if recipientS == "assigned_user" {
@@ -615,11 +634,8 @@ func (m *Manager) SendNotification(scheduledNotification models.ScheduledSLANoti
 			continue
 		}
 
-		var (
-			dueIn, overdueBy string
-			tmpl             string
-		)
 		// Set the template based on the notification type.
+		var tmpl string
 		switch scheduledNotification.NotificationType {
 		case NotificationTypeBreach:
 			tmpl = template.TmplSLABreached
@@ -630,36 +646,11 @@ func (m *Manager) SendNotification(scheduledNotification models.ScheduledSLANoti
 			return fmt.Errorf("unknown notification type: %s", scheduledNotification.NotificationType)
 		}
 
-		// Set the dueIn and overdueBy values based on the metric.
-		// These are relative to the current time as setting exact time would require agent's timezone.
-		getFriendlyDuration := func(target time.Time) string {
-			d := time.Until(target)
-			if d < 0 {
-				return stringutil.FormatDuration(-d, false)
-			}
-			return stringutil.FormatDuration(d, false)
-		}
-
-		switch scheduledNotification.Metric {
-		case MetricFirstResponse:
-			dueIn = getFriendlyDuration(appliedSLA.FirstResponseDeadlineAt.Time)
-			overdueBy = getFriendlyDuration(appliedSLA.FirstResponseBreachedAt.Time)
-		case MetricResolution:
-			dueIn = getFriendlyDuration(appliedSLA.ResolutionDeadlineAt.Time)
-			overdueBy = getFriendlyDuration(appliedSLA.ResolutionBreachedAt.Time)
-		case MetricNextResponse:
-			dueIn = getFriendlyDuration(slaEvent.DeadlineAt)
-			overdueBy = getFriendlyDuration(slaEvent.BreachedAt.Time)
-		default:
-			m.lo.Error("unknown metric type", "metric", scheduledNotification.Metric)
-			return fmt.Errorf("unknown metric type: %s", scheduledNotification.Metric)
-		}
-
-		// Set the metric label.
-		var metricLabel string
-		if label, ok := metricLabels[scheduledNotification.Metric]; ok {
-			metricLabel = label
+		text, err := m.notificationText(scheduledNotification, appliedSLA, slaEvent)
+		if err != nil {
+			return err
 		}
+		dueIn, overdueBy, metricLabel := text.dueIn, text.overdueBy, text.metricLabel
 
 		// Render the email template.
 		content, subject, err := m.template.RenderStoredEmailTemplate(tmpl,
@@ -703,22 +694,12 @@ func (m *Manager) SendNotification(scheduledNotification models.ScheduledSLANoti
 			notifType = nmodels.NotificationTypeSLAWarning
 		}
 
-		notificationTitle := fmt.Sprintf("SLA %s: %s for #%s",
-			scheduledNotification.NotificationType, metricLabel, appliedSLA.ConversationReferenceNumber)
-
-		var notificationBody string
-		if scheduledNotification.NotificationType == NotificationTypeBreach {
-			notificationBody = fmt.Sprintf("Overdue by %s", overdueBy)
-		} else {
-			notificationBody = fmt.Sprintf("Due in %s", dueIn)
-		}
-
 		// Send notification via dispatcher (handles in-app, WebSocket, and email).
 		m.dispatcher.Send(notifier.Notification{
 			Type:             notifType,
 			RecipientIDs:     []int{recipientID},
-			Title:            notificationTitle,
-			Body:             null.StringFrom(notificationBody),
+			Title:            text.title,
+			Body:             null.StringFrom(text.body),
 			ConversationID:   null.IntFrom(appliedSLA.ConversationID),
 			ConversationUUID: appliedSLA.ConversationUUID,
 			Email: &notifier.EmailNotification{
@@ -736,6 +717,104 @@ func (m *Manager) SendNotification(scheduledNotification models.ScheduledSLANoti
 	return nil
 }
 
+// slaNotificationText holds the human readable parts of a SLA notification.
+type slaNotificationText struct {
+	dueIn       string
+	overdueBy   string
+	metricLabel string
+	title       string
+	body        string
+}
+
+// notificationText returns the due in and overdue by durations, title and body of a scheduled SLA notification.
+func (m *Manager) notificationText(scheduledNotification models.ScheduledSLANotification, appliedSLA models.AppliedSLA, slaEvent models.SLAEvent) (slaNotificationText, error) {
+	var text slaNotificationText
+
+	// Set the dueIn and overdueBy values based on the metric.
+	// These are relative to the current time as setting exact time would require agent's timezone.
+	getFriendlyDuration := func(target time.Time) string {
+		d := time.Until(target)
+		if d < 0 {
+			return stringutil.FormatDuration(-d, false)
+		}
+		return stringutil.FormatDuration(d, false)
+	}
+
+	switch scheduledNotification.Metric {
+	case MetricFirstResponse:
+		text.dueIn = getFriendlyDuration(appliedSLA.FirstResponseDeadlineAt.Time)
+		text.overdueBy = getFriendlyDuration(appliedSLA.FirstResponseBreachedAt.Time)
+	case MetricResolution:
+		text.dueIn = getFriendlyDuration(appliedSLA.ResolutionDeadlineAt.Time)
+		text.overdueBy = getFriendlyDuration(appliedSLA.ResolutionBreachedAt.Time)
+	case MetricNextResponse:
+		text.dueIn = getFriendlyDuration(slaEvent.DeadlineAt)
+		text.overdueBy = getFriendlyDuration(slaEvent.BreachedAt.Time)
+	default:
+		m.lo.Error("unknown metric type", "metric", scheduledNotification.Metric)
+		return text, fmt.Errorf("unknown metric type: %s", scheduledNotification.Metric)
+	}
+
+	// Set the metric label.
+	if label, ok := metricLabels[scheduledNotification.Metric]; ok {
+		text.metricLabel = label
+	}
+
+	text.title = fmt.Sprintf("SLA %s: %s for #%s",
+		scheduledNotification.NotificationType, text.metricLabel, appliedSLA.ConversationReferenceNumber)
+	if scheduledNotification.NotificationType == NotificationTypeBreach {
+		text.body = fmt.Sprintf("Overdue by %s", text.overdueBy)
+	} else {
+		text.body = fmt.Sprintf("Due in %s", text.dueIn)
+	}
+	return text, nil
+}
+
+// teamRecipientID returns the team ID of a `team:<id>` or `assigned_team` notification recipient.
+func teamRecipientID(recipient string, appliedSLA models.AppliedSLA) (int, bool) {
+	if recipient == RecipientAssignedTeam {
+		return appliedSLA.ConversationAssignedTeamID.Int, true
+	}
+	idS, ok := strings.CutPrefix(recipient, RecipientTeamPrefix)
+	if !ok {
+		return 0, false
+	}
+	id, _ := strconv.Atoi(idS)
+	return id, true
+}
+
+// sendTeamNotification posts a SLA notification to the chat channel of a team, teams without a channel are skipped.
+func (m *Manager) sendTeamNotification(teamID int, scheduledNotification models.ScheduledSLANotification, appliedSLA models.AppliedSLA, slaEvent models.SLAEvent) {
+	if teamID == 0 {
+		return
+	}
+	team, err := m.teamStore.Get(teamID)
+	if err != nil {
+		m.lo.Error("error fetching team for SLA notification", "team_id", teamID, "error", err)
+		return
+	}
+	if !team.ChatProvider.Valid || team.ChatWebhookURL.String == "" {
+		m.lo.Debug("skipping SLA notification as team has no chat channel", "team_id", teamID)
+		return
+	}
+	text, err := m.notificationText(scheduledNotification, appliedSLA, slaEvent)
+	if err != nil {
+		return
+	}
+
+	notifType := nmodels.NotificationTypeSLAWarning
+	if scheduledNotification.NotificationType == NotificationTypeBreach {
+		notifType = nmodels.NotificationTypeSLABreach
+	}
+	m.dispatcher.SendToChannel(team.ChatProvider.String, team.ChatWebhookURL.String, notifier.Notification{
+		Type:             notifType,
+		Title:            text.title,
+		Body:             null.StringFrom(text.body),
+		ConversationID:   null.IntFrom(appliedSLA.ConversationID),
+		ConversationUUID: appliedSLA.ConversationUUID,
+	})
+}
+
 // Close closes the SLA evaluation loop by stopping the worker pool.
 func (m *Manager) Close() error {
 	m.wg.Wait()
@@ -188,6 +188,15 @@ func ValidEmail(email string) bool {
 	return addr.Name == "" && addr.Address == email
 }
 
+// EmailDomain returns the lowercased domain of an email address, or an empty string if it has none.
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
 // ExtractEmail extracts the email address from a string.
 func ExtractEmail(s string) (string, error) {
 	addr, err := mail.ParseAddress(s)
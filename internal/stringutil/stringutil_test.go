@@ -375,3 +375,23 @@ func TestExtractReferenceNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestEmailDomain(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"jane@example.com", "example.com"},
+		{"Jane@Example.COM", "example.com"},
+		{"jane@support.example.com", "support.example.com"},
+		{"jane+tag@example.com", "example.com"},
+		{"jane@", ""},
+		{"example.com", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := EmailDomain(tt.email); got != tt.want {
+			t.Errorf("EmailDomain(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
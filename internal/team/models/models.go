@@ -20,6 +20,8 @@ type Team struct {
 	BusinessHoursID              null.Int    `db:"business_hours_id" json:"business_hours_id"`
 	SLAPolicyID                  null.Int    `db:"sla_policy_id" json:"sla_policy_id"`
 	MaxAutoAssignedConversations int         `db:"max_auto_assigned_conversations" json:"max_auto_assigned_conversations"`
+	ChatProvider                 null.String `db:"chat_provider" json:"chat_provider"`
+	ChatWebhookURL               null.String `db:"chat_webhook_url" json:"chat_webhook_url"`
 }
 
 type TeamCompact struct {
@@ -15,10 +15,13 @@ const (
 	TmplSLABreachWarning     = "SLA breach warning"
 	TmplSLABreached          = "SLA breached"
 	TmplMentioned            = "Mentioned in conversation"
+	TmplScheduledReport      = "Scheduled report"
+	TmplNotificationDigest   = "Notification digest"
 
 	// Built-in templates fetched from memory stored in `static` directory.
 	TmplResetPassword       = "reset-password"
 	TmplPortalResetPassword = "portal-reset-password"
+	TmplPortalMagicLink     = "portal-magic-link"
 	TmplWelcome             = "welcome"
 
 	// Template names for rendering.
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
+
+// TicketForm is a form contacts fill in to create a conversation in its inbox from the portal.
+type TicketForm struct {
+	ID          int        `db:"id" json:"id"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	Name        string     `db:"name" json:"name"`
+	Description string     `db:"description" json:"description"`
+	InboxID     int        `db:"inbox_id" json:"inbox_id"`
+	Enabled     bool       `db:"enabled" json:"enabled"`
+	Fields      FormFields `db:"fields" json:"fields"`
+}
+
+type FormFields []FormField
+
+// Value implements the driver.Valuer interface.
+func (f FormFields) Value() (driver.Value, error) {
+	if f == nil {
+		f = FormFields{}
+	}
+	return json.Marshal(f)
+}
+
+// Scan implements the sql.Scanner interface.
+func (f *FormFields) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported type: %T", src)
+	}
+	return json.Unmarshal(data, f)
+}
+
+// FormField is a conversation custom attribute asked for in a ticket form, in the order of the form.
+type FormField struct {
+	CustomAttributeID int    `json:"custom_attribute_id"`
+	HelpText          string `json:"help_text"`
+	Required          bool   `json:"required"`
+	// Condition shows the field only when an earlier field of the form has one of the values.
+	Condition *FieldCondition `json:"condition"`
+}
+
+// FieldCondition is the condition a field is shown on.
+type FieldCondition struct {
+	CustomAttributeID int      `json:"custom_attribute_id"`
+	Values            []string `json:"values"`
+}
+
+// PortalTicketForm is a ticket form as shown to contacts in the portal.
+type PortalTicketForm struct {
+	ID          int           `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Fields      []PortalField `json:"fields"`
+}
+
+// PortalField is a ticket form field with the definition of its custom attribute.
+type PortalField struct {
+	Key       string         `json:"key"`
+	Name      string         `json:"name"`
+	HelpText  string         `json:"help_text"`
+	Required  bool           `json:"required"`
+	DataType  string         `json:"data_type"`
+	Values    pq.StringArray `json:"values"`
+	Regex     string         `json:"regex"`
+	RegexHint string         `json:"regex_hint"`
+	MinValue  null.Float64   `json:"min_value"`
+	MaxValue  null.Float64   `json:"max_value"`
+	// Condition is the key and values of the earlier field the field is shown on.
+	Condition *PortalCondition `json:"condition"`
+}
+
+// PortalCondition is the condition a field is shown on in the portal.
+type PortalCondition struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
@@ -0,0 +1,44 @@
+-- name: get-all-ticket-forms
+SELECT id,
+    created_at,
+    updated_at,
+    "name",
+    description,
+    inbox_id,
+    enabled,
+    fields
+FROM ticket_forms
+WHERE CASE WHEN $1 THEN enabled ELSE TRUE END
+ORDER BY "name";
+
+-- name: get-ticket-form
+SELECT id,
+    created_at,
+    updated_at,
+    "name",
+    description,
+    inbox_id,
+    enabled,
+    fields
+FROM ticket_forms
+WHERE id = $1;
+
+-- name: insert-ticket-form
+INSERT INTO ticket_forms ("name", description, inbox_id, enabled, fields)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: update-ticket-form
+UPDATE ticket_forms
+SET "name" = $2,
+    description = $3,
+    inbox_id = $4,
+    enabled = $5,
+    fields = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: delete-ticket-form
+DELETE FROM ticket_forms
+WHERE id = $1;
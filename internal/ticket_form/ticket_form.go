@@ -0,0 +1,313 @@
+// Package ticketform handles the ticket forms contacts fill in to create conversations from the portal.
+package ticketform
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	customAttribute "github.com/ghotso/libredesk/internal/custom_attribute"
+	camodels "github.com/ghotso/libredesk/internal/custom_attribute/models"
+	"github.com/ghotso/libredesk/internal/dbutil"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/ticket_form/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+const (
+	maxNameLength        = 140
+	maxDescriptionLength = 1000
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+)
+
+type Manager struct {
+	q                    queries
+	lo                   *logf.Logger
+	i18n                 *i18n.I18n
+	customAttributeStore customAttributeStore
+}
+
+type customAttributeStore interface {
+	GetAll(appliesTo string) ([]camodels.CustomAttribute, error)
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB                   *sqlx.DB
+	Lo                   *logf.Logger
+	I18n                 *i18n.I18n
+	CustomAttributeStore customAttributeStore
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetAllTicketForms *sqlx.Stmt `query:"get-all-ticket-forms"`
+	GetTicketForm     *sqlx.Stmt `query:"get-ticket-form"`
+	InsertTicketForm  *sqlx.Stmt `query:"insert-ticket-form"`
+	UpdateTicketForm  *sqlx.Stmt `query:"update-ticket-form"`
+	DeleteTicketForm  *sqlx.Stmt `query:"delete-ticket-form"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:                    q,
+		lo:                   opts.Lo,
+		i18n:                 opts.I18n,
+		customAttributeStore: opts.CustomAttributeStore,
+	}, nil
+}
+
+// GetAll retrieves all ticket forms, or only the enabled ones if enabledOnly is set.
+func (m *Manager) GetAll(enabledOnly bool) ([]models.TicketForm, error) {
+	var forms = make([]models.TicketForm, 0)
+	if err := m.q.GetAllTicketForms.Select(&forms, enabledOnly); err != nil {
+		m.lo.Error("error fetching ticket forms", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.ticketForm")), nil)
+	}
+	return forms, nil
+}
+
+// Get retrieves a ticket form by ID.
+func (m *Manager) Get(id int) (models.TicketForm, error) {
+	var form models.TicketForm
+	if err := m.q.GetTicketForm.Get(&form, id); err != nil {
+		if err == sql.ErrNoRows {
+			return form, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.ticketForm}"), nil)
+		}
+		m.lo.Error("error fetching ticket form", "id", id, "error", err)
+		return form, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.ticketForm}"), nil)
+	}
+	return form, nil
+}
+
+// Create creates a new ticket form.
+func (m *Manager) Create(form models.TicketForm) (models.TicketForm, error) {
+	if err := m.validate(&form); err != nil {
+		return models.TicketForm{}, err
+	}
+	var created models.TicketForm
+	if err := m.q.InsertTicketForm.Get(&created, form.Name, form.Description, form.InboxID, form.Enabled, form.Fields); err != nil {
+		m.lo.Error("error inserting ticket form", "error", err)
+		return models.TicketForm{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.ticketForm}"), nil)
+	}
+	return created, nil
+}
+
+// Update updates a ticket form by ID.
+func (m *Manager) Update(id int, form models.TicketForm) (models.TicketForm, error) {
+	if err := m.validate(&form); err != nil {
+		return models.TicketForm{}, err
+	}
+	var updated models.TicketForm
+	if err := m.q.UpdateTicketForm.Get(&updated, id, form.Name, form.Description, form.InboxID, form.Enabled, form.Fields); err != nil {
+		if err == sql.ErrNoRows {
+			return models.TicketForm{}, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.ticketForm}"), nil)
+		}
+		m.lo.Error("error updating ticket form", "id", id, "error", err)
+		return models.TicketForm{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.ticketForm}"), nil)
+	}
+	return updated, nil
+}
+
+// Delete deletes a ticket form by ID.
+func (m *Manager) Delete(id int) error {
+	if _, err := m.q.DeleteTicketForm.Exec(id); err != nil {
+		m.lo.Error("error deleting ticket form", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.ticketForm}"), nil)
+	}
+	return nil
+}
+
+// GetPortalForms returns the enabled ticket forms with the definitions of their fields for the portal,
+// fields whose custom attribute was deleted are left out.
+func (m *Manager) GetPortalForms() ([]models.PortalTicketForm, error) {
+	forms, err := m.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := m.conversationAttributes()
+	if err != nil {
+		return nil, err
+	}
+	var portalForms = make([]models.PortalTicketForm, 0, len(forms))
+	for _, form := range forms {
+		portalForm := models.PortalTicketForm{
+			ID:          form.ID,
+			Name:        form.Name,
+			Description: form.Description,
+			Fields:      make([]models.PortalField, 0, len(form.Fields)),
+		}
+		for _, field := range form.Fields {
+			attr, ok := attrs[field.CustomAttributeID]
+			if !ok {
+				continue
+			}
+			portalField := models.PortalField{
+				Key:       attr.Key,
+				Name:      attr.Name,
+				HelpText:  field.HelpText,
+				Required:  field.Required,
+				DataType:  attr.DataType,
+				Values:    attr.Values,
+				Regex:     attr.Regex,
+				RegexHint: attr.RegexHint,
+				MinValue:  attr.MinValue,
+				MaxValue:  attr.MaxValue,
+			}
+			if field.Condition != nil {
+				if on, ok := attrs[field.Condition.CustomAttributeID]; ok {
+					portalField.Condition = &models.PortalCondition{Key: on.Key, Values: field.Condition.Values}
+				}
+			}
+			portalForm.Fields = append(portalForm.Fields, portalField)
+		}
+		portalForms = append(portalForms, portalForm)
+	}
+	return portalForms, nil
+}
+
+// GetSubmission returns an enabled ticket form and the values submitted for its visible fields, values of hidden fields
+// and of attributes not on the form are dropped and visible required fields must be set.
+func (m *Manager) GetSubmission(id int, values map[string]any) (models.TicketForm, map[string]any, error) {
+	form, err := m.Get(id)
+	if err != nil {
+		return form, nil, err
+	}
+	if !form.Enabled {
+		return form, nil, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.ticketForm}"), nil)
+	}
+	attrs, err := m.conversationAttributes()
+	if err != nil {
+		return form, nil, err
+	}
+	submitted, missing := submittedValues(form.Fields, attrs, values)
+	if len(missing) > 0 {
+		return form, nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.required", "name", strings.Join(missing, ", ")), nil)
+	}
+	return form, submitted, nil
+}
+
+// validate validates a ticket form and its fields.
+func (m *Manager) validate(form *models.TicketForm) error {
+	form.Name = strings.TrimSpace(form.Name)
+	if form.Name == "" {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`name`"), nil)
+	}
+	if len([]rune(form.Name)) > maxNameLength {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.tooLong", "name", "`name`", "max", fmt.Sprint(maxNameLength)), nil)
+	}
+	if len([]rune(form.Description)) > maxDescriptionLength {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.tooLong", "name", "`description`", "max", fmt.Sprint(maxDescriptionLength)), nil)
+	}
+	if form.InboxID <= 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`inbox_id`"), nil)
+	}
+	if form.Fields == nil {
+		form.Fields = models.FormFields{}
+	}
+
+	attrs, err := m.conversationAttributes()
+	if err != nil {
+		return err
+	}
+	var seen []int
+	for _, field := range form.Fields {
+		attr, ok := attrs[field.CustomAttributeID]
+		if !ok {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`custom_attribute_id`"), nil)
+		}
+		// Contacts can't be shown the records lookups refer to.
+		if attr.DataType == camodels.DataTypeLookup {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("admin.ticketForms.lookupNotAllowed", "name", attr.Name), nil)
+		}
+		if slices.Contains(seen, field.CustomAttributeID) {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("admin.ticketForms.duplicateField", "name", attr.Name), nil)
+		}
+		// Conditions depend on earlier fields so that they can't loop.
+		if field.Condition != nil {
+			if !slices.Contains(seen, field.Condition.CustomAttributeID) {
+				return envelope.NewError(envelope.InputError, m.i18n.Ts("admin.ticketForms.conditionOrder", "name", attr.Name), nil)
+			}
+			if len(field.Condition.Values) == 0 {
+				return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`condition.values`"), nil)
+			}
+		}
+		seen = append(seen, field.CustomAttributeID)
+	}
+	return nil
+}
+
+// conversationAttributes returns the conversation custom attributes by ID.
+func (m *Manager) conversationAttributes() (map[int]camodels.CustomAttribute, error) {
+	attrs, err := m.customAttributeStore.GetAll(camodels.AppliesToConversation)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]camodels.CustomAttribute, len(attrs))
+	for _, attr := range attrs {
+		byID[attr.ID] = attr
+	}
+	return byID, nil
+}
+
+// submittedValues returns the values of the visible fields of a form and the names of the visible required fields
+// that are not set, fields are visible when they have no condition or their condition is met.
+func submittedValues(fields models.FormFields, attrs map[int]camodels.CustomAttribute, values map[string]any) (map[string]any, []string) {
+	var (
+		submitted = map[string]any{}
+		missing   []string
+	)
+	for _, field := range fields {
+		attr, ok := attrs[field.CustomAttributeID]
+		if !ok {
+			continue
+		}
+		if field.Condition != nil {
+			on, ok := attrs[field.Condition.CustomAttributeID]
+			if !ok || !conditionMet(submitted[on.Key], field.Condition.Values) {
+				continue
+			}
+		}
+		value := values[attr.Key]
+		if customAttribute.IsEmpty(value) {
+			if field.Required {
+				missing = append(missing, attr.Name)
+			}
+			continue
+		}
+		submitted[attr.Key] = value
+	}
+	return submitted, missing
+}
+
+// conditionMet returns true if a field value is one of the values, multi-select values need one of theirs to be.
+func conditionMet(value any, values []string) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case []any:
+		for _, item := range v {
+			if conditionMet(item, values) {
+				return true
+			}
+		}
+		return false
+	case string:
+		return slices.Contains(values, v)
+	default:
+		return slices.Contains(values, fmt.Sprint(v))
+	}
+}
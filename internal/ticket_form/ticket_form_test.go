@@ -0,0 +1,87 @@
+package ticketform
+
+import (
+	"reflect"
+	"testing"
+
+	camodels "github.com/ghotso/libredesk/internal/custom_attribute/models"
+	"github.com/ghotso/libredesk/internal/ticket_form/models"
+)
+
+func TestSubmittedValues(t *testing.T) {
+	attrs := map[int]camodels.CustomAttribute{
+		1: {ID: 1, Key: "product", Name: "Product"},
+		2: {ID: 2, Key: "order_id", Name: "Order ID"},
+		3: {ID: 3, Key: "serial", Name: "Serial"},
+		4: {ID: 4, Key: "notes", Name: "Notes"},
+	}
+	fields := models.FormFields{
+		{CustomAttributeID: 1, Required: true},
+		{CustomAttributeID: 2, Required: true, Condition: &models.FieldCondition{CustomAttributeID: 1, Values: []string{"shop"}}},
+		{CustomAttributeID: 3, Required: true, Condition: &models.FieldCondition{CustomAttributeID: 2, Values: []string{"42"}}},
+		{CustomAttributeID: 4},
+		// The attribute was deleted.
+		{CustomAttributeID: 5, Required: true},
+	}
+
+	tests := []struct {
+		name        string
+		values      map[string]any
+		wantValues  map[string]any
+		wantMissing []string
+	}{
+		{
+			name:        "required field missing",
+			values:      map[string]any{"notes": "hi"},
+			wantValues:  map[string]any{"notes": "hi"},
+			wantMissing: []string{"Product"},
+		},
+		{
+			name:       "hidden fields and unknown keys are dropped",
+			values:     map[string]any{"product": "app", "order_id": "42", "serial": "x", "other": "y"},
+			wantValues: map[string]any{"product": "app"},
+		},
+		{
+			name:        "visible required field missing",
+			values:      map[string]any{"product": "shop"},
+			wantValues:  map[string]any{"product": "shop"},
+			wantMissing: []string{"Order ID"},
+		},
+		{
+			name:       "chained conditions",
+			values:     map[string]any{"product": "shop", "order_id": 42.0, "serial": "x"},
+			wantValues: map[string]any{"product": "shop", "order_id": 42.0, "serial": "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotValues, gotMissing := submittedValues(fields, attrs, tt.values)
+			if !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("submittedValues() values = %v, want %v", gotValues, tt.wantValues)
+			}
+			if !reflect.DeepEqual(gotMissing, tt.wantMissing) {
+				t.Errorf("submittedValues() missing = %v, want %v", gotMissing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestConditionMet(t *testing.T) {
+	tests := []struct {
+		value any
+		want  bool
+	}{
+		{nil, false},
+		{"a", true},
+		{"c", false},
+		{[]any{"c", "b"}, true},
+		{[]any{"c"}, false},
+		{true, true},
+		{false, false},
+	}
+	for _, tt := range tests {
+		if got := conditionMet(tt.value, []string{"a", "b", "true"}); got != tt.want {
+			t.Errorf("conditionMet(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Time entry sources.
+const (
+	SourceManual    = "manual"
+	SourceAutomatic = "automatic"
+)
+
+// TimeEntry is time an agent spent on a conversation.
+type TimeEntry struct {
+	ID              int       `db:"id" json:"id"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+	ConversationID  int       `db:"conversation_id" json:"conversation_id"`
+	UserID          int       `db:"user_id" json:"user_id"`
+	Source          string    `db:"source" json:"source"`
+	DurationSeconds int       `db:"duration_seconds" json:"duration_seconds"`
+	Note            string    `db:"note" json:"note"`
+	Billable        bool      `db:"billable" json:"billable"`
+	StartedAt       time.Time `db:"started_at" json:"started_at"`
+	UserFirstName   string    `db:"user_first_name" json:"user_first_name"`
+	UserLastName    string    `db:"user_last_name" json:"user_last_name"`
+}
+
+// ConversationTime is the time entries of a conversation with their totals.
+type ConversationTime struct {
+	Entries         []TimeEntry `json:"entries"`
+	TotalSeconds    int         `json:"total_seconds"`
+	BillableSeconds int         `json:"billable_seconds"`
+}
@@ -0,0 +1,73 @@
+-- name: get-time-entries
+SELECT
+    t.id,
+    t.created_at,
+    t.updated_at,
+    t.conversation_id,
+    t.user_id,
+    t.source,
+    t.duration_seconds,
+    t.note,
+    t.billable,
+    t.started_at,
+    u.first_name AS user_first_name,
+    u.last_name AS user_last_name
+FROM
+    conversation_time_entries t
+    INNER JOIN conversations c ON c.id = t.conversation_id
+    INNER JOIN users u ON u.id = t.user_id
+WHERE
+    c.uuid = $1
+ORDER BY
+    t.started_at DESC;
+
+-- name: get-time-entry
+SELECT
+    t.id,
+    t.created_at,
+    t.updated_at,
+    t.conversation_id,
+    t.user_id,
+    t.source,
+    t.duration_seconds,
+    t.note,
+    t.billable,
+    t.started_at,
+    u.first_name AS user_first_name,
+    u.last_name AS user_last_name
+FROM
+    conversation_time_entries t
+    INNER JOIN conversations c ON c.id = t.conversation_id
+    INNER JOIN users u ON u.id = t.user_id
+WHERE
+    c.uuid = $1
+    AND t.id = $2;
+
+-- name: insert-time-entry
+-- $7 is the start of the tracked work, the entry is created as started now when it is NULL.
+INSERT INTO
+    conversation_time_entries (conversation_id, user_id, source, duration_seconds, note, billable, started_at)
+SELECT
+    c.id, $2, $3, $4, $5, $6, COALESCE($7, NOW())
+FROM
+    conversations c
+WHERE
+    c.uuid = $1
+RETURNING id;
+
+-- name: update-time-entry
+UPDATE
+    conversation_time_entries
+SET
+    duration_seconds = $2,
+    note = $3,
+    billable = $4,
+    updated_at = NOW()
+WHERE
+    id = $1;
+
+-- name: delete-time-entry
+DELETE FROM
+    conversation_time_entries
+WHERE
+    id = $1;
@@ -0,0 +1,179 @@
+// Package timetracking handles the time agents spend on conversations, entered manually or
+// tracked automatically while a conversation is open.
+package timetracking
+
+import (
+	"database/sql"
+	"embed"
+	"strconv"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/dbutil"
+	"github.com/ghotso/libredesk/internal/envelope"
+	"github.com/ghotso/libredesk/internal/timetracking/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+)
+
+const (
+	maxNoteLength = 1000
+
+	// Automatic sessions shorter than this, a conversation opened in passing, are not recorded.
+	minAutomaticDuration = 30 * time.Second
+)
+
+// Manager handles time entries.
+type Manager struct {
+	q    queries
+	lo   *logf.Logger
+	i18n *i18n.I18n
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetTimeEntries  *sqlx.Stmt `query:"get-time-entries"`
+	GetTimeEntry    *sqlx.Stmt `query:"get-time-entry"`
+	InsertTimeEntry *sqlx.Stmt `query:"insert-time-entry"`
+	UpdateTimeEntry *sqlx.Stmt `query:"update-time-entry"`
+	DeleteTimeEntry *sqlx.Stmt `query:"delete-time-entry"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:    q,
+		lo:   opts.Lo,
+		i18n: opts.I18n,
+	}, nil
+}
+
+// GetConversationTime returns the time entries of a conversation with their totals.
+func (m *Manager) GetConversationTime(conversationUUID string) (models.ConversationTime, error) {
+	var entries = make([]models.TimeEntry, 0)
+	if err := m.q.GetTimeEntries.Select(&entries, conversationUUID); err != nil {
+		m.lo.Error("error fetching time entries", "conversation_uuid", conversationUUID, "error", err)
+		return models.ConversationTime{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.timeEntry}"), nil)
+	}
+	return totalTime(entries), nil
+}
+
+// GetEntry returns a time entry of a conversation.
+func (m *Manager) GetEntry(conversationUUID string, id int) (models.TimeEntry, error) {
+	var entry models.TimeEntry
+	if err := m.q.GetTimeEntry.Get(&entry, conversationUUID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return entry, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.timeEntry}"), nil)
+		}
+		m.lo.Error("error fetching time entry", "id", id, "error", err)
+		return entry, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.timeEntry}"), nil)
+	}
+	return entry, nil
+}
+
+// CreateEntry adds a manual time entry of an agent to a conversation, the work started now if startedAt is zero.
+func (m *Manager) CreateEntry(conversationUUID string, userID, durationSeconds int, note string, billable bool, startedAt time.Time) (models.TimeEntry, error) {
+	if err := m.validate(durationSeconds, note); err != nil {
+		return models.TimeEntry{}, err
+	}
+	id, err := m.insert(conversationUUID, userID, models.SourceManual, durationSeconds, note, billable, null.NewTime(startedAt, !startedAt.IsZero()))
+	if err != nil {
+		return models.TimeEntry{}, err
+	}
+	return m.GetEntry(conversationUUID, id)
+}
+
+// UpdateEntry updates the duration, note and billable flag of a time entry.
+func (m *Manager) UpdateEntry(conversationUUID string, id, durationSeconds int, note string, billable bool) (models.TimeEntry, error) {
+	if err := m.validate(durationSeconds, note); err != nil {
+		return models.TimeEntry{}, err
+	}
+	if _, err := m.q.UpdateTimeEntry.Exec(id, durationSeconds, note, billable); err != nil {
+		m.lo.Error("error updating time entry", "id", id, "error", err)
+		return models.TimeEntry{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.timeEntry}"), nil)
+	}
+	return m.GetEntry(conversationUUID, id)
+}
+
+// DeleteEntry deletes a time entry.
+func (m *Manager) DeleteEntry(id int) error {
+	if _, err := m.q.DeleteTimeEntry.Exec(id); err != nil {
+		m.lo.Error("error deleting time entry", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.timeEntry}"), nil)
+	}
+	return nil
+}
+
+// RecordTime records the time an agent had a conversation open as an automatic, non-billable time entry.
+// It is called by the websocket hub when the agent's automatically timed session of the conversation ends.
+func (m *Manager) RecordTime(conversationUUID string, userID int, start, end time.Time) {
+	seconds, ok := automaticDuration(start, end)
+	if !ok {
+		return
+	}
+	if _, err := m.insert(conversationUUID, userID, models.SourceAutomatic, seconds, "", false, null.TimeFrom(start)); err != nil {
+		m.lo.Error("error recording automatic time entry", "conversation_uuid", conversationUUID, "user_id", userID, "error", err)
+	}
+}
+
+// insert inserts a time entry and returns its ID.
+func (m *Manager) insert(conversationUUID string, userID int, source string, durationSeconds int, note string, billable bool, startedAt null.Time) (int, error) {
+	var id int
+	if err := m.q.InsertTimeEntry.Get(&id, conversationUUID, userID, source, durationSeconds, note, billable, startedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
+		}
+		m.lo.Error("error inserting time entry", "conversation_uuid", conversationUUID, "error", err)
+		return 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.timeEntry}"), nil)
+	}
+	return id, nil
+}
+
+// validate validates the duration and note of a time entry.
+func (m *Manager) validate(durationSeconds int, note string) error {
+	if durationSeconds <= 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`duration_seconds`"), nil)
+	}
+	if len(note) > maxNoteLength {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.tooLong", "name", "`note`", "max", strconv.Itoa(maxNoteLength)), nil)
+	}
+	return nil
+}
+
+// automaticDuration returns the whole seconds of an automatically timed session and false if it is too short to record.
+func automaticDuration(start, end time.Time) (int, bool) {
+	d := end.Sub(start)
+	if d < minAutomaticDuration {
+		return 0, false
+	}
+	return int(d / time.Second), true
+}
+
+// totalTime returns the entries with their total and billable seconds.
+func totalTime(entries []models.TimeEntry) models.ConversationTime {
+	t := models.ConversationTime{Entries: entries}
+	for _, e := range entries {
+		t.TotalSeconds += e.DurationSeconds
+		if e.Billable {
+			t.BillableSeconds += e.DurationSeconds
+		}
+	}
+	return t
+}
@@ -0,0 +1,46 @@
+package timetracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghotso/libredesk/internal/timetracking/models"
+)
+
+func TestAutomaticDuration(t *testing.T) {
+	start := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		end         time.Time
+		wantSeconds int
+		wantOK      bool
+	}{
+		{"opened in passing", start.Add(29 * time.Second), 0, false},
+		{"minimum", start.Add(30 * time.Second), 30, true},
+		{"partial seconds are dropped", start.Add(5*time.Minute + 700*time.Millisecond), 300, true},
+		{"end before start", start.Add(-time.Minute), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seconds, ok := automaticDuration(start, tt.end)
+			if seconds != tt.wantSeconds || ok != tt.wantOK {
+				t.Errorf("got %d, %v, want %d, %v", seconds, ok, tt.wantSeconds, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTotalTime(t *testing.T) {
+	got := totalTime([]models.TimeEntry{
+		{DurationSeconds: 600, Billable: true},
+		{DurationSeconds: 120},
+		{DurationSeconds: 45, Billable: true},
+	})
+	if got.TotalSeconds != 765 || got.BillableSeconds != 645 {
+		t.Errorf("got total %d, billable %d, want 765, 645", got.TotalSeconds, got.BillableSeconds)
+	}
+	if len(got.Entries) != 3 {
+		t.Errorf("got %d entries, want 3", len(got.Entries))
+	}
+}
@@ -60,6 +60,7 @@ type User struct {
 	Password               null.String          `db:"password" json:"-"`
 	LastActiveAt           null.Time            `db:"last_active_at" json:"last_active_at"`
 	LastLoginAt            null.Time            `db:"last_login_at" json:"last_login_at"`
+	UndoSendSeconds        int                  `db:"undo_send_seconds" json:"undo_send_seconds"`
 	Roles                  pq.StringArray       `db:"roles" json:"roles"`
 	Permissions            pq.StringArray       `db:"permissions" json:"permissions"`
 	CustomAttributes       json.RawMessage      `db:"custom_attributes" json:"custom_attributes"`
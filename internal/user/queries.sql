@@ -39,6 +39,7 @@ SELECT
     u.availability_status,
     u.last_active_at,
     u.last_login_at,
+    u.undo_send_seconds,
     u.phone_number_country_code,
     u.phone_number,
     u.api_key,
@@ -107,6 +108,11 @@ UPDATE users
 SET availability_status = $2
 WHERE id = $1;
 
+-- name: update-undo-send-seconds
+UPDATE users
+SET undo_send_seconds = $2, updated_at = NOW()
+WHERE id = $1;
+
 -- name: update-last-active-at
 UPDATE users
 SET last_active_at = now(),
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -40,6 +41,10 @@ var (
 	// GenerateFromPassword is too long (i.e. > 72 bytes).
 	ErrPasswordTooLong = errors.New("password length exceeds 72 bytes")
 
+	// Range of the undo send grace period of an agent in seconds.
+	MinUndoSendSeconds = 5
+	MaxUndoSendSeconds = 30
+
 	PasswordHint = fmt.Sprintf("Password must be %d-%d characters long should contain at least one uppercase letter, one lowercase letter, one number, and one special character.", minPassword, maxPassword)
 )
 
@@ -70,6 +75,7 @@ type queries struct {
 	UpdateCustomAttributes *sqlx.Stmt `query:"update-custom-attributes"`
 	UpdateAvatar           *sqlx.Stmt `query:"update-avatar"`
 	UpdateAvailability     *sqlx.Stmt `query:"update-availability"`
+	UpdateUndoSendSeconds  *sqlx.Stmt `query:"update-undo-send-seconds"`
 	UpdateLastActiveAt     *sqlx.Stmt `query:"update-last-active-at"`
 	UpdateInactiveOffline  *sqlx.Stmt `query:"update-inactive-offline"`
 	UpdateLastLoginAt      *sqlx.Stmt `query:"update-last-login-at"`
@@ -289,6 +295,19 @@ func (u *Manager) UpdateAvailability(id int, status string) error {
 	return nil
 }
 
+// UpdateUndoSendSeconds updates the grace period during which an agent can recall a sent reply, 0 disables it.
+func (u *Manager) UpdateUndoSendSeconds(id, seconds int) error {
+	if seconds != 0 && (seconds < MinUndoSendSeconds || seconds > MaxUndoSendSeconds) {
+		return envelope.NewError(envelope.InputError, u.i18n.Ts("user.undoSendSecondsRange", "min", strconv.Itoa(MinUndoSendSeconds), "max", strconv.Itoa(MaxUndoSendSeconds)), nil)
+	}
+	if _, err := u.q.UpdateUndoSendSeconds.Exec(id, seconds); err != nil {
+		u.lo.Error("error updating user undo send seconds", "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
+	}
+	u.InvalidateAgentCache(id)
+	return nil
+}
+
 // UpdateLastActive updates the last active timestamp of an user.
 func (u *Manager) UpdateLastActive(id int) error {
 	if _, err := u.q.UpdateLastActiveAt.Exec(id); err != nil {
@@ -17,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/ghotso/libredesk/internal/crypto"
 	"github.com/ghotso/libredesk/internal/dbutil"
 	"github.com/ghotso/libredesk/internal/envelope"
@@ -348,13 +349,16 @@ func (m *Manager) deliverSingleWebhook(webhook models.Webhook, task DeliveryTask
 	)
 
 	// Make the request
+	start := time.Now()
 	resp, err := m.httpClient.Do(req)
+	metrics.GetOrCreateSummary(fmt.Sprintf(`libredesk_webhook_delivery_duration_seconds{event="%s"}`, task.Event)).UpdateDuration(start)
 	if err != nil {
 		m.lo.Error("webhook delivery failed - HTTP request error",
 			"webhook_id", webhook.ID,
 			"url", webhook.URL,
 			"event", task.Event,
 			"error", err)
+		metrics.GetOrCreateCounter(fmt.Sprintf(`libredesk_webhook_deliveries_total{event="%s",status="error"}`, task.Event)).Inc()
 		return
 	}
 	defer resp.Body.Close()
@@ -370,12 +374,14 @@ func (m *Manager) deliverSingleWebhook(webhook models.Webhook, task DeliveryTask
 	success := resp.StatusCode >= 200 && resp.StatusCode < 300
 
 	if success {
+		metrics.GetOrCreateCounter(fmt.Sprintf(`libredesk_webhook_deliveries_total{event="%s",status="success"}`, task.Event)).Inc()
 		m.lo.Info("webhook delivered successfully",
 			"webhook_id", webhook.ID,
 			"event", task.Event,
 			"url", webhook.URL,
 			"status_code", resp.StatusCode)
 	} else {
+		metrics.GetOrCreateCounter(fmt.Sprintf(`libredesk_webhook_deliveries_total{event="%s",status="failed"}`, task.Event)).Inc()
 		m.lo.Error("webhook delivery failed",
 			"webhook_id", webhook.ID,
 			"event", task.Event,
@@ -100,7 +100,22 @@ func (c *Client) processIncomingMessage(data []byte) {
 		c.SendMessage([]byte("pong"), websocket.TextMessage)
 		return
 	}
-	c.SendError("unknown incoming message type")
+
+	var msg models.IncomingMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.SendError("invalid incoming message")
+		return
+	}
+
+	switch msg.Type {
+	case models.MessageTypeConversationPresence:
+		var update models.PresenceUpdate
+		if err := json.Unmarshal(msg.Data, &update); err != nil || !c.Hub.UpdatePresence(c, update.ConversationUUID, update.Status, update.TrackTime) {
+			c.SendError("invalid conversation presence")
+		}
+	default:
+		c.SendError("unknown incoming message type")
+	}
 }
 
 // close closes the client connection.
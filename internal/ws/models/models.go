@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // Action constants for WebSocket messages.
 const (
 	MessageTypeMessagePropUpdate          = "message_prop_update"
@@ -7,9 +9,19 @@ const (
 	MessageTypeNewMessage                 = "new_message"
 	MessageTypeNewConversation            = "new_conversation"
 	MessageTypeNewNotification            = "new_notification"
+	MessageTypeMessageRecalled            = "message_recalled"
+	MessageTypeConversationPresence       = "conversation_presence"
+	MessageTypeNewSideConversationMessage = "new_side_conversation_message"
 	MessageTypeError                      = "error"
 )
 
+// Presence statuses of an agent in a conversation.
+const (
+	PresenceViewing = "viewing"
+	PresenceTyping  = "typing"
+	PresenceStopped = "stopped"
+)
+
 // WSMessage represents a WS message.
 type WSMessage struct {
 	MessageType int
@@ -22,6 +34,32 @@ type Message struct {
 	Data interface{} `json:"data"`
 }
 
+// IncomingMessage represents a message sent by the client.
+type IncomingMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// PresenceUpdate is sent by the client when the agent views, types in or leaves a conversation.
+// TrackTime automatically times the agent's work on the conversation while it is open.
+type PresenceUpdate struct {
+	ConversationUUID string `json:"conversation_uuid"`
+	Status           string `json:"status"`
+	TrackTime        bool   `json:"track_time"`
+}
+
+// ConversationPresence represents the agents present in a conversation.
+type ConversationPresence struct {
+	ConversationUUID string          `json:"conversation_uuid"`
+	Agents           []AgentPresence `json:"agents"`
+}
+
+// AgentPresence represents the presence of an agent in a conversation.
+type AgentPresence struct {
+	UserID int    `json:"user_id"`
+	Status string `json:"status"`
+}
+
 // BroadcastMessage represents a message to be pushed to users.
 type BroadcastMessage struct {
 	Data  []byte `json:"data"`
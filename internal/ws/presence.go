@@ -0,0 +1,290 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/ghotso/libredesk/internal/ws/models"
+)
+
+const (
+	// A viewing agent has to resend its presence before this expires, typing falls back to viewing once it expires.
+	presenceViewingTTL = 60 * time.Second
+	presenceTypingTTL  = 10 * time.Second
+
+	maxConversationUUIDLength = 36
+)
+
+// presence is the presence of a single client in a conversation.
+type presence struct {
+	userID       int
+	viewingUntil time.Time
+	typingUntil  time.Time
+	// typing is whether the last broadcast showed the client as typing.
+	typing bool
+	// trackTime is whether the client automatically times the agent's work on the conversation.
+	trackTime bool
+}
+
+// timer is the automatically timed session of an agent in a conversation.
+type timer struct {
+	start time.Time
+}
+
+// TimeRecorder records the time an agent had a conversation open with automatic timing on.
+type TimeRecorder interface {
+	RecordTime(conversationUUID string, userID int, start, end time.Time)
+}
+
+// ConversationAccess decides which conversations an agent can be present in and whether their time is tracked.
+type ConversationAccess interface {
+	CanAccessConversation(userID int, conversationUUID string) bool
+	CanTrackTime(userID int) bool
+}
+
+// SetTimeRecorder sets the recorder of automatically timed sessions, it has to be set before clients connect.
+func (h *Hub) SetTimeRecorder(r TimeRecorder) {
+	h.timeRecorder = r
+}
+
+// SetConversationAccess sets the access checks of presence updates, it has to be set before clients connect.
+// Without it no presence is accepted.
+func (h *Hub) SetConversationAccess(a ConversationAccess) {
+	h.access = a
+}
+
+// status returns the presence status at the given time, empty once it has expired.
+func (p *presence) status(now time.Time) string {
+	if now.Before(p.typingUntil) {
+		return models.PresenceTyping
+	}
+	if now.Before(p.viewingUntil) {
+		return models.PresenceViewing
+	}
+	return ""
+}
+
+// UpdatePresence records that the client is viewing, typing in or stopped viewing a conversation and broadcasts
+// the agents present in the conversation to every client that has it open. Agents can only be present in the
+// conversations they can access. While trackTime is set and the agent can track time, the agent's time in the
+// conversation is timed automatically.
+func (h *Hub) UpdatePresence(c *Client, conversationUUID, status string, trackTime bool) bool {
+	if conversationUUID == "" || len(conversationUUID) > maxConversationUUIDLength {
+		return false
+	}
+	// The checks query the database, so they run before presenceMu is taken.
+	if status == models.PresenceViewing || status == models.PresenceTyping {
+		if h.access == nil || !h.access.CanAccessConversation(c.ID, conversationUUID) {
+			return false
+		}
+		trackTime = trackTime && h.access.CanTrackTime(c.ID)
+	}
+
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+
+	now := time.Now()
+	clients := h.presence[conversationUUID]
+	switch status {
+	case models.PresenceViewing, models.PresenceTyping:
+		if clients == nil {
+			clients = make(map[*Client]*presence)
+			h.presence[conversationUUID] = clients
+		}
+		p, ok := clients[c]
+		if !ok {
+			p = &presence{userID: c.ID}
+			clients[c] = p
+		}
+		p.viewingUntil = now.Add(presenceViewingTTL)
+		if status == models.PresenceTyping {
+			p.typingUntil = now.Add(presenceTypingTTL)
+		} else {
+			p.typingUntil = time.Time{}
+		}
+		p.typing = status == models.PresenceTyping
+		p.trackTime = trackTime
+	case models.PresenceStopped:
+		if _, ok := clients[c]; !ok {
+			return true
+		}
+		delete(clients, c)
+		// The client that stopped viewing is told too, so it can clear the presence it shows.
+		h.sendPresence(conversationUUID, []*Client{c}, now)
+	default:
+		return false
+	}
+
+	h.updateTimer(conversationUUID, c.ID, now)
+	h.broadcastPresence(conversationUUID, now)
+	return true
+}
+
+// RunPresenceExpiry expires the presence of clients that have not refreshed it every interval until the context is cancelled.
+func (h *Hub) RunPresenceExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.expirePresence(time.Now())
+		}
+	}
+}
+
+// expirePresence removes expired presence and broadcasts the conversations where it changed.
+func (h *Hub) expirePresence(now time.Time) {
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+
+	for conversationUUID, clients := range h.presence {
+		changed := false
+		for c, p := range clients {
+			switch p.status(now) {
+			case "":
+				delete(clients, c)
+				// The agent was last seen when the presence was refreshed.
+				h.updateTimer(conversationUUID, p.userID, p.viewingUntil.Add(-presenceViewingTTL))
+				changed = true
+			case models.PresenceViewing:
+				if p.typing {
+					p.typing = false
+					changed = true
+				}
+			}
+		}
+		if changed {
+			h.broadcastPresence(conversationUUID, now)
+		}
+	}
+}
+
+// removePresence removes the presence of a disconnected client from every conversation.
+func (h *Hub) removePresence(c *Client) {
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+
+	now := time.Now()
+	for conversationUUID, clients := range h.presence {
+		if _, ok := clients[c]; ok {
+			delete(clients, c)
+			h.updateTimer(conversationUUID, c.ID, now)
+			h.broadcastPresence(conversationUUID, now)
+		}
+	}
+}
+
+// updateTimer starts or ends the automatic timing of an agent in a conversation at the given time, the agent is timed
+// while any of their clients that has the conversation open tracks time, presenceMu must be held.
+func (h *Hub) updateTimer(conversationUUID string, userID int, at time.Time) {
+	if h.closed {
+		return
+	}
+	tracking := false
+	for _, p := range h.presence[conversationUUID] {
+		if p.userID == userID && p.trackTime {
+			tracking = true
+			break
+		}
+	}
+
+	timers := h.timers[conversationUUID]
+	t, ok := timers[userID]
+	switch {
+	case tracking && !ok:
+		if timers == nil {
+			timers = make(map[int]*timer)
+			h.timers[conversationUUID] = timers
+		}
+		timers[userID] = &timer{start: at}
+	case !tracking && ok:
+		delete(timers, userID)
+		if len(timers) == 0 {
+			delete(h.timers, conversationUUID)
+		}
+		if h.timeRecorder != nil {
+			h.recording.Add(1)
+			go func() {
+				defer h.recording.Done()
+				h.timeRecorder.RecordTime(conversationUUID, userID, t.start, at)
+			}()
+		}
+	}
+}
+
+// Close ends the automatically timed sessions that are still open and waits until every session is recorded, no
+// session is started after it. It has to be called before the time recorder shuts down.
+func (h *Hub) Close() {
+	h.presenceMu.Lock()
+	now := time.Now()
+	for conversationUUID, timers := range h.timers {
+		for userID, t := range timers {
+			if h.timeRecorder != nil {
+				h.timeRecorder.RecordTime(conversationUUID, userID, t.start, now)
+			}
+		}
+	}
+	h.timers = make(map[string]map[int]*timer)
+	h.closed = true
+	h.presenceMu.Unlock()
+
+	h.recording.Wait()
+}
+
+// broadcastPresence sends the agents present in a conversation to all clients that have it open, presenceMu must be held.
+func (h *Hub) broadcastPresence(conversationUUID string, now time.Time) {
+	clients := h.presence[conversationUUID]
+	if len(clients) == 0 {
+		delete(h.presence, conversationUUID)
+		return
+	}
+	recipients := make([]*Client, 0, len(clients))
+	for c := range clients {
+		recipients = append(recipients, c)
+	}
+	h.sendPresence(conversationUUID, recipients, now)
+}
+
+// sendPresence sends the agents present in a conversation to the given clients, presenceMu must be held.
+func (h *Hub) sendPresence(conversationUUID string, recipients []*Client, now time.Time) {
+	b, err := json.Marshal(models.Message{
+		Type: models.MessageTypeConversationPresence,
+		Data: models.ConversationPresence{
+			ConversationUUID: conversationUUID,
+			Agents:           presentAgents(h.presence[conversationUUID], now),
+		},
+	})
+	if err != nil {
+		log.Println("error marshalling conversation presence", err)
+		return
+	}
+	for _, c := range recipients {
+		c.SendMessage(b, websocket.TextMessage)
+	}
+}
+
+// presentAgents returns the agents present through any of their clients, typing takes precedence over viewing.
+func presentAgents(clients map[*Client]*presence, now time.Time) []models.AgentPresence {
+	statuses := make(map[int]string)
+	for _, p := range clients {
+		status := p.status(now)
+		if status == "" || statuses[p.userID] == models.PresenceTyping {
+			continue
+		}
+		statuses[p.userID] = status
+	}
+
+	agents := make([]models.AgentPresence, 0, len(statuses))
+	for userID, status := range statuses {
+		agents = append(agents, models.AgentPresence{UserID: userID, Status: status})
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].UserID < agents[j].UserID })
+	return agents
+}
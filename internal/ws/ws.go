@@ -14,6 +14,19 @@ type Hub struct {
 	clients      map[int][]*Client
 	clientsMutex sync.Mutex
 
+	// Conversation UUID to the presence of each client that has the conversation open.
+	presence   map[string]map[*Client]*presence
+	presenceMu sync.Mutex
+
+	// Conversation UUID to the automatically timed session of each agent, guarded by presenceMu.
+	timers       map[string]map[int]*timer
+	timeRecorder TimeRecorder
+	// recording tracks the sessions being recorded, closed stops timing once the hub is closed.
+	recording sync.WaitGroup
+	closed    bool
+
+	access ConversationAccess
+
 	userStore userStore
 }
 
@@ -26,6 +39,8 @@ func NewHub(userStore userStore) *Hub {
 	return &Hub{
 		clients:      make(map[int][]*Client, 10000),
 		clientsMutex: sync.Mutex{},
+		presence:     make(map[string]map[*Client]*presence),
+		timers:       make(map[string]map[int]*timer),
 		userStore:    userStore,
 	}
 }
@@ -39,6 +54,8 @@ func (h *Hub) AddClient(client *Client) {
 
 // RemoveClient removes a client from the hub.
 func (h *Hub) RemoveClient(client *Client) {
+	h.removePresence(client)
+
 	h.clientsMutex.Lock()
 	defer h.clientsMutex.Unlock()
 	if clients, ok := h.clients[client.ID]; ok {
@@ -51,6 +68,17 @@ func (h *Hub) RemoveClient(client *Client) {
 	}
 }
 
+// ClientCount returns the number of connected websocket clients.
+func (h *Hub) ClientCount() int {
+	h.clientsMutex.Lock()
+	defer h.clientsMutex.Unlock()
+	var count int
+	for _, clients := range h.clients {
+		count += len(clients)
+	}
+	return count
+}
+
 // BroadcastMessage broadcasts a message to the specified users.
 // If no users are specified, the message is broadcast to all users.
 func (h *Hub) BroadcastMessage(msg models.BroadcastMessage) {
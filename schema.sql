@@ -3,12 +3,16 @@ CREATE EXTENSION IF NOT EXISTS pg_trgm;
 DROP TYPE IF EXISTS "channels" CASCADE; CREATE TYPE "channels" AS ENUM ('email');
 DROP TYPE IF EXISTS "message_type" CASCADE; CREATE TYPE "message_type" AS ENUM ('incoming','outgoing','activity');
 DROP TYPE IF EXISTS "message_sender_type" CASCADE; CREATE TYPE "message_sender_type" AS ENUM ('agent','contact');
-DROP TYPE IF EXISTS "message_status" CASCADE; CREATE TYPE "message_status" AS ENUM ('received','sent','failed','pending');
+DROP TYPE IF EXISTS "message_status" CASCADE; CREATE TYPE "message_status" AS ENUM ('received','sent','failed','pending','scheduled');
 DROP TYPE IF EXISTS "content_type" CASCADE; CREATE TYPE "content_type" AS ENUM ('text','html');
+DROP TYPE IF EXISTS "conversation_link_type" CASCADE; CREATE TYPE "conversation_link_type" AS ENUM ('parent_child', 'related');
+DROP TYPE IF EXISTS "time_entry_source" CASCADE; CREATE TYPE "time_entry_source" AS ENUM ('manual', 'automatic');
 DROP TYPE IF EXISTS "conversation_assignment_type" CASCADE; CREATE TYPE "conversation_assignment_type" AS ENUM ('Round robin','Manual');
 DROP TYPE IF EXISTS "template_type" CASCADE; CREATE TYPE "template_type" AS ENUM ('email_outgoing', 'email_notification');
 DROP TYPE IF EXISTS "user_type" CASCADE; CREATE TYPE "user_type" AS ENUM ('agent', 'contact');
-DROP TYPE IF EXISTS "ai_provider" CASCADE; CREATE TYPE "ai_provider" AS ENUM ('openai');
+DROP TYPE IF EXISTS "ai_provider" CASCADE; CREATE TYPE "ai_provider" AS ENUM ('openai', 'claude', 'openai_compatible');
+DROP TYPE IF EXISTS "ai_embedding_source" CASCADE; CREATE TYPE "ai_embedding_source" AS ENUM ('conversation', 'macro');
+DROP TYPE IF EXISTS "kb_article_status" CASCADE; CREATE TYPE "kb_article_status" AS ENUM ('draft', 'published');
 DROP TYPE IF EXISTS "automation_execution_mode" CASCADE; CREATE TYPE "automation_execution_mode" AS ENUM ('all', 'first_match');
 DROP TYPE IF EXISTS "macro_visibility" CASCADE; CREATE TYPE "macro_visibility" AS ENUM ('all', 'team', 'user');
 DROP TYPE IF EXISTS "view_visibility" CASCADE; CREATE TYPE "view_visibility" AS ENUM ('all', 'team', 'user');
@@ -19,9 +23,11 @@ DROP TYPE IF EXISTS "applied_sla_status" CASCADE; CREATE TYPE "applied_sla_statu
 DROP TYPE IF EXISTS "sla_event_status" CASCADE; CREATE TYPE "sla_event_status" AS ENUM ('pending', 'breached', 'met');
 DROP TYPE IF EXISTS "sla_metric" CASCADE; CREATE TYPE "sla_metric" AS ENUM ('first_response', 'resolution', 'next_response');
 DROP TYPE IF EXISTS "sla_notification_type" CASCADE; CREATE TYPE "sla_notification_type" AS ENUM ('warning', 'breach');
-DROP TYPE IF EXISTS "activity_log_type" CASCADE; CREATE TYPE "activity_log_type" AS ENUM ('agent_login', 'agent_logout', 'agent_away', 'agent_away_reassigned', 'agent_online', 'agent_password_set', 'agent_role_permissions_changed');
+DROP TYPE IF EXISTS "activity_log_type" CASCADE; CREATE TYPE "activity_log_type" AS ENUM ('agent_login', 'agent_logout', 'agent_away', 'agent_away_reassigned', 'agent_online', 'agent_password_set', 'agent_role_permissions_changed', 'entity_created', 'entity_updated', 'entity_deleted', 'contact_data_exported', 'contact_data_erased');
 DROP TYPE IF EXISTS "macro_visible_when" CASCADE; CREATE TYPE "macro_visible_when" AS ENUM ('replying', 'starting_conversation', 'adding_private_note');
-DROP TYPE IF EXISTS "user_notification_type" CASCADE; CREATE TYPE "user_notification_type" AS ENUM ('mention', 'assignment', 'sla_warning', 'sla_breach');
+DROP TYPE IF EXISTS "user_notification_type" CASCADE; CREATE TYPE "user_notification_type" AS ENUM ('mention', 'assignment', 'sla_warning', 'sla_breach', 'task_due');
+DROP TYPE IF EXISTS "report_schedule_frequency" CASCADE; CREATE TYPE "report_schedule_frequency" AS ENUM ('daily', 'weekly', 'monthly');
+DROP TYPE IF EXISTS "organization_member_role" CASCADE; CREATE TYPE "organization_member_role" AS ENUM ('member', 'admin');
 DROP TYPE IF EXISTS "webhook_event" CASCADE; CREATE TYPE webhook_event AS ENUM (
 	'conversation.created',
 	'conversation.status_changed',
@@ -102,6 +108,10 @@ CREATE TABLE teams (
 	sla_policy_id INT REFERENCES sla_policies(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
 
 	timezone TEXT NULL,
+
+	-- Chat channel SLA alerts of the team are posted to.
+	chat_provider TEXT NULL,
+	chat_webhook_url TEXT NULL,
 	CONSTRAINT constraint_teams_on_emoji CHECK (length(emoji) <= 10),
 	CONSTRAINT constraint_teams_on_name CHECK (length("name") <= 140),
 	CONSTRAINT constraint_teams_on_timezone CHECK (length(timezone) <= 140),
@@ -146,12 +156,15 @@ CREATE TABLE users (
 	api_key TEXT NULL,
 	api_secret TEXT NULL,
 	api_key_last_used_at TIMESTAMPTZ NULL,
+	-- Grace period during which replies of an agent are held and can be recalled, 0 disables it.
+	undo_send_seconds INT DEFAULT 0 NOT NULL,
     CONSTRAINT constraint_users_on_country CHECK (LENGTH(country) <= 140),
     CONSTRAINT constraint_users_on_phone_number CHECK (LENGTH(phone_number) <= 20),
 	CONSTRAINT constraint_users_on_phone_number_country_code CHECK (LENGTH(phone_number_country_code) <= 10),
     CONSTRAINT constraint_users_on_email_length CHECK (LENGTH(email) <= 320),
     CONSTRAINT constraint_users_on_first_name CHECK (LENGTH(first_name) <= 140),
-    CONSTRAINT constraint_users_on_last_name CHECK (LENGTH(last_name) <= 140)
+    CONSTRAINT constraint_users_on_last_name CHECK (LENGTH(last_name) <= 140),
+	CONSTRAINT constraint_users_on_undo_send_seconds CHECK (undo_send_seconds = 0 OR undo_send_seconds BETWEEN 5 AND 30)
 );
 CREATE UNIQUE INDEX index_unique_users_on_email_and_type_when_deleted_at_is_null ON users (email, type)
 WHERE deleted_at IS NULL;
@@ -222,6 +235,8 @@ CREATE TABLE organization_members (
 	organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE ON UPDATE CASCADE,
 	contact_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
 	share_tickets_by_default BOOLEAN DEFAULT false NOT NULL,
+	-- Admins see every conversation of the organization in the portal and manage its members.
+	"role" organization_member_role DEFAULT 'member' NOT NULL,
 	CONSTRAINT constraint_organization_members_on_organization_id_and_contact_id_unique UNIQUE (organization_id, contact_id)
 );
 CREATE INDEX index_organization_members_on_contact_id ON organization_members(contact_id);
@@ -272,6 +287,8 @@ CREATE TABLE conversations (
     last_reply_at TIMESTAMPTZ NULL,
     closed_at TIMESTAMPTZ NULL,
     resolved_at TIMESTAMPTZ NULL,
+	-- Set when a resolved or closed conversation is opened again.
+	reopened_at TIMESTAMPTZ NULL,
 
 	"subject" TEXT NULL,
 	waiting_since TIMESTAMPTZ NULL,
@@ -314,13 +331,19 @@ CREATE TABLE conversation_messages (
     source_id TEXT NULL,
  	sender_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
     sender_type message_sender_type NOT NULL,
-    meta JSONB DEFAULT '{}'::JSONB NULL
+    meta JSONB DEFAULT '{}'::JSONB NULL,
+    -- Scheduled messages are sent once this is due.
+    send_at TIMESTAMPTZ NULL,
+    -- The parent conversation reply this message is a copy of, cancelling or recalling the reply deletes its copies.
+    parent_message_id BIGINT REFERENCES conversation_messages(id) ON DELETE SET NULL ON UPDATE CASCADE NULL
 );
 CREATE INDEX index_trgm_conversation_messages_on_text_content ON conversation_messages USING GIN (text_content gin_trgm_ops);
 CREATE INDEX index_conversation_messages_on_conversation_id ON conversation_messages (conversation_id);
 CREATE INDEX index_conversation_messages_on_created_at ON conversation_messages (created_at);
 CREATE INDEX index_conversation_messages_on_source_id ON conversation_messages (source_id);
 CREATE INDEX index_conversation_messages_on_status ON conversation_messages (status);
+CREATE INDEX index_conversation_messages_on_send_at ON conversation_messages (send_at) WHERE status = 'scheduled';
+CREATE INDEX index_conversation_messages_on_parent_message_id ON conversation_messages (parent_message_id) WHERE parent_message_id IS NOT NULL;
 
 DROP TABLE IF EXISTS automation_rules CASCADE;
 CREATE TABLE automation_rules (
@@ -353,6 +376,97 @@ CREATE TABLE conversation_drafts (
 );
 CREATE UNIQUE INDEX index_uniq_conversation_drafts_on_conversation_id_and_user_id ON conversation_drafts (conversation_id, user_id);
 
+DROP TABLE IF EXISTS side_conversations CASCADE;
+CREATE TABLE side_conversations (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    "uuid" UUID DEFAULT gen_random_uuid() NOT NULL UNIQUE,
+    conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    -- Side conversations keep the inbox they were started from even if the conversation moves to another inbox.
+    inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    created_by_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+    subject TEXT NOT NULL,
+    "to" TEXT[] NOT NULL,
+    cc TEXT[] DEFAULT '{}' NOT NULL,
+    last_message_at TIMESTAMPTZ NULL
+);
+CREATE INDEX index_side_conversations_on_conversation_id ON side_conversations (conversation_id);
+
+DROP TABLE IF EXISTS side_conversation_messages CASCADE;
+CREATE TABLE side_conversation_messages (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    "uuid" UUID DEFAULT gen_random_uuid() NOT NULL UNIQUE,
+    side_conversation_id INT REFERENCES side_conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    "type" message_type NOT NULL,
+    status message_status NOT NULL,
+    -- Agent who sent an outgoing message, incoming messages only have the from address.
+    sender_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+    from_address TEXT NOT NULL,
+    "content" TEXT NOT NULL,
+    text_content TEXT NOT NULL,
+    source_id TEXT NOT NULL UNIQUE
+);
+CREATE INDEX index_side_conversation_messages_on_side_conversation_id ON side_conversation_messages (side_conversation_id);
+
+DROP TABLE IF EXISTS conversation_links CASCADE;
+CREATE TABLE conversation_links (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    "type" conversation_link_type NOT NULL,
+    -- For parent_child links the conversation is the parent and the linked conversation the child.
+    conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    linked_conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    created_by_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+    CONSTRAINT constraint_conversation_links_on_self CHECK (conversation_id != linked_conversation_id)
+);
+-- Two conversations are linked at most once, and a child has a single parent.
+CREATE UNIQUE INDEX index_uniq_conversation_links_on_conversations ON conversation_links (LEAST(conversation_id, linked_conversation_id), GREATEST(conversation_id, linked_conversation_id));
+CREATE UNIQUE INDEX index_uniq_conversation_links_on_child ON conversation_links (linked_conversation_id) WHERE "type" = 'parent_child';
+CREATE INDEX index_conversation_links_on_conversation_id ON conversation_links (conversation_id);
+CREATE INDEX index_conversation_links_on_linked_conversation_id ON conversation_links (linked_conversation_id);
+
+DROP TABLE IF EXISTS conversation_time_entries CASCADE;
+CREATE TABLE conversation_time_entries (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    "source" time_entry_source NOT NULL DEFAULT 'manual',
+    duration_seconds INT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    billable BOOLEAN NOT NULL DEFAULT false,
+    -- When the tracked work started, defaults to when the entry was created.
+    started_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    CONSTRAINT constraint_conversation_time_entries_on_duration_seconds CHECK (duration_seconds > 0),
+    CONSTRAINT constraint_conversation_time_entries_on_note CHECK (length(note) <= 1000)
+);
+CREATE INDEX index_conversation_time_entries_on_conversation_id ON conversation_time_entries (conversation_id);
+CREATE INDEX index_conversation_time_entries_on_user_id ON conversation_time_entries (user_id);
+CREATE INDEX index_conversation_time_entries_on_started_at ON conversation_time_entries (started_at);
+
+DROP TABLE IF EXISTS conversation_tasks CASCADE;
+CREATE TABLE conversation_tasks (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    created_by_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+    assigned_user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    title TEXT NOT NULL,
+    due_at TIMESTAMPTZ NULL,
+    completed_at TIMESTAMPTZ NULL,
+    -- Set once the due reminder is sent, cleared when the due date changes.
+    reminded_at TIMESTAMPTZ NULL,
+    CONSTRAINT constraint_conversation_tasks_on_title CHECK (length(title) BETWEEN 1 AND 500)
+);
+CREATE INDEX index_conversation_tasks_on_conversation_id ON conversation_tasks (conversation_id);
+CREATE INDEX index_conversation_tasks_on_assigned_user_id ON conversation_tasks (assigned_user_id);
+CREATE INDEX index_conversation_tasks_on_due_at ON conversation_tasks (due_at) WHERE completed_at IS NULL AND reminded_at IS NULL;
+
 DROP TABLE IF EXISTS macros CASCADE;
 CREATE TABLE macros (
    id SERIAL PRIMARY KEY,
@@ -444,6 +558,7 @@ CREATE TABLE oidc (
 	enabled bool DEFAULT TRUE NOT NULL,
 	provider VARCHAR NULL,
 	logo_url TEXT NOT NULL DEFAULT '',
+	portal BOOLEAN DEFAULT false NOT NULL,
 	CONSTRAINT constraint_oidc_on_name CHECK (length("name") <= 140)
 );
 
@@ -620,11 +735,106 @@ CREATE TABLE ai_prompts (
 	title TEXT NOT NULL,
     key TEXT NOT NULL UNIQUE,
     content TEXT NOT NULL,
+	-- rewrite prompts are applied to the reply being typed, conversation prompts are sent with the conversation thread,
+	-- classification prompts are used by the AI classification automation action.
+	kind TEXT NOT NULL DEFAULT 'rewrite',
+	-- Provider the prompt is sent to, NULL uses the default provider.
+	provider_id INT REFERENCES ai_providers(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
 	CONSTRAINT constraint_prompts_on_title CHECK (length(title) <= 140),
-    CONSTRAINT constraint_prompts_on_key CHECK (length(key) <= 140)
+    CONSTRAINT constraint_prompts_on_key CHECK (length(key) <= 140),
+	CONSTRAINT constraint_prompts_on_kind CHECK (kind IN ('rewrite', 'conversation', 'classification'))
 );
 CREATE INDEX index_ai_prompts_on_key ON ai_prompts USING btree (key);
 
+DROP TABLE IF EXISTS ai_embeddings CASCADE;
+CREATE TABLE ai_embeddings (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	source_type ai_embedding_source NOT NULL,
+	-- ID of the resolved conversation or macro, not a foreign key as it points to either table.
+	source_id BIGINT NOT NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	-- Plain float array so the pgvector extension is not required, when installed it ranks them instead of the app.
+	embedding FLOAT8[] NOT NULL,
+	model TEXT NOT NULL,
+	-- Resolved at of the conversation or updated at of the macro when it was indexed.
+	source_updated_at TIMESTAMPTZ NOT NULL,
+	CONSTRAINT constraint_ai_embeddings_unique_source UNIQUE (source_type, source_id)
+);
+
+DROP TABLE IF EXISTS ai_message_classifications CASCADE;
+CREATE TABLE ai_message_classifications (
+	-- Classification of an incoming message cached for the AI classification automation action.
+	message_id BIGINT PRIMARY KEY REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	intent TEXT NOT NULL DEFAULT '',
+	sentiment TEXT NOT NULL DEFAULT '',
+	"language" TEXT NOT NULL DEFAULT '',
+	urgency TEXT NOT NULL DEFAULT ''
+);
+
+DROP TABLE IF EXISTS kb_categories CASCADE;
+CREATE TABLE kb_categories (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	"name" TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	slug TEXT NOT NULL,
+	position INT NOT NULL DEFAULT 0,
+	CONSTRAINT constraint_kb_categories_on_slug_unique UNIQUE (slug)
+);
+
+DROP TABLE IF EXISTS kb_articles CASCADE;
+CREATE TABLE kb_articles (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	category_id INT REFERENCES kb_categories(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	slug TEXT NOT NULL,
+	position INT NOT NULL DEFAULT 0,
+	CONSTRAINT constraint_kb_articles_on_slug_unique UNIQUE (slug)
+);
+CREATE INDEX index_kb_articles_on_category_id ON kb_articles(category_id);
+
+DROP TABLE IF EXISTS kb_article_translations CASCADE;
+CREATE TABLE kb_article_translations (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	article_id INT REFERENCES kb_articles(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	locale TEXT NOT NULL,
+	title TEXT NOT NULL,
+	-- Markdown.
+	body TEXT NOT NULL,
+	-- Unpublished changes of a published translation, they replace the title and body when it is published.
+	draft_title TEXT NULL,
+	draft_body TEXT NULL,
+	status kb_article_status NOT NULL DEFAULT 'draft',
+	version INT NOT NULL DEFAULT 1,
+	published_at TIMESTAMPTZ NULL,
+	-- The simple configuration does not stem, which works the same for every locale.
+	search TSVECTOR GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', body), 'B')
+	) STORED,
+	CONSTRAINT constraint_kb_article_translations_on_article_id_and_locale_unique UNIQUE (article_id, locale)
+);
+CREATE INDEX index_kb_article_translations_on_search ON kb_article_translations USING GIN (search);
+
+DROP TABLE IF EXISTS kb_article_versions CASCADE;
+CREATE TABLE kb_article_versions (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	translation_id INT REFERENCES kb_article_translations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	version INT NOT NULL,
+	title TEXT NOT NULL,
+	body TEXT NOT NULL,
+	user_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL
+);
+CREATE INDEX index_kb_article_versions_on_translation_id ON kb_article_versions(translation_id);
+
 DROP TABLE IF EXISTS custom_attribute_definitions CASCADE;
 CREATE TABLE custom_attribute_definitions (
 	id SERIAL PRIMARY KEY,
@@ -638,6 +848,10 @@ CREATE TABLE custom_attribute_definitions (
 	data_type TEXT NOT NULL,
 	regex TEXT NULL,
 	regex_hint TEXT NULL,
+	min_value NUMERIC NULL,
+	max_value NUMERIC NULL,
+	lookup_type TEXT DEFAULT '' NOT NULL,
+	required_on_resolve BOOLEAN DEFAULT false NOT NULL,
 	CONSTRAINT constraint_custom_attribute_definitions_on_name CHECK (length("name") <= 140),
 	CONSTRAINT constraint_custom_attribute_definitions_on_description CHECK (length(description) <= 300),
 	CONSTRAINT constraint_custom_attribute_definitions_on_key CHECK (length(key) <= 140),
@@ -648,6 +862,22 @@ CREATE TABLE custom_attribute_definitions (
 	CONSTRAINT constraint_custom_attribute_definitions_key_applies_to_unique UNIQUE (key, applies_to)
 );
 
+DROP TABLE IF EXISTS ticket_forms CASCADE;
+CREATE TABLE ticket_forms (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	"name" TEXT NOT NULL,
+	description TEXT DEFAULT '' NOT NULL,
+	inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	enabled BOOLEAN DEFAULT true NOT NULL,
+	-- Ordered conversation custom attributes with their help text, required flag and condition.
+	fields JSONB DEFAULT '[]'::jsonb NOT NULL,
+	CONSTRAINT constraint_ticket_forms_on_name CHECK (length("name") <= 140),
+	CONSTRAINT constraint_ticket_forms_on_description CHECK (length(description) <= 1000)
+);
+CREATE INDEX index_ticket_forms_on_inbox_id ON ticket_forms (inbox_id);
+
 DROP TABLE IF EXISTS contact_notes CASCADE;
 CREATE TABLE contact_notes (
 	id SERIAL PRIMARY KEY,
@@ -669,9 +899,12 @@ CREATE TABLE activity_logs (
 	actor_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
 	target_model_type TEXT NOT NULL,
 	target_model_id BIGINT NOT NULL,
-	ip INET
+	ip INET,
+	-- Redacted diff of admin changes, {"field": {"old": ..., "new": ...}}.
+	changes JSONB NULL
 );
 CREATE INDEX IF NOT EXISTS index_activity_logs_on_actor_id ON activity_logs (actor_id);
+CREATE INDEX IF NOT EXISTS index_activity_logs_on_target_model_type_target_model_id ON activity_logs (target_model_type, target_model_id);
 CREATE INDEX IF NOT EXISTS index_activity_logs_on_activity_type ON activity_logs (activity_type);
 CREATE INDEX IF NOT EXISTS index_activity_logs_on_created_at ON activity_logs (created_at);
 
@@ -713,9 +946,107 @@ CREATE INDEX index_user_notifications_on_user_id_is_read ON user_notifications(u
 CREATE INDEX index_user_notifications_on_created_at ON user_notifications(created_at);
 CREATE INDEX index_user_notifications_on_conversation_id ON user_notifications(conversation_id);
 
+DROP TABLE IF EXISTS user_chat_identities CASCADE;
+CREATE TABLE user_chat_identities (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	provider TEXT NOT NULL,
+	webhook_url TEXT NOT NULL,
+	enabled BOOLEAN DEFAULT TRUE NOT NULL,
+	CONSTRAINT constraint_user_chat_identities_on_webhook_url CHECK (length(webhook_url) <= 2000),
+	CONSTRAINT constraint_user_chat_identities_on_user_id_provider_unique UNIQUE (user_id, provider)
+);
+
+DROP TABLE IF EXISTS user_push_subscriptions CASCADE;
+CREATE TABLE user_push_subscriptions (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	-- Push service URL of the browser, unique per device and browser profile.
+	endpoint TEXT NOT NULL,
+	p256dh TEXT NOT NULL,
+	auth TEXT NOT NULL,
+	user_agent TEXT DEFAULT '' NOT NULL,
+	CONSTRAINT constraint_user_push_subscriptions_on_endpoint CHECK (length(endpoint) <= 2000),
+	CONSTRAINT constraint_user_push_subscriptions_on_user_agent CHECK (length(user_agent) <= 1000),
+	CONSTRAINT constraint_user_push_subscriptions_on_endpoint_unique UNIQUE (endpoint)
+);
+CREATE INDEX index_user_push_subscriptions_on_user_id ON user_push_subscriptions(user_id);
+
+DROP TABLE IF EXISTS user_notification_settings CASCADE;
+CREATE TABLE user_notification_settings (
+	user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	timezone TEXT DEFAULT 'UTC' NOT NULL,
+	quiet_hours_enabled BOOLEAN DEFAULT FALSE NOT NULL,
+	-- Quiet hours and digest times are HH:MM in the timezone of the agent.
+	quiet_hours_start TEXT DEFAULT '22:00' NOT NULL,
+	quiet_hours_end TEXT DEFAULT '07:00' NOT NULL,
+	digest_enabled BOOLEAN DEFAULT FALSE NOT NULL,
+	digest_time TEXT DEFAULT '08:00' NOT NULL,
+	last_digest_at TIMESTAMPTZ NULL,
+	CONSTRAINT constraint_user_notification_settings_on_timezone CHECK (length(timezone) <= 140)
+);
+
+DROP TABLE IF EXISTS user_notification_preferences CASCADE;
+CREATE TABLE user_notification_preferences (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	notification_type user_notification_type NOT NULL,
+	in_app BOOLEAN DEFAULT TRUE NOT NULL,
+	email BOOLEAN DEFAULT TRUE NOT NULL,
+	push BOOLEAN DEFAULT TRUE NOT NULL,
+	chat BOOLEAN DEFAULT TRUE NOT NULL,
+	CONSTRAINT constraint_user_notification_preferences_on_user_id_notification_type_unique UNIQUE (user_id, notification_type)
+);
+
+-- Notifications held back for the daily digest email of an agent.
+DROP TABLE IF EXISTS notification_digest_items CASCADE;
+CREATE TABLE notification_digest_items (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	notification_type user_notification_type NOT NULL,
+	title TEXT NOT NULL,
+	body TEXT NULL,
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NULL
+);
+CREATE INDEX index_notification_digest_items_on_user_id ON notification_digest_items(user_id);
+
+DROP TABLE IF EXISTS report_schedules CASCADE;
+CREATE TABLE report_schedules (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	name TEXT NOT NULL,
+	frequency report_schedule_frequency NOT NULL,
+	-- Report keys included in the export, e.g. overview_counts, agents, teams.
+	reports TEXT[] NOT NULL DEFAULT '{}',
+	format TEXT NOT NULL DEFAULT 'xlsx',
+	-- Agents the report is emailed to.
+	recipient_ids INT[] NOT NULL DEFAULT '{}',
+	enabled BOOLEAN DEFAULT true NOT NULL,
+	last_sent_at TIMESTAMPTZ NULL,
+	next_run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT constraint_report_schedules_on_name CHECK (length(name) <= 255),
+	CONSTRAINT constraint_report_schedules_on_format CHECK (format IN ('csv', 'xlsx')),
+	CONSTRAINT constraint_report_schedules_on_reports_not_empty CHECK (array_length(reports, 1) > 0),
+	CONSTRAINT constraint_report_schedules_on_recipient_ids_not_empty CHECK (array_length(recipient_ids, 1) > 0)
+);
+CREATE INDEX index_report_schedules_on_next_run_at ON report_schedules(next_run_at) WHERE enabled = true;
+
 INSERT INTO ai_providers
 ("name", provider, config, is_default)
-VALUES('openai', 'openai', '{"api_key": ""}'::jsonb, true);
+VALUES
+('openai', 'openai', '{"api_key": ""}'::jsonb, true),
+('claude', 'claude', '{"api_key": "", "model": "claude-sonnet-4-5"}'::jsonb, false),
+('openai_compatible', 'openai_compatible', '{"api_key": "", "base_url": "http://localhost:11434/v1", "model": ""}'::jsonb, false);
 
 -- Default AI prompts
 INSERT INTO ai_prompts ("key", "content", title)
@@ -726,6 +1057,20 @@ VALUES
 ('adjust_positive_tone', 'Adjust the tone of the text to make it sound more positive and reassuring.', 'Adjust Positive Tone'),
 ('make_professional', 'Rephrase the text to make it sound more formal and professional and to the point.', 'Make Professional');
 
+INSERT INTO ai_prompts ("key", "content", title, kind)
+VALUES
+('conversation_summary', 'You are a support agent handing over a conversation to a colleague. Summarize the conversation below in a few short bullet points: who the customer is, what they need, what has been tried or promised so far, including relevant private notes, and what the next step is. Reply in English with only the summary.', 'Conversation Summary', 'conversation'),
+('classify_message', 'Classify the customer message below. Reply with only a JSON object with the keys intent (a short snake_case label such as billing, refund, bug_report, feature_request, account_access or general_question), sentiment (one of positive, neutral, negative), language (the ISO 639-1 code of the language of the message) and urgency (one of low, medium, high, urgent).', 'Classify Message', 'classification'),
+('suggested_reply', 'You are a helpful customer support agent. Write the next reply to the customer in the conversation below. Reply in the same language the customer writes in. Use the private notes as internal context but never reveal them. Do not make promises that are not backed by the conversation. Reply with only the message body, without a subject line.', 'Suggested Reply', 'conversation');
+
+-- Conversation custom attributes set by the AI classification automation action.
+INSERT INTO custom_attribute_definitions (applies_to, "name", description, key, values, data_type)
+VALUES
+('conversation', 'AI intent', 'Intent of the latest incoming message set by the AI classification automation action.', 'ai_intent', '{}', 'text'),
+('conversation', 'AI sentiment', 'Sentiment of the latest incoming message set by the AI classification automation action.', 'ai_sentiment', '{positive,neutral,negative}', 'list'),
+('conversation', 'AI language', 'ISO 639-1 language code of the latest incoming message set by the AI classification automation action.', 'ai_language', '{}', 'text'),
+('conversation', 'AI urgency', 'Urgency of the latest incoming message set by the AI classification automation action.', 'ai_urgency', '{low,medium,high,urgent}', 'list');
+
 -- Default settings
 INSERT INTO settings ("key", value)
 VALUES
@@ -740,6 +1085,10 @@ VALUES
 	('app.business_hours_id', '""'::jsonb),
 	('app.portal_enabled', 'false'::jsonb),
 	('app.portal_default_inbox_id', '0'::jsonb),
+	('app.portal_magic_link_enabled', 'false'::jsonb),
+	('app.portal_self_registration_enabled', 'false'::jsonb),
+	('app.portal_registration_domains', '[]'::jsonb),
+	('app.portal_registration_organizations', 'false'::jsonb),
 	('app.organizations_enabled', 'false'::jsonb),
     ('notification.email.username', '"admin@yourcompany.com"'::jsonb),
     ('notification.email.host', '"smtp.gmail.com"'::jsonb),
@@ -776,7 +1125,7 @@ VALUES
 	(
 		'Agent',
 		'Role for all agents with limited access to conversations.',
-		'{conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read_team_all,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,messages:read,messages:write,view:manage}'
+		'{conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read_team_all,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,conversations:update_links,conversations:track_time,conversations:manage_tasks,messages:read,messages:write,view:manage}'
 	);
 
 INSERT INTO
@@ -785,7 +1134,7 @@ VALUES
 	(
 		'Admin',
 		'Role for users who have complete access to everything.',
-		'{webhooks:manage,activity_logs:manage,custom_attributes:manage,contacts:read_all,contacts:read,contacts:write,contacts:block,contact_notes:read,contact_notes:write,contact_notes:delete,conversations:write,ai:manage,general_settings:manage,notification_settings:manage,oidc:manage,conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read_team_all,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,messages:read,messages:write,view:manage,shared_views:manage,status:manage,tags:manage,macros:manage,users:manage,teams:manage,organizations:manage,automations:manage,inboxes:manage,roles:manage,reports:manage,templates:manage,business_hours:manage,sla:manage}'
+		'{webhooks:manage,activity_logs:manage,custom_attributes:manage,contacts:read_all,contacts:read,contacts:write,contacts:block,contacts:export,contacts:erase,contact_notes:read,contact_notes:write,contact_notes:delete,conversations:write,ai:manage,knowledge_base:manage,general_settings:manage,notification_settings:manage,oidc:manage,conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read_team_all,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,conversations:update_links,conversations:track_time,conversations:manage_tasks,messages:read,messages:write,view:manage,shared_views:manage,status:manage,tags:manage,macros:manage,users:manage,teams:manage,organizations:manage,automations:manage,inboxes:manage,roles:manage,reports:manage,templates:manage,business_hours:manage,sla:manage}'
 	);
 
 
@@ -899,3 +1248,62 @@ Libredesk
   '{{ .MentionedBy.FullName }} mentioned you in conversation #{{ .Conversation.ReferenceNumber }}',
   true
 );
+
+INSERT INTO templates
+("type", body, is_default, "name", subject, is_builtin)
+VALUES (
+  'email_notification'::template_type,
+  '
+<p>Hi {{ .Recipient.FirstName }},</p>
+
+<p>Your {{ .Report.Frequency }} report <strong>{{ .Report.Name }}</strong> for the last {{ .Report.Days }} day(s) is attached.</p>
+
+<ul>
+{{ range .Report.Summary }}
+<li>{{ .Label }}: {{ .Value }}</li>
+{{ end }}
+</ul>
+
+<p>
+<a href="{{ RootURL }}/reports/overview">View Reports</a>
+</p>
+
+<p>
+Best regards,<br>
+Libredesk
+</p>
+',
+  false,
+  'Scheduled report',
+  '{{ .Report.Name }} - {{ .Report.Date }}',
+  true
+);
+
+INSERT INTO templates
+("type", body, is_default, "name", subject, is_builtin)
+VALUES (
+  'email_notification'::template_type,
+  '
+<p>Hi {{ .Recipient.FirstName }},</p>
+
+<p>You have {{ .Digest.Count }} notification(s) since your last digest.</p>
+
+<ul>
+{{ range .Digest.Items }}
+<li>
+{{ if .ConversationUUID.Valid }}<a href="{{ RootURL }}/inboxes/all/conversation/{{ .ConversationUUID.String }}">{{ .Title }}</a>{{ else }}{{ .Title }}{{ end }}
+{{ if .Body.Valid }}<br>{{ .Body.String }}{{ end }}
+</li>
+{{ end }}
+</ul>
+
+<p>
+Best regards,<br>
+Libredesk
+</p>
+',
+  false,
+  'Notification digest',
+  'Your notification digest - {{ .Digest.Date }}',
+  true
+);